@@ -0,0 +1,220 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//////// ANSI styles
+// Terminals read ESC [ (written \x1b[ in Go) as the start of a command rather than text.
+// Commands ending in m set the style of what follows, by number: 1 bold, 31 red text,
+// 42 green background, and so on. Each one has an off switch that undoes just it: 22
+// for bold, 39 for the default text colour. Use those rather than 0, which resets
+// everything, and styles nest: bold(red("fail")) stays bold after the red ends.
+//
+// Colour comes in three sizes:
+//   - 16 colours: 30-37 and the bright 90-97. Every terminal has these, but the user's
+//     theme decides what they look like
+//   - 256 colours: 38;5;n, a 6×6×6 cube of RGB plus 24 greys
+//   - truecolor: 38;2;r;g;b, any RGB at all
+// No terminal says which it supports. The conventions are environment variables:
+// COLORTERM=truecolor (or 24bit), a TERM with 256color in it, TERM=dumb for none. And
+// NO_COLOR (https://no-color.org) turns colour off whatever the terminal can do.
+// Output that isn't a terminal gets no escape codes either: a log file is no place for
+// them.
+//
+// The styles here check termColors each time they're called, and rgb picks the nearest
+// colour the terminal has, so callers just write red(msg) and it works everywhere.
+
+type colorLevel int
+
+const (
+	colorNone colorLevel = iota
+	color16
+	color256
+	colorTrue
+)
+
+func (l colorLevel) String() string {
+	return [...]string{"none", "16", "256", "truecolor"}[l]
+}
+
+// The cursor and screen commands life, snake and the progress bars use
+const (
+	ansiClearScreen = "\x1b[2J"
+	ansiCursorHome  = "\x1b[H"
+	ansiHideCursor  = "\x1b[?25l"
+	ansiShowCursor  = "\x1b[?25h"
+	ansiClearLine   = "\x1b[K" // from the cursor to the end of the line
+)
+
+// detectColorLevel works out what a terminal supports from its environment; tty is
+// whether the output is a terminal at all
+func detectColorLevel(getenv func(string) string, tty bool) colorLevel {
+	term := getenv("TERM")
+	switch {
+	case !tty || term == "dumb" || getenv("NO_COLOR") != "":
+		return colorNone
+	case getenv("COLORTERM") == "truecolor" || getenv("COLORTERM") == "24bit":
+		return colorTrue
+	case strings.Contains(term, "256color"):
+		return color256
+	}
+	return color16
+}
+
+// termColors is what the styles write for: decided once, for stdout
+var termColors = detectColorLevel(os.Getenv, isTerminal(os.Stdout))
+
+// ansiStyle wraps text in a style, or returns it as it is when colour is off
+type ansiStyle func(text string) string
+
+// sgr makes a style from the codes that turn it on and off. Inside the text, wherever
+// a nested style switches the same thing off, it's switched back on: red(a + green(b) + c)
+// leaves c red.
+func sgr(on, off string) ansiStyle {
+	return func(text string) string {
+		if termColors == colorNone {
+			return text
+		}
+		start, end := "\x1b["+on+"m", "\x1b["+off+"m"
+		return start + strings.ReplaceAll(text, end, end+start) + end
+	}
+}
+
+var (
+	bold      = sgr("1", "22")
+	dim       = sgr("2", "22")
+	italic    = sgr("3", "23")
+	underline = sgr("4", "24")
+
+	red     = sgr("31", "39")
+	green   = sgr("32", "39")
+	yellow  = sgr("33", "39")
+	blue    = sgr("34", "39")
+	magenta = sgr("35", "39")
+	cyan    = sgr("36", "39")
+	gray    = sgr("90", "39")
+)
+
+// rgb is a text colour, sent as is to a truecolor terminal and as the nearest colour
+// it has to any other
+func rgb(r, g, b uint8) ansiStyle {
+	return func(text string) string {
+		var code string
+		switch termColors {
+		case colorNone:
+			return text
+		case colorTrue:
+			code = fmt.Sprintf("38;2;%d;%d;%d", r, g, b)
+		case color256:
+			code = "38;5;" + strconv.Itoa(int(rgbTo256(r, g, b)))
+		default:
+			code = strconv.Itoa(rgbTo16(r, g, b))
+		}
+		return sgr(code, "39")(text)
+	}
+}
+
+// cubeLevels are the 6 values each of red, green and blue can take in the 256 colour cube
+var cubeLevels = [6]int{0, 95, 135, 175, 215, 255}
+
+func nearestCubeLevel(v uint8) int {
+	best := 0
+	for i, l := range cubeLevels {
+		if abs(int(v)-l) < abs(int(v)-cubeLevels[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+func abs(n int) int { return max(n, -n) }
+
+// rgbTo256 is the nearest of the 256 colours: greys go to the grey ramp (232-255, 8 to
+// 238 in steps of 10), which is finer than the cube's diagonal; the rest to the cube,
+// which starts at 16
+func rgbTo256(r, g, b uint8) uint8 {
+	if r == g && g == b {
+		switch {
+		case r < 4:
+			return 16 // black, in the cube
+		case r > 243:
+			return 231 // white
+		}
+		return 232 + uint8(min((int(r)-8+5)/10, 23))
+	}
+	return uint8(16 + 36*nearestCubeLevel(r) + 6*nearestCubeLevel(g) + nearestCubeLevel(b))
+}
+
+// rgbTo16 is a 30-37 or 90-97 code: a bit each for red, green and blue, and bright if
+// any channel is
+func rgbTo16(r, g, b uint8) int {
+	code := 0
+	for i, v := range []uint8{r, g, b} {
+		if v > 127 {
+			code |= 1 << i
+		}
+	}
+	if max(r, g, b) > 191 {
+		return 90 + code
+	}
+	return 30 + code
+}
+
+func testANSI() {
+	// Colour is on for the examples whatever stdout is, and %q shows the escape codes
+	defer func(saved colorLevel) { termColors = saved }(termColors)
+	termColors = colorTrue
+
+	fmt.Printf("%q\n", red("fail"))       // "\x1b[31mfail\x1b[39m"
+	fmt.Printf("%q\n", bold(red("fail"))) // "\x1b[1m\x1b[31mfail\x1b[39m\x1b[22m"
+	// Nesting the same kind of style: green's 39 would leave "!" uncoloured, so red
+	// switches itself back on after it
+	fmt.Printf("%q\n", red("no "+green("yes")+"!")) // "\x1b[31mno \x1b[32myes\x1b[39m\x1b[31m!\x1b[39m"
+
+	// One colour at each level
+	orange := rgb(255, 135, 0)
+	for _, level := range []colorLevel{colorTrue, color256, color16, colorNone} {
+		termColors = level
+		fmt.Printf("%-9v %q\n", level, orange("warn"))
+	}
+	// truecolor "\x1b[38;2;255;135;0mwarn\x1b[39m"
+	// 256       "\x1b[38;5;208mwarn\x1b[39m"
+	// 16        "\x1b[93mwarn\x1b[39m": bright yellow, as near as 16 colours get
+	// none      "warn"
+
+	runCase("TestANSI/detect", func(errorf errorfFunc) {
+		for _, tc := range []struct {
+			env  map[string]string
+			tty  bool
+			want colorLevel
+		}{
+			{map[string]string{"TERM": "xterm"}, true, color16},
+			{map[string]string{"TERM": "xterm-256color"}, true, color256},
+			{map[string]string{"TERM": "xterm-256color", "COLORTERM": "truecolor"}, true, colorTrue},
+			{map[string]string{"TERM": "xterm-256color"}, false, colorNone}, // piped into a file
+			{map[string]string{"TERM": "dumb", "COLORTERM": "truecolor"}, true, colorNone},
+			{map[string]string{"TERM": "xterm-256color", "NO_COLOR": "1"}, true, colorNone},
+		} {
+			if got := detectColorLevel(func(k string) string { return tc.env[k] }, tc.tty); got != tc.want {
+				errorf("detectColorLevel(%v, tty %v) = %v, want %v", tc.env, tc.tty, got, tc.want)
+			}
+		}
+	})
+	runCase("TestANSI/256", func(errorf errorfFunc) {
+		for _, tc := range []struct {
+			r, g, b uint8
+			want    uint8
+		}{
+			{0, 0, 0, 16}, {255, 255, 255, 231}, {255, 0, 0, 196}, {0, 255, 0, 46}, {0, 0, 255, 21},
+			{128, 128, 128, 244}, {95, 135, 175, 67},
+		} {
+			if got := rgbTo256(tc.r, tc.g, tc.b); got != tc.want {
+				errorf("rgbTo256(%d, %d, %d) = %d, want %d", tc.r, tc.g, tc.b, got, tc.want)
+			}
+		}
+	})
+}
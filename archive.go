@@ -0,0 +1,233 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+//////// zip and tar archives
+// Both are "walk a directory, write a header per file, then copy its bytes"
+// zip: random access, compression built in, what Windows users expect
+// tar: just a stream of files, usually gzipped on top (see compression.go)
+// hellogo exercise submit (exercise.go) bundles an exercise's directory with either
+
+//// Zip slip
+// Archive entries are just names, and a name can be "../../.bashrc" or "/etc/passwd"!
+// Extracting without checking lets an archive write anywhere you have permission.
+// filepath.IsLocal rejects absolute paths, .. escapes, and reserved names on Windows
+
+func safeExtractPath(dest, name string) (string, error) {
+	if !filepath.IsLocal(name) {
+		return "", fmt.Errorf("archive entry %q escapes the destination", name)
+	}
+	return filepath.Join(dest, name), nil
+}
+
+// zipDir writes every regular file under dir into a zip, with paths relative to dir
+func zipDir(dir string, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := zip.FileInfoHeader(info)
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel) // zip names always use /
+		header.Method = zip.Deflate
+
+		fw, err := zw.CreateHeader(header)
+		if err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(fw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	// Close writes the zip's central directory, skip it and the zip is unreadable
+	return zw.Close()
+}
+
+func unzip(r io.ReaderAt, size int64, dest string) error {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return err
+	}
+	for _, zf := range zr.File {
+		path, err := safeExtractPath(dest, zf.Name)
+		if err != nil {
+			return err
+		}
+		if zf.FileInfo().IsDir() {
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := extractZipFile(zf, path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Separate func so the defers run per file, not all at the end of unzip
+func extractZipFile(zf *zip.File, path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	src, err := zf.Open()
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, zf.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return err
+	}
+	return dst.Close()
+}
+
+// tarDir is zipDir's twin; the io.Writer could be a file, a gzip.Writer, a network conn...
+func tarDir(dir string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+func untar(r io.Reader, dest string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		path, err := safeExtractPath(dest, header.Name)
+		if err != nil {
+			return err
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(path, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm())
+			if err != nil {
+				return err
+			}
+			// tr acts as a reader for the current entry's contents only
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		default:
+			// Symlinks and friends can point outside dest too, simplest is to not extract them
+			fmt.Println("skipping", header.Name)
+		}
+	}
+}
+
+func testArchives() {
+	src := makeTempDir("archive-src")
+	defer os.RemoveAll(src)
+	os.MkdirAll(filepath.Join(src, "sub"), 0755)
+	os.WriteFile(filepath.Join(src, "main.go"), []byte("package main\n"), 0644)
+	os.WriteFile(filepath.Join(src, "sub", "notes.txt"), []byte("hello\n"), 0644)
+
+	var zipped bytes.Buffer
+	if err := zipDir(src, &zipped); err != nil {
+		panic(err)
+	}
+	zipOut := makeTempDir("archive-zip")
+	defer os.RemoveAll(zipOut)
+	err := unzip(bytes.NewReader(zipped.Bytes()), int64(zipped.Len()), zipOut)
+	data, _ := os.ReadFile(filepath.Join(zipOut, "sub", "notes.txt"))
+	fmt.Printf("zip: %d bytes, %q, %v\n", zipped.Len(), data, err)
+
+	var tarred bytes.Buffer
+	if err := tarDir(src, &tarred); err != nil {
+		panic(err)
+	}
+	tarOut := makeTempDir("archive-tar")
+	defer os.RemoveAll(tarOut)
+	err = untar(&tarred, tarOut)
+	data, _ = os.ReadFile(filepath.Join(tarOut, "main.go"))
+	fmt.Printf("tar: %q, %v\n", data, err)
+
+	// A malicious archive
+	var evil bytes.Buffer
+	zw := zip.NewWriter(&evil)
+	w, _ := zw.Create("../../evil.sh")
+	w.Write([]byte("rm -rf ~"))
+	zw.Close()
+	err = unzip(bytes.NewReader(evil.Bytes()), int64(evil.Len()), zipOut)
+	fmt.Println(err) // archive entry "../../evil.sh" escapes the destination
+}
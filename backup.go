@@ -0,0 +1,280 @@
+package main
+
+import (
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+//////// Mini project: a backup tool
+// Copies a directory tree and writes manifest.json next to the copy: every file's
+// path, size, modification time and sha256. The manifest is what makes the rest work:
+//   - incremental runs: a file whose size and modification time match the manifest is
+//     skipped without reading it, the same shortcut rsync and make take
+//   - verify: rehash every file in the backup and compare, which catches bit rot and
+//     tampering that sizes and times wouldn't
+// Files deleted from the source are deleted from the backup on the next run, so the
+// backup is a mirror, not a history.
+//
+//	hellogo backup <src> <dest>
+//	hellogo backup --verify <dest>
+
+const backupManifestName = "manifest.json"
+
+type backupEntry struct {
+	Path    string    `json:"path"` // relative, with / separators on every OS
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	SHA256  string    `json:"sha256"`
+}
+
+type backupManifest struct {
+	Created time.Time     `json:"created"`
+	Files   []backupEntry `json:"files"` // sorted by path, so manifests diff nicely
+}
+
+type backupReport struct {
+	copied, skipped, removed int
+}
+
+func readBackupManifest(dest string) (backupManifest, error) {
+	var m backupManifest
+	data, err := os.ReadFile(filepath.Join(dest, backupManifestName))
+	if err != nil {
+		return m, err
+	}
+	err = json.Unmarshal(data, &m)
+	return m, err
+}
+
+// copyAndHash copies src to dst, hashing the bytes on the way through so the file is
+// read once. It writes to a temp file and renames, so a crash never leaves half a copy
+func copyAndHash(src, dst string, modTime time.Time) (sum string, err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dst), "."+filepath.Base(dst)+".tmp*")
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	h := sha256.New()
+	if _, err = io.Copy(io.MultiWriter(tmp, h), in); err != nil {
+		return "", err
+	}
+	if err = tmp.Close(); err != nil {
+		return "", err
+	}
+	// The copy gets the source's time, so the next run's comparison is like for like
+	if err = os.Chtimes(tmp.Name(), modTime, modTime); err != nil {
+		return "", err
+	}
+	if err = os.Rename(tmp.Name(), dst); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// backupDir mirrors src into dest and rewrites dest's manifest
+func backupDir(src, dest string, clk clock) (backupReport, error) {
+	var report backupReport
+	previous := map[string]backupEntry{}
+	old, err := readBackupManifest(dest)
+	switch {
+	case err == nil:
+		for _, e := range old.Files {
+			previous[e.Path] = e
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return report, fmt.Errorf("reading the old manifest: %w", err)
+	}
+
+	m := backupManifest{Created: clk.Now().UTC()}
+	err = filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() { // directories come along with their files; links and devices are skipped
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		entry := backupEntry{Path: filepath.ToSlash(rel), Size: info.Size(), ModTime: info.ModTime().UTC()}
+
+		target := filepath.Join(dest, rel)
+		prev, ok := previous[entry.Path]
+		delete(previous, entry.Path) // whatever's left at the end was deleted from src
+		if ok && prev.Size == entry.Size && prev.ModTime.Equal(entry.ModTime) && fileExists(target) {
+			m.Files = append(m.Files, prev)
+			report.skipped++
+			return nil
+		}
+		if entry.SHA256, err = copyAndHash(path, target, info.ModTime()); err != nil {
+			return err
+		}
+		m.Files = append(m.Files, entry)
+		report.copied++
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	for rel := range previous {
+		if err := os.Remove(filepath.Join(dest, filepath.FromSlash(rel))); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return report, err
+		}
+		report.removed++
+	}
+
+	// WalkDir goes in lexical order already; sorting says so rather than relying on it
+	slices.SortFunc(m.Files, func(a, b backupEntry) int { return cmp.Compare(a.Path, b.Path) })
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return report, err
+	}
+	return report, writeFileAtomic(filepath.Join(dest, backupManifestName), append(data, '\n'), 0644)
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
+
+// verifyBackup rehashes everything the manifest lists and reports files that are
+// missing, changed, or in the backup without being in the manifest
+func verifyBackup(dest string) ([]string, error) {
+	m, err := readBackupManifest(dest)
+	if err != nil {
+		return nil, err
+	}
+	var problems []string
+	listed := map[string]bool{backupManifestName: true}
+	for _, e := range m.Files {
+		listed[e.Path] = true
+		sum, err := sha256File(filepath.Join(dest, filepath.FromSlash(e.Path)))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			problems = append(problems, e.Path+": missing")
+		case err != nil:
+			return nil, err
+		case sum != e.SHA256:
+			problems = append(problems, e.Path+": checksum mismatch")
+		}
+	}
+	err = filepath.WalkDir(dest, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dest, path)
+		if err == nil && !listed[filepath.ToSlash(rel)] {
+			problems = append(problems, filepath.ToSlash(rel)+": not in the manifest")
+		}
+		return err
+	})
+	return problems, err
+}
+
+// hellogo backup <src> <dest> | hellogo backup --verify <dest>
+func backupCommand(args []string) error {
+	flags := flag.NewFlagSet("backup", flag.ContinueOnError)
+	verify := flags.Bool("verify", false, "check a backup against its manifest")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	if *verify {
+		if flags.NArg() != 1 {
+			return errUsage
+		}
+		problems, err := verifyBackup(flags.Arg(0))
+		if err != nil {
+			return err
+		}
+		for _, p := range problems {
+			fmt.Println(p)
+		}
+		if len(problems) > 0 {
+			return fmt.Errorf("%d problems in the backup", len(problems))
+		}
+		fmt.Println("backup ok")
+		return nil
+	}
+
+	if flags.NArg() != 2 {
+		return errUsage
+	}
+	report, err := backupDir(flags.Arg(0), flags.Arg(1), realClock{})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d copied, %d unchanged, %d removed\n", report.copied, report.skipped, report.removed)
+	return nil
+}
+
+func testBackup() {
+	root := makeTempDir("backup")
+	defer os.RemoveAll(root)
+	src, dest := filepath.Join(root, "src"), filepath.Join(root, "dest")
+	write := func(rel, content string) {
+		path := filepath.Join(src, rel)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+	write("notes.txt", "hello, world\n")
+	write("lessons/hello.go", "package main\n")
+	write("lessons/maps.go", "package main\n\n// maps\n")
+	clk := &fakeClock{now: time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)}
+
+	report, err := backupDir(src, dest, clk)
+	fmt.Printf("%+v %v\n", report, err) // {copied:3 skipped:0 removed:0} <nil>
+	m, _ := readBackupManifest(dest)
+	for _, e := range m.Files {
+		fmt.Println(e.SHA256[:12], e.Size, e.Path)
+	}
+	// df1d036cbbf3 13 lessons/hello.go
+	// 4aa558d477cc 22 lessons/maps.go
+	// 853ff93762a0 13 notes.txt
+
+	report, _ = backupDir(src, dest, clk)
+	fmt.Printf("%+v\n", report) // {copied:0 skipped:3 removed:0}, nothing read
+
+	write("notes.txt", "hello, backups\n")
+	os.Remove(filepath.Join(src, "lessons/maps.go"))
+	report, _ = backupDir(src, dest, clk)
+	fmt.Printf("%+v\n", report) // {copied:1 skipped:1 removed:1}
+
+	fmt.Println(verifyBackup(dest)) // [] <nil>
+	os.WriteFile(filepath.Join(dest, "lessons/hello.go"), []byte("package evil\n"), 0644)
+	os.WriteFile(filepath.Join(dest, "stray.txt"), nil, 0644)
+	problems, _ := verifyBackup(dest)
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	// lessons/hello.go: checksum mismatch
+	// stray.txt: not in the manifest
+}
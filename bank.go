@@ -0,0 +1,214 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math/rand/v2"
+	"runtime"
+	"sync"
+	"time"
+)
+
+//////// Mini project: a bank under concurrent transfers
+// Many goroutines move money between accounts at random. Money is never created or
+// destroyed, so the total must come out the same; checkBankInvariant says whether it did.
+//
+// racyBank does it with no locking at all. balance -= amount is a read, a subtract and
+// a write, and two goroutines interleaving those lose one of the updates, so the total
+// drifts. Checking the balance and updating it in separate steps also lets an account
+// go negative. go run -race points straight at it.
+//
+// lockedBank gives every account its own mutex and holds both for a transfer. Locking
+// "from, then to" deadlocks: A->B locks A and waits for B while B->A locks B and waits
+// for A, forever. Always locking the lower account number first makes that cycle
+// impossible, because everyone waits in the same direction.
+//
+// hellogo bank [--racy] [-accounts n] [-workers n] [-transfers n] runs a simulation.
+
+type bankAccount struct {
+	mu      sync.Mutex
+	balance int
+}
+
+type bank interface {
+	// Transfer moves amount from one account to another, false if from can't cover it
+	Transfer(from, to, amount int) bool
+	// Balances is a snapshot of every account, for the invariant check
+	Balances() []int
+}
+
+func newAccounts(n, opening int) []*bankAccount {
+	accounts := make([]*bankAccount, n)
+	for i := range accounts {
+		accounts[i] = &bankAccount{balance: opening}
+	}
+	return accounts
+}
+
+type racyBank struct {
+	accounts []*bankAccount
+}
+
+func (b *racyBank) Transfer(from, to, amount int) bool {
+	f, t := b.accounts[from], b.accounts[to]
+	balance := f.balance // f.balance -= amount spelled out: read, then later write
+	if balance < amount {
+		return false
+	}
+	runtime.Gosched() // let other goroutines in between, like a slow disk or network call would
+	f.balance = balance - amount
+	t.balance += amount
+	return true
+}
+
+func (b *racyBank) Balances() []int {
+	balances := make([]int, len(b.accounts))
+	for i, a := range b.accounts {
+		balances[i] = a.balance
+	}
+	return balances
+}
+
+type lockedBank struct {
+	accounts []*bankAccount
+}
+
+func (b *lockedBank) Transfer(from, to, amount int) bool {
+	if from == to {
+		return false // locking the same mutex twice would deadlock on its own
+	}
+	first, second := b.accounts[from], b.accounts[to]
+	if from > to {
+		first, second = second, first // lower account number first, whichever way the money goes
+	}
+	first.mu.Lock()
+	defer first.mu.Unlock()
+	second.mu.Lock()
+	defer second.mu.Unlock()
+
+	f, t := b.accounts[from], b.accounts[to]
+	if f.balance < amount {
+		return false
+	}
+	f.balance -= amount
+	t.balance += amount
+	return true
+}
+
+// Balances locks every account, in order, so the snapshot is one consistent moment
+// rather than accounts read before and after a transfer between them
+func (b *lockedBank) Balances() []int {
+	for _, a := range b.accounts {
+		a.mu.Lock()
+	}
+	balances := make([]int, len(b.accounts))
+	for i, a := range b.accounts {
+		balances[i] = a.balance
+		a.mu.Unlock()
+	}
+	return balances
+}
+
+var errBankTotal = errors.New("money was created or destroyed")
+
+// checkBankInvariant checks balances add up to want and none went negative
+func checkBankInvariant(balances []int, want int) error {
+	total := 0
+	var errs []error
+	for i, balance := range balances {
+		total += balance
+		if balance < 0 {
+			errs = append(errs, fmt.Errorf("account %d is overdrawn: %d", i, balance))
+		}
+	}
+	if total != want {
+		errs = append(errs, fmt.Errorf("%w: total is %d, want %d", errBankTotal, total, want))
+	}
+	return errors.Join(errs...)
+}
+
+type bankSimulation struct {
+	accounts  int
+	opening   int // every account's starting balance
+	workers   int
+	transfers int // per worker
+	seed      uint64
+}
+
+type bankReport struct {
+	done, declined int
+	elapsed        time.Duration
+}
+
+// run does the transfers on b, then checks the invariant
+func (s bankSimulation) run(b bank) (bankReport, error) {
+	var report bankReport
+	var mu sync.Mutex // guards report's counts
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := range s.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r := rand.New(rand.NewPCG(s.seed, uint64(w))) // one source per worker: *rand.Rand isn't safe to share
+			done := 0
+			for range s.transfers {
+				from := r.IntN(s.accounts)
+				to := (from + 1 + r.IntN(s.accounts-1)) % s.accounts // never from itself
+				if b.Transfer(from, to, 1+r.IntN(s.opening/2)) {
+					done++
+				}
+			}
+			mu.Lock()
+			report.done += done
+			report.declined += s.transfers - done
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	report.elapsed = time.Since(start)
+	return report, checkBankInvariant(b.Balances(), s.accounts*s.opening)
+}
+
+// hellogo bank [--racy] [-accounts n] [-workers n] [-transfers n]
+func bankCommand(args []string) error {
+	flags := flag.NewFlagSet("bank", flag.ContinueOnError)
+	racy := flags.Bool("racy", false, "transfer without locks, to watch the total drift")
+	s := bankSimulation{opening: 100, seed: uint64(time.Now().UnixNano())}
+	flags.IntVar(&s.accounts, "accounts", 10, "number of accounts")
+	flags.IntVar(&s.workers, "workers", 8, "goroutines doing transfers")
+	flags.IntVar(&s.transfers, "transfers", 10000, "transfers per goroutine")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 || s.accounts < 2 || s.workers < 1 {
+		return errUsage
+	}
+
+	accounts := newAccounts(s.accounts, s.opening)
+	var b bank = &lockedBank{accounts}
+	if *racy {
+		b = &racyBank{accounts}
+	}
+	report, err := s.run(b)
+	fmt.Printf("%d transfers, %d declined, in %v\n", report.done, report.declined, report.elapsed.Round(time.Millisecond))
+	if err != nil {
+		return err
+	}
+	fmt.Println("total balance intact:", s.accounts*s.opening)
+	return nil
+}
+
+func testBank() {
+	fmt.Println(checkBankInvariant([]int{50, 150, 100}, 300)) // <nil>
+	fmt.Println(checkBankInvariant([]int{-5, 150, 100}, 300))
+	// account 0 is overdrawn: -5
+	// money was created or destroyed: total is 245, want 300
+
+	s := bankSimulation{accounts: 5, opening: 100, workers: 8, transfers: 2000, seed: 1}
+	_, err := s.run(&lockedBank{newAccounts(s.accounts, s.opening)})
+	fmt.Println("locked:", err) // locked: <nil>
+
+	// Usually wrong, but not always: whether updates get lost depends on how the
+	// scheduler interleaves the goroutines, which is exactly what makes races nasty
+	_, err = s.run(&racyBank{newAccounts(s.accounts, s.opening)})
+	fmt.Println("racy broke the invariant:", errors.Is(err, errBankTotal)) // racy broke the invariant: true (most runs)
+}
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+//////// Big numbers with math/big
+// int is 64 bits and float64 has 53 bits of mantissa; past that, ints wrap around
+// without a word and floats round. math/big has three types that don't:
+//   - big.Int: integers of any size
+//   - big.Rat: fractions of two big.Ints, so exact for anything you can write as a
+//     decimal. That makes it right for money, where 0.1 has to be exactly 0.1
+//   - big.Float: floating point with as many bits of mantissa as you ask for. Still
+//     binary, still rounds, just later
+//
+// They're used through pointers and methods that write into the receiver and return it:
+// z.Add(x, y) sets z to x+y. That lets a loop reuse one value instead of allocating a
+// new one every step, and it's why the zero value, new(big.Int), is ready to use.
+// Never copy one by value (*x = *y): the copy shares the digits underneath.
+
+// bigFactorial is recursiveFunction (hello.go) with a big.Int, which overflows never
+func bigFactorial(n int) *big.Int {
+	if n == 0 {
+		return big.NewInt(1)
+	}
+	return new(big.Int).Mul(big.NewInt(int64(n)), bigFactorial(n-1))
+}
+
+//// Money
+// Amounts as big.Rat, parsed from and printed as decimal strings. Rounding to cents
+// happens once, when an amount is shown or paid out, never along the way
+
+var errMoney = errors.New("not an amount of money")
+
+func parseMoney(s string) (*big.Rat, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimPrefix(s, "$"))
+	if !ok {
+		return nil, fmt.Errorf("%w: %q", errMoney, s)
+	}
+	return r, nil
+}
+
+// formatMoney rounds to cents, halves away from zero
+func formatMoney(r *big.Rat) string { return "$" + r.FloatString(2) }
+
+// splitMoney divides total into n shares of whole cents that add up to total exactly:
+// everyone gets the amount rounded down, and the cents left over go one each to the
+// first few shares
+func splitMoney(total *big.Rat, n int) []*big.Rat {
+	cents := new(big.Rat).Mul(total, big.NewRat(100, 1))
+	whole := new(big.Int).Quo(cents.Num(), cents.Denom()) // fractions of a cent are dropped
+	share, left := new(big.Int).QuoRem(whole, big.NewInt(int64(n)), new(big.Int))
+	shares := make([]*big.Rat, n)
+	for i := range shares {
+		c := new(big.Int).Set(share)
+		if int64(i) < left.Int64() {
+			c.Add(c, big.NewInt(1))
+		}
+		shares[i] = new(big.Rat).SetFrac(c, big.NewInt(100))
+	}
+	return shares
+}
+
+// compound is principal after periods of interest at rate per period, exactly
+func compound(principal, rate *big.Rat, periods int) *big.Rat {
+	growth := new(big.Rat).Add(big.NewRat(1, 1), rate)
+	amount := new(big.Rat).Set(principal)
+	for range periods {
+		amount.Mul(amount, growth) // into amount itself: no new value each time round
+	}
+	return amount
+}
+
+func testBigNumbers() {
+	//// Integers
+	fmt.Println(recursiveFunction(20), recursiveFunction(21)) // 2432902008176640000 -4249290049419214848: 21! wrapped
+	fmt.Println(bigFactorial(21), bigFactorial(30))           // 51090942171709440000 265252859812191058636308480000000
+	f100 := bigFactorial(100).String()
+	fmt.Println(len(f100), f100[:20]+"...") // 158 93326215443944152681...
+
+	two := big.NewInt(2)
+	mersenne := new(big.Int).Sub(new(big.Int).Exp(two, big.NewInt(127), nil), big.NewInt(1))
+	fmt.Println(mersenne, mersenne.ProbablyPrime(20), mersenne.BitLen()) // 170141183460469231731687303715884105727 true 127
+
+	//// Money
+	// Ten 10 cent coins
+	var f float64
+	sum := new(big.Rat)
+	dime, _ := parseMoney("$0.10")
+	for range 10 {
+		f += 0.10
+		sum.Add(sum, dime)
+	}
+	fmt.Println(f, f == 1, formatMoney(sum), sum.Cmp(big.NewRat(1, 1)) == 0) // 0.9999999999999999 false $1.00 true
+
+	bill, _ := parseMoney("$100")
+	shares := splitMoney(bill, 3)
+	var out []string
+	for _, s := range shares {
+		out = append(out, formatMoney(s))
+	}
+	fmt.Println(out) // [$33.34 $33.33 $33.33]: the spare cent has to go somewhere
+
+	// $1000 at 5% a year, compounded daily: round once at the end, not every day
+	principal, _ := parseMoney("1000")
+	daily := new(big.Rat).Quo(big.NewRat(5, 100), big.NewRat(365, 1))
+	exact := compound(principal, daily, 365)
+	roundedDaily := new(big.Rat).Set(principal)
+	for range 365 {
+		roundedDaily.Mul(roundedDaily, new(big.Rat).Add(big.NewRat(1, 1), daily))
+		roundedDaily, _ = parseMoney(roundedDaily.FloatString(2))
+	}
+	fmt.Println(formatMoney(exact), formatMoney(roundedDaily)) // $1051.27 $1051.10: 365 roundings lost 17 cents
+	_, err := parseMoney("ten dollars")
+	fmt.Println(err) // not an amount of money: "ten dollars"
+
+	//// float64 next to big
+	fmt.Println(0.1+0.2 == 0.3)                                                        // true: constants, exact at compile time
+	a, b := 0.1, 0.2                                                                   // at run time, float64
+	fmt.Println(a+b == 0.3, a+b)                                                       // false 0.30000000000000004
+	fmt.Println(new(big.Rat).Add(big.NewRat(1, 10), big.NewRat(2, 10)))                // 3/10
+	fmt.Println(float64(1<<53)+1 == float64(1<<53), math.MaxInt64)                     // true 9223372036854775807: 2⁵³+1 isn't a float64
+	fmt.Println(new(big.Float).SetPrec(200).Add(big.NewFloat(0.1), big.NewFloat(0.2))) // 0.3000000000000000166533453693773481063544750213623046875: 0.1 and 0.2 were float64 already, and 200 bits show their error exactly
+
+	// More bits only help when the inputs are exact: parse the decimal yourself
+	x, _ := new(big.Float).SetPrec(200).SetString("0.1")
+	y, _ := new(big.Float).SetPrec(200).SetString("0.2")
+	fmt.Println(new(big.Float).SetPrec(200).Add(x, y).Text('g', 40)) // 0.3, to 40 digits
+	sqrt2 := new(big.Float).SetPrec(200).Sqrt(big.NewFloat(2))
+	fmt.Println(sqrt2.Text('f', 50)) // 1.41421356237309504880168872420969807856967187537695
+	fmt.Println(math.Sqrt(2))        // 1.4142135623730951: 16 digits, then guessing
+
+	runCase("TestBigNumbers/factorial", func(errorf errorfFunc) {
+		for n := range 21 {
+			if got := bigFactorial(n); !got.IsInt64() || got.Int64() != int64(recursiveFunction(n)) {
+				errorf("bigFactorial(%d) = %v, recursiveFunction says %d", n, got, recursiveFunction(n))
+			}
+		}
+		// 100! ends in 24 zeros: one for every 5 in 1..100, and 25, 50, 75, 100 have two
+		if zeros := len(f100) - len(strings.TrimRight(f100, "0")); zeros != 24 {
+			errorf("100! ends in %d zeros, want 24", zeros)
+		}
+	})
+	runCase("TestBigNumbers/split", func(errorf errorfFunc) {
+		for _, amount := range []string{"100", "0.01", "0.05", "1234.56", "7"} {
+			total, _ := parseMoney(amount)
+			for n := 1; n <= 7; n++ {
+				sum := new(big.Rat)
+				for _, s := range splitMoney(total, n) {
+					sum.Add(sum, s)
+				}
+				if sum.Cmp(total) != 0 {
+					errorf("splitting %s %d ways adds up to %s", amount, n, sum.FloatString(2))
+				}
+			}
+		}
+	})
+}
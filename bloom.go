@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+)
+
+//////// Bloom filters
+// A set that never stores its keys. It's an array of m bits; adding a key sets k of
+// them, picked by hashing the key, and a key is "maybe in the set" if all k of its bits
+// are set. Other keys' bits can cover a key that was never added, so it can say yes
+// wrongly (a false positive), but never no wrongly. In exchange it takes about 10 bits
+// per key for a 1% false positive rate, whatever the keys are: a URL is a few hundred.
+//
+// For n keys and a false positive rate p, the best sizes are
+//
+//	m = -n·ln(p) / ln(2)²   bits
+//	k = m/n · ln(2)         hash functions
+//
+// k different hash functions sounds expensive; double hashing fakes them from two,
+// h1 + i·h2 for i from 0 to k-1, which is as good in practice (Kirsch and Mitzenmacher).
+// Here the two are FNV-1a and FNV-1 (hashing.go), 64 bit.
+//
+// The crawler (crawler.go) can use one for its seen set: less memory, and a false
+// positive just means a page that doesn't get crawled.
+
+type bloomFilter struct {
+	bits []uint64
+	m    uint64 // number of bits
+	k    int    // bits set per key
+	n    int    // keys added
+}
+
+// The false positive rates newBloomFilter sizes for; a rate of 0 would need infinite bits
+// and one of 1 or more none at all, so anything outside is clamped to these
+const (
+	minBloomFPRate = 1e-9 // about 43 bits a key
+	maxBloomFPRate = 0.5  // one hash function, 1.44 bits a key
+)
+
+// newBloomFilter sizes a filter for expected keys at false positive rate fpRate
+func newBloomFilter(expected int, fpRate float64) *bloomFilter {
+	if math.IsNaN(fpRate) {
+		fpRate = maxBloomFPRate
+	}
+	fpRate = min(max(fpRate, minBloomFPRate), maxBloomFPRate)
+	n := float64(max(expected, 1))
+	m := max(uint64(math.Ceil(-n*math.Log(fpRate)/(math.Ln2*math.Ln2))), 1)
+	k := max(int(math.Round(float64(m)/n*math.Ln2)), 1)
+	return &bloomFilter{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+func bloomHashes(key string) (h1, h2 uint64) {
+	a, b := fnv.New64a(), fnv.New64()
+	a.Write([]byte(key))
+	b.Write([]byte(key))
+	return a.Sum64(), b.Sum64() | 1 // odd, so the k positions can't all land on one bit
+}
+
+func (f *bloomFilter) Add(key string) {
+	h1, h2 := bloomHashes(key)
+	for i := range uint64(f.k) {
+		bit := (h1 + i*h2) % f.m
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+	f.n++
+}
+
+// Contains is false if key was definitely never added, true if it probably was
+func (f *bloomFilter) Contains(key string) bool {
+	h1, h2 := bloomHashes(key)
+	for i := range uint64(f.k) {
+		bit := (h1 + i*h2) % f.m
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// expectedFPRate is the theory: the chance all k bits of a new key are already set,
+// with n keys added so far
+func (f *bloomFilter) expectedFPRate() float64 {
+	return math.Pow(1-math.Exp(-float64(f.k)*float64(f.n)/float64(f.m)), float64(f.k))
+}
+
+// bloomSet is a bloomFilter as the crawler's urlSet; the lock makes check-and-add one step
+type bloomSet struct {
+	mu sync.Mutex
+	f  *bloomFilter
+}
+
+func newBloomSet(expected int, fpRate float64) *bloomSet {
+	return &bloomSet{f: newBloomFilter(expected, fpRate)}
+}
+
+func (s *bloomSet) add(key string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.f.Contains(key) {
+		return false
+	}
+	s.f.Add(key)
+	return true
+}
+
+func testBloom() {
+	f := newBloomFilter(10_000, 0.01)
+	fmt.Println(f.m, f.k, len(f.bits)*8) // 95851 7 11984: 12KB for 10,000 keys, about 9.6 bits each
+
+	for i := range 10_000 {
+		f.Add("https://example.com/page/" + strconv.Itoa(i))
+	}
+	fmt.Println(f.Contains("https://example.com/page/42"), f.Contains("https://example.com/nope")) // true false
+
+	// Ask about 100,000 keys that were never added and count the yeses
+	runCase("TestBloom/false positive rate", func(errorf errorfFunc) {
+		for _, target := range []float64{0.1, 0.01, 0.001} {
+			f := newBloomFilter(10_000, target)
+			for i := range 10_000 {
+				f.Add("https://example.com/page/" + strconv.Itoa(i))
+			}
+			fp := 0
+			for i := range 100_000 {
+				if f.Contains("https://example.com/other/" + strconv.Itoa(i)) {
+					fp++
+				}
+			}
+			got, want := float64(fp)/100_000, f.expectedFPRate()
+			fmt.Printf("target %.3f, theory %.4f, measured %.4f\n", target, want, got)
+			if got > want*1.25+0.0002 { // sampling noise, mostly; a bad hash would be way off
+				errorf("false positive rate %.4f, theory says %.4f", got, want)
+			}
+		}
+	})
+	// target 0.100, theory 0.1007, measured 0.1004
+	// target 0.010, theory 0.0100, measured 0.0100
+	// target 0.001, theory 0.0010, measured 0.0011
+
+	// Rates that make no sense are clamped rather than giving a filter with no bits
+	runCase("TestBloom/rate out of range", func(errorf errorfFunc) {
+		for _, rate := range []float64{0, -1, 1, 2, math.NaN()} {
+			f := newBloomFilter(100, rate)
+			f.Add("key")
+			if f.m < 1 || f.k < 1 || !f.Contains("key") {
+				errorf("newBloomFilter(100, %v): m %d, k %d, Contains after Add %v", rate, f.m, f.k, f.Contains("key"))
+			}
+		}
+	})
+
+	// Overfilling: past its expected size the rate climbs fast
+	for _, n := range []int{10_000, 20_000, 40_000} {
+		f.n = n
+		fmt.Printf("%d keys: %.3f\n", n, f.expectedFPRate())
+	}
+	// 10000 keys: 0.010
+	// 20000 keys: 0.157
+	// 40000 keys: 0.679
+
+	// The crawler with a bloom filter for its seen set: 200 pages, each linking to 10 others
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, _ := strconv.Atoi(r.URL.Path[1:])
+		for i := range 10 {
+			fmt.Fprintf(w, `<a href="/%d">next</a> `, (page*7+i*13)%200)
+		}
+	}))
+	defer site.Close()
+	crawled := func(seen urlSet) int {
+		c := newCrawler(site.Client(), 50, 8, 0)
+		c.seen = seen
+		return len(c.crawl(context.Background(), site.URL+"/0"))
+	}
+	runCase("TestBloom/crawler", func(errorf errorfFunc) {
+		exact := crawled(&seenSet{})
+		sized := crawled(newBloomSet(200, 0.001))
+		tiny := crawled(newBloomSet(5, 0.1)) // 24 bits for 200 URLs: soon every URL looks seen
+		if sized > exact || sized < exact*95/100 {
+			errorf("bloom filter crawl found %d pages, the exact set %d", sized, exact)
+		}
+		if tiny >= exact/2 {
+			errorf("an overfull filter crawled %d of %d pages, expected it to give up early", tiny, exact)
+		}
+	})
+}
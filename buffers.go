@@ -0,0 +1,247 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+//////// bytes.Buffer and bufio
+// A bytes.Buffer is a []byte with a read position: writes append at the end, reads
+// take from the front. It grows the way append does, roughly doubling, so n one-byte
+// writes copy the data about log n times rather than n. The first write gets 64 bytes
+// however small it is. Grow(n) makes room for n more up front when you know the size.
+// Bytes() is the buffer's own memory, not a copy: the next write or Reset can change it.
+//
+// bufio wraps a Reader or Writer with a buffer (4KB unless you ask for another size):
+//   - bufio.Writer collects small writes and passes them on in one big one when it
+//     fills up. Whatever is left at the end stays there until Flush, and a program
+//     that forgets it loses the last few KB without an error anywhere. A failed write
+//     sticks: every Write and Flush after it returns the same error
+//   - bufio.Reader reads ahead a buffer at a time. ReadString and ReadBytes read up to
+//     and including a delimiter; at the end of input without one, they return what
+//     there was and io.EOF together, so handle the data before the error. ReadSlice is
+//     the same without the copy, and what it returns is only good until the next read
+//   - Peek(n) looks at the next n bytes without reading them, for sniffing what kind
+//     of data is coming (gzip's 1f 8b, say) before deciding what to wrap it in
+//
+// Every write to an *os.File is a system call, which is what makes bufio worth having
+// there; see testBufferBenchmarks. A bytes.Buffer or strings.Builder doesn't need it.
+
+// openMaybeGzip peeks at the first two bytes and unzips the stream if they're gzip's
+// magic number, so callers can read either the same way
+func openMaybeGzip(r io.Reader) (io.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		return gzip.NewReader(br)
+	}
+	return br, nil // shorter than 2 bytes can't be gzip; br still has them to read
+}
+
+func testBuffers() {
+	//// bytes.Buffer growth
+	var buf bytes.Buffer
+	caps := []int{buf.Cap()}
+	for range 1000 {
+		buf.WriteByte('x')
+		if c := buf.Cap(); c != caps[len(caps)-1] {
+			caps = append(caps, c)
+		}
+	}
+	fmt.Println(caps) // [0 64 128 256 512 1024]: 5 allocations for 1000 writes
+
+	var sized bytes.Buffer
+	sized.Grow(1000)
+	before := sized.Cap()
+	sized.WriteString(strings.Repeat("x", 1000))
+	fmt.Println(before, sized.Cap()) // 1024 1024: one allocation, rounded up to a size the allocator has
+
+	// Reads take from the front; what's left is still in the buffer
+	buf.Reset()
+	buf.WriteString("hello, world")
+	word, _ := buf.ReadString(',')
+	fmt.Printf("%q %q %d\n", word, buf.String(), buf.Len()) // "hello," " world" 6
+
+	// Bytes() shares memory with the buffer
+	buf.Reset()
+	buf.WriteString("first")
+	held := buf.Bytes()
+	buf.Reset()
+	buf.WriteString("SECOND")
+	fmt.Printf("%s\n", held) // SECON: overwritten in place, and still only 5 bytes long
+
+	//// bufio.Writer: nothing arrives until Flush
+	var out strings.Builder
+	w := bufio.NewWriterSize(&out, 16)
+	w.WriteString("hello")
+	fmt.Printf("%q %d\n", out.String(), w.Buffered()) // "" 5
+	w.WriteString(", buffered world")
+	fmt.Printf("%q %d\n", out.String(), w.Buffered()) // "hello, buffered " 5: a full buffer goes out, the rest waits
+	w.Flush()
+	fmt.Printf("%q %d\n", out.String(), w.Buffered()) // "hello, buffered world" 0
+
+	// The classic bug: writing to a file and returning without Flush
+	dir := makeTempDir("buffers")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "lines.txt")
+	writeLines := func(flush bool) {
+		f, err := os.Create(path)
+		if err != nil {
+			panic(err)
+		}
+		defer f.Close()
+		w := bufio.NewWriter(f)
+		for i := range 1000 {
+			fmt.Fprintln(w, "line", i)
+		}
+		if flush {
+			w.Flush()
+		}
+	}
+	writeLines(false)
+	data, _ := os.ReadFile(path)
+	fmt.Println(len(data), bytes.Count(data, []byte("\n"))) // 8192 922: two full buffers made it, the last 698 bytes didn't
+	writeLines(true)
+	data, _ = os.ReadFile(path)
+	fmt.Println(len(data), bytes.Count(data, []byte("\n"))) // 8890 1000
+
+	// Errors stick: after one failed write, everything fails
+	w = bufio.NewWriterSize(failingWriter{}, 16)
+	w.WriteString("more than sixteen bytes")
+	_, err := w.WriteString("ok")
+	fmt.Println(err, w.Flush()) // disk full disk full
+
+	//// bufio.Reader: delimiters
+	r := bufio.NewReader(strings.NewReader("a,b,c"))
+	for {
+		field, err := r.ReadString(',')
+		fmt.Printf("%q %v\n", field, err)
+		if err != nil {
+			break
+		}
+	}
+	// "a," <nil>
+	// "b," <nil>
+	// "c" EOF: the last field comes with the error, so don't stop before using it
+
+	// ReadSlice returns part of the reader's buffer, which the next read refills
+	r = bufio.NewReaderSize(strings.NewReader("aaaa\nbbbbbbbbbbbbbb\n"), 16)
+	first, _ := r.ReadSlice('\n')
+	fmt.Printf("%q\n", first) // "aaaa\n"
+	r.ReadSlice('\n')
+	fmt.Printf("%q\n", first) // "bbbbb": the second line was moved over it
+	// ReadBytes and ReadString copy, so they don't have this problem
+
+	//// Peek
+	var zipped bytes.Buffer
+	zw := gzip.NewWriter(&zipped)
+	zw.Write([]byte("hello from gzip\n"))
+	zw.Close()
+	for _, src := range []io.Reader{strings.NewReader("hello as it is\n"), &zipped} {
+		r, err := openMaybeGzip(src)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		text, _ := io.ReadAll(r)
+		fmt.Printf("%q\n", text)
+	}
+	// "hello as it is\n"
+	// "hello from gzip\n"
+
+	// Peek can't look further than the buffer
+	r = bufio.NewReaderSize(strings.NewReader(strings.Repeat("x", 100)), 16)
+	_, err = r.Peek(20)
+	fmt.Println(err, r.Buffered()) // bufio: buffer full 16
+
+	runCase("TestBuffers/maybe gzip", func(errorf errorfFunc) {
+		for _, in := range []string{"", "x", "\x1f", "plain text"} {
+			r, err := openMaybeGzip(strings.NewReader(in))
+			if err != nil {
+				errorf("openMaybeGzip(%q): %v", in, err)
+				continue
+			}
+			if got, _ := io.ReadAll(r); string(got) != in {
+				errorf("openMaybeGzip(%q) read %q", in, got)
+			}
+		}
+		if _, err := openMaybeGzip(strings.NewReader("\x1f\x8bnot really")); err == nil {
+			errorf("gzip magic with a broken header: no error")
+		}
+	})
+	runCase("TestBuffers/read string at EOF", func(errorf errorfFunc) {
+		for _, tc := range []struct {
+			in     string
+			fields []string
+		}{
+			{"", nil},
+			{"a", []string{"a"}},
+			{"a\n", []string{"a\n"}},
+			{"a\nb", []string{"a\n", "b"}},
+		} {
+			var got []string
+			r := bufio.NewReader(strings.NewReader(tc.in))
+			for {
+				line, err := r.ReadString('\n')
+				if line != "" {
+					got = append(got, line)
+				}
+				if errors.Is(err, io.EOF) {
+					break
+				} else if err != nil {
+					errorf("%q: %v", tc.in, err)
+					break
+				}
+			}
+			if fmt.Sprint(got) != fmt.Sprint(tc.fields) {
+				errorf("lines of %q = %q, want %q", tc.in, got, tc.fields)
+			}
+		}
+	})
+}
+
+// testBufferBenchmarks writes the same 10000 short lines to a file straight and through
+// a bufio.Writer: one system call per line against one per 4KB
+func testBufferBenchmarks() {
+	dir := makeTempDir("buffers-bench")
+	defer os.RemoveAll(dir)
+	for _, bc := range []struct {
+		name string
+		wrap func(io.Writer) (io.Writer, func() error)
+	}{
+		{"unbuffered", func(f io.Writer) (io.Writer, func() error) { return f, func() error { return nil } }},
+		{"bufio", func(f io.Writer) (io.Writer, func() error) {
+			w := bufio.NewWriter(f)
+			return w, w.Flush
+		}},
+		{"bufio-64KB", func(f io.Writer) (io.Writer, func() error) {
+			w := bufio.NewWriterSize(f, 64<<10)
+			return w, w.Flush
+		}},
+	} {
+		printBench("BenchmarkWriteLines/"+bc.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				f, err := os.Create(filepath.Join(dir, bc.name))
+				if err != nil {
+					b.Fatal(err)
+				}
+				w, flush := bc.wrap(f)
+				for j := range 10000 {
+					fmt.Fprintln(w, "line", j)
+				}
+				if err := flush(); err != nil {
+					b.Fatal(err)
+				}
+				f.Close()
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"go/build"
+	"runtime"
+)
+
+//////// Build tags and per-OS code
+// A //go:build line before the package clause decides whether the file is compiled at all:
+//
+//	//go:build linux                    only on Linux
+//	//go:build !linux && !windows       everywhere else
+//	//go:build unix                     any unix-like OS (processes_unix.go)
+//	//go:build go1.23                   only with Go 1.23 or newer (iterators.go)
+//	//go:build debug                    only with go build -tags debug
+//
+// File names work too: x_linux.go, x_windows_amd64.go, x_test.go. The usual pattern is
+// one function with the same signature in each file, so the rest of the code just calls
+// homeDir() or notify() and never checks runtime.GOOS (see platform_*.go).
+// Some set has to cover every platform or the build fails there with "undefined".
+//
+// Try it: GOOS=windows go build, GOOS=darwin go vet. Cross compiling is just env vars.
+
+// platformFiles are the files with constraints in this repo
+var platformFiles = []string{
+	"platform_linux.go", "platform_darwin.go", "platform_windows.go", "platform_other.go",
+	"processes_unix.go", "generics.go", "generics_pre121.go", "iterators.go", "iterators_pre123.go",
+}
+
+// filesFor lists which of platformFiles a build for goos with the given Go release would use.
+// go/build applies the same rules as the go command
+func filesFor(goos string, release int) []string {
+	ctx := build.Default
+	ctx.GOOS = goos
+	ctx.ReleaseTags = nil
+	for v := 1; v <= release; v++ {
+		ctx.ReleaseTags = append(ctx.ReleaseTags, fmt.Sprintf("go1.%d", v))
+	}
+
+	var files []string
+	for _, name := range platformFiles {
+		if ok, err := ctx.MatchFile(".", name); err == nil && ok {
+			files = append(files, name)
+		}
+	}
+	return files
+}
+
+func testBuildTags() {
+	fmt.Println(platformName, runtime.GOOS, runtime.GOARCH) // linux linux amd64
+	home, err := homeDir()
+	fmt.Println(home, err) // /home/you <nil>
+	if err := notify("hellogo", "build tags lesson done"); err != nil {
+		fmt.Println("notify:", err) // exec: "notify-send": executable file not found in $PATH, on a server
+	}
+
+	// Which files each build would compile, read from the files themselves (run from the repo root)
+	for _, goos := range []string{"linux", "darwin", "windows", "freebsd"} {
+		fmt.Printf("%-8s %v\n", goos, filesFor(goos, 24))
+	}
+	fmt.Printf("%-8s %v\n", "go1.22", filesFor(runtime.GOOS, 22))
+	fmt.Printf("%-8s %v\n", "go1.20", filesFor(runtime.GOOS, 20))
+	// linux    [platform_linux.go processes_unix.go generics.go iterators.go]
+	// darwin   [platform_darwin.go processes_unix.go generics.go iterators.go]
+	// windows  [platform_windows.go generics.go iterators.go]
+	// freebsd  [platform_other.go processes_unix.go generics.go iterators.go]
+	// go1.22   [platform_linux.go processes_unix.go generics.go iterators_pre123.go]
+	// go1.20   [platform_linux.go processes_unix.go generics_pre121.go iterators_pre123.go]
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+//////// A concurrent-safe cache with expiry
+// A map plus a sync.RWMutex: any number of readers at once, writers one at a time.
+// Entries can expire. Expired ones are hidden from Get straight away and actually
+// removed by a janitor goroutine now and then, so reads never need the write lock.
+// The clock is passed in (timeformat.go), so expiry can be tested without sleeping.
+
+type cacheItem[V any] struct {
+	value   V
+	expires time.Time // zero: never
+}
+
+type ttlCache[K comparable, V any] struct {
+	mu    sync.RWMutex
+	items map[K]cacheItem[V]
+	clock clock
+}
+
+func newTTLCache[K comparable, V any](clk clock) *ttlCache[K, V] {
+	return &ttlCache[K, V]{items: map[K]cacheItem[V]{}, clock: clk}
+}
+
+func (item cacheItem[V]) expired(now time.Time) bool {
+	return !item.expires.IsZero() && !now.Before(item.expires)
+}
+
+func (c *ttlCache[K, V]) Get(key K) (V, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	item, ok := c.items[key]
+	if !ok || item.expired(c.clock.Now()) {
+		var zero V
+		return zero, false
+	}
+	return item.value, true
+}
+
+// Set stores value under key; a ttl of 0 (or less) means it never expires
+func (c *ttlCache[K, V]) Set(key K, value V, ttl time.Duration) {
+	item := cacheItem[V]{value: value}
+	if ttl > 0 {
+		item.expires = c.clock.Now().Add(ttl)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.items[key] = item
+}
+
+// Delete reports whether there was a live entry to delete
+func (c *ttlCache[K, V]) Delete(key K) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	item, ok := c.items[key]
+	delete(c.items, key)
+	return ok && !item.expired(c.clock.Now())
+}
+
+// Expires returns when key expires, the zero time if never; false if there's no such key
+func (c *ttlCache[K, V]) Expires(key K) (time.Time, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	item, ok := c.items[key]
+	if !ok || item.expired(c.clock.Now()) {
+		return time.Time{}, false
+	}
+	return item.expires, true
+}
+
+// Len counts entries that haven't been swept yet, expired or not
+func (c *ttlCache[K, V]) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.items)
+}
+
+// deleteExpired removes everything past its expiry and says how many that was
+func (c *ttlCache[K, V]) deleteExpired() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.clock.Now()
+	n := 0
+	for key, item := range c.items { // deleting while ranging over a map is allowed
+		if item.expired(now) {
+			delete(c.items, key)
+			n++
+		}
+	}
+	return n
+}
+
+// runJanitor sweeps every interval until ctx is done
+func (c *ttlCache[K, V]) runJanitor(ctx context.Context, every time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.deleteExpired()
+		}
+	}
+}
+
+func testCache() {
+	clk := &fakeClock{now: time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	c := newTTLCache[string, int](clk)
+
+	c.Set("forever", 1, 0)
+	c.Set("short", 2, time.Minute)
+	fmt.Println(c.Get("short"))     // 2 true
+	fmt.Println(c.Expires("short")) // 2019-01-01 00:01:00 +0000 UTC true
+
+	clk.Advance(time.Minute)
+	fmt.Println(c.Get("short"))                           // 0 false, hidden at once
+	fmt.Println(c.Len(), c.deleteExpired(), c.Len())      // 2 1 1, but only swept now
+	fmt.Println(c.Get("forever"))                         // 1 true
+	fmt.Println(c.Delete("forever"), c.Delete("forever")) // true false
+
+	// Hammer it from many goroutines; go run -race would complain if the locking were wrong
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 1000 {
+				key := fmt.Sprint("k", i%50)
+				c.Set(key, g, time.Hour)
+				c.Get(key)
+				if i%10 == 0 {
+					c.Delete(key)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	fmt.Println(c.Len() <= 50) // true
+}
@@ -0,0 +1,431 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//////// Mini project: an expression calculator
+// The three classic stages of reading a language:
+//   1. the lexer turns "2*(x+1)" into tokens: 2 * ( x + 1 )
+//   2. the parser turns tokens into a tree (AST) that makes precedence explicit
+//   3. the evaluator walks the tree to get a number
+// The parser is recursive descent: one function per precedence level, each calling the
+// next tighter one, so the call stack mirrors the grammar:
+//
+//	statement  = name "=" expr | expr
+//	expr       = term { ("+" | "-") term }
+//	term       = unary { ("*" | "/" | "%") unary }
+//	unary      = "-" unary | power
+//	power      = primary [ "^" unary ]        right associative: 2^3^2 is 2^(3^2)
+//	primary    = number | name | "(" expr ")"
+//
+// Numbers are anything parseCalcNumber (strconv.go) takes: 42, 0xff, 1_000, 2.5e3.
+// hellogo calc '2*(3+4)' evaluates one expression, plain hellogo calc is a REPL where
+// x = 3 defines a variable for the lines after it.
+
+type calcTokenKind int
+
+const (
+	calcEOF calcTokenKind = iota
+	calcNumber
+	calcName
+	calcOp // one of + - * / % ^ ( ) =
+)
+
+type calcToken struct {
+	kind calcTokenKind
+	text string
+	pos  int // byte offset, for error messages
+}
+
+// calcError points at where in the input things went wrong
+type calcError struct {
+	pos int
+	msg string
+}
+
+func (e *calcError) Error() string {
+	return fmt.Sprintf("at %d: %s", e.pos+1, e.msg)
+}
+
+// lexCalc splits the input into tokens, ending with a calcEOF one
+func lexCalc(src string) ([]calcToken, error) {
+	var tokens []calcToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case strings.IndexByte("+-*/%^()=", c) >= 0:
+			tokens = append(tokens, calcToken{calcOp, string(c), i})
+			i++
+		case isCalcNumberStart(c):
+			start := i
+			i = scanCalcNumber(src, i)
+			tokens = append(tokens, calcToken{calcNumber, src[start:i], start})
+		case isCalcNameByte(c):
+			start := i
+			for i < len(src) && isCalcNameByte(src[i]) {
+				i++
+			}
+			tokens = append(tokens, calcToken{calcName, src[start:i], start})
+		default:
+			return nil, &calcError{i, fmt.Sprintf("unexpected %q", rune(c))}
+		}
+	}
+	return append(tokens, calcToken{calcEOF, "", len(src)}), nil
+}
+
+func isCalcNumberStart(c byte) bool { return c >= '0' && c <= '9' || c == '.' }
+
+// scanCalcNumber returns where the number starting at src[start] ends; it takes in
+// more than numbers (1..2, 12abc) and leaves parseCalcNumber to reject those
+func scanCalcNumber(src string, start int) int {
+	exponent := "eE"
+	if strings.HasPrefix(strings.ToLower(src[start:]), "0x") {
+		exponent = "pP" // hex floats: 0x1p-3
+	}
+	i := start
+	for i < len(src) {
+		c := src[i]
+		// an exponent's sign belongs to the number: the - in 1e-3 isn't a minus
+		isExpSign := (c == '+' || c == '-') && i > start && strings.IndexByte(exponent, src[i-1]) >= 0
+		if !isExpSign && !isCalcNameByte(c) && c != '.' {
+			break
+		}
+		i++
+	}
+	return i
+}
+
+func isCalcNameByte(c byte) bool {
+	return c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9'
+}
+
+//// The AST
+// Each kind of node is its own type; the evaluator tells them apart with a type switch.
+// String prints a node fully parenthesized, which shows what the parser decided.
+
+type calcNode interface {
+	String() string
+}
+
+type (
+	numberNode struct{ value float64 }
+	nameNode   struct{ name string }
+	unaryNode  struct {
+		op      string
+		operand calcNode
+	}
+	binaryNode struct {
+		op          string
+		left, right calcNode
+	}
+	assignNode struct {
+		name  string
+		value calcNode
+	}
+)
+
+func (n numberNode) String() string { return strconv.FormatFloat(n.value, 'g', -1, 64) }
+func (n nameNode) String() string   { return n.name }
+func (n unaryNode) String() string  { return "(" + n.op + n.operand.String() + ")" }
+func (n binaryNode) String() string {
+	return "(" + n.left.String() + " " + n.op + " " + n.right.String() + ")"
+}
+func (n assignNode) String() string { return n.name + " = " + n.value.String() }
+
+//// The parser
+
+type calcParser struct {
+	tokens []calcToken
+	pos    int
+}
+
+// parseCalc parses one statement: an expression, or name = expression
+func parseCalc(src string) (calcNode, error) {
+	tokens, err := lexCalc(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &calcParser{tokens: tokens}
+	node, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != calcEOF {
+		return nil, &calcError{tok.pos, fmt.Sprintf("unexpected %q after the expression", tok.text)}
+	}
+	return node, nil
+}
+
+func (p *calcParser) peek() calcToken { return p.tokens[p.pos] }
+
+func (p *calcParser) next() calcToken {
+	tok := p.tokens[p.pos]
+	if tok.kind != calcEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// accept consumes the next token if it's one of the operators in ops
+func (p *calcParser) accept(ops ...string) (string, bool) {
+	tok := p.peek()
+	if tok.kind == calcOp {
+		for _, op := range ops {
+			if tok.text == op {
+				p.pos++
+				return op, true
+			}
+		}
+	}
+	return "", false
+}
+
+func (p *calcParser) statement() (calcNode, error) {
+	if p.peek().kind == calcName && p.tokens[p.pos+1].text == "=" {
+		name := p.next().text
+		p.next() // the =
+		value, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		return assignNode{name, value}, nil
+	}
+	return p.expr()
+}
+
+// binaryLevel parses operand { op operand } for one precedence level, left associative
+func (p *calcParser) binaryLevel(operand func() (calcNode, error), ops ...string) (calcNode, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := p.accept(ops...)
+		if !ok {
+			return left, nil
+		}
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		left = binaryNode{op, left, right} // 1-2-3 builds ((1-2)-3)
+	}
+}
+
+func (p *calcParser) expr() (calcNode, error) { return p.binaryLevel(p.term, "+", "-") }
+func (p *calcParser) term() (calcNode, error) { return p.binaryLevel(p.unary, "*", "/", "%") }
+
+func (p *calcParser) unary() (calcNode, error) {
+	if _, ok := p.accept("-"); ok {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{"-", operand}, nil
+	}
+	return p.power()
+}
+
+func (p *calcParser) power() (calcNode, error) {
+	base, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("^"); !ok {
+		return base, nil
+	}
+	exp, err := p.unary() // unary, not power: 2^-1 works, and recursing makes it right associative
+	if err != nil {
+		return nil, err
+	}
+	return binaryNode{"^", base, exp}, nil
+}
+
+func (p *calcParser) primary() (calcNode, error) {
+	tok := p.next()
+	switch {
+	case tok.kind == calcNumber:
+		v, err := parseCalcNumber(tok.text)
+		if err != nil {
+			return nil, &calcError{tok.pos, err.Error()}
+		}
+		return numberNode{v}, nil
+	case tok.kind == calcName:
+		return nameNode{tok.text}, nil
+	case tok.text == "(":
+		inner, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := p.accept(")"); !ok {
+			return nil, &calcError{p.peek().pos, "missing )"}
+		}
+		return inner, nil
+	case tok.kind == calcEOF:
+		return nil, &calcError{tok.pos, "unexpected end of input"}
+	default:
+		return nil, &calcError{tok.pos, fmt.Sprintf("unexpected %q", tok.text)}
+	}
+}
+
+//// The evaluator
+
+var errDivisionByZero = errors.New("division by zero")
+
+// evalCalc computes n; assignments store into vars and evaluate to the assigned value
+func evalCalc(n calcNode, vars map[string]float64) (float64, error) {
+	switch n := n.(type) {
+	case numberNode:
+		return n.value, nil
+	case nameNode:
+		v, ok := vars[n.name]
+		if !ok {
+			return 0, fmt.Errorf("undefined variable %s", n.name)
+		}
+		return v, nil
+	case unaryNode:
+		v, err := evalCalc(n.operand, vars)
+		return -v, err
+	case binaryNode:
+		l, err := evalCalc(n.left, vars)
+		if err != nil {
+			return 0, err
+		}
+		r, err := evalCalc(n.right, vars)
+		if err != nil {
+			return 0, err
+		}
+		return calcArith(n.op, l, r)
+	case assignNode:
+		v, err := evalCalc(n.value, vars)
+		if err != nil {
+			return 0, err
+		}
+		vars[n.name] = v
+		return v, nil
+	}
+	return 0, fmt.Errorf("unknown node %T", n) // a new node type the switch wasn't taught
+}
+
+// calcArith applies one of the binary operators + - * / % ^
+func calcArith(op string, l, r float64) (float64, error) {
+	switch op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	case "/", "%":
+		if r == 0 { // floats would give ±Inf or NaN; a calculator user wants to be told
+			return 0, errDivisionByZero
+		}
+		if op == "%" {
+			return math.Mod(l, r), nil
+		}
+		return l / r, nil
+	case "^":
+		return math.Pow(l, r), nil
+	}
+	return 0, fmt.Errorf("unknown operator %s", op)
+}
+
+func formatCalc(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// calcREPL evaluates a line at a time; variables last for the whole session
+func calcREPL(in io.Reader, out io.Writer, prompt string) error {
+	vars := map[string]float64{"pi": math.Pi, "e": math.E}
+	lines := bufio.NewScanner(in)
+	lines.Buffer(make([]byte, 64*1024), 1<<20) // past the default 64KB a line is "token too long"
+	for fmt.Fprint(out, prompt); lines.Scan(); fmt.Fprint(out, prompt) {
+		line := strings.TrimSpace(lines.Text())
+		if line == "" {
+			continue
+		}
+		node, err := parseCalc(line)
+		var v float64
+		if err == nil {
+			v, err = evalCalc(node, vars)
+		}
+		if err != nil {
+			fmt.Fprintln(out, "error:", err)
+			continue
+		}
+		fmt.Fprintln(out, formatCalc(v))
+	}
+	if prompt != "" {
+		fmt.Fprintln(out)
+	}
+	return lines.Err()
+}
+
+// hellogo calc [expression]; a REPL without one
+func calcCommand(args []string) error {
+	if len(args) == 0 {
+		return calcREPL(os.Stdin, os.Stdout, "calc> ")
+	}
+	node, err := parseCalc(strings.Join(args, " "))
+	if err != nil {
+		return err
+	}
+	v, err := evalCalc(node, map[string]float64{"pi": math.Pi, "e": math.E})
+	if err != nil {
+		return err
+	}
+	fmt.Println(formatCalc(v))
+	return nil
+}
+
+func testCalc() {
+	tokens, _ := lexCalc("2*(x + 1.5e-3)")
+	for _, tok := range tokens {
+		fmt.Printf("%q ", tok.text)
+	}
+	fmt.Println() // "2" "*" "(" "x" "+" "1.5e-3" ")" ""
+
+	for _, src := range []string{"1 + 2 * 3", "(1 + 2) * 3", "1 - 2 - 3", "2 ^ 3 ^ 2", "-2 ^ 2", "x = 0x10 % 3"} {
+		node, _ := parseCalc(src)
+		fmt.Printf("%-12s parses as %s\n", src, node)
+	}
+	// 1 + 2 * 3    parses as (1 + (2 * 3))
+	// (1 + 2) * 3  parses as ((1 + 2) * 3)
+	// 1 - 2 - 3    parses as ((1 - 2) - 3)
+	// 2 ^ 3 ^ 2    parses as (2 ^ (3 ^ 2))
+	// -2 ^ 2       parses as (-(2 ^ 2))
+	// x = 0x10 % 3 parses as x = (16 % 3)
+
+	for _, src := range []string{"2 * (3 + 4", "2 +", "3 $ 4", "1 2", "1..2", "1 / (2 - 2)", "y + 1"} {
+		node, err := parseCalc(src)
+		if err == nil {
+			_, err = evalCalc(node, map[string]float64{})
+		}
+		fmt.Printf("%-12s %v\n", src, err)
+	}
+	// 2 * (3 + 4   at 11: missing )
+	// 2 +          at 4: unexpected end of input
+	// 3 $ 4        at 3: unexpected '$'
+	// 1 2          at 3: unexpected "2" after the expression
+	// 1..2         at 1: "1..2" is not a number
+	// 1 / (2 - 2)  division by zero
+	// y + 1        undefined variable y
+
+	session := "r = 2\narea = pi * r ^ 2\narea / r\n10 % 4\nnope(\n"
+	calcREPL(strings.NewReader(session), os.Stdout, "")
+	// 2
+	// 12.566370614359172
+	// 6.283185307179586
+	// 2
+	// error: at 5: unexpected "(" after the expression
+}
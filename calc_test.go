@@ -0,0 +1,26 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCalcREPL(t *testing.T) {
+	for _, tc := range []struct {
+		name, in, want string
+	}{
+		{"variables", "x = 2\nx * 3 + 1\n", "2\n7\n"},
+		{"error", "1 +\n2\n", "error: "},
+		{"long line", strings.Repeat(" ", 100_000) + "1 + 2\n", "3\n"}, // over bufio.Scanner's default 64KB
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := calcREPL(strings.NewReader(tc.in), &out, ""); err != nil {
+				t.Fatal(err)
+			}
+			if !strings.HasPrefix(out.String(), tc.want) {
+				t.Errorf("printed %q, want it to start with %q", out.String(), tc.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+//////// cgo: calling C
+// import "C" plus some C code in the comment above it, and Go can call C functions
+// (see cgo_enabled.go). It's how Go talks to sqlite, OpenSSL or a GPU driver.
+// This lesson is opt in, the default build stays pure Go (cgo_fallback.go):
+//
+//	go build -tags hellogo_cgo     # needs gcc or clang, and CGO_ENABLED=1 (the default natively)
+//
+// What it costs:
+//   - Builds need a C toolchain for the TARGET, so GOOS=windows go build no longer just works
+//   - The binary links against libc and isn't static any more; CGO_ENABLED=0 gets that back
+//   - Builds are slower, and the race detector, fuzzer and profiler can't see into C
+//   - Every call switches stacks and tells the scheduler, ~20-50ns instead of ~1ns, so call
+//     C for big chunks of work, not in a tight loop
+//   - A goroutine stuck in C holds an OS thread the whole time
+//   - Memory: C can't keep Go pointers after the call returns, and Go's GC never frees C
+//     memory; every C.CString needs a C.free
+//
+// "cgo is not Go": reach for it when there's no pure Go option.
+
+// goAdd is the same work as cgoAdd without crossing into C, for the benchmark
+//
+//go:noinline
+func goAdd(a, b int) int {
+	return a + b
+}
+
+func testCgo() {
+	fmt.Println("using", cgoImplementation)                // using pure Go, or using cgo with -tags hellogo_cgo
+	fmt.Println(cgoAdd(40, 2))                             // 42
+	fmt.Println(cgoCountWords("  the quick\tbrown\nfox ")) // 4
+	fmt.Println(cgoGreeting("gopher"))                     // hello from C, gopher
+
+	printBench("BenchmarkAdd/go", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sinkInt = goAdd(i, 1)
+		}
+	})
+	printBench("BenchmarkAdd/"+cgoImplementation, func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			sinkInt = cgoAdd(i, 1)
+		}
+	})
+	// With -tags hellogo_cgo:
+	// BenchmarkAdd/go     ~1.5 ns/op
+	// BenchmarkAdd/cgo     ~35 ns/op  <- all of it is the trip into C and back
+}
@@ -0,0 +1,67 @@
+//go:build cgo && hellogo_cgo
+
+package main
+
+/*
+#include <ctype.h>
+#include <stdio.h>
+#include <stdlib.h>
+
+static long add(long a, long b) {
+	return a + b;
+}
+
+static int count_words(const char *s) {
+	int n = 0, in_word = 0;
+	for (; *s; s++) {
+		if (isspace((unsigned char)*s)) {
+			in_word = 0;
+		} else if (!in_word) {
+			in_word = 1;
+			n++;
+		}
+	}
+	return n;
+}
+
+// The caller owns the result and has to free() it
+static char *greeting(const char *name) {
+	size_t size = snprintf(NULL, 0, "hello from C, %s", name) + 1;
+	char *out = malloc(size);
+	if (out != NULL) {
+		snprintf(out, size, "hello from C, %s", name);
+	}
+	return out;
+}
+*/
+import "C"
+
+import "unsafe"
+
+// The comment right above import "C" is C code, compiled by the system C compiler and
+// callable as C.name. C types are spelled C.long, C.int, *C.char...
+
+const cgoImplementation = "cgo"
+
+func cgoAdd(a, b int) int {
+	return int(C.add(C.long(a), C.long(b))) // numbers just convert
+}
+
+func cgoCountWords(s string) int {
+	// Go strings aren't NUL terminated and the GC may move Go memory, so CString copies
+	// into C's heap, which Go never frees for you
+	cs := C.CString(s)
+	defer C.free(unsafe.Pointer(cs))
+	return int(C.count_words(cs))
+}
+
+func cgoGreeting(name string) string {
+	cname := C.CString(name)
+	defer C.free(unsafe.Pointer(cname))
+	out := C.greeting(cname)
+	if out == nil {
+		return ""
+	}
+	defer C.free(unsafe.Pointer(out))
+	return C.GoString(out) // copies back into a Go string
+}
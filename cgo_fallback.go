@@ -0,0 +1,22 @@
+//go:build !cgo || !hellogo_cgo
+
+package main
+
+import "strings"
+
+// The pure Go versions, used unless built with -tags hellogo_cgo and a C compiler.
+// Same names and signatures as cgo_enabled.go, so cgo.go doesn't care which it gets
+
+const cgoImplementation = "pure Go"
+
+func cgoAdd(a, b int) int {
+	return a + b
+}
+
+func cgoCountWords(s string) int {
+	return len(strings.Fields(s))
+}
+
+func cgoGreeting(name string) string {
+	return "hello from Go, " + name
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//////// Mini project: a toy blockchain
+// A blockchain is a list where every entry (block) includes the hash of the one before.
+// Change anything in block 3 and its hash changes, so block 4's "previous hash" no
+// longer matches, and so on to the end: tampering shows. Recomputing every later hash
+// is cheap though, which is what proof of work is for: a block only counts if its hash
+// starts with some number of zeros, and the only way to find one is to keep changing a
+// throwaway number (the nonce) and hashing again. Each extra zero (hex digit) makes
+// that 16 times more work, for the tamperer as much as for anyone.
+//
+// No network of peers, no consensus, no money; just the data structure.
+//
+//	hellogo chain [-difficulty n] [addr]
+//	curl -d '{"data":"hello"}' localhost:8080/blocks
+//	curl localhost:8080/blocks
+
+type block struct {
+	Index     int       `json:"index"`
+	Timestamp time.Time `json:"timestamp"`
+	Data      string    `json:"data"`
+	PrevHash  string    `json:"prev_hash"`
+	Nonce     int       `json:"nonce"`
+	Hash      string    `json:"hash"`
+}
+
+// computeHash hashes everything but the hash. JSON is an easy way to get the same
+// bytes for the same fields every time (struct fields always encode in order)
+func (b block) computeHash() string {
+	b.Hash = ""
+	data, _ := json.Marshal(b) // can't fail for this struct
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func meetsDifficulty(hash string, difficulty int) bool {
+	return strings.HasPrefix(hash, strings.Repeat("0", difficulty))
+}
+
+// mine tries nonces until the hash has enough leading zeros
+func (b block) mine(difficulty int) block {
+	for b.Nonce = 0; ; b.Nonce++ {
+		if b.Hash = b.computeHash(); meetsDifficulty(b.Hash, difficulty) {
+			return b
+		}
+	}
+}
+
+type blockchain struct {
+	mu         sync.RWMutex
+	blocks     []block
+	difficulty int
+	clk        clock
+}
+
+func newBlockchain(difficulty int, clk clock) *blockchain {
+	genesis := block{Timestamp: clk.Now().UTC(), Data: "genesis"}.mine(difficulty)
+	return &blockchain{blocks: []block{genesis}, difficulty: difficulty, clk: clk}
+}
+
+// add mines a block for data and appends it. Mining happens without the lock, so
+// readers aren't stuck behind it; if another block got in first in the meantime,
+// this one points at the wrong previous block and has to be mined again
+func (c *blockchain) add(data string) block {
+	for {
+		c.mu.RLock()
+		tip := c.blocks[len(c.blocks)-1]
+		c.mu.RUnlock()
+
+		b := block{Index: tip.Index + 1, Timestamp: c.clk.Now().UTC(), Data: data, PrevHash: tip.Hash}.mine(c.difficulty)
+
+		c.mu.Lock()
+		if c.blocks[len(c.blocks)-1].Hash == tip.Hash {
+			c.blocks = append(c.blocks, b)
+			c.mu.Unlock()
+			return b
+		}
+		c.mu.Unlock()
+	}
+}
+
+// snapshot copies the blocks, so callers can read them without the lock
+func (c *blockchain) snapshot() []block {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return append([]block(nil), c.blocks...)
+}
+
+var errBadChain = errors.New("invalid chain")
+
+// validateChain checks every block's hash, proof of work and link to the one before
+func validateChain(blocks []block, difficulty int) error {
+	for i, b := range blocks {
+		switch {
+		case b.Index != i:
+			return fmt.Errorf("%w: block %d has index %d", errBadChain, i, b.Index)
+		case b.Hash != b.computeHash():
+			return fmt.Errorf("%w: block %d's hash doesn't match its contents", errBadChain, i)
+		case !meetsDifficulty(b.Hash, difficulty):
+			return fmt.Errorf("%w: block %d wasn't mined", errBadChain, i)
+		case i > 0 && b.PrevHash != blocks[i-1].Hash:
+			return fmt.Errorf("%w: block %d doesn't follow block %d", errBadChain, i, i-1)
+		}
+	}
+	return nil
+}
+
+//// Over HTTP
+//
+//	GET  /blocks          the whole chain
+//	GET  /blocks/{index}  one block
+//	POST /blocks          {"data": "..."}, mines a block and returns it
+//	GET  /validate        {"valid": true} or the reason it isn't
+
+func (c *blockchain) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /blocks", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, c.snapshot())
+	})
+	mux.HandleFunc("GET /blocks/{index}", func(w http.ResponseWriter, r *http.Request) {
+		blocks := c.snapshot()
+		i, err := strconv.Atoi(r.PathValue("index"))
+		if err != nil || i < 0 || i >= len(blocks) {
+			http.NotFound(w, r)
+			return
+		}
+		writeJSON(w, http.StatusOK, blocks[i])
+	})
+	mux.HandleFunc("POST /blocks", func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, 1<<16)
+		var req struct {
+			Data string `json:"data"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		b := c.add(req.Data)
+		w.Header().Set("Location", fmt.Sprintf("/blocks/%d", b.Index))
+		writeJSON(w, http.StatusCreated, b)
+	})
+	mux.HandleFunc("GET /validate", func(w http.ResponseWriter, r *http.Request) {
+		if err := validateChain(c.snapshot(), c.difficulty); err != nil {
+			writeJSON(w, http.StatusOK, map[string]any{"valid": false, "error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"valid": true})
+	})
+	return mux
+}
+
+// hellogo chain [-difficulty n] [addr]
+func chainCommand(args []string) error {
+	flags := flag.NewFlagSet("chain", flag.ContinueOnError)
+	difficulty := flags.Int("difficulty", 4, "leading zero hex digits a block's hash needs")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 1 || *difficulty < 0 || *difficulty > 8 {
+		return errUsage
+	}
+	cfg, err := loadConfig(configFile)
+	if err != nil {
+		return err
+	}
+	addr := cfg.Server.Addr
+	if flags.NArg() == 1 {
+		addr = flags.Arg(0)
+	}
+
+	ctx, stop := shutdownContext()
+	defer stop()
+	c := newBlockchain(*difficulty, realClock{})
+	return serveUntilDone(ctx, newLessonServer(addr, standardMiddleware(c.routes())))
+}
+
+func testChain() {
+	clk := &fakeClock{now: time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	c := newBlockchain(3, clk)
+	clk.Advance(time.Minute)
+	c.add("alice pays bob 5")
+	clk.Advance(time.Minute)
+	c.add("bob pays carol 2")
+	for _, b := range c.snapshot() {
+		fmt.Printf("%d %-16s nonce=%-5d %s...\n", b.Index, b.Data, b.Nonce, b.Hash[:16])
+	}
+	// 0 genesis          nonce=258   0001bf982b674069...
+	// 1 alice pays bob 5 nonce=786   0002c26a709d5e23...
+	// 2 bob pays carol 2 nonce=10125 000cddd98f6fe598...
+	// 4096 tries on average for three zeros; block 2 was unlucky
+
+	blocks := c.snapshot()
+	fmt.Println(validateChain(blocks, 3)) // <nil>
+
+	// Tampering: change the data and the hash is wrong
+	blocks[1].Data = "alice pays bob 500"
+	fmt.Println(validateChain(blocks, 3)) // invalid chain: block 1's hash doesn't match its contents
+	// Fix the hash without mining: it almost certainly lacks the zeros
+	blocks[1].Hash = blocks[1].computeHash()
+	fmt.Println(validateChain(blocks, 3)) // invalid chain: block 1 wasn't mined
+	// Mine it again: now block 2 points at the old block 1
+	blocks[1] = blocks[1].mine(3)
+	fmt.Println(validateChain(blocks, 3)) // invalid chain: block 2 doesn't follow block 1
+
+	// And over HTTP
+	h := c.routes()
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+		return rec
+	}
+	clk.Advance(time.Minute)
+	rec := do(http.MethodPost, "/blocks", `{"data": "carol pays dave 1"}`)
+	var added block
+	json.Unmarshal(rec.Body.Bytes(), &added)
+	fmt.Println(rec.Code, rec.Header().Get("Location"), added.Data, meetsDifficulty(added.Hash, 3)) // 201 /blocks/3 carol pays dave 1 true
+	fmt.Print(do(http.MethodGet, "/validate", "").Body)                                             // {"valid":true}
+	fmt.Println(do(http.MethodGet, "/blocks/9", "").Code)                                           // 404
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+)
+
+//////// Commands
+// Small utilities built on top of the lessons, run with: hellogo <command> [args...]
+// Add new ones to the commands map
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"backup":    {"backup <src> <dest> | backup --verify <dest>", backupCommand},
+	"bank":      {"bank [--racy] [-accounts n] [-workers n] [-transfers n]", bankCommand},
+	"buildwasm": {"buildwasm [dir]", buildWasmCommand},
+	"calc":      {"calc [expression]", calcCommand},
+	"chain":     {"chain [-difficulty n] [addr]", chainCommand},
+	"checksum":  {"checksum <path>...", checksumCommand},
+	"decrypt":   {"decrypt <in> <out>", cryptFileCommand(decryptWithPassphrase)},
+	"dnsserver": {"dnsserver [-zone file] [addr]", dnsserverCommand},
+	"encrypt":   {"encrypt <in> <out>", cryptFileCommand(encryptWithPassphrase)},
+	"exercise":  {"exercise list | start <name> | check [--watch] [-every d] <name> | submit [-format zip|tar.gz] <name>", exerciseCommand},
+	"fakegen":   {"fakegen <file> <interface> [out]", fakegenCommand},
+	"filter":    {"filter upper|lower|trim", filterCommand},
+	"fractal":   {"fractal [-o file.png] [-width n] [-height n] [-center re,im] [-zoom z] [-palette name] [-julia re,im] [--speedup]", fractalCommand},
+	"gh":        {"gh repos <user> | gh issues <owner/repo> [--state open|closed|all]", ghCommand},
+	"json":      {"json pretty|minify|get <.path> (reads stdin)", jsonToolCommand},
+	"kv":        {"kv --serve [addr] | kv [addr]", kvCommand},
+	"list":      {"list [-width n]", listCommand},
+	"life":      {"life [-width n] [-height n] [-pattern name|random] [-seed n] [-generations n] [-every d] [--headless]", lifeCommand},
+	"loadtest":  {"loadtest [-c n] [-d duration] [-rate n] <url>", loadtestCommand},
+	"minigrep":  {"minigrep [-i] [-n] [-r] [-E] pattern [path...]", minigrepCommand},
+	"minilang":  {"minilang [file]", minilangCommand},
+	"migrate":   {"migrate [status|up|down] [version]", migrateCommand},
+	"progress":  {"progress", progressCommand},
+	"proxy":     {"proxy [--hand-rolled] [-routes \"prefix=url ...\"] [addr]", proxyCommand},
+	"replay":    {"replay [-n lines] file", replayCommand},
+	"resolve":   {"resolve <name>...", resolveCommand},
+	"run":       {"run [--plugin file.so]... [--all] [--prereqs] [--record file [-keep n]] [lesson...]", runLessonCommand},
+	"serve":     {"serve [addr]", serveCommand},
+	"shortener": {"shortener [addr]", shortenerCommand},
+	"snake":     {"snake [-width n] [-height n] [-every d]", snakeCommand},
+	"sort":      {"sort [--bench] [-algo insertion|merge|quick] [-shape shape] [-n items] [-every d]", sortCommand},
+	"source":    {"source [file]", sourceCommand},
+	"tcpchat":   {"tcpchat --serve|--join [addr]", tcpchatCommand},
+	"tool":      {"tool wc [-l] [-w] [-c] [-m] [file...] | tool tail [-n lines] [-f] file | tool diff [-context n] a b", toolCommand},
+	"todo":      {"todo add|list|done|delete [args...]", todoCommand},
+	"transfer":  {"transfer --receive [-dir d] [addr] | transfer --send file [addr]", transferCommand},
+	"verify":    {"verify [--update] [lesson...]", verifyCommand},
+	"watch":     {"watch [-every d] path... -- command [args...]", watchCommand},
+	"weather":   {"weather [--offline|--record] [-days n] <place>", weatherCommand},
+}
+
+// Commands return errUsage when called with the wrong arguments
+var errUsage = errors.New("wrong arguments")
+
+// Exit codes, so scripts and CI can tell "it failed" from "you called it wrong"
+// See processes.go for how exit codes work
+const (
+	exitOK     = 0
+	exitFailed = 1
+	exitUsage  = 2
+)
+
+// Errors with an ExitCode method pick their own code; *exec.ExitError is one,
+// so a command wrapping another program passes its exit code straight through
+type exitCoder interface {
+	ExitCode() int
+}
+
+func printUsage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(os.Stderr, "usage: hellogo [--log-level debug|info|warn|error] [--log-json] [--profile dir] [--trace file] <command> [args...]")
+	for _, name := range names {
+		fmt.Fprintln(os.Stderr, "  hellogo", commands[name].usage)
+	}
+}
+
+// runCLI handles the global flags and then runs the command, returning the exit code for main
+//
+//	hellogo [--log-level debug|info|warn|error] [--log-json] [--profile dir] [--trace file] <command> [args...]
+func runCLI(args []string) int {
+	flags := flag.NewFlagSet("hellogo", flag.ContinueOnError)
+	flags.Usage = printUsage
+	var level slog.Level
+	flags.TextVar(&level, "log-level", slog.LevelInfo, "minimum level of diagnostics to show")
+	jsonLogs := flags.Bool("log-json", false, "write diagnostics as JSON lines")
+	profileDir := flags.String("profile", "", "write CPU and heap profiles of the command into `dir` (see pprof.go)")
+	traceFile := flags.String("trace", "", "write an execution trace of the command to `file` (see trace.go)")
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return exitOK
+		}
+		return exitUsage
+	}
+
+	slog.SetDefault(newCLILogger(os.Stderr, level, *jsonLogs))
+	if flags.NArg() == 0 {
+		printUsage()
+		return exitUsage
+	}
+
+	if *profileDir != "" {
+		stop, err := startProfiling(*profileDir)
+		if err != nil {
+			slog.Error("can't start profiling", "err", err)
+			return exitFailed
+		}
+		defer func() {
+			if err := stop(); err != nil {
+				slog.Error("can't write profiles", "err", err)
+			}
+		}()
+	}
+	if *traceFile != "" {
+		stop, err := startTracing(*traceFile)
+		if err != nil {
+			slog.Error("can't start tracing", "err", err)
+			return exitFailed
+		}
+		defer func() {
+			if err := stop(); err != nil {
+				slog.Error("can't write trace", "err", err)
+			}
+		}()
+	}
+	return runCommand(flags.Arg(0), flags.Args()[1:])
+}
+
+func runCommand(name string, args []string) int {
+	cmd, ok := commands[name]
+	if !ok {
+		if close := newTrie(commandNames()...).Suggest(name, 2); len(close) > 0 {
+			slog.Error("unknown command", "command", name, "did_you_mean", close[0])
+			return exitUsage
+		}
+		slog.Error("unknown command", "command", name)
+		printUsage()
+		return exitUsage
+	}
+
+	slog.Debug("running command", "command", name, "args", args)
+	err := cmd.run(args)
+	if err == nil {
+		return exitOK
+	}
+	if errors.Is(err, errUsage) {
+		fmt.Fprintln(os.Stderr, "usage: hellogo", cmd.usage)
+		return exitUsage
+	}
+
+	code := exitFailed
+	var coder exitCoder
+	if errors.As(err, &coder) && coder.ExitCode() > 0 {
+		code = coder.ExitCode()
+	}
+	slog.Error("command failed", "command", name, "err", err, "exit_code", code)
+	return code
+}
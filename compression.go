@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+//////// Compression with gzip and zlib
+// Both wrap an io.Writer (to compress) or io.Reader (to decompress), so they slot
+// into any stream: files, network connections, HTTP bodies, tar archives...
+// gzip = deflate + a header with a name/timestamp and a CRC; zlib = deflate + a smaller header
+
+func gzipBytes(data []byte, level int) ([]byte, error) {
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, level)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := zw.Write(data); err != nil {
+		return nil, err
+	}
+	// Close flushes the last block and writes the footer; the output is truncated without it
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func gunzipBytes(data []byte) ([]byte, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err // not gzip at all, bad header
+	}
+	defer zr.Close()
+	return io.ReadAll(zr) // a corrupt body shows up here as a checksum error
+}
+
+func testGzipRoundTrip() {
+	text := []byte(strings.Repeat("all work and no play makes jack a dull boy\n", 100))
+	packed, err := gzipBytes(text, gzip.DefaultCompression)
+	if err != nil {
+		panic(err)
+	}
+	unpacked, err := gunzipBytes(packed)
+	fmt.Println(len(text), "->", len(packed), bytes.Equal(text, unpacked), err) // 4300 -> 86 true <nil>
+
+	_, err = gunzipBytes([]byte("definitely not gzip data"))
+	fmt.Println(err) // gzip: invalid header
+
+	// zlib is used the exact same way
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	zw.Write(text)
+	zw.Close()
+	fmt.Println("zlib:", buf.Len())
+}
+
+// Compression ratios on real text: the lesson sources embedded in embed.go
+func testCompressionRatios() {
+	var all bytes.Buffer
+	fs.WalkDir(lessonSources, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		data, err := lessonSources.ReadFile(path)
+		all.Write(data)
+		return err
+	})
+
+	for _, level := range []int{gzip.BestSpeed, gzip.DefaultCompression, gzip.BestCompression} {
+		packed, err := gzipBytes(all.Bytes(), level)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("level %2d: %d -> %d bytes (%.1f%%)\n",
+			level, all.Len(), len(packed), 100*float64(len(packed))/float64(all.Len()))
+	}
+}
+
+//// Transparent gzip for HTTP
+// Wrap any handler; clients that send Accept-Encoding: gzip get a compressed body
+// (Go's http.Client asks for gzip and decompresses by itself, so callers never notice)
+
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	zw          *gzip.Writer // nil when the response goes out as it is
+	wroteHeader bool
+}
+
+// WriteHeader decides whether to compress, once the status is known: 204 and 304 have
+// no body, a 206 is a byte range of the uncompressed file, and a handler that set its
+// own Content-Encoding has compressed the body already
+func (g *gzipResponseWriter) WriteHeader(code int) {
+	if g.wroteHeader || code < 200 { // 1xx can come before the real status
+		g.ResponseWriter.WriteHeader(code)
+		return
+	}
+	g.wroteHeader = true
+	h := g.Header()
+	switch {
+	case code == http.StatusNoContent, code == http.StatusNotModified, code == http.StatusPartialContent:
+	case h.Get("Content-Encoding") != "":
+	default:
+		h.Set("Content-Encoding", "gzip")
+		h.Del("Content-Length") // the length changes once compressed
+		g.zw = gzip.NewWriter(g.ResponseWriter)
+	}
+	g.ResponseWriter.WriteHeader(code)
+}
+
+func (g *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !g.wroteHeader {
+		g.WriteHeader(http.StatusOK)
+	}
+	if g.zw == nil {
+		return g.ResponseWriter.Write(b)
+	}
+	return g.zw.Write(b)
+}
+
+// Flush pushes out what gzip is holding on to as well, so streams (sse.go) still stream
+func (g *gzipResponseWriter) Flush() {
+	if g.zw != nil {
+		g.zw.Flush()
+	}
+	http.NewResponseController(g.ResponseWriter).Flush()
+}
+
+func gzipHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Add("Vary", "Accept-Encoding")
+		// HEAD has no body to compress, and a websocket upgrade takes the connection
+		// away from the ResponseWriter altogether
+		if r.Method == http.MethodHead || r.Header.Get("Upgrade") != "" ||
+			!strings.Contains(r.Header.Get("Accept-Encoding"), "gzip") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		gw := &gzipResponseWriter{ResponseWriter: w}
+		next.ServeHTTP(gw, r)
+		if gw.zw != nil {
+			gw.zw.Close() // writes the gzip footer
+		}
+	})
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+//////// Config files
+// hellogo reads a small TOML style config file:
+//
+//	# comments start with #
+//	color = true
+//
+//	[progress]
+//	path = "progress.gob"
+//
+//	[quiz]
+//	questions = 10
+//
+//	[storage]
+//	backend = "json" # or "sqlite"
+//
+// Real projects would pull in a TOML or YAML library (BurntSushi/toml, yaml.v3) which
+// work the same way: struct tags map keys to fields, you start from defaults and then
+// validate. The parser below only handles the subset hellogo needs.
+
+// Commands read their config from here, in the current directory
+const configFile = "hellogo.toml"
+
+type config struct {
+	Color    bool           `toml:"color"`
+	Progress progressConfig `toml:"progress"`
+	Proxy    proxyConfig    `toml:"proxy"`
+	Quiz     quizConfig     `toml:"quiz"`
+	Server   serverConfig   `toml:"server"`
+	Storage  storageConfig  `toml:"storage"`
+}
+
+type progressConfig struct {
+	Path string `toml:"path"`
+}
+
+type proxyConfig struct {
+	Addr   string `toml:"addr"`
+	Routes string `toml:"routes"` // "/prefix=http://upstream ...", see proxy.go
+}
+
+type quizConfig struct {
+	Questions int   `toml:"questions"`
+	Seed      int64 `toml:"seed"` // 0 means pick one from the clock
+}
+
+type serverConfig struct {
+	Addr string `toml:"addr"`
+}
+
+type storageConfig struct {
+	Backend string `toml:"backend"` // "json" or "sqlite", see storage.go
+	Path    string `toml:"path"`    // empty means hellogo.json or hellogo.db
+}
+
+func defaultConfig() config {
+	return config{
+		Color:    true,
+		Progress: progressConfig{Path: "progress.gob"},
+		Proxy:    proxyConfig{Addr: "localhost:8000"},
+		Quiz:     quizConfig{Questions: 10},
+		Server:   serverConfig{Addr: "localhost:8080"},
+		Storage:  storageConfig{Backend: "json"},
+	}
+}
+
+// Error type carrying the line number, same idea as customError in hello.go
+type configError struct {
+	line int
+	msg  string
+}
+
+func (e *configError) Error() string {
+	if e.line == 0 {
+		return "config: " + e.msg
+	}
+	return fmt.Sprintf("config line %d: %s", e.line, e.msg)
+}
+
+// findTomlField returns the struct field tagged with key, if any
+func findTomlField(v reflect.Value, key string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("toml") == key {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+func setConfigValue(field reflect.Value, raw string) error {
+	switch field.Kind() {
+	case reflect.String:
+		s, err := strconv.Unquote(raw)
+		if err != nil {
+			return fmt.Errorf("expected a quoted string, got %s", raw)
+		}
+		field.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("expected true or false, got %s", raw)
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return fmt.Errorf("expected an integer, got %s", raw)
+		}
+		field.SetInt(n)
+	default:
+		return fmt.Errorf("unsupported field type %s", field.Kind())
+	}
+	return nil
+}
+
+// stripComment drops a # comment after a value. In a quoted string a # is part of the value
+func stripComment(raw string) string {
+	if quoted, err := strconv.QuotedPrefix(raw); err == nil {
+		if rest := strings.TrimSpace(raw[len(quoted):]); rest == "" || rest[0] == '#' {
+			return quoted
+		}
+		return raw // something else after the string; setConfigValue says what's wrong
+	}
+	value, _, _ := strings.Cut(raw, "#")
+	return strings.TrimSpace(value)
+}
+
+// parseConfig reads a config over the top of the defaults
+// keyLines remembers where each key was set so validate can point at the right line
+func parseConfig(r io.Reader) (config, error) {
+	cfg := defaultConfig()
+	keyLines := map[string]int{}
+
+	root := reflect.ValueOf(&cfg).Elem()
+	section, sectionName := root, ""
+
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(text, "[") {
+			if !strings.HasSuffix(text, "]") {
+				return cfg, &configError{line, "unterminated section header"}
+			}
+			sectionName = strings.TrimSpace(text[1 : len(text)-1])
+			s, ok := findTomlField(root, sectionName)
+			if !ok || s.Kind() != reflect.Struct {
+				return cfg, &configError{line, fmt.Sprintf("unknown section [%s]", sectionName)}
+			}
+			section = s
+			continue
+		}
+
+		key, raw, ok := strings.Cut(text, "=")
+		if !ok {
+			return cfg, &configError{line, fmt.Sprintf("expected key = value, got %q", text)}
+		}
+		key, raw = strings.TrimSpace(key), stripComment(strings.TrimSpace(raw))
+
+		field, ok := findTomlField(section, key)
+		if !ok || field.Kind() == reflect.Struct {
+			return cfg, &configError{line, fmt.Sprintf("unknown key %q", key)}
+		}
+		if err := setConfigValue(field, raw); err != nil {
+			return cfg, &configError{line, fmt.Sprintf("%s: %v", key, err)}
+		}
+
+		if sectionName != "" {
+			key = sectionName + "." + key
+		}
+		keyLines[key] = line
+	}
+	if err := scanner.Err(); err != nil {
+		return cfg, err
+	}
+
+	return cfg, cfg.validate(keyLines)
+}
+
+// validate checks values that parse fine but make no sense
+func (c config) validate(keyLines map[string]int) error {
+	if c.Quiz.Questions <= 0 {
+		return &configError{keyLines["quiz.questions"], "quiz.questions must be positive"}
+	}
+	if c.Progress.Path == "" {
+		return &configError{keyLines["progress.path"], "progress.path must not be empty"}
+	}
+	if _, err := parseProxyRoutes(c.Proxy.Routes); err != nil {
+		return &configError{keyLines["proxy.routes"], "proxy.routes: " + err.Error()}
+	}
+	if !strings.Contains(c.Server.Addr, ":") {
+		return &configError{keyLines["server.addr"], "server.addr must be host:port"}
+	}
+	if _, ok := storeOpeners[c.Storage.Backend]; !ok {
+		return &configError{keyLines["storage.backend"], fmt.Sprintf("unknown storage.backend %q", c.Storage.Backend)}
+	}
+	return nil
+}
+
+// loadConfig reads the config file at path, a missing file just means defaults
+// HELLOGO_* environment variables override the file (see env.go)
+func loadConfig(path string) (config, error) {
+	cfg := defaultConfig()
+	f, err := os.Open(path)
+	if err == nil {
+		defer f.Close()
+		cfg, err = parseConfig(f)
+	} else if os.IsNotExist(err) {
+		err = nil
+	}
+	if err != nil {
+		return config{}, err
+	}
+
+	if err := applyEnvConfig(&cfg, os.LookupEnv); err != nil {
+		return config{}, &configError{msg: err.Error()}
+	}
+	return cfg, cfg.validate(nil)
+}
+
+func testConfigParsing() {
+	good := `
+# my hellogo setup
+color = false
+
+[quiz]
+questions = 5 # the default is 10
+
+[storage]
+backend = "json" # or "sqlite"
+`
+	cfg, err := parseConfig(strings.NewReader(good))
+	fmt.Printf("%+v %v\n", cfg, err)
+	// {Color:false Progress:{Path:progress.gob} Proxy:{Addr:localhost:8000 Routes:} Quiz:{Questions:5 Seed:0} Server:{Addr:localhost:8080} Storage:{Backend:json Path:}} <nil>
+
+	for _, bad := range []string{
+		"colour = true",                  // config line 1: unknown key "colour"
+		"[quiz]\nquestions = ten",        // config line 2: questions: expected an integer, got ten
+		"[quiz]\n\n\nquestions = -1",     // config line 4: quiz.questions must be positive
+		"[server]\naddr = localhost",     // config line 2: addr: expected a quoted string, got localhost
+		"[servers]\naddr = \"x\"",        // config line 1: unknown section [servers]
+		"[server]\naddr = \"localhost\"", // config line 2: server.addr must be host:port
+	} {
+		_, err := parseConfig(strings.NewReader(bad))
+		fmt.Println(err)
+	}
+
+	// Which error was it? errors.As (or a type assertion) gets the line back out
+	_, err = parseConfig(strings.NewReader("[quiz]\nquestions = 0"))
+	if cfgErr, ok := err.(*configError); ok {
+		fmt.Println("look at line", cfgErr.line) // look at line 2
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseConfigComments(t *testing.T) {
+	for _, tc := range []struct {
+		name, text string
+		want       func(config) bool
+	}{
+		{"after a string", "[storage]\nbackend = \"sqlite\" # or \"json\"", func(c config) bool { return c.Storage.Backend == "sqlite" }},
+		{"# in a string", "[progress]\npath = \"runs#1.gob\"", func(c config) bool { return c.Progress.Path == "runs#1.gob" }},
+		{"# in a string and after it", "[progress]\npath = \"a#b.gob\" # c", func(c config) bool { return c.Progress.Path == "a#b.gob" }},
+		{"after a number", "[quiz]\nquestions = 5 # fewer", func(c config) bool { return c.Quiz.Questions == 5 }},
+		{"after a bool", "color = true#yes", func(c config) bool { return c.Color }},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := parseConfig(strings.NewReader(tc.text))
+			if err != nil || !tc.want(cfg) {
+				t.Errorf("parseConfig(%q) = %+v, %v", tc.text, cfg, err)
+			}
+		})
+	}
+
+	// Anything but a comment after a string is still an error
+	if _, err := parseConfig(strings.NewReader("[storage]\nbackend = \"json\" sqlite")); err == nil {
+		t.Error("text after a quoted value parsed without an error")
+	}
+}
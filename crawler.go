@@ -0,0 +1,252 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//////// Mini project: a concurrent web crawler
+// Start at a seed URL, fetch it, pull the links out and fetch those too, down to a
+// depth limit. Every page gets its own goroutine, which is fine because three things
+// keep them in check:
+//   - a concurrent set of seen URLs, so two pages linking to the same one fetch it once
+//   - a semaphore (a buffered channel) so only a few fetches are in flight at a time
+//   - a per-host rate limit, so we don't hammer one server however many links point at it
+// The worker pool in trace.go is the other way to bound parallelism: a fixed set of
+// goroutines taking jobs from a channel. That suits a job list known up front; here new
+// work turns up as pages are read, so a goroutine per URL plus a semaphore is simpler.
+
+// urlSet remembers which URLs the crawler has queued. seenSet is exact; a bloomSet
+// (bloom.go) takes a fixed, small amount of memory however many URLs it sees, at the
+// price of now and then calling a new URL seen and skipping it
+type urlSet interface {
+	add(key string) bool
+}
+
+// seenSet is a set that's safe to use from many goroutines
+type seenSet struct {
+	m sync.Map
+}
+
+// add reports whether key was new; LoadOrStore makes check-and-insert one step, so two
+// goroutines can't both see a URL as new
+func (s *seenSet) add(key string) bool {
+	_, loaded := s.m.LoadOrStore(key, struct{}{})
+	return !loaded
+}
+
+// hostLimiter hands out one slot per host every interval
+type hostLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     map[string]time.Time
+}
+
+func newHostLimiter(interval time.Duration) *hostLimiter {
+	return &hostLimiter{interval: interval, next: map[string]time.Time{}}
+}
+
+// wait blocks until host's next slot, or ctx is done
+func (l *hostLimiter) wait(ctx context.Context, host string) error {
+	l.mu.Lock()
+	now := time.Now()
+	slot := now
+	if next := l.next[host]; next.After(now) {
+		slot = next
+	}
+	l.next[host] = slot.Add(l.interval)
+	l.mu.Unlock()
+
+	timer := time.NewTimer(slot.Sub(now))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+type crawlResult struct {
+	URL    string
+	Depth  int
+	Status int
+	Links  []string
+	Err    error
+}
+
+type crawler struct {
+	client   *http.Client
+	maxDepth int
+	sem      chan struct{}
+	limiter  *hostLimiter
+	seen     urlSet
+
+	mu      sync.Mutex
+	results []crawlResult
+
+	inFlight, maxInFlight atomic.Int32 // only to show the semaphore working
+}
+
+func newCrawler(client *http.Client, maxDepth, parallel int, perHost time.Duration) *crawler {
+	return &crawler{
+		client:   client,
+		maxDepth: maxDepth,
+		sem:      make(chan struct{}, parallel),
+		limiter:  newHostLimiter(perHost),
+		seen:     &seenSet{},
+	}
+}
+
+// crawl fetches seed and everything reachable from it on the same host, results sorted by URL
+func (c *crawler) crawl(ctx context.Context, seed string) []crawlResult {
+	var wg sync.WaitGroup
+	c.seen.add(seed)
+	c.visit(ctx, &wg, seed, 0)
+	wg.Wait()
+	slices.SortFunc(c.results, func(a, b crawlResult) int { return cmp.Compare(a.URL, b.URL) })
+	return c.results
+}
+
+func (c *crawler) visit(ctx context.Context, wg *sync.WaitGroup, pageURL string, depth int) {
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		res := c.fetch(ctx, pageURL, depth)
+		c.mu.Lock()
+		c.results = append(c.results, res)
+		c.mu.Unlock()
+
+		if depth == c.maxDepth {
+			return
+		}
+		for _, link := range res.Links {
+			if c.seen.add(link) {
+				c.visit(ctx, wg, link, depth+1)
+			}
+		}
+	}()
+}
+
+func (c *crawler) fetch(ctx context.Context, pageURL string, depth int) crawlResult {
+	res := crawlResult{URL: pageURL, Depth: depth}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	if res.Err = c.limiter.wait(ctx, base.Host); res.Err != nil {
+		return res
+	}
+
+	c.sem <- struct{}{} // blocks while the semaphore is full
+	defer func() { <-c.sem }()
+	c.trackInFlight(c.inFlight.Add(1))
+	defer c.inFlight.Add(-1)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", pageURL, nil)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	defer resp.Body.Close()
+	res.Status = resp.StatusCode
+	if resp.StatusCode != http.StatusOK {
+		return res
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	res.Links = extractLinks(base, body)
+	return res
+}
+
+// trackInFlight raises maxInFlight to n; a compare-and-swap loop, since another fetch may
+// raise it between our load and store
+func (c *crawler) trackInFlight(n int32) {
+	for old := c.maxInFlight.Load(); n > old; old = c.maxInFlight.Load() {
+		if c.maxInFlight.CompareAndSwap(old, n) {
+			return
+		}
+	}
+}
+
+// A regexp is enough for the lesson's pages; real HTML wants a parser
+// (golang.org/x/net/html), since links also hide in single quotes, srcset, <base>...
+var hrefPattern = regexp.MustCompile(`<a\s[^>]*href="([^"#]*)`)
+
+// extractLinks returns the page's links made absolute, same host only, without duplicates
+func extractLinks(base *url.URL, body []byte) []string {
+	var links []string
+	for _, m := range hrefPattern.FindAllSubmatch(body, -1) {
+		ref, err := url.Parse(string(m[1]))
+		if err != nil {
+			continue
+		}
+		u := base.ResolveReference(ref) // "../b" on /a/x is /b
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host != base.Host {
+			continue
+		}
+		if s := u.String(); !slices.Contains(links, s) {
+			links = append(links, s)
+		}
+	}
+	return links
+}
+
+func testCrawler() {
+	pages := map[string]string{
+		"/":         `<a href="/a">a</a> <a href="b">b</a> <a href="https://example.com/">elsewhere</a>`,
+		"/a":        `<a href="/">home</a> <a href="/a/1">one</a> <a href="/a/2">two</a> <a href="/a/1#top">one again</a>`,
+		"/a/1":      `<a href="../b">b</a> <a href="/a/1/deep">too deep</a>`,
+		"/a/2":      `<a href="mailto:gopher@example.com">mail</a>`,
+		"/b":        `<a href="/missing">broken</a>`,
+		"/a/1/deep": `never fetched with a depth limit of 2`,
+	}
+	var requests atomic.Int32
+	site := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		time.Sleep(20 * time.Millisecond) // slow enough for fetches to overlap
+		page, ok := pages[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		fmt.Fprint(w, "<html><body>", page, "</body></html>")
+	}))
+	defer site.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	c := newCrawler(site.Client(), 2, 2, 10*time.Millisecond) // depth 2, 2 at a time, 100 a second
+	start := time.Now()
+	for _, r := range c.crawl(ctx, site.URL+"/") {
+		path := r.URL[len(site.URL):]
+		fmt.Printf("depth %d %-9s %d, %d links %v\n", r.Depth, path, r.Status, len(r.Links), r.Err)
+	}
+	// depth 0 /         200, 2 links <nil>
+	// depth 1 /a        200, 3 links <nil>
+	// depth 2 /a/1      200, 2 links <nil>
+	// depth 2 /a/2      200, 0 links <nil>
+	// depth 1 /b        200, 1 links <nil>
+	// depth 2 /missing  404, 0 links <nil>
+	fmt.Println(requests.Load(), c.maxInFlight.Load() <= 2) // 6 true: each page once, never more than 2 at a time
+	fmt.Println(time.Since(start) >= 50*time.Millisecond)   // true: one host, so the 6 starts were 10ms apart
+}
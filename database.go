@@ -0,0 +1,297 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+//////// Databases with database/sql
+// database/sql is the interface; a driver package provides the actual database.
+// Drivers register themselves by name when imported, usually for side effects only:
+//
+//	import _ "modernc.org/sqlite" // pure Go sqlite, no cgo needed; registers "sqlite"
+//
+// That's the one used here, imported in sqlite.go (not for js/wasm, which it can't build
+// for; sqlite_js.go says so instead). Leave it out and sql.Open fails with
+// `sql: unknown driver "sqlite" (forgotten import?)`. Everything else below is plain
+// database/sql and works the same with any driver.
+
+const sqliteDriver = "sqlite"
+
+type quizResult struct {
+	ID      string    `json:"id,omitempty"` // submission id, a ULID (ids.go); empty on results saved before there were ids
+	Lesson  string    `json:"lesson"`
+	Score   int       `json:"score"`
+	TakenAt time.Time `json:"taken_at"`
+	Comment string    `json:"comment,omitempty"` // optional; stored as NULL when empty
+}
+
+// newQuizResult is a submission with a fresh id, which sorts with the others by when it was taken
+func newQuizResult(lesson string, score int, takenAt time.Time) quizResult {
+	return quizResult{ID: newULID(takenAt).String(), Lesson: lesson, Score: score, TakenAt: takenAt}
+}
+
+// sqlStore keeps progress, quiz results and achievements in a SQL database
+type sqlStore struct {
+	db *sql.DB
+}
+
+// openSQLStore opens the database and brings its schema up to date (see migrate.go)
+func openSQLStore(ctx context.Context, path string) (*sqlStore, error) {
+	db, err := openSQLite(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if err := migrateTo(ctx, db, storeMigrations, len(storeMigrations), nil); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &sqlStore{db: db}, nil
+}
+
+func (s *sqlStore) Close() error { return s.db.Close() }
+
+// SaveProgress writes every record in one transaction: all of them land, or none do
+func (s *sqlStore) SaveProgress(ctx context.Context, ps []progress) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	// Rollback after a successful Commit is a harmless no-op, so always defer it
+	defer tx.Rollback()
+
+	// Prepare once, run many times. ALWAYS use ? placeholders for values,
+	// never fmt.Sprintf them into the SQL (that's how SQL injection happens)
+	stmt, err := tx.PrepareContext(ctx, `
+		INSERT INTO progress (lesson, completed, attempts, last_seen) VALUES (?, ?, ?, ?)
+		ON CONFLICT (lesson) DO UPDATE SET
+			completed = excluded.completed,
+			attempts = excluded.attempts,
+			last_seen = excluded.last_seen`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+
+	for _, p := range ps {
+		lastSeen := sql.NullInt64{Int64: p.LastSeen, Valid: p.LastSeen != 0}
+		if _, err := stmt.ExecContext(ctx, p.Lesson, p.Completed, p.Attempts, lastSeen); err != nil {
+			return fmt.Errorf("saving %s: %w", p.Lesson, err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *sqlStore) LoadProgress(ctx context.Context) ([]progress, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT lesson, completed, attempts, last_seen FROM progress ORDER BY lesson`)
+	if err != nil {
+		return nil, err
+	}
+	// Forgetting to close rows keeps a connection busy forever
+	defer rows.Close()
+
+	var ps []progress
+	for rows.Next() {
+		var p progress
+		var lastSeen sql.NullInt64 // scanning NULL into a plain int64 is an error
+		if err := rows.Scan(&p.Lesson, &p.Completed, &p.Attempts, &lastSeen); err != nil {
+			return nil, err
+		}
+		p.LastSeen = lastSeen.Int64 // 0 when NULL
+		ps = append(ps, p)
+	}
+	// Like bufio.Scanner, Next returning false might mean an error, not the end
+	return ps, rows.Err()
+}
+
+func (s *sqlStore) SaveQuizResult(ctx context.Context, r quizResult) error {
+	comment := sql.NullString{String: r.Comment, Valid: r.Comment != ""}
+	id := sql.NullString{String: r.ID, Valid: r.ID != ""}
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO quiz_results (submission_id, lesson, score, taken_at, comment) VALUES (?, ?, ?, ?, ?)`,
+		id, r.Lesson, r.Score, r.TakenAt.Unix(), comment)
+	return err
+}
+
+func (s *sqlStore) QuizResults(ctx context.Context) ([]quizResult, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT submission_id, lesson, score, taken_at, comment FROM quiz_results ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var results []quizResult
+	for rows.Next() {
+		var r quizResult
+		var takenAt int64
+		var id, comment sql.NullString
+		if err := rows.Scan(&id, &r.Lesson, &r.Score, &takenAt, &comment); err != nil {
+			return nil, err
+		}
+		r.ID = id.String
+		r.TakenAt = time.Unix(takenAt, 0).UTC()
+		r.Comment = comment.String
+		results = append(results, r)
+	}
+	return results, rows.Err()
+}
+
+func (s *sqlStore) UnlockAchievement(ctx context.Context, name string, at time.Time) error {
+	// The UNIQUE constraint plus DO NOTHING keeps the first unlock time
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO achievements (name, unlocked_at) VALUES (?, ?) ON CONFLICT (name) DO NOTHING`,
+		name, at.Unix())
+	return err
+}
+
+func (s *sqlStore) Achievements(ctx context.Context) ([]achievement, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT name, unlocked_at FROM achievements ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var as []achievement
+	for rows.Next() {
+		var a achievement
+		var unlockedAt int64
+		if err := rows.Scan(&a.Name, &unlockedAt); err != nil {
+			return nil, err
+		}
+		a.UnlockedAt = time.Unix(unlockedAt, 0).UTC()
+		as = append(as, a)
+	}
+	return as, rows.Err()
+}
+
+func (s *sqlStore) AddTodo(ctx context.Context, title string, created time.Time) (todo, error) {
+	t := todo{Title: title, Created: created.Truncate(time.Second).UTC()}
+	res, err := s.db.ExecContext(ctx, `INSERT INTO todos (title, done, created) VALUES (?, ?, ?)`,
+		t.Title, t.Done, t.Created.Unix())
+	if err != nil {
+		return todo{}, err
+	}
+	id, err := res.LastInsertId() // the id sqlite picked
+	t.ID = int(id)
+	return t, err
+}
+
+func (s *sqlStore) Todos(ctx context.Context) ([]todo, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, title, done, created FROM todos ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var todos []todo
+	for rows.Next() {
+		var t todo
+		var created int64
+		if err := rows.Scan(&t.ID, &t.Title, &t.Done, &created); err != nil {
+			return nil, err
+		}
+		t.Created = time.Unix(created, 0).UTC()
+		todos = append(todos, t)
+	}
+	return todos, rows.Err()
+}
+
+// changeOne runs a statement meant to change one row, returning notFound if it matched none
+func (s *sqlStore) changeOne(ctx context.Context, notFound error, query string, args ...any) error {
+	res, err := s.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return err
+	}
+	n, err := res.RowsAffected()
+	if err == nil && n == 0 {
+		err = notFound
+	}
+	return err
+}
+
+func (s *sqlStore) SetTodoDone(ctx context.Context, id int, done bool) error {
+	return s.changeOne(ctx, errTodoNotFound, `UPDATE todos SET done = ? WHERE id = ?`, done, id)
+}
+
+func (s *sqlStore) DeleteTodo(ctx context.Context, id int) error {
+	return s.changeOne(ctx, errTodoNotFound, `DELETE FROM todos WHERE id = ?`, id)
+}
+
+func (s *sqlStore) CreateLink(ctx context.Context, url string, created time.Time) (link, error) {
+	l := link{URL: url, Created: created.Truncate(time.Second).UTC()}
+	res, err := s.db.ExecContext(ctx, `INSERT INTO links (url, created) VALUES (?, ?)`, l.URL, l.Created.Unix())
+	if err != nil {
+		return link{}, err
+	}
+	id, err := res.LastInsertId()
+	l.ID = int(id)
+	return l, err
+}
+
+func (s *sqlStore) Link(ctx context.Context, id int) (link, error) {
+	l := link{ID: id}
+	var created int64
+	err := s.db.QueryRowContext(ctx, `SELECT url, created, visits FROM links WHERE id = ?`, id).
+		Scan(&l.URL, &created, &l.Visits)
+	if errors.Is(err, sql.ErrNoRows) {
+		return link{}, errLinkNotFound
+	}
+	if err != nil {
+		return link{}, err
+	}
+	l.Created = time.Unix(created, 0).UTC()
+	return l, nil
+}
+
+func (s *sqlStore) RecordVisit(ctx context.Context, id int) error {
+	// visits = visits + 1 in the database, not read-add-write in Go, so concurrent
+	// visits can't overwrite each other's counts
+	return s.changeOne(ctx, errLinkNotFound, `UPDATE links SET visits = visits + 1 WHERE id = ?`, id)
+}
+
+// bestScore shows QueryRow, and sql.ErrNoRows for "nothing matched"
+func (s *sqlStore) bestScore(ctx context.Context, lesson string) (int, error) {
+	var score int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT score FROM quiz_results WHERE lesson = ? ORDER BY score DESC LIMIT 1`, lesson).Scan(&score)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, fmt.Errorf("no quiz results for %s", lesson)
+	}
+	return score, err
+}
+
+func testDatabase() {
+	dir := makeTempDir("db")
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+
+	s, err := openSQLStore(ctx, filepath.Join(dir, "hellogo.db"))
+	if err != nil {
+		fmt.Println("open failed:", err) // a directory that can't be written to, say
+		return
+	}
+	defer s.Close()
+
+	ps := sampleProgress(3)
+	ps[1].LastSeen = 0 // goes in as NULL
+	if err := s.SaveProgress(ctx, ps); err != nil {
+		fmt.Println("save failed:", err)
+		return
+	}
+	loaded, err := s.LoadProgress(ctx)
+	fmt.Println(len(loaded), loaded[1].LastSeen, err) // 3 0 <nil>
+
+	taken := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+	s.SaveQuizResult(ctx, quizResult{Lesson: "lesson-0", Score: 7, TakenAt: taken})
+	s.SaveQuizResult(ctx, quizResult{Lesson: "lesson-0", Score: 9, TakenAt: taken, Comment: "much better"})
+	results, err := s.QuizResults(ctx)
+	fmt.Printf("%+v %v\n", results, err)
+
+	fmt.Println(s.bestScore(ctx, "lesson-0")) // 9 <nil>
+	fmt.Println(s.bestScore(ctx, "lesson-9")) // 0 no quiz results for lesson-9
+}
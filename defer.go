@@ -0,0 +1,223 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+//////// defer in depth
+// testFinally in hello.go defers a Close. The rules behind it:
+//   - the deferred call runs when the function returns, however it returns: a return
+//     statement, the end of the body, or a panic on its way up. Not os.Exit, which
+//     ends the program on the spot, and not log.Fatal, which calls it
+//   - the function and its arguments are evaluated when the defer statement runs, not
+//     when the call does. defer fmt.Println(x) prints x as it was then; a deferred
+//     closure reads x when it finally runs
+//   - defers run last in, first out, so cleanup undoes setup in reverse order
+//   - a deferred closure can read and change the function's named results, after the
+//     return statement has set them. That's how recover turns a panic into an error,
+//     and how a Close error gets reported from a function that already returned
+//   - defers in a loop don't run at the end of each iteration but at the end of the
+//     function, all of them. Opening files in a loop with defer Close keeps every one
+//     open until then. Move the body into a function so each defer runs in time
+//
+// Loop variables: since Go 1.22 (decided by the go line in go.mod) each iteration of a
+// for loop has its own copy of the variable, so closures deferred or started in a loop
+// see the value from their own iteration. Before that there was one variable for the
+// whole loop, and every closure saw its last value; i := i in the body was the fix.
+//
+// Cost: defers at most once per call (not in a loop) are "open-coded", compiled into
+// the function's exits and nearly free. Defers in a loop need a record each at run
+// time, which costs more; see testDeferBenchmarks.
+
+// deferDouble returns 6: return sets n to 3, then the deferred closure doubles it
+func deferDouble() (n int) {
+	defer func() { n *= 2 }()
+	return 3
+}
+
+// safeDivide turns a division by zero panic into an error through its named result
+func safeDivide(a, b int) (q int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("dividing %d by %d: %v", a, b, r)
+		}
+	}()
+	return a / b, nil
+}
+
+// writeAndClose reports the Close error when the writes went fine: for a file, Close
+// can be where a failed write (a full disk, say) first shows up
+func writeAndClose(path, data string) (err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}()
+	_, err = f.WriteString(data)
+	return err
+}
+
+// openTracker counts open resources, and the most that were open at once
+type openTracker struct {
+	open, peak int
+}
+
+func (t *openTracker) Open() *openTracker {
+	t.open++
+	t.peak = max(t.peak, t.open)
+	return t
+}
+
+func (t *openTracker) Close() { t.open-- }
+
+func testDefer() {
+	//// When arguments are evaluated
+	func() {
+		x := 1
+		defer fmt.Println("argument:", x)             // x is read now
+		defer func() { fmt.Println("closure:", x) }() // x is read when it runs
+		x = 2
+	}()
+	// closure: 2
+	// argument: 1
+
+	// A struct argument is copied then too
+	func() {
+		p := person{"Bob", 20}
+		defer fmt.Println(p) // {Bob 20}
+		p.age = 99
+	}()
+
+	//// Last in, first out
+	func() {
+		for _, step := range []string{"open", "lock", "begin"} {
+			defer fmt.Println("undo", step)
+		}
+	}()
+	// undo begin
+	// undo lock
+	// undo open
+
+	//// Loop variables
+	var seen []int
+	func() {
+		for i := 0; i < 3; i++ {
+			defer func() { seen = append(seen, i) }()
+		}
+	}()
+	fmt.Println(seen) // [2 1 0]: a new i each iteration (Go 1.22 and later)
+	seen = nil
+	func() {
+		var i int // what the loop above was before Go 1.22: one i for every iteration
+		for i = 0; i < 3; i++ {
+			defer func() { seen = append(seen, i) }()
+		}
+	}()
+	fmt.Println(seen) // [3 3 3]: every closure sees the i that ended the loop
+
+	// Same thing with goroutines, which is where it usually bit
+	var wg sync.WaitGroup
+	results := make([]int, 3)
+	for i := range 3 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = i * 10
+		}()
+	}
+	wg.Wait()
+	fmt.Println(results) // [0 10 20]
+
+	//// Named results
+	fmt.Println(deferDouble()) // 6
+	fmt.Println(safeDivide(7, 2))
+	fmt.Println(safeDivide(7, 0))
+	// 3 <nil>
+	// 0 dividing 7 by 0: runtime error: integer divide by zero
+
+	dir := makeTempDir("defer-deep")
+	defer os.RemoveAll(dir)
+	fmt.Println(writeAndClose(filepath.Join(dir, "ok.txt"), "data")) // <nil>
+	err := writeAndClose(filepath.Join(dir, "missing", "x.txt"), "data")
+	fmt.Println(errors.Is(err, os.ErrNotExist)) // true
+
+	//// Defer in a loop
+	var t openTracker
+	func() {
+		for range 5 {
+			r := t.Open()
+			defer r.Close() // waits for the end of the function
+		}
+	}()
+	fmt.Println("defer in the loop:", t.peak) // defer in the loop: 5
+
+	t = openTracker{}
+	for range 5 {
+		func() {
+			r := t.Open()
+			defer r.Close() // runs at the end of this iteration's function
+		}()
+	}
+	fmt.Println("function per item:", t.peak) // function per item: 1
+
+	runCase("TestDefer/panic runs defers", func(errorf errorfFunc) {
+		ran := false
+		msg := panicMessage(func() {
+			defer func() { ran = true }()
+			panic("boom")
+		})
+		if !ran || msg != "boom" {
+			errorf("deferred call ran %v, panic %q", ran, msg)
+		}
+	})
+	runCase("TestDefer/close error", func(errorf errorfFunc) {
+		dir := makeTempDir("defer-close")
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "x.txt")
+		if err := writeAndClose(path, "hello"); err != nil {
+			errorf("writeAndClose: %v", err)
+		}
+		if data, _ := os.ReadFile(path); string(data) != "hello" {
+			errorf("wrote %q, want %q", data, "hello")
+		}
+	})
+}
+
+// testDeferBenchmarks compares a plain call, the same call deferred once (open-coded),
+// and deferred in a loop of one iteration, which can't be open-coded
+func testDeferBenchmarks() {
+	var mu sync.Mutex
+	printBench("BenchmarkUnlock/direct", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			mu.Lock()
+			mu.Unlock()
+		}
+	})
+	printBench("BenchmarkUnlock/defer", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			func() {
+				mu.Lock()
+				defer mu.Unlock()
+			}()
+		}
+	})
+	printBench("BenchmarkUnlock/defer-in-loop", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			func() {
+				for range 1 {
+					mu.Lock()
+					defer mu.Unlock()
+				}
+			}()
+		}
+	})
+}
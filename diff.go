@@ -0,0 +1,284 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+//////// Diffs
+// A diff is the shortest set of lines to delete from a and add to get b. Turned around,
+// that's the longest common subsequence (LCS): the most lines the two share, in the
+// same order, not necessarily next to each other. Everything in a that isn't in the
+// LCS was deleted, everything in b that isn't was added.
+//
+// The LCS comes from a table: lcs[i][j] is the LCS length of a[i:] and b[j:], filled
+// from the end. If a[i] == b[j] that line is in it, 1 + lcs[i+1][j+1]; if not, drop a
+// line from one side or the other, whichever keeps more. Walking the table from [0][0]
+// reads off the edits. That's len(a)×len(b) cells, so diffLines first strips the lines
+// the two start and end with, which is usually most of them, and gives up on a
+// minimal diff when the middle is still too big (git and GNU diff use Myers' algorithm,
+// which is fast when the differences are small, whatever the file size).
+//
+// The unified format is what git diff prints: hunks of changes with a few unchanged
+// lines around them for context, each under a header saying where it is,
+// @@ -start,count +start,count @@, in lines of a and of b.
+//
+//	hellogo tool diff [-context n] a b
+//
+// hellogo verify shows golden file mismatches (golden.go) the same way.
+
+// diffOp is one line of an edit script: kept (' '), deleted from a ('-') or added from b ('+')
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// maxDiffCells caps the LCS table: beyond it, the unmatched middle is all deleted and then all added
+const maxDiffCells = 25_000_000
+
+// splitLines keeps each line's \n, so a last line without one differs from one with it
+func splitLines(s string) []string {
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
+
+func diffLines(a, b []string) []diffOp {
+	var ops []diffOp
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		ops = append(ops, diffOp{' ', a[prefix]})
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	ops = append(ops, diffMiddle(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+func diffMiddle(a, b []string) []diffOp {
+	var ops []diffOp
+	if len(a)*len(b) > maxDiffCells {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+
+	// lcs[i][j]: length of the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	// Deletions before additions where either would do, as diff and git print them
+	for i, j := 0, 0; i < len(a) || j < len(b); {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i, j = i+1, j+1
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	return ops
+}
+
+// hunkRange is the header's start,count: 1-based, the count left out when it's 1, and
+// for no lines at all, the line they'd come after
+func hunkRange(start, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", start)
+	case 1:
+		return fmt.Sprint(start + 1)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// unifiedDiff is a diff -u of a and b, labelled with their names; "" when they're the same
+func unifiedDiff(aName, bName, a, b string, context int) string {
+	ops := diffLines(splitLines(a), splitLines(b))
+
+	// The lines of a and b before each op
+	aAt, bAt := make([]int, len(ops)+1), make([]int, len(ops)+1)
+	var changes []int
+	for k, op := range ops {
+		aAt[k+1], bAt[k+1] = aAt[k], bAt[k]
+		if op.kind != '+' {
+			aAt[k+1]++
+		}
+		if op.kind != '-' {
+			bAt[k+1]++
+		}
+		if op.kind != ' ' {
+			changes = append(changes, k)
+		}
+	}
+	if len(changes) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s\n+++ %s\n", aName, bName)
+	for c := 0; c < len(changes); {
+		// A hunk runs until the gap to the next change is too long to share context
+		end := c
+		for end+1 < len(changes) && changes[end+1]-changes[end] <= 2*context {
+			end++
+		}
+		from, to := max(0, changes[c]-context), min(len(ops), changes[end]+context+1)
+		fmt.Fprintf(&sb, "@@ -%s +%s @@\n", hunkRange(aAt[from], aAt[to]-aAt[from]), hunkRange(bAt[from], bAt[to]-bAt[from]))
+		for _, op := range ops[from:to] {
+			sb.WriteByte(op.kind)
+			sb.WriteString(op.line)
+			if !strings.HasSuffix(op.line, "\n") {
+				sb.WriteString("\n\\ No newline at end of file\n")
+			}
+		}
+		c = end + 1
+	}
+	return sb.String()
+}
+
+var errFilesDiffer = errors.New("files differ")
+
+// hellogo tool diff [-context n] a b: exits 1 when they differ, like diff
+func diffCommand(args []string) error {
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	context := flags.Int("context", 3, "unchanged lines around each change")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 2 || *context < 0 {
+		return errUsage
+	}
+	args = flags.Args()
+	a, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	b, err := os.ReadFile(args[1])
+	if err != nil {
+		return err
+	}
+	if d := unifiedDiff(args[0], args[1], string(a), string(b), *context); d != "" {
+		fmt.Print(d)
+		return errFilesDiffer
+	}
+	return nil
+}
+
+func testDiff() {
+	a := "package main\n\nimport \"fmt\"\n\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n"
+	b := "package main\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n\nfunc main() {\n\tfmt.Println(\"hello\")\n}\n"
+	fmt.Print(unifiedDiff("a/main.go", "b/main.go", a, b, 1))
+	// --- a/main.go
+	// +++ b/main.go
+	// @@ -2,3 +2,6 @@
+	//
+	// -import "fmt"
+	// +import (
+	// +	"fmt"
+	// +	"os"
+	// +)
+	//
+
+	// Two changes far enough apart are two hunks; the line counts say where each one is
+	var old, changed []string
+	for i := 1; i <= 20; i++ {
+		old = append(old, fmt.Sprint("line ", i))
+		changed = append(changed, fmt.Sprint("line ", i))
+	}
+	changed[2] = "line three"
+	changed = append(changed[:15], changed[16:]...) // line 16 goes
+	fmt.Print(unifiedDiff("old", "new", strings.Join(old, "\n")+"\n", strings.Join(changed, "\n"), 2))
+	// --- old
+	// +++ new
+	// @@ -1,5 +1,5 @@
+	//  line 1
+	//  line 2
+	// -line 3
+	// +line three
+	//  line 4
+	//  line 5
+	// @@ -14,7 +14,6 @@
+	//  line 14
+	//  line 15
+	// -line 16
+	//  line 17
+	//  line 18
+	//  line 19
+	// -line 20
+	// +line 20
+	// \ No newline at end of file
+	// The last line lost its \n, which is a change too
+
+	runCase("TestDiff/apply", func(errorf errorfFunc) {
+		// Keeping the ' ' and '+' lines of the script gives b back, and ' ' and '-' gives a
+		for _, tc := range [][2]string{
+			{"", "a\nb\n"},
+			{"a\nb\nc\n", ""},
+			{"a\nb\nc\n", "a\nc\n"},
+			{"x\na\nb\n", "a\nb\ny\n"},
+			{"a\nb\nc\nd\n", "d\nc\nb\na\n"},
+			{"same\n", "same\n"},
+		} {
+			var gotA, gotB string
+			ops := diffLines(splitLines(tc[0]), splitLines(tc[1]))
+			kept := 0
+			for _, op := range ops {
+				if op.kind != '+' {
+					gotA += op.line
+				}
+				if op.kind != '-' {
+					gotB += op.line
+				}
+				if op.kind == ' ' {
+					kept++
+				}
+			}
+			if gotA != tc[0] || gotB != tc[1] {
+				errorf("diff of %q and %q rebuilds %q and %q", tc[0], tc[1], gotA, gotB)
+			}
+			// reversing 4 lines can keep only 1 of them
+			if tc[0] == "a\nb\nc\nd\n" && kept != 1 {
+				errorf("reversed lines kept %d, want 1", kept)
+			}
+		}
+	})
+	runCase("TestDiff/ranges", func(errorf errorfFunc) {
+		if got := unifiedDiff("a", "b", "", "x\ny\n", 3); !strings.Contains(got, "@@ -0,0 +1,2 @@") {
+			errorf("diff from an empty file:\n%s", got)
+		}
+		if got := unifiedDiff("a", "b", "x\n", "y\n", 3); !strings.Contains(got, "@@ -1 +1 @@") {
+			errorf("diff of one line each:\n%s", got)
+		}
+		if got := unifiedDiff("a", "b", "same\n", "same\n", 3); got != "" {
+			errorf("no changes gave %q", got)
+		}
+	})
+}
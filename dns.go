@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"time"
+)
+
+//////// DNS lookups and IP addresses
+// net.Resolver does the lookups; every method takes a context so a slow DNS server
+// can't hang you. net.DefaultResolver uses the system's settings (/etc/resolv.conf)
+
+func lookupContext() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), 3*time.Second)
+}
+
+func testDNSLookups() {
+	ctx, cancel := lookupContext()
+	defer cancel()
+	r := net.DefaultResolver
+
+	addrs, err := r.LookupHost(ctx, "go.dev")
+	fmt.Println(addrs, err)
+
+	mxs, err := r.LookupMX(ctx, "gmail.com")
+	for _, mx := range mxs {
+		fmt.Println("mx", mx.Pref, mx.Host)
+	}
+	if err != nil {
+		fmt.Println(err)
+	}
+
+	txts, err := r.LookupTXT(ctx, "google.com")
+	fmt.Println(len(txts), "txt records", err)
+
+	// Names that don't exist come back as a *net.DNSError with IsNotFound set
+	_, err = r.LookupHost(ctx, "no-such-host.invalid")
+	if dnsErr, ok := err.(*net.DNSError); ok {
+		fmt.Println(dnsErr.IsNotFound, dnsErr) // true lookup no-such-host.invalid: no such host
+	}
+}
+
+//// net/netip
+// netip.Addr is a small comparable value type (usable as a map key, no allocations),
+// the modern replacement for net.IP which is a []byte
+
+func classifyIP(addr netip.Addr) string {
+	switch {
+	case addr.IsLoopback():
+		return "loopback"
+	case addr.IsPrivate():
+		return "private"
+	case addr.IsLinkLocalUnicast():
+		return "link-local"
+	case addr.IsMulticast():
+		return "multicast"
+	case addr.IsUnspecified():
+		return "unspecified"
+	case addr.IsGlobalUnicast():
+		return "public"
+	}
+	return "other"
+}
+
+func testNetip() {
+	for _, s := range []string{"127.0.0.1", "10.1.2.3", "8.8.8.8", "::1", "fe80::1", "2606:4700::1111", "not.an.ip"} {
+		addr, err := netip.ParseAddr(s)
+		if err != nil {
+			fmt.Println(err) // ParseAddr("not.an.ip"): unexpected character ...
+			continue
+		}
+		fmt.Printf("%-16s v4=%-5v %s\n", addr, addr.Is4(), classifyIP(addr))
+	}
+
+	// Prefixes (CIDR blocks)
+	office := netip.MustParsePrefix("192.168.0.0/16")
+	fmt.Println(office.Contains(netip.MustParseAddr("192.168.42.7"))) // true
+	fmt.Println(office.Contains(netip.MustParseAddr("10.0.0.1")))     // false
+
+	// Address + port
+	ap, _ := netip.ParseAddrPort("[::1]:8080")
+	fmt.Println(ap.Addr(), ap.Port()) // ::1 8080
+}
+
+// hellogo resolve <name>...; every address with what kind it is, plus mail servers
+func resolveCommand(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	ctx, cancel := lookupContext()
+	defer cancel()
+
+	for _, name := range args {
+		addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", name)
+		if err != nil {
+			return err
+		}
+		for _, addr := range addrs {
+			addr = addr.Unmap() // ::ffff:1.2.3.4 -> 1.2.3.4
+			fmt.Printf("%s\t%s\t%s\n", name, addr, classifyIP(addr))
+		}
+		// No MX records is normal for most names, so errors here aren't fatal
+		mxs, _ := net.DefaultResolver.LookupMX(ctx, name)
+		for _, mx := range mxs {
+			fmt.Printf("%s\tmx %d %s\n", name, mx.Pref, mx.Host)
+		}
+	}
+	return nil
+}
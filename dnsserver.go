@@ -0,0 +1,454 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//////// Mini project: a toy DNS server
+// dns.go asks DNS servers questions; this is the other end, an authoritative server for
+// one zone that answers A (IPv4 address) queries over UDP. Every DNS message is the same
+// layout, all numbers big endian:
+//
+//	header    12 bytes: ID, flags, then how many questions, answers, authority and
+//	          additional records follow (2 bytes each)
+//	question  name, type (1 = A), class (1 = IN, the internet)
+//	answer    name, type, class, TTL (4 bytes), data length, data (4 bytes for an A record)
+//
+// Names are labels, each a length byte and that many bytes, ending with a 0 length:
+// www.hellogo.test is 3 www 7 hellogo 4 test 0. To save space a name can end with a
+// pointer instead: two bytes with the top bits set, the rest an offset into the message
+// where the name carries on. A server has to follow them (with a limit, or a pointer to
+// itself loops forever); this one never writes them, which is allowed.
+//
+// The zone is a file in (a small part of) the standard zone file format, see
+// testdata/dnsserver/example.zone. Try it with dig:
+//
+//	hellogo dnsserver
+//	dig @127.0.0.1 -p 5353 www.hellogo.test
+
+const (
+	dnsTypeA   = 1
+	dnsClassIN = 1
+
+	dnsFlagResponse         = 1 << 15
+	dnsFlagAuthoritative    = 1 << 10
+	dnsFlagTruncated        = 1 << 9
+	dnsFlagRecursionDesired = 1 << 8
+
+	// Response codes, the low 4 bits of the flags
+	dnsRcodeFormatError    = 1
+	dnsRcodeNameError      = 3 // NXDOMAIN: no such name
+	dnsRcodeNotImplemented = 4
+	dnsRcodeRefused        = 5 // not our zone
+
+	dnsMaxUDPSize = 512 // without EDNS0, a UDP answer must fit in this; bigger ones are truncated
+)
+
+var errDNSShort = errors.New("dns: message too short")
+
+// Exported fields, in wire order, so binary.Read and binary.Write can do it in one go
+type dnsHeader struct {
+	ID      uint16
+	Flags   uint16
+	QDCount uint16 // questions
+	ANCount uint16 // answers
+	NSCount uint16 // authority records
+	ARCount uint16 // additional records
+}
+
+func (h dnsHeader) opcode() int { return int(h.Flags>>11) & 0xF }
+func (h dnsHeader) rcode() int  { return int(h.Flags & 0xF) }
+
+type dnsQuestion struct {
+	Name        string // fully qualified, with the trailing dot
+	Type, Class uint16
+}
+
+type dnsRecord struct {
+	dnsQuestion
+	TTL  uint32
+	Data []byte
+}
+
+type dnsMessage struct {
+	Header    dnsHeader
+	Questions []dnsQuestion
+	Answers   []dnsRecord // authority and additional records are skipped when parsing
+}
+
+//// Reading
+
+// readDNSName reads the name at off and returns it with the offset just after it,
+// which after a pointer is just after the pointer, not after where it led
+func readDNSName(msg []byte, off int) (string, int, error) {
+	var labels []string
+	next := -1
+	for jumps := 0; ; {
+		if off >= len(msg) {
+			return "", 0, errDNSShort
+		}
+		n := int(msg[off])
+		switch {
+		case n == 0:
+			if next < 0 {
+				next = off + 1
+			}
+			return strings.Join(labels, ".") + ".", next, nil
+		case n&0xC0 == 0xC0:
+			if off+1 >= len(msg) {
+				return "", 0, errDNSShort
+			}
+			if jumps++; jumps > 16 {
+				return "", 0, errors.New("dns: too many compression pointers")
+			}
+			if next < 0 {
+				next = off + 2
+			}
+			off = int(binary.BigEndian.Uint16(msg[off:]) & 0x3FFF)
+		case n > 63:
+			return "", 0, fmt.Errorf("dns: bad label length %d", n)
+		default:
+			if off+1+n > len(msg) {
+				return "", 0, errDNSShort
+			}
+			labels = append(labels, string(msg[off+1:off+1+n]))
+			off += 1 + n
+		}
+	}
+}
+
+func readDNSQuestion(msg []byte, off int) (dnsQuestion, int, error) {
+	name, off, err := readDNSName(msg, off)
+	if err != nil {
+		return dnsQuestion{}, 0, err
+	}
+	if off+4 > len(msg) {
+		return dnsQuestion{}, 0, errDNSShort
+	}
+	q := dnsQuestion{name, binary.BigEndian.Uint16(msg[off:]), binary.BigEndian.Uint16(msg[off+2:])}
+	return q, off + 4, nil
+}
+
+func parseDNSMessage(msg []byte) (dnsMessage, error) {
+	var m dnsMessage
+	if err := binary.Read(bytes.NewReader(msg), binary.BigEndian, &m.Header); err != nil {
+		return m, errDNSShort
+	}
+	off := 12
+	for range m.Header.QDCount {
+		q, next, err := readDNSQuestion(msg, off)
+		if err != nil {
+			return m, err
+		}
+		m.Questions = append(m.Questions, q)
+		off = next
+	}
+	for range m.Header.ANCount {
+		q, next, err := readDNSQuestion(msg, off) // a record starts like a question
+		if err != nil {
+			return m, err
+		}
+		if next+6 > len(msg) {
+			return m, errDNSShort
+		}
+		ttl, size := binary.BigEndian.Uint32(msg[next:]), int(binary.BigEndian.Uint16(msg[next+4:]))
+		next += 6
+		if next+size > len(msg) {
+			return m, errDNSShort
+		}
+		m.Answers = append(m.Answers, dnsRecord{q, ttl, msg[next : next+size]})
+		off = next + size
+	}
+	return m, nil
+}
+
+//// Writing
+
+func appendDNSName(b []byte, name string) ([]byte, error) {
+	name = strings.TrimSuffix(name, ".")
+	if len(name) > 253 {
+		return nil, fmt.Errorf("dns: name %q too long", name)
+	}
+	if name != "" {
+		for _, label := range strings.Split(name, ".") {
+			if len(label) == 0 || len(label) > 63 {
+				return nil, fmt.Errorf("dns: bad label %q in %q", label, name)
+			}
+			b = append(b, byte(len(label)))
+			b = append(b, label...)
+		}
+	}
+	return append(b, 0), nil
+}
+
+func appendDNSQuestion(b []byte, q dnsQuestion) ([]byte, error) {
+	b, err := appendDNSName(b, q.Name)
+	if err != nil {
+		return nil, err
+	}
+	b = binary.BigEndian.AppendUint16(b, q.Type)
+	return binary.BigEndian.AppendUint16(b, q.Class), nil
+}
+
+// marshal fills in the header's counts from the slices
+func (m dnsMessage) marshal() ([]byte, error) {
+	h := m.Header
+	h.QDCount, h.ANCount, h.NSCount, h.ARCount = uint16(len(m.Questions)), uint16(len(m.Answers)), 0, 0
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, h) // writes to a bytes.Buffer don't fail
+	b := buf.Bytes()
+
+	var err error
+	for _, q := range m.Questions {
+		if b, err = appendDNSQuestion(b, q); err != nil {
+			return nil, err
+		}
+	}
+	for _, r := range m.Answers {
+		if b, err = appendDNSQuestion(b, r.dnsQuestion); err != nil {
+			return nil, err
+		}
+		b = binary.BigEndian.AppendUint32(b, r.TTL)
+		b = binary.BigEndian.AppendUint16(b, uint16(len(r.Data)))
+		b = append(b, r.Data...)
+	}
+	return b, nil
+}
+
+//// The zone
+
+type dnsZone struct {
+	origin  string                 // lower case, with the trailing dot
+	records map[string][]dnsRecord // by lower case name
+}
+
+// parseDNSZone reads lines of "name ttl A address", after a $ORIGIN line. ; starts a comment
+func parseDNSZone(r io.Reader) (*dnsZone, error) {
+	z := &dnsZone{records: map[string][]dnsRecord{}}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), ";")
+		fields := strings.Fields(text)
+		switch {
+		case len(fields) == 0:
+			continue
+		case fields[0] == "$ORIGIN" && len(fields) == 2 && strings.HasSuffix(fields[1], "."):
+			z.origin = strings.ToLower(fields[1])
+			continue
+		case z.origin == "":
+			return nil, fmt.Errorf("zone line %d: expected $ORIGIN name. first", line)
+		case len(fields) != 4:
+			return nil, fmt.Errorf("zone line %d: expected name ttl A address", line)
+		case !strings.EqualFold(fields[2], "A"):
+			return nil, fmt.Errorf("zone line %d: only A records are supported, not %s", line, fields[2])
+		}
+
+		name := strings.ToLower(fields[0])
+		switch {
+		case name == "@":
+			name = z.origin
+		case !strings.HasSuffix(name, "."):
+			name += "." + z.origin
+		}
+		if name != z.origin && !strings.HasSuffix(name, "."+z.origin) {
+			return nil, fmt.Errorf("zone line %d: %s isn't in %s", line, name, z.origin)
+		}
+		ttl, err := strconv.ParseUint(fields[1], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("zone line %d: bad ttl %q", line, fields[1])
+		}
+		addr, err := netip.ParseAddr(fields[3])
+		if err != nil || !addr.Is4() {
+			return nil, fmt.Errorf("zone line %d: %q isn't an IPv4 address", line, fields[3])
+		}
+		ip := addr.As4()
+		rec := dnsRecord{dnsQuestion{name, dnsTypeA, dnsClassIN}, uint32(ttl), ip[:]}
+		z.records[name] = append(z.records[name], rec)
+	}
+	if z.origin == "" && scanner.Err() == nil {
+		return nil, errors.New("zone: no $ORIGIN")
+	}
+	return z, scanner.Err()
+}
+
+// answer builds the reply to query. False means don't reply at all: without a whole
+// header there isn't even an ID to reply to
+func (z *dnsZone) answer(query []byte) ([]byte, bool) {
+	q, err := parseDNSMessage(query)
+	if len(query) < 12 || q.Header.Flags&dnsFlagResponse != 0 {
+		return nil, false
+	}
+	reply := dnsMessage{Header: dnsHeader{
+		ID:    q.Header.ID,
+		Flags: dnsFlagResponse | dnsFlagAuthoritative | q.Header.Flags&(0xF<<11|dnsFlagRecursionDesired), // same opcode and RD
+	}}
+	rcode := 0
+	switch {
+	case q.Header.opcode() != 0: // only standard queries, no notify/update
+		rcode = dnsRcodeNotImplemented
+	case err != nil || len(q.Questions) != 1: // more than one question is legal on paper, nobody does it
+		rcode = dnsRcodeFormatError
+	default:
+		question := q.Questions[0]
+		question.Name = strings.ToLower(question.Name) // DNS names are case insensitive
+		reply.Questions = q.Questions
+		records, found := z.records[question.Name]
+		switch {
+		case question.Name != z.origin && !strings.HasSuffix(question.Name, "."+z.origin):
+			rcode = dnsRcodeRefused
+			reply.Header.Flags &^= dnsFlagAuthoritative
+		case !found:
+			rcode = dnsRcodeNameError
+		case question.Type == dnsTypeA && question.Class == dnsClassIN:
+			reply.Answers = records
+		} // found but some other type (AAAA, MX...): no error, no answers
+	}
+	reply.Header.Flags |= uint16(rcode)
+
+	b, err := reply.marshal()
+	if err == nil && len(b) > dnsMaxUDPSize {
+		// Too big for UDP: send what fits, flagged, and the client retries over TCP
+		reply.Header.Flags |= dnsFlagTruncated
+		reply.Answers = nil
+		b, err = reply.marshal()
+	}
+	return b, err == nil
+}
+
+// serveDNS answers queries on conn until it's closed
+func serveDNS(conn net.PacketConn, z *dnsZone) error {
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := conn.ReadFrom(buf)
+		if errors.Is(err, net.ErrClosed) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if reply, ok := z.answer(buf[:n]); ok {
+			conn.WriteTo(reply, addr) // if it's lost the client asks again, that's UDP
+		}
+	}
+}
+
+func loadDNSZone(path string) (*dnsZone, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return parseDNSZone(f)
+}
+
+// hellogo dnsserver [-zone file] [addr]
+func dnsserverCommand(args []string) error {
+	flags := flag.NewFlagSet("dnsserver", flag.ContinueOnError)
+	zonePath := flags.String("zone", "testdata/dnsserver/example.zone", "zone file to answer from")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 1 {
+		return errUsage
+	}
+	addr := "127.0.0.1:5353" // 53 needs root
+	if flags.NArg() == 1 {
+		addr = flags.Arg(0)
+	}
+	z, err := loadDNSZone(*zonePath)
+	if err != nil {
+		return err
+	}
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	ctx, stop := shutdownContext()
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		conn.Close() // unblocks ReadFrom
+	}()
+	slog.Info("serving dns", "zone", z.origin, "addr", conn.LocalAddr())
+	return serveDNS(conn, z)
+}
+
+func testDNSServer() {
+	z, err := loadDNSZone("testdata/dnsserver/example.zone")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// A query by hand: RD set like dig does, one question
+	query, _ := dnsMessage{
+		Header:    dnsHeader{ID: 0xbeef, Flags: dnsFlagRecursionDesired},
+		Questions: []dnsQuestion{{"www.hellogo.test.", dnsTypeA, dnsClassIN}},
+	}.marshal()
+	fmt.Printf("% x\n", query)
+	// be ef 01 00 00 01 00 00 00 00 00 00 03 77 77 77 07 68 65 6c 6c 6f 67 6f 04 74 65 73 74 00 00 01 00 01
+	// ID    flags QD=1  AN=0  NS=0  AR=0   3  w  w  w  7  h  e  l  l  o  g  o  4  t  e  s  t  0  A     IN
+
+	reply, _ := z.answer(query)
+	m, err := parseDNSMessage(reply)
+	fmt.Printf("id=%#x flags=%#04x rcode=%d err=%v\n", m.Header.ID, m.Header.Flags, m.Header.rcode(), err) // id=0xbeef flags=0x8500 rcode=0 err=<nil>
+	for _, a := range m.Answers {
+		fmt.Println(a.Name, a.TTL, netip.AddrFrom4([4]byte(a.Data)))
+	}
+	// www.hellogo.test. 300 127.0.0.1
+	// www.hellogo.test. 300 127.0.0.2
+
+	for _, name := range []string{"API.hellogo.test.", "nope.hellogo.test.", "go.dev."} {
+		q, _ := dnsMessage{Header: dnsHeader{ID: 1}, Questions: []dnsQuestion{{name, dnsTypeA, dnsClassIN}}}.marshal()
+		reply, _ := z.answer(q)
+		m, _ := parseDNSMessage(reply)
+		fmt.Println(name, "rcode", m.Header.rcode(), "answers", len(m.Answers))
+	}
+	// API.hellogo.test. rcode 0 answers 1
+	// nope.hellogo.test. rcode 3 answers 0, NXDOMAIN
+	// go.dev. rcode 5 answers 0, refused: we're not a resolver
+
+	// A name that points at itself: followed naively, that never ends
+	loop := append(query[:12:12], 0xC0, 12, 0, 1, 0, 1) // the same header, then a name pointing at offset 12: itself
+	reply, _ = z.answer(loop)
+	m, _ = parseDNSMessage(reply)
+	fmt.Println("rcode", m.Header.rcode()) // rcode 1, format error
+	_, ok := z.answer([]byte{0xbe})
+	fmt.Println("reply to 1 byte:", ok) // reply to 1 byte: false
+
+	//// Over the network
+	// net.Resolver with PreferGo and a Dial func talks to any server you like, so Go's
+	// own DNS client checks our wire format
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		fmt.Println("listen failed:", err)
+		return
+	}
+	defer conn.Close()
+	go serveDNS(conn, z)
+	resolver := &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "udp", conn.LocalAddr().String())
+		},
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	addrs, err := resolver.LookupHost(ctx, "www.hellogo.test.")
+	fmt.Println(addrs, err) // [127.0.0.1 127.0.0.2] <nil>, its AAAA question got no answers and no error
+	_, err = resolver.LookupHost(ctx, "nope.hellogo.test.")
+	var dnsErr *net.DNSError
+	fmt.Println(errors.As(err, &dnsErr) && dnsErr.IsNotFound) // true
+}
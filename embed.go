@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io/fs"
+	"os"
+	"text/template"
+)
+
+//////// go:embed
+// Bake files into the binary at compile time, so `hellogo` works from anywhere
+// without the repo next to it. The //go:embed line goes right above a package level var
+// Note, paths are relative to this file's dir and can't use .. or reach outside the module
+
+// A single file as a string (or []byte)
+//
+//go:embed README.md
+var readme string
+
+// Many files as a read only file system; the lesson sources themselves
+//
+//go:embed *.go
+var lessonSources embed.FS
+
+//go:embed templates
+var templateFS embed.FS
+
+// The exercises' starting files (exercise.go); under testdata so go test ./... skips the stubs
+//
+//go:embed testdata/exercises
+var exerciseFS embed.FS
+
+// Parsed once at startup, a broken template is a programming error so Must panics
+var lessonsTemplate = template.Must(template.ParseFS(templateFS, "templates/lessons.tmpl"))
+
+func testEmbed() {
+	fmt.Println(len(readme) > 0, bytes.HasPrefix([]byte(readme), []byte("# HelloGo"))) // true true
+
+	// embed.FS is an fs.FS, so everything in io/fs works on it
+	matches, _ := fs.Glob(lessonSources, "h*.go")
+	fmt.Println(matches) // [hashing.go hello.go]
+
+	data, err := lessonSources.ReadFile("hello.go")
+	fmt.Println(len(data), err)
+
+	_, err = lessonSources.ReadFile("nope.go")
+	fmt.Println(err) // open nope.go: file does not exist
+}
+
+type lessonFile struct {
+	Name       string     `json:"name"`
+	Lines      int        `json:"lines"`
+	Difficulty Difficulty `json:"difficulty"` // see generate.go
+}
+
+func embeddedLessons() ([]lessonFile, error) {
+	entries, err := lessonSources.ReadDir(".")
+	if err != nil {
+		return nil, err
+	}
+	var lessons []lessonFile
+	for _, e := range entries {
+		data, err := lessonSources.ReadFile(e.Name())
+		if err != nil {
+			return nil, err
+		}
+		lessons = append(lessons, lessonFile{e.Name(), bytes.Count(data, []byte("\n")), lessonDifficulty(e.Name())})
+	}
+	return lessons, nil
+}
+
+// hellogo source [file]; lists the built in lesson files, or prints one
+func sourceCommand(args []string) error {
+	switch len(args) {
+	case 0:
+		lessons, err := embeddedLessons()
+		if err != nil {
+			return err
+		}
+		return lessonsTemplate.Execute(os.Stdout, lessons)
+	case 1:
+		data, err := lessonSources.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(data)
+		return err
+	default:
+		return errUsage
+	}
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+//////// Symmetric encryption with AES-GCM
+// GCM both hides the data AND detects tampering (it's "authenticated" encryption)
+// Rules of the road:
+// * key must be 16/24/32 random bytes (AES-128/192/256), never a raw password
+// * a nonce must NEVER be reused with the same key; 12 random bytes from crypto/rand
+//   per message is the normal approach
+// * the nonce isn't secret, store it next to the ciphertext
+
+// Encrypted file layout: magic | salt | nonce | ciphertext+tag
+var encMagic = []byte("HGENC1")
+
+const encSaltLen = 16
+
+var errNotEncrypted = errors.New("not a hellogo encrypted file")
+
+// deriveKey stretches a passphrase into an AES-256 key, see passwords.go for why
+// a slow, salted function is needed here
+func deriveKey(passphrase string, salt []byte) ([]byte, error) {
+	return pbkdf2.Key(sha256.New, passphrase, salt, passwordIterations, 32)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func encryptWithPassphrase(passphrase string, plaintext []byte) ([]byte, error) {
+	salt := make([]byte, encSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	header := append([]byte{}, encMagic...)
+	header = append(header, salt...)
+	header = append(header, nonce...)
+	// The header goes in as "additional data": not encrypted, but any change to it
+	// will make decryption fail. Seal appends to dst, and dst's new bytes mustn't overlap
+	// the additional data; capping header's capacity makes that append copy to a new array
+	return gcm.Seal(header[:len(header):len(header)], nonce, plaintext, header), nil
+}
+
+func decryptWithPassphrase(passphrase string, data []byte) ([]byte, error) {
+	if !bytes.HasPrefix(data, encMagic) || len(data) < len(encMagic)+encSaltLen {
+		return nil, errNotEncrypted
+	}
+	salt := data[len(encMagic) : len(encMagic)+encSaltLen]
+	key, err := deriveKey(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := newGCM(key)
+	if err != nil {
+		return nil, err
+	}
+
+	headerLen := len(encMagic) + encSaltLen + gcm.NonceSize()
+	if len(data) < headerLen {
+		return nil, errNotEncrypted
+	}
+	nonce := data[headerLen-gcm.NonceSize() : headerLen]
+	// Wrong passphrase and tampered data look the same: "message authentication failed"
+	return gcm.Open(nil, nonce, data[headerLen:], data[:headerLen])
+}
+
+func testAESGCM() {
+	secret := []byte("the cake is a lie")
+
+	sealed, err := encryptWithPassphrase("correct horse", secret)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("%x\n", sealed)
+
+	opened, err := decryptWithPassphrase("correct horse", sealed)
+	fmt.Println(string(opened), err) // the cake is a lie <nil>
+
+	_, err = decryptWithPassphrase("battery staple", sealed)
+	fmt.Println(err) // cipher: message authentication failed
+
+	sealed[len(sealed)-1] ^= 1 // flip one bit
+	_, err = decryptWithPassphrase("correct horse", sealed)
+	fmt.Println(err) // cipher: message authentication failed
+
+	// Encrypting the same thing twice gives different output thanks to the random salt+nonce
+	a, _ := encryptWithPassphrase("correct horse", secret)
+	b, _ := encryptWithPassphrase("correct horse", secret)
+	fmt.Println(bytes.Equal(a, b)) // false
+}
+
+// readPassphrase takes $HELLOGO_PASSPHRASE if set, otherwise asks on stdin
+// Note, this echoes what you type; golang.org/x/term can turn that off
+func readPassphrase() (string, error) {
+	if p := os.Getenv("HELLOGO_PASSPHRASE"); p != "" {
+		return p, nil
+	}
+	fmt.Fprint(os.Stderr, "passphrase: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return "", err
+	}
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return "", errors.New("empty passphrase")
+	}
+	return line, nil
+}
+
+// cryptFileCommand builds hellogo encrypt / hellogo decrypt <in> <out>
+func cryptFileCommand(crypt func(passphrase string, data []byte) ([]byte, error)) func(args []string) error {
+	return func(args []string) error {
+		if len(args) != 2 {
+			return errUsage
+		}
+		data, err := os.ReadFile(args[0])
+		if err != nil {
+			return err
+		}
+		passphrase, err := readPassphrase()
+		if err != nil {
+			return err
+		}
+		out, err := crypt(passphrase, data)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(args[1], out, 0600)
+	}
+}
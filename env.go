@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+)
+
+//////// Environment variables
+
+func testEnvVars() {
+	// Getenv gives "" for both unset and set-to-empty
+	fmt.Println(os.Getenv("HOME"))
+
+	// LookupEnv tells them apart
+	if v, ok := os.LookupEnv("HELLOGO_NOT_SET"); !ok {
+		fmt.Println("not set")
+	} else {
+		fmt.Println("set to", v)
+	}
+
+	// Setenv/Unsetenv only change this process (and children started afterwards),
+	// never the shell that launched us
+	os.Setenv("HELLOGO_GREETING", "hi")
+	fmt.Println(os.Getenv("HELLOGO_GREETING")) // hi
+	os.Unsetenv("HELLOGO_GREETING")
+
+	// Environ is every KEY=value pair
+	for _, kv := range os.Environ() {
+		if key, _, _ := strings.Cut(kv, "="); key == "PATH" {
+			fmt.Println(kv)
+		}
+	}
+
+	// Expanding $VAR and ${VAR} in strings
+	fmt.Println(os.ExpandEnv("home is $HOME"))
+	// Expand lets you choose where values come from, e.g. a map instead of the real env
+	vars := map[string]string{"lesson": "env", "n": "3"}
+	fmt.Println(os.Expand("lesson ${lesson} of $n, $missing", func(k string) string {
+		return vars[k] // missing keys become ""
+	})) // lesson env of 3,
+}
+
+//// HELLOGO_* overrides for the config
+// Every config key can also be set from the environment, handy in CI and containers:
+// color -> HELLOGO_COLOR, quiz.questions -> HELLOGO_QUIZ_QUESTIONS, and so on.
+// Unset variables leave the default (or config file value) alone.
+
+// applyEnvConfig takes a lookup func (normally os.LookupEnv) so it can be fed a map
+func applyEnvConfig(cfg *config, lookup func(string) (string, bool)) error {
+	return applyEnvStruct(reflect.ValueOf(cfg).Elem(), "HELLOGO", lookup)
+}
+
+func applyEnvStruct(v reflect.Value, prefix string, lookup func(string) (string, bool)) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		name := prefix + "_" + strings.ToUpper(t.Field(i).Tag.Get("toml"))
+		field := v.Field(i)
+
+		if field.Kind() == reflect.Struct {
+			if err := applyEnvStruct(field, name, lookup); err != nil {
+				return err
+			}
+			continue
+		}
+
+		raw, ok := lookup(name)
+		if !ok {
+			continue
+		}
+		// Env values aren't quoted like strings in the config file are
+		if field.Kind() == reflect.String {
+			field.SetString(raw)
+			continue
+		}
+		if err := setConfigValue(field, raw); err != nil {
+			return fmt.Errorf("%s: %v", name, err)
+		}
+	}
+	return nil
+}
+
+func testEnvConfig() {
+	env := map[string]string{
+		"HELLOGO_COLOR":          "false",
+		"HELLOGO_QUIZ_QUESTIONS": "3",
+		"HELLOGO_SERVER_ADDR":    ":9000",
+	}
+	lookup := func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}
+
+	cfg := defaultConfig()
+	err := applyEnvConfig(&cfg, lookup)
+	fmt.Printf("%+v %v\n", cfg, err)
+	// {Color:false Progress:{Path:progress.gob} Proxy:{Addr:localhost:8000 Routes:} Quiz:{Questions:3 Seed:0} Server:{Addr::9000} Storage:{Backend:json Path:}} <nil>
+
+	env["HELLOGO_QUIZ_SEED"] = "lots"
+	fmt.Println(applyEnvConfig(&cfg, lookup)) // HELLOGO_QUIZ_SEED: expected an integer, got lots
+}
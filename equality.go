@@ -0,0 +1,211 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"sort"
+)
+
+//////// Comparing and copying structs
+// A struct is comparable with == when every field is: numbers, strings, bools,
+// pointers, channels, arrays of comparable things, and other comparable structs.
+// person and dog (hello.go) are, so they work as map keys too. One slice, map or func
+// field and == stops compiling, for the struct and anything it's part of.
+//
+// For the rest there are three choices:
+//   - reflect.DeepEqual walks both values and compares everything. It's the quick
+//     answer in tests, but it's strict in ways that surprise: a nil slice isn't equal
+//     to an empty one, NaN isn't equal to itself, and funcs are only equal if both nil
+//   - an Equal method, written for the type: it says what "the same" means (is a nil
+//     list the same as an empty one? does order matter?) and is much faster
+//   - a diff, like github.com/google/go-cmp's cmp.Diff: not just whether they differ
+//     but where, which is what a failing test wants to print. diffValues is a small one
+//
+// Assigning a struct copies it, but only one level deep. The fields are copied; a
+// slice or map field is a pointer to shared data, so the copy and the original share
+// it. Changing a string field of the copy is safe; changing an element of its slice
+// changes the original's too. Clone has to copy those by hand.
+
+// household isn't comparable with ==: it has a slice and a map
+type household struct {
+	owner     person
+	dogs      []dog
+	nicknames map[string]string
+}
+
+// Equal treats nil and empty as the same, and cares about the order of the dogs
+func (h household) Equal(o household) bool {
+	if h.owner != o.owner || !slices.Equal(h.dogs, o.dogs) || len(h.nicknames) != len(o.nicknames) {
+		return false
+	}
+	for k, v := range h.nicknames {
+		if ov, ok := o.nicknames[k]; !ok || ov != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Clone copies the slice and map too, so changing the clone leaves h alone. dog has no
+// pointers in it, so copying the dogs one level is enough.
+func (h household) Clone() household {
+	h.dogs = slices.Clone(h.dogs)
+	if h.nicknames != nil {
+		nicknames := make(map[string]string, len(h.nicknames))
+		for k, v := range h.nicknames {
+			nicknames[k] = v
+		}
+		h.nicknames = nicknames
+	}
+	return h // h is already a copy: it was passed by value
+}
+
+// diffValues lists where a and b differ, one "path: a != b" per difference. It goes
+// through reflect.Value rather than Interface(), which panics on unexported fields.
+func diffValues(path string, a, b reflect.Value) []string {
+	if a.Type() != b.Type() {
+		return []string{fmt.Sprintf("%s: %v != %v", path, a.Type(), b.Type())}
+	}
+	var diffs []string
+	switch a.Kind() {
+	case reflect.Struct:
+		for i := range a.NumField() {
+			diffs = append(diffs, diffValues(path+"."+a.Type().Field(i).Name, a.Field(i), b.Field(i))...)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := range min(a.Len(), b.Len()) {
+			diffs = append(diffs, diffValues(fmt.Sprintf("%s[%d]", path, i), a.Index(i), b.Index(i))...)
+		}
+		if a.Len() != b.Len() {
+			diffs = append(diffs, fmt.Sprintf("%s: length %d != %d", path, a.Len(), b.Len()))
+		}
+	case reflect.Map:
+		keys := append(a.MapKeys(), b.MapKeys()...)
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for i, k := range keys {
+			if i > 0 && fmt.Sprint(k) == fmt.Sprint(keys[i-1]) {
+				continue // in both maps
+			}
+			at := fmt.Sprintf("%s[%v]", path, k)
+			av, bv := a.MapIndex(k), b.MapIndex(k)
+			switch {
+			case !av.IsValid():
+				diffs = append(diffs, fmt.Sprintf("%s: missing != %v", at, bv))
+			case !bv.IsValid():
+				diffs = append(diffs, fmt.Sprintf("%s: %v != missing", at, av))
+			default:
+				diffs = append(diffs, diffValues(at, av, bv)...)
+			}
+		}
+	case reflect.Pointer, reflect.Interface:
+		if a.IsNil() || b.IsNil() {
+			if a.IsNil() != b.IsNil() {
+				diffs = append(diffs, fmt.Sprintf("%s: %v != %v", path, a, b))
+			}
+			return diffs
+		}
+		diffs = diffValues(path, a.Elem(), b.Elem())
+	default:
+		if !a.Equal(b) {
+			diffs = append(diffs, fmt.Sprintf("%s: %v != %v", path, a, b))
+		}
+	}
+	return diffs
+}
+
+func testStructEquality() {
+	//// == on structs
+	bob := person{"Bob", 20}
+	fmt.Println(bob == person{name: "Bob", age: 20}, bob == person{"Bob", 21}) // true false
+
+	// Comparable, so usable as a map key: each distinct value is its own key
+	visits := map[person]int{}
+	visits[bob]++
+	visits[person{"Bob", 20}]++
+	visits[person{"Bob", 21}]++
+	fmt.Println(len(visits), visits[bob]) // 2 2
+
+	// household{} == household{} doesn't compile: invalid operation: household{} ==
+	// household{} (struct containing []dog cannot be compared). Hidden in interfaces,
+	// the same comparison compiles and panics (interfaces.go)
+	fmt.Println(panicMessage(func() { _ = any(household{}) == any(household{}) }))
+	// runtime error: comparing uncomparable type main.household
+
+	//// DeepEqual, Equal, and a diff
+	sam := dog{name: "Sam", age: 2, weight: 35}
+	a := household{owner: bob, dogs: []dog{sam}, nicknames: map[string]string{"Sam": "Sammy"}}
+	b := household{owner: bob, dogs: []dog{sam}, nicknames: map[string]string{"Sam": "Sammy"}}
+	fmt.Println(reflect.DeepEqual(a, b), a.Equal(b)) // true true
+
+	// Where they disagree
+	empty, none := household{owner: bob, dogs: []dog{}}, household{owner: bob}
+	fmt.Println(reflect.DeepEqual(empty, none), empty.Equal(none)) // false true: nil and empty
+	nan := []float64{math.NaN()}
+	fmt.Println(reflect.DeepEqual(nan, nan), slices.Equal(nan, nan))             // true false: the same slice twice, which DeepEqual doesn't look inside
+	fmt.Println(reflect.DeepEqual([]float64{math.NaN()}, []float64{math.NaN()})) // false: NaN != NaN
+
+	// A diff says what's different
+	c := a.Clone()
+	c.dogs[0].age = 3
+	c.dogs = append(c.dogs, dog{name: "Rex", age: 5, weight: 30})
+	c.nicknames["Rex"] = "T. Rex"
+	delete(c.nicknames, "Sam")
+	for _, d := range diffValues("household", reflect.ValueOf(a), reflect.ValueOf(c)) {
+		fmt.Println(d)
+	}
+	// household.dogs[0].age: 2 != 3
+	// household.dogs: length 1 != 2
+	// household.nicknames[Rex]: missing != T. Rex
+	// household.nicknames[Sam]: Sammy != missing
+
+	//// Copies are shallow
+	shallow := a
+	shallow.owner.name = "Robert" // owner is a person, copied whole
+	shallow.dogs[0].age = 10      // dogs is a slice header, pointing at a's array
+	shallow.nicknames["Sam"] = "Samwise"
+	fmt.Println(a.owner.name, a.dogs[0].age, a.nicknames["Sam"]) // Bob 10 Samwise: only the name was really copied
+
+	deep := a.Clone()
+	deep.dogs[0].age = 2
+	deep.nicknames["Sam"] = "Sammy"
+	fmt.Println(a.dogs[0].age, a.nicknames["Sam"]) // 10 Samwise: untouched
+
+	// append to a copy writes into the shared array if it has room, and the original
+	// can't see it (its length didn't change) until it appends over it
+	dogs := make([]dog, 1, 4)
+	one := household{dogs: dogs}
+	two := one
+	two.dogs = append(two.dogs, sam)
+	one.dogs = append(one.dogs, dog{name: "Rex"})
+	fmt.Println(two.dogs[1].name) // Rex: one's append overwrote two's dog
+
+	runCase("TestStructEquality/clone", func(errorf errorfFunc) {
+		orig := household{owner: bob, dogs: []dog{sam}, nicknames: map[string]string{"Sam": "Sammy"}}
+		c := orig.Clone()
+		if !c.Equal(orig) {
+			errorf("clone %+v != original %+v", c, orig)
+		}
+		c.dogs[0].weight++
+		c.nicknames["Sam"] = "S"
+		if orig.dogs[0].weight != 35 || orig.nicknames["Sam"] != "Sammy" {
+			errorf("changing the clone changed the original: %+v", orig)
+		}
+		if nilClone := (household{}).Clone(); nilClone.dogs != nil || nilClone.nicknames != nil {
+			errorf("clone of nils = %+v, want nils", nilClone)
+		}
+	})
+	runCase("TestStructEquality/diff", func(errorf errorfFunc) {
+		if d := diffValues("h", reflect.ValueOf(a), reflect.ValueOf(a.Clone())); len(d) != 0 {
+			errorf("clone differs: %q", d)
+		}
+		var p *person
+		if d := diffValues("p", reflect.ValueOf(p), reflect.ValueOf(&bob)); len(d) != 1 {
+			errorf("nil against a person: %q", d)
+		}
+		if d := diffValues("x", reflect.ValueOf([2]int{1, 2}), reflect.ValueOf([2]int{1, 3})); len(d) != 1 || d[0] != "x[1]: 2 != 3" {
+			errorf("arrays: %q", d)
+		}
+	})
+}
@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"go/build"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+//////// Escape analysis: stack vs heap
+// Go doesn't have you choose between stack and heap, the compiler does. If it can prove a
+// value isn't used after the function returns, it goes on the stack (free: gone when the
+// function returns). Otherwise it "escapes" to the heap, which costs an allocation now
+// and GC work later. The usual reasons something escapes:
+//
+//	returning a pointer to it          &v outlives the function
+//	storing a pointer somewhere global or in something that escapes itself
+//	putting it in an interface         fmt.Println(x), any, error... mostly
+//	a closure that outlives the call   captured variables move to the heap
+//	make with a big or run time size   the stack frame's size is fixed at compile time
+//
+// Ask the compiler what it decided:
+//
+//	go build -gcflags=-m ./...     # -m=2 explains why, at length
+//
+// Don't contort code to dodge the heap on a hunch, benchmark with -benchmem first.
+
+type vec3 struct {
+	X, Y, Z float64
+}
+
+// Each pair below does the same work, one keeps its value on the stack and one doesn't.
+// go:noinline keeps it that way for the benchmarks: once inlined into the caller, the
+// compiler often sees that &vec3{} doesn't escape after all (which is the good news)
+
+//go:noinline
+func vecValue(x float64) vec3 {
+	return vec3{x, x, x} // copied out to the caller: does not escape
+}
+
+//go:noinline
+func vecPointer(x float64) *vec3 {
+	return &vec3{x, x, x} // &vec3{...} escapes to heap
+}
+
+var keptVec *vec3
+
+func vecLength2(v *vec3) float64 { // v does not escape: only read
+	return v.X*v.X + v.Y*v.Y + v.Z*v.Z
+}
+
+func keepVec(v *vec3) { // leaking param: v, it's stored in a global
+	keptVec = v
+}
+
+//go:noinline
+func sumFixed() int {
+	nums := make([]int, 64) // size known at compile time: does not escape
+	for i := range nums {
+		nums[i] = i
+	}
+	return sumInts(nums)
+}
+
+//go:noinline
+func sumSized(n int) int {
+	// Size only known at run time. Compilers before Go 1.25 said "escapes to heap"; now it's
+	// "does not escape" but only a 32 byte stack buffer is set aside, anything bigger
+	// is still allocated on the heap
+	nums := make([]int, n)
+	for i := range nums {
+		nums[i] = i
+	}
+	return sumInts(nums)
+}
+
+func sumInts(nums []int) int {
+	total := 0
+	for _, n := range nums {
+		total += n
+	}
+	return total
+}
+
+//go:noinline
+func labelItoa(n int) string {
+	return "item " + strconv.Itoa(n)
+}
+
+//go:noinline
+func labelSprint(n int) string {
+	return fmt.Sprint("item ", n) // n escapes to heap: it goes into a ...any
+}
+
+//go:noinline
+func countLocal(n int) int {
+	count := 0
+	inc := func() { count++ } // only called here, so it's inlined and count stays on the stack
+	for range n {
+		inc()
+	}
+	return count
+}
+
+//go:noinline
+func counterClosure() func() int {
+	count := 0 // moved to heap: count, the closure outlives the call
+	return func() int {
+		count++
+		return count
+	}
+}
+
+//// Asking the compiler
+
+// escapeDiagnostics rebuilds the package in dir with -gcflags=-m and returns what
+// the compiler said about file, minus the (many) inlining notes
+func escapeDiagnostics(dir, file string) ([]string, error) {
+	sources, err := goSources(build.Default, dir)
+	if err != nil {
+		return nil, err
+	}
+	args := append([]string{"build", "-gcflags=-m", "-o", "/dev/null"}, sources...)
+	cmd := exec.Command("go", args...)
+	cmd.Dir = dir
+	out, err := cmd.CombinedOutput() // the diagnostics come out on stderr
+	if err != nil {
+		return nil, fmt.Errorf("go build: %v\n%s", err, out)
+	}
+
+	var notes []string
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		line := scanner.Text()
+		rest, ok := strings.CutPrefix(line, "./"+file+":")
+		if !ok || strings.Contains(rest, "inlin") { // can inline, inlining call to
+			continue
+		}
+		notes = append(notes, file+":"+rest)
+	}
+	return notes, scanner.Err()
+}
+
+// goSources lists the .go files in dir that a build for ctx (GOOS, tags...) would compile.
+// Passing them to go build compiles them as one package without needing a go.mod; files
+// named on the command line skip the build constraint check, hence the filtering here
+func goSources(ctx build.Context, dir string) ([]string, error) {
+	pkg, err := ctx.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	return append(pkg.GoFiles, pkg.CgoFiles...), nil
+}
+
+func testEscapeDiagnostics() {
+	notes, err := escapeDiagnostics(".", "escape.go") // run from the repo root
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Only the notes about the pairs above, not the ones about this lesson's own code
+	src, _ := lessonSources.ReadFile("escape.go")
+	end := bytes.Index(src, []byte("//// Asking the compiler"))
+	endLine := bytes.Count(src[:end], []byte("\n")) + 1
+	for _, note := range notes {
+		line, _ := strconv.Atoi(strings.Split(note, ":")[1])
+		if line < endLine {
+			fmt.Println(note)
+		}
+	}
+	// escape.go:47:9: &vec3{...} escapes to heap
+	// escape.go:52:17: v does not escape
+	// escape.go:56:14: leaking param: v
+	// escape.go:62:14: make([]int, 64) does not escape
+	// escape.go:74:14: make([]int, n) does not escape
+	// escape.go:81:14: nums does not escape
+	// escape.go:91:17: "item " + ~r0 escapes to heap
+	// escape.go:96:19: ... argument does not escape
+	// escape.go:96:20: "item " escapes to heap
+	// escape.go:96:29: n escapes to heap
+	// escape.go:111:2: moved to heap: count
+	// escape.go:112:9: func literal escapes to heap
+}
+
+//// What it costs
+
+var sinkFloat float64
+
+func testEscapeBenchmarks() {
+	pairs := []struct {
+		name string
+		fn   func(b *testing.B)
+	}{
+		{"BenchmarkVec/value", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				v := vecValue(float64(i))
+				sinkFloat = vecLength2(&v)
+			}
+		}},
+		{"BenchmarkVec/pointer", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				sinkFloat = vecLength2(vecPointer(float64(i)))
+			}
+		}},
+		{"BenchmarkSum/fixed", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				sinkInt = sumFixed()
+			}
+		}},
+		{"BenchmarkSum/sized", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				sinkInt = sumSized(64)
+			}
+		}},
+		{"BenchmarkLabel/itoa", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				sinkString = labelItoa(i)
+			}
+		}},
+		{"BenchmarkLabel/sprint", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				sinkString = labelSprint(i)
+			}
+		}},
+		{"BenchmarkCounter/local", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				sinkInt = countLocal(10)
+			}
+		}},
+		{"BenchmarkCounter/closure", func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				next := counterClosure()
+				for range 10 {
+					sinkInt = next()
+				}
+			}
+		}},
+	}
+	for _, p := range pairs {
+		printBench(p.name, func(b *testing.B) {
+			b.ReportAllocs()
+			p.fn(b)
+		})
+	}
+	// BenchmarkVec/value         ~2 ns/op     0 B/op   0 allocs/op
+	// BenchmarkVec/pointer       ~20 ns/op    24 B/op   1 allocs/op
+	// BenchmarkSum/fixed         ~80 ns/op     0 B/op   0 allocs/op
+	// BenchmarkSum/sized        ~160 ns/op   512 B/op   1 allocs/op
+	// BenchmarkLabel/itoa        ~60 ns/op    23 B/op   1 allocs/op  <- the result string
+	// BenchmarkLabel/sprint     ~140 ns/op    24 B/op   1 allocs/op
+	// BenchmarkCounter/local      ~8 ns/op     0 B/op   0 allocs/op
+	// BenchmarkCounter/closure   ~55 ns/op    24 B/op   2 allocs/op  <- count and the closure
+	// Sprint gets away with one allocation here, but still pays for going through ...any. Every allocation is small, but they add up in a hot loop
+	// and each one is GC work later
+}
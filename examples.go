@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"go/doc"
+	"go/parser"
+	"go/token"
+	"io"
+	"os"
+)
+
+//////// Example functions
+// An Example function in a _test.go file is documentation AND a test. go test runs it
+// and compares what it printed against the // Output: comment at the end; godoc and
+// pkg.go.dev show it (runnable) next to whatever it's named after:
+//
+//	func ExampleRetry()            // example for func Retry
+//	func ExampleBroker_Publish()   // for method Publish on type Broker
+//	func Example_geometry()        // for the package as a whole, suffix after _
+//
+// The name has to match something that's there: go vet reports ExampleRetry when the
+// package has no Retry. A main package exports nothing, so the examples for the
+// lessons' reusable bits, in examples_test.go, are all package examples with a lower
+// case suffix (Example_retry, Example_brokerPublish).
+//
+// // Unordered output: compares the lines in any order (map iteration, goroutines).
+// An example without an output comment is compiled but not run.
+//
+// go test -run Example -v runs them. The lesson below reads them the way godoc does,
+// from the embedded source with go/doc.
+
+// captureStdout runs fn and returns everything it printed
+func captureStdout(fn func()) (string, error) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		return "", err
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+	out := make(chan string)
+	go func() { // read while fn writes, or a big output fills the pipe and blocks fn
+		b, _ := io.ReadAll(r)
+		out <- string(b)
+	}()
+	defer func() { os.Stdout = stdout }()
+	fn()
+	w.Close()
+	return <-out, nil
+}
+
+// exampleOutputs maps each Example in file to the output go test expects from it,
+// "" for one that's only compiled
+func exampleOutputs(file string) (map[string]string, error) {
+	src, err := lessonSources.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	f, err := parser.ParseFile(token.NewFileSet(), file, src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	outputs := map[string]string{}
+	for _, ex := range doc.Examples(f) {
+		outputs["Example"+ex.Name] = ex.Output // doc strips the prefix: "_retry"
+	}
+	return outputs, nil
+}
+
+func testExamples() {
+	outputs, err := exampleOutputs("examples_test.go")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, name := range sortedKeys(outputs) {
+		fmt.Printf("%-24s %q\n", name, outputs[name])
+	}
+	// Example_broker           "alice got hi all\nbob got hi all\n"
+	// ...
+	// Example_retryPermanent   "1 404 not found\n"
+}
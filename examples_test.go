@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+func Example_geometry() {
+	for _, g := range []geometry{rect{width: 3, height: 4}, circle{radius: 1}} {
+		fmt.Printf("%T area=%.2f perim=%.2f\n", g, g.area(), g.perim())
+	}
+	// Output:
+	// main.rect area=12.00 perim=14.00
+	// main.circle area=3.14 perim=6.28
+}
+
+func Example_retry() {
+	calls := 0
+	err := retry(context.Background(), 5, time.Millisecond, func() error {
+		calls++
+		if calls < 3 {
+			return errors.New("flaky")
+		}
+		return nil
+	})
+	fmt.Println(calls, err)
+	// Output: 3 <nil>
+}
+
+func Example_retryPermanent() {
+	calls := 0
+	err := retry(context.Background(), 5, time.Millisecond, func() error {
+		calls++
+		return permanent(errors.New("404 not found"))
+	})
+	fmt.Println(calls, err)
+	// Output: 1 404 not found
+}
+
+func Example_broker() {
+	b := newBroker[string]()
+	alice, bob := b.Subscribe(1), b.Subscribe(1)
+	b.Publish("hi all")
+	fmt.Println("alice got", <-alice)
+	fmt.Println("bob got", <-bob)
+	// Output:
+	// alice got hi all
+	// bob got hi all
+}
+
+func Example_brokerPublish() {
+	b := newBroker[int]()
+	slow := b.Subscribe(1)
+	b.Publish(1)
+	b.Publish(2) // buffer full: dropped for this subscriber, Publish doesn't wait
+	b.Unsubscribe(slow)
+	for n := range slow {
+		fmt.Println(n)
+	}
+	// Output: 1
+}
+
+func Example_lruCache() {
+	c := newLRUCache[string, int](2)
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Get("a")
+	evicted, ok := c.Put("c", 3)
+	fmt.Println(evicted, ok, c.Keys())
+	// Output: b true [c a]
+}
+
+func Example_mapSlice() {
+	squares := mapSlice([]int{1, 2, 3}, func(n int) int { return n * n })
+	words := mapSlice(squares, func(n int) string { return fmt.Sprint("#", n) })
+	fmt.Println(squares, words)
+	fmt.Println(reduceSlice(squares, 0, func(acc, n int) int { return acc + n }))
+	// Output:
+	// [1 4 9] [#1 #4 #9]
+	// 14
+}
+
+func Example_parseCalcNumber() {
+	for _, s := range []string{"42", "0x1f", "2.5e3", "lots"} {
+		fmt.Println(parseCalcNumber(s))
+	}
+	// Output:
+	// 42 <nil>
+	// 31 <nil>
+	// 2500 <nil>
+	// 0 "lots" is not a number
+}
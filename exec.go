@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+//////// Running other programs with os/exec
+// Note, exec.Command runs the program directly, NOT through a shell,
+// so no globs, pipes or $VARS unless you run "sh", "-c", "..." yourself
+
+func testExecBasics() {
+	// LookPath searches $PATH, same as the shell would
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		fmt.Println("go isn't on the PATH:", err)
+		return
+	}
+	fmt.Println(goBin)
+
+	// Output runs it and returns stdout
+	out, err := exec.Command("go", "version").Output()
+	fmt.Printf("%s %v\n", bytes.TrimSpace(out), err) // go version go1.x linux/amd64 <nil>
+
+	// CombinedOutput interleaves stdout and stderr, handy for showing errors to a human
+	out, _ = exec.Command("go", "nosuchcommand").CombinedOutput()
+	fmt.Printf("%s", out) // go nosuchcommand: unknown command ...
+}
+
+// Capture stdout and stderr separately, feed stdin, set env and working directory
+func testExecPlumbing() {
+	cmd := exec.Command("gofmt")
+	cmd.Stdin = strings.NewReader("package main\nfunc  main( ) {fmt.Println( 1 )}\n")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		fmt.Println("gofmt failed:", err, stderr.String())
+		return
+	}
+	fmt.Print(stdout.String()) // nicely formatted code
+
+	// Bad input: the exit status and stderr tell you what went wrong
+	cmd = exec.Command("gofmt")
+	cmd.Stdin = strings.NewReader("package main\nfunc {")
+	stderr.Reset()
+	cmd.Stderr = &stderr
+	err := cmd.Run()
+
+	// A non zero exit comes back as *exec.ExitError
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		fmt.Println("exit code", exitErr.ExitCode()) // exit code 2
+		fmt.Print(stderr.String())                   // <standard input>:2:6: expected 'IDENT', found '{'
+	}
+
+	// Env replaces the whole environment, so start from os.Environ() and append
+	cmd = exec.Command("go", "env", "GOOS")
+	cmd.Env = append(os.Environ(), "GOOS=plan9")
+	cmd.Dir = os.TempDir() // run it from somewhere else
+	out, _ := cmd.Output()
+	fmt.Printf("%s", out) // plan9
+
+	// Program not found at all is a different error from a failed run
+	err = exec.Command("definitely-not-a-real-program").Run()
+	fmt.Println(errors.Is(err, exec.ErrNotFound)) // true
+}
+
+// Timeouts: CommandContext kills the process when the context is done
+func testExecTimeout() {
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := exec.CommandContext(ctx, "sleep", "5").Run()             // note, no sleep binary on Windows
+	fmt.Println(err, time.Since(start).Round(100*time.Millisecond)) // signal: killed 500ms
+	fmt.Println(ctx.Err())                                          // context deadline exceeded
+}
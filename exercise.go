@@ -0,0 +1,255 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+//////// Exercises
+// Each exercise is a function to write and the tests it has to pass. hellogo exercise
+// start copies one into exercises/<name>/ under the current directory: solution.go with
+// the function stubbed out, solution_test.go with the checks, and a go.mod so the
+// directory is a module of its own (a go test ./... around it doesn't run the stubs).
+//
+//	hellogo exercise list
+//	hellogo exercise start reverse
+//	hellogo exercise check reverse            # go test in exercises/reverse
+//	hellogo exercise check --watch reverse    # and again each time solution.go is saved
+//	hellogo exercise submit reverse           # bundle exercises/reverse into reverse-<ulid>.zip
+//
+// Checking is plain go test, so the output is what the tests lesson (testing.go) shows.
+// --watch is hellogo watch (watch.go) pointed at the exercise's directory, and submit
+// packs the directory with zipDir or tarDir (archive.go) into one file to upload, named
+// with a ULID (ids.go) so each submission gets its own file and they sort by time.
+
+const exercisesDir = "exercises"
+
+// exerciseGoMod makes an exercise directory a module; no dependencies, only the standard library
+const exerciseGoMod = "module exercise\n\ngo 1.22\n"
+
+var errNoExercise = errors.New("no such exercise")
+
+func exerciseNames() ([]string, error) {
+	entries, err := fs.ReadDir(exerciseFS, "testdata/exercises")
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, e := range entries {
+		if e.IsDir() {
+			names = append(names, e.Name())
+		}
+	}
+	return names, nil
+}
+
+// startExercise copies the exercise's files into dir. Files already there are left
+// alone, so starting again never throws away a solution in progress
+func startExercise(name, dir string) (created []string, err error) {
+	src := path.Join("testdata/exercises", name)
+	entries, err := fs.ReadDir(exerciseFS, src)
+	if err != nil {
+		return nil, fmt.Errorf("%w %q", errNoExercise, name)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	files := map[string][]byte{"go.mod": []byte(exerciseGoMod)}
+	for _, e := range entries {
+		data, err := exerciseFS.ReadFile(path.Join(src, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		files[e.Name()] = data
+	}
+	for _, file := range sortedKeys(files) {
+		dest := filepath.Join(dir, file)
+		if _, err := os.Stat(dest); err == nil {
+			continue
+		}
+		if err := writeFileAtomic(dest, files[file], 0644); err != nil {
+			return created, err
+		}
+		created = append(created, dest)
+	}
+	return created, nil
+}
+
+// exerciseWorkspace is where exercise start put name, or an error saying to run it.
+// It scans the directory with findGoFiles (paths.go) for the solution
+func exerciseWorkspace(name string) (string, error) {
+	dir := filepath.Join(exercisesDir, name)
+	files, _, err := findGoFiles(dir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	solution := filepath.Join(dir, "solution.go")
+	if !slices.ContainsFunc(files, func(f goFile) bool { return f.path == solution }) {
+		return "", fmt.Errorf("%s: no solution.go, run hellogo exercise start %s first", dir, name)
+	}
+	return dir, nil
+}
+
+// checkExercise runs the exercise's tests; the error is go test's exit status
+func checkExercise(ctx context.Context, dir string) error {
+	cmd := exec.CommandContext(ctx, "go", "test", ".")
+	cmd.Dir = dir
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	return cmd.Run()
+}
+
+// bundleExercise packs everything in dir into a zip, or a gzipped tar
+func bundleExercise(dir, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	switch format {
+	case "zip":
+		if err := zipDir(dir, &buf); err != nil {
+			return nil, err
+		}
+	case "tar.gz":
+		zw := gzip.NewWriter(&buf)
+		if err := tarDir(dir, zw); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown archive format %q, want zip or tar.gz", format)
+	}
+	return buf.Bytes(), nil
+}
+
+// hellogo exercise list | start <name> | check [--watch] [-every d] <name> | submit [-format zip|tar.gz] <name>
+func exerciseCommand(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch sub, rest := args[0], args[1:]; sub {
+	case "list":
+		if len(rest) > 0 {
+			return errUsage
+		}
+		names, err := exerciseNames()
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return err
+	case "start":
+		if len(rest) != 1 {
+			return errUsage
+		}
+		created, err := startExercise(rest[0], filepath.Join(exercisesDir, rest[0]))
+		for _, file := range created {
+			fmt.Println("created", file)
+		}
+		return err
+	case "check":
+		return exerciseCheckCommand(rest)
+	case "submit":
+		return exerciseSubmitCommand(rest)
+	default:
+		return errUsage
+	}
+}
+
+func exerciseCheckCommand(args []string) error {
+	flags := flag.NewFlagSet("exercise check", flag.ContinueOnError)
+	watch := flags.Bool("watch", false, "check again every time the solution changes, until Ctrl+C")
+	every := flags.Duration("every", 500*time.Millisecond, "how often --watch looks for changes")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 || *every <= 0 {
+		return errUsage
+	}
+	dir, err := exerciseWorkspace(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := shutdownContext()
+	defer stop()
+	if !*watch {
+		return checkExercise(ctx, dir)
+	}
+	return rerunOnChange(ctx, []string{dir}, *every, func() error { return checkExercise(ctx, dir) })
+}
+
+// exercise submit writes <name>-<ulid>.zip (or .tar.gz) in the current directory
+func exerciseSubmitCommand(args []string) error {
+	flags := flag.NewFlagSet("exercise submit", flag.ContinueOnError)
+	format := flags.String("format", "zip", "zip or tar.gz")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 {
+		return errUsage
+	}
+	name := flags.Arg(0)
+	dir, err := exerciseWorkspace(name)
+	if err != nil {
+		return err
+	}
+	data, err := bundleExercise(dir, *format)
+	if err != nil {
+		return err
+	}
+	out := fmt.Sprintf("%s-%s.%s", name, newULID(time.Now()), *format)
+	if err := writeFileAtomic(out, data, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("wrote %s, %d bytes, ready to upload\n", out, len(data))
+	return nil
+}
+
+func testExercises() {
+	names, err := exerciseNames()
+	fmt.Println(names, err) // [reverse topwords] <nil>
+
+	dir := makeTempDir("exercise")
+	defer os.RemoveAll(dir)
+	created, err := startExercise("reverse", dir)
+	for _, file := range created {
+		fmt.Println(filepath.Base(file))
+	}
+	fmt.Println(err)
+	// go.mod
+	// solution.go
+	// solution_test.go
+	// <nil>
+
+	// Starting over keeps the work done so far
+	solution := filepath.Join(dir, "solution.go")
+	os.WriteFile(solution, []byte("package exercise\n\n// work in progress\n"), 0644)
+	created, err = startExercise("reverse", dir)
+	data, _ := os.ReadFile(solution)
+	fmt.Println(len(created), err, string(data) == "package exercise\n\n// work in progress\n") // 0 <nil> true
+
+	_, err = startExercise("nope", dir)
+	fmt.Println(err) // no such exercise "nope"
+
+	//// Submitting
+	// The archive unpacks to the same files, wherever it's extracted
+	bundle, err := bundleExercise(dir, "zip")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	out := makeTempDir("exercise-submit")
+	defer os.RemoveAll(out)
+	err = unzip(bytes.NewReader(bundle), int64(len(bundle)), out)
+	entries, _ := os.ReadDir(out)
+	for _, e := range entries {
+		fmt.Print(e.Name(), " ")
+	}
+	fmt.Println(err) // go.mod solution.go solution_test.go <nil>
+	_, err = bundleExercise(dir, "rar")
+	fmt.Println(err) // unknown archive format "rar", want zip or tar.gz
+}
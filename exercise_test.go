@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestStartExercise(t *testing.T) {
+	dir := t.TempDir()
+	created, err := startExercise("reverse", dir)
+	if err != nil || len(created) != 3 {
+		t.Fatalf("startExercise = %v, %v; want go.mod, solution.go and solution_test.go", created, err)
+	}
+	solution := filepath.Join(dir, "solution.go")
+	if err := os.WriteFile(solution, []byte("package exercise\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if created, err := startExercise("reverse", dir); err != nil || len(created) != 0 {
+		t.Errorf("starting again = %v, %v; want nothing created", created, err)
+	}
+	if data, _ := os.ReadFile(solution); string(data) != "package exercise\n" {
+		t.Errorf("starting again replaced solution.go with %q", data)
+	}
+	if _, err := startExercise("nope", dir); !errors.Is(err, errNoExercise) {
+		t.Errorf("unknown exercise: %v, want errNoExercise", err)
+	}
+}
+
+// Every exercise, as started, compiles: the stubs fail their tests but must build
+func TestExercisesBuild(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("no go command")
+	}
+	names, err := exerciseNames()
+	if err != nil || len(names) == 0 {
+		t.Fatalf("exerciseNames = %v, %v", names, err)
+	}
+	for _, name := range names {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			if _, err := startExercise(name, dir); err != nil {
+				t.Fatal(err)
+			}
+			cmd := exec.Command("go", "vet", ".")
+			cmd.Dir = dir
+			if out, err := cmd.CombinedOutput(); err != nil {
+				t.Errorf("go vet: %v\n%s", err, out)
+			}
+		})
+	}
+}
+
+func TestBundleExercise(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := startExercise("topwords", dir); err != nil {
+		t.Fatal(err)
+	}
+	for _, format := range []string{"zip", "tar.gz"} {
+		t.Run(format, func(t *testing.T) {
+			data, err := bundleExercise(dir, format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			out := t.TempDir()
+			if format == "zip" {
+				err = unzip(bytes.NewReader(data), int64(len(data)), out)
+			} else {
+				var zr *gzip.Reader
+				if zr, err = gzip.NewReader(bytes.NewReader(data)); err == nil {
+					err = untar(zr, out)
+				}
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, file := range []string{"go.mod", "solution.go", "solution_test.go"} {
+				want, _ := os.ReadFile(filepath.Join(dir, file))
+				if got, err := os.ReadFile(filepath.Join(out, file)); err != nil || !bytes.Equal(got, want) {
+					t.Errorf("%s after extracting: %q, %v", file, got, err)
+				}
+			}
+		})
+	}
+}
+
+// Each submission is a new file, named with a ULID, so resubmitting keeps the earlier one
+func TestExerciseSubmit(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if _, err := startExercise("reverse", filepath.Join(exercisesDir, "reverse")); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := exerciseSubmitCommand([]string{"reverse"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, _ := filepath.Glob("reverse-*.zip")
+	if len(files) != 2 {
+		t.Fatalf("after submitting twice: %v, want two bundles", files)
+	}
+	for _, file := range files {
+		id := strings.TrimSuffix(strings.TrimPrefix(file, "reverse-"), ".zip")
+		if _, err := parseULID(id); err != nil {
+			t.Errorf("%s: %v", file, err)
+		}
+	}
+}
+
+func TestExerciseWorkspace(t *testing.T) {
+	t.Chdir(t.TempDir())
+	if _, err := exerciseWorkspace("reverse"); err == nil {
+		t.Error("before exercise start: no error, want one saying to run it")
+	}
+	dir := filepath.Join(exercisesDir, "reverse")
+	if _, err := startExercise("reverse", dir); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := exerciseWorkspace("reverse"); err != nil || got != dir {
+		t.Errorf("exerciseWorkspace = %q, %v; want %q", got, err, dir)
+	}
+	os.Remove(filepath.Join(dir, "solution.go"))
+	if _, err := exerciseWorkspace("reverse"); err == nil {
+		t.Error("without solution.go: no error, want one saying to run exercise start")
+	}
+}
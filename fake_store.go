@@ -0,0 +1,156 @@
+// Code generated by hellogo fakegen storage.go store; DO NOT EDIT.
+
+package main
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// fakeStore implements store. Methods run the matching XxxFunc field if set,
+// otherwise they return zero values. Every call is recorded.
+type fakeStore struct {
+	SaveProgressFunc      func(a0 context.Context, a1 []progress) (r0 error)
+	LoadProgressFunc      func(a0 context.Context) (r0 []progress, r1 error)
+	SaveQuizResultFunc    func(a0 context.Context, a1 quizResult) (r0 error)
+	QuizResultsFunc       func(a0 context.Context) (r0 []quizResult, r1 error)
+	UnlockAchievementFunc func(a0 context.Context, a1 string, a2 time.Time) (r0 error)
+	AchievementsFunc      func(a0 context.Context) (r0 []achievement, r1 error)
+	AddTodoFunc           func(a0 context.Context, a1 string, a2 time.Time) (r0 todo, r1 error)
+	TodosFunc             func(a0 context.Context) (r0 []todo, r1 error)
+	SetTodoDoneFunc       func(a0 context.Context, a1 int, a2 bool) (r0 error)
+	DeleteTodoFunc        func(a0 context.Context, a1 int) (r0 error)
+	CreateLinkFunc        func(a0 context.Context, a1 string, a2 time.Time) (r0 link, r1 error)
+	LinkFunc              func(a0 context.Context, a1 int) (r0 link, r1 error)
+	RecordVisitFunc       func(a0 context.Context, a1 int) (r0 error)
+	CloseFunc             func() (r0 error)
+
+	mu    sync.Mutex
+	calls []fakeCall
+}
+
+func (f *fakeStore) SaveProgress(a0 context.Context, a1 []progress) (r0 error) {
+	f.record("SaveProgress", a0, a1)
+	if f.SaveProgressFunc != nil {
+		return f.SaveProgressFunc(a0, a1)
+	}
+	return
+}
+
+func (f *fakeStore) LoadProgress(a0 context.Context) (r0 []progress, r1 error) {
+	f.record("LoadProgress", a0)
+	if f.LoadProgressFunc != nil {
+		return f.LoadProgressFunc(a0)
+	}
+	return
+}
+
+func (f *fakeStore) SaveQuizResult(a0 context.Context, a1 quizResult) (r0 error) {
+	f.record("SaveQuizResult", a0, a1)
+	if f.SaveQuizResultFunc != nil {
+		return f.SaveQuizResultFunc(a0, a1)
+	}
+	return
+}
+
+func (f *fakeStore) QuizResults(a0 context.Context) (r0 []quizResult, r1 error) {
+	f.record("QuizResults", a0)
+	if f.QuizResultsFunc != nil {
+		return f.QuizResultsFunc(a0)
+	}
+	return
+}
+
+func (f *fakeStore) UnlockAchievement(a0 context.Context, a1 string, a2 time.Time) (r0 error) {
+	f.record("UnlockAchievement", a0, a1, a2)
+	if f.UnlockAchievementFunc != nil {
+		return f.UnlockAchievementFunc(a0, a1, a2)
+	}
+	return
+}
+
+func (f *fakeStore) Achievements(a0 context.Context) (r0 []achievement, r1 error) {
+	f.record("Achievements", a0)
+	if f.AchievementsFunc != nil {
+		return f.AchievementsFunc(a0)
+	}
+	return
+}
+
+func (f *fakeStore) AddTodo(a0 context.Context, a1 string, a2 time.Time) (r0 todo, r1 error) {
+	f.record("AddTodo", a0, a1, a2)
+	if f.AddTodoFunc != nil {
+		return f.AddTodoFunc(a0, a1, a2)
+	}
+	return
+}
+
+func (f *fakeStore) Todos(a0 context.Context) (r0 []todo, r1 error) {
+	f.record("Todos", a0)
+	if f.TodosFunc != nil {
+		return f.TodosFunc(a0)
+	}
+	return
+}
+
+func (f *fakeStore) SetTodoDone(a0 context.Context, a1 int, a2 bool) (r0 error) {
+	f.record("SetTodoDone", a0, a1, a2)
+	if f.SetTodoDoneFunc != nil {
+		return f.SetTodoDoneFunc(a0, a1, a2)
+	}
+	return
+}
+
+func (f *fakeStore) DeleteTodo(a0 context.Context, a1 int) (r0 error) {
+	f.record("DeleteTodo", a0, a1)
+	if f.DeleteTodoFunc != nil {
+		return f.DeleteTodoFunc(a0, a1)
+	}
+	return
+}
+
+func (f *fakeStore) CreateLink(a0 context.Context, a1 string, a2 time.Time) (r0 link, r1 error) {
+	f.record("CreateLink", a0, a1, a2)
+	if f.CreateLinkFunc != nil {
+		return f.CreateLinkFunc(a0, a1, a2)
+	}
+	return
+}
+
+func (f *fakeStore) Link(a0 context.Context, a1 int) (r0 link, r1 error) {
+	f.record("Link", a0, a1)
+	if f.LinkFunc != nil {
+		return f.LinkFunc(a0, a1)
+	}
+	return
+}
+
+func (f *fakeStore) RecordVisit(a0 context.Context, a1 int) (r0 error) {
+	f.record("RecordVisit", a0, a1)
+	if f.RecordVisitFunc != nil {
+		return f.RecordVisitFunc(a0, a1)
+	}
+	return
+}
+
+func (f *fakeStore) Close() (r0 error) {
+	f.record("Close")
+	if f.CloseFunc != nil {
+		return f.CloseFunc()
+	}
+	return
+}
+
+func (f *fakeStore) record(method string, args ...any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, fakeCall{method, args})
+}
+
+// Calls returns every call so far, in order
+func (f *fakeStore) Calls() []fakeCall {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]fakeCall(nil), f.calls...)
+}
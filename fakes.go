@@ -0,0 +1,240 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+//////// Interfaces for testing: fakes and mocks
+// "Accept interfaces, return structs." The CONSUMER declares the small interface it
+// needs, listing only the methods it calls. The code below wants to read progress and
+// unlock achievements; it doesn't care about quiz results or Close, so it doesn't ask
+// for a whole store. Any store satisfies it, and so does a ten line fake.
+//
+// Go interfaces are satisfied implicitly, so the store implementations never hear
+// about progressAwards; nothing has to be changed to make them fit.
+
+type progressAwards interface {
+	LoadProgress(ctx context.Context) ([]progress, error)
+	UnlockAchievement(ctx context.Context, name string, at time.Time) error
+}
+
+// awardAchievements unlocks achievements for milestones reached so far
+func awardAchievements(ctx context.Context, s progressAwards, clk clock) error {
+	ps, err := s.LoadProgress(ctx)
+	if err != nil {
+		return fmt.Errorf("awarding achievements: %w", err)
+	}
+	completed := 0
+	for _, p := range ps {
+		if p.Completed {
+			completed++
+		}
+	}
+	for _, milestone := range []struct {
+		name   string
+		needed int
+	}{{"first-lesson", 1}, {"five-lessons", 5}, {"ten-lessons", 10}} {
+		if completed < milestone.needed {
+			break
+		}
+		if err := s.UnlockAchievement(ctx, milestone.name, clk.Now()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//// A hand written fake
+// Canned answers in, recorded calls out. fakeClock (timeformat.go) is the same idea
+
+type fakeAwards struct {
+	progress []progress
+	loadErr  error
+	unlocked []string // every UnlockAchievement call, in order
+}
+
+func (f *fakeAwards) LoadProgress(context.Context) ([]progress, error) {
+	return f.progress, f.loadErr
+}
+
+func (f *fakeAwards) UnlockAchievement(_ context.Context, name string, _ time.Time) error {
+	f.unlocked = append(f.unlocked, name)
+	return nil
+}
+
+//// A generated fake
+// Writing fakes by hand gets old for big interfaces. `hellogo fakegen` writes one from
+// the interface's source: every method records its call and then runs the matching
+// XxxFunc field if the test set one, or returns zero values. fake_store.go is generated
+// this way, by the go:generate line next to the store interface in storage.go:
+//
+//	go generate ./...
+
+// fakeCall is one recorded call on a generated fake
+type fakeCall struct {
+	Method string
+	Args   []any
+}
+
+// hellogo fakegen <file> <interface> [out]; writes a fake implementation to out, or stdout
+func fakegenCommand(args []string) error {
+	if len(args) != 2 && len(args) != 3 {
+		return errUsage
+	}
+	src, err := os.ReadFile(args[0])
+	if err != nil {
+		return err
+	}
+	code, err := generateFake(args[0], src, args[1])
+	if err != nil {
+		return err
+	}
+	if len(args) == 3 {
+		// Not `> out` in a shell: that empties the file before go run compiles the
+		// package, which then no longer builds because the fake is missing
+		return writeFileAtomic(args[2], code, 0644)
+	}
+	_, err = os.Stdout.Write(code)
+	return err
+}
+
+func generateFake(filename string, src []byte, iface string) ([]byte, error) {
+	f, err := parser.ParseFile(token.NewFileSet(), filename, src, 0)
+	if err != nil {
+		return nil, err
+	}
+	obj := f.Scope.Lookup(iface)
+	if obj == nil || obj.Kind != ast.Typ {
+		return nil, fmt.Errorf("%s: no type %s", filename, iface)
+	}
+	spec, ok := obj.Decl.(*ast.TypeSpec)
+	if !ok {
+		return nil, fmt.Errorf("%s: no type %s", filename, iface)
+	}
+	it, ok := spec.Type.(*ast.InterfaceType)
+	if !ok {
+		return nil, fmt.Errorf("%s: %s is not an interface", filename, iface)
+	}
+
+	fake := "fake" + strings.ToUpper(iface[:1]) + iface[1:]
+	var fields, methods bytes.Buffer
+	pkgs := map[string]bool{"sync": true} // packages the method signatures mention
+	ast.Inspect(it, func(n ast.Node) bool {
+		if sel, ok := n.(*ast.SelectorExpr); ok {
+			if id, ok := sel.X.(*ast.Ident); ok {
+				pkgs[id.Name] = true
+			}
+		}
+		return true
+	})
+	for _, m := range it.Methods.List {
+		fn, ok := m.Type.(*ast.FuncType)
+		if !ok || len(m.Names) == 0 {
+			return nil, errors.New("embedded interfaces aren't supported")
+		}
+		name := m.Names[0].Name
+
+		// Name every parameter a0, a1... and every result r0, r1... so the
+		// signature can be reused for both the method and its XxxFunc field
+		var params, argNames, results []string
+		for _, p := range fn.Params.List {
+			for range max(1, len(p.Names)) {
+				a := fmt.Sprintf("a%d", len(argNames))
+				params = append(params, a+" "+types.ExprString(p.Type))
+				if _, variadic := p.Type.(*ast.Ellipsis); variadic {
+					a += "..."
+				}
+				argNames = append(argNames, a)
+			}
+		}
+		if fn.Results != nil {
+			for _, r := range fn.Results.List {
+				for range max(1, len(r.Names)) {
+					results = append(results, fmt.Sprintf("r%d %s", len(results), types.ExprString(r.Type)))
+				}
+			}
+		}
+		sig := "(" + strings.Join(params, ", ") + ") (" + strings.Join(results, ", ") + ")"
+		call := name + "Func(" + strings.Join(argNames, ", ") + ")"
+		recorded := strings.ReplaceAll(strings.Join(append([]string{fmt.Sprintf("%q", name)}, argNames...), ", "), "...", "")
+
+		fmt.Fprintf(&fields, "\t%sFunc func%s\n", name, sig)
+		fmt.Fprintf(&methods, "\nfunc (f *%s) %s%s {\n\tf.record(%s)\n", fake, name, sig, recorded)
+		fmt.Fprintf(&methods, "\tif f.%sFunc != nil {\n", name)
+		if len(results) > 0 {
+			fmt.Fprintf(&methods, "\t\treturn f.%s\n\t}\n\treturn\n}\n", call)
+		} else {
+			fmt.Fprintf(&methods, "\t\tf.%s\n\t}\n}\n", call)
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by hellogo fakegen %s %s; DO NOT EDIT.\n\n", filename, iface)
+	fmt.Fprintf(&out, "package %s\n\n", f.Name.Name)
+	imports := []string{`"sync"`}
+	for _, imp := range f.Imports {
+		path := strings.Trim(imp.Path.Value, `"`)
+		name := path[strings.LastIndex(path, "/")+1:]
+		if imp.Name != nil {
+			name = imp.Name.Name
+		}
+		if pkgs[name] && path != "sync" {
+			imports = append(imports, imp.Path.Value)
+		}
+	}
+	slices.Sort(imports)
+	fmt.Fprintf(&out, "import (\n\t%s\n)\n\n", strings.Join(imports, "\n\t"))
+	fmt.Fprintf(&out, "// %s implements %s. Methods run the matching XxxFunc field if set,\n", fake, iface)
+	fmt.Fprintf(&out, "// otherwise they return zero values. Every call is recorded.\n")
+	fmt.Fprintf(&out, "type %s struct {\n%s\n\tmu    sync.Mutex\n\tcalls []fakeCall\n}\n", fake, fields.String())
+	out.Write(methods.Bytes())
+	fmt.Fprintf(&out, "\nfunc (f *%s) record(method string, args ...any) {\n", fake)
+	fmt.Fprintf(&out, "\tf.mu.Lock()\n\tdefer f.mu.Unlock()\n\tf.calls = append(f.calls, fakeCall{method, args})\n}\n")
+	fmt.Fprintf(&out, "\n// Calls returns every call so far, in order\nfunc (f *%s) Calls() []fakeCall {\n", fake)
+	fmt.Fprintf(&out, "\tf.mu.Lock()\n\tdefer f.mu.Unlock()\n\treturn append([]fakeCall(nil), f.calls...)\n}\n")
+
+	code, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("generated code doesn't parse: %w", err)
+	}
+	return code, nil
+}
+
+func testFakes() {
+	ctx := context.Background()
+	clk := &fakeClock{now: time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)}
+
+	// Hand written: check the decisions, no files or databases involved
+	done := sampleProgress(6)
+	for i := range done {
+		done[i].Completed = true
+	}
+	hand := &fakeAwards{progress: done}
+	fmt.Println(awardAchievements(ctx, hand, clk), hand.unlocked) // <nil> [first-lesson five-lessons]
+
+	broken := &fakeAwards{loadErr: errors.New("disk on fire")}
+	fmt.Println(awardAchievements(ctx, broken, clk)) // awarding achievements: disk on fire
+
+	// Generated: a full store, so it also fits anything else that wants one
+	gen := &fakeStore{
+		LoadProgressFunc: func(context.Context) ([]progress, error) { return done[:1], nil },
+	}
+	fmt.Println(awardAchievements(ctx, gen, clk)) // <nil>
+	for _, call := range gen.Calls() {
+		fmt.Println(call.Method, call.Args[1:]) // skip the ctx
+	}
+	// LoadProgress []
+	// UnlockAchievement [first-lesson 2019-01-01 00:00:00 +0000 UTC]
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+)
+
+// fake_store.go has to be what go generate would write now; rerun it if this fails
+func TestFakeStoreUpToDate(t *testing.T) {
+	src, err := os.ReadFile("storage.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := generateFake("storage.go", src, "store")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("fake_store.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("fake_store.go is out of date with the store interface, run go generate storage.go")
+	}
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//////// Reading files
+// The defer lesson in hello.go only creates and writes; here's the other direction
+
+func readWholeFile(path string) {
+	// Simplest: whole file into memory. Fine for small files, bad for huge ones
+	data, err := os.ReadFile(path)
+	if err != nil {
+		fmt.Println("read failed:", err)
+		return
+	}
+	fmt.Println(len(data), "bytes")
+}
+
+func readFileLines(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Println("open failed:", err)
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		if lineNo <= 3 {
+			fmt.Printf("%d: %s\n", lineNo, scanner.Text()) // Text has the \n stripped
+		}
+	}
+	// Scan returns false for both EOF and errors, so always check Err afterwards
+	if err := scanner.Err(); err != nil {
+		fmt.Println("scan failed:", err)
+	}
+	fmt.Println(lineNo, "lines")
+}
+
+// Gotcha: Scanner gives up on lines longer than 64KB with "token too long"
+func testScannerTokenLimit() {
+	longLine := strings.Repeat("x", 100_000)
+
+	scanner := bufio.NewScanner(strings.NewReader(longLine))
+	for scanner.Scan() {
+	}
+	fmt.Println(scanner.Err()) // bufio.Scanner: token too long
+
+	// Fix: give it a bigger buffer (or use bufio.Reader.ReadString for unbounded lines)
+	scanner = bufio.NewScanner(strings.NewReader(longLine))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		fmt.Println(len(scanner.Text())) // 100000
+	}
+	fmt.Println(scanner.Err()) // <nil>
+
+	// Scanners can split on other things too
+	words := bufio.NewScanner(strings.NewReader("the quick  brown\nfox"))
+	words.Split(bufio.ScanWords)
+	for words.Scan() {
+		fmt.Print(words.Text(), ",") // the,quick,brown,fox,
+	}
+	fmt.Println()
+}
+
+// Chunked: call Read yourself with a fixed buffer
+// Read can return n > 0 AND an error (like io.EOF) at the same time, so handle the bytes first
+func readFileChunks(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Println("open failed:", err)
+		return
+	}
+	defer f.Close()
+
+	buf := make([]byte, 4096)
+	total, chunks := 0, 0
+	for {
+		n, err := f.Read(buf)
+		total += n
+		if n > 0 {
+			chunks++
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			fmt.Println("read failed:", err)
+			return
+		}
+	}
+	fmt.Println(total, "bytes in", chunks, "chunks")
+}
+
+func testReadingFiles() {
+	dir := makeTempDir("fileread")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "lines.txt")
+	var sb strings.Builder
+	for i := 1; i <= 1000; i++ {
+		fmt.Fprintf(&sb, "line %d\n", i)
+	}
+	if err := os.WriteFile(path, []byte(sb.String()), 0644); err != nil {
+		fmt.Println("write failed:", err)
+		return
+	}
+
+	// All three print what they found; compare that with what the file holds
+	for _, tc := range []struct {
+		name string
+		read func(string)
+		want string
+	}{
+		{"whole", readWholeFile, "8893 bytes\n"},
+		{"lines", readFileLines, "1: line 1\n2: line 2\n3: line 3\n1000 lines\n"},
+		{"chunks", readFileChunks, "8893 bytes in 3 chunks\n"}, // 4096 + 4096 + 701
+	} {
+		runCase("TestReadFile/"+tc.name, func(errorf errorfFunc) {
+			got, err := captureStdout(func() { tc.read(path) })
+			if err != nil || got != tc.want {
+				errorf("%s printed %q, %v; want %q", tc.name, got, err, tc.want)
+			}
+		})
+	}
+	// --- PASS: TestReadFile/whole
+	// --- PASS: TestReadFile/lines
+	// --- PASS: TestReadFile/chunks
+}
+
+//// Composing readers
+// Everything takes and returns io.Reader, so wrappers stack like pipes
+
+func testReaderWrappers() {
+	src := strings.NewReader("hello, world\nthis line gets cut off")
+
+	// LimitReader stops after n bytes, handy against huge/malicious inputs
+	limited := io.LimitReader(src, 12)
+
+	// TeeReader copies everything read through it into a writer (here a hash)
+	h := sha256.New()
+	tee := io.TeeReader(limited, h)
+
+	data, err := io.ReadAll(tee)
+	fmt.Printf("%q %v\n", data, err) // "hello, world" <nil>
+	fmt.Printf("%x\n", h.Sum(nil))   // sha256 of "hello, world", computed on the way past
+
+	// MultiReader glues readers end to end
+	all, _ := io.ReadAll(io.MultiReader(strings.NewReader("one "), strings.NewReader("two")))
+	fmt.Println(string(all)) // one two
+
+	// SectionReader reads a window of something that supports ReadAt (like *os.File)
+	section := io.NewSectionReader(strings.NewReader("0123456789"), 3, 4)
+	part, _ := io.ReadAll(section)
+	fmt.Println(string(part)) // 3456
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+//////// Writing files
+// See testFinally in hello.go for create/write/close with defer
+
+func testWriteFileVsBuffered(dir string) {
+	// One shot; creates or truncates. The perm bits only matter when the file is created
+	// (and get masked by the umask, so 0644 is usually what you end up with)
+	path := filepath.Join(dir, "oneshot.txt")
+	if err := os.WriteFile(path, []byte("hello\n"), 0644); err != nil {
+		fmt.Println("write failed:", err)
+		return
+	}
+
+	// Lots of small writes? Wrap the file in a bufio.Writer so they become a few big ones
+	f, err := os.OpenFile(filepath.Join(dir, "buffered.txt"), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		fmt.Println("open failed:", err)
+		return
+	}
+	defer f.Close()
+
+	w := bufio.NewWriter(f)
+	for i := 0; i < 1000; i++ {
+		fmt.Fprintln(w, "line", i)
+	}
+	// Forget Flush and the last few KB never reach the file!
+	if err := w.Flush(); err != nil {
+		fmt.Println("flush failed:", err)
+	}
+
+	// Appending instead of truncating
+	logFile, err := os.OpenFile(filepath.Join(dir, "append.log"), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		fmt.Println("open failed:", err)
+		return
+	}
+	fmt.Fprintln(logFile, "another entry")
+	logFile.Close()
+
+	info, _ := os.Stat(filepath.Join(dir, "buffered.txt"))
+	fmt.Println(info.Size(), info.Mode()) // 8890 -rw-------
+}
+
+//// Atomic writes
+// If the program dies half way through os.WriteFile, you're left with half a file.
+// Instead: write a temp file in the SAME directory, fsync it, then rename over the target.
+// Rename within a filesystem is atomic, so readers see either the old file or the new one.
+
+func writeFileAtomic(path string, data []byte, perm os.FileMode) (err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp*")
+	if err != nil {
+		return err
+	}
+	// Clean up the temp file if anything below fails
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return err
+	}
+	// Sync = fsync, make sure the bytes are on disk and not just in the OS cache
+	if err = tmp.Sync(); err != nil {
+		return err
+	}
+	if err = tmp.Chmod(perm); err != nil {
+		return err
+	}
+	if err = tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func testAtomicWrite(dir string) {
+	path := filepath.Join(dir, "progress.gob")
+	if err := saveProgressFile(path, sampleProgress(3)); err != nil {
+		fmt.Println("save failed:", err)
+		return
+	}
+	ps, err := loadProgressFile(path)
+	fmt.Println(len(ps), err) // 3 <nil>
+
+	// No .tmp files left lying around
+	matches, _ := filepath.Glob(filepath.Join(dir, ".progress.gob.tmp*"))
+	fmt.Println(len(matches)) // 0
+}
+
+func testFileWrites() {
+	dir := makeTempDir("filewrite")
+	defer os.RemoveAll(dir)
+
+	testWriteFileVsBuffered(dir)
+	testAtomicWrite(dir)
+
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		fmt.Print(e.Name(), " ")
+	}
+	fmt.Println() // append.log buffered.txt oneshot.txt progress.gob
+}
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//////// Fuzzing
+// Tests check the inputs you thought of. A fuzzer generates the ones you didn't: it
+// starts from a seed corpus, mutates the inputs (flip bytes, insert, delete, splice
+// two together) and watches for panics or broken properties. Native Go fuzzing is a
+// FuzzXxx(f *testing.F) in a _test.go file; fuzz_test.go has one per target below:
+//
+//	func FuzzParseCalcNumber(f *testing.F) {
+//		f.Add("42") // seeds; more come from testdata/fuzz/FuzzParseCalcNumber/*
+//		f.Fuzz(func(t *testing.T, s string) {
+//			if err := fuzzCalcNumber(s); err != nil { // a panic in here is a failure too
+//				t.Error(err)
+//			}
+//		})
+//	}
+//
+//	go test -run FuzzParseCalcNumber              # just the seeds, like any test
+//	go test -fuzz FuzzParseCalcNumber -fuzztime 30s
+//
+// When it finds a failure, go test writes the input to testdata/fuzz/FuzzXxx/<hash>,
+// and from then on plain `go test` replays it as a regression test. Commit those files.
+// Go's fuzzer also watches code coverage to steer mutations toward new branches, which
+// the little random one below doesn't; it still finds shallow bugs in seconds.
+
+// fuzzTarget returns an error when a property is broken; a panic counts as a failure too
+type fuzzTarget func(input string) error
+
+// loadFuzzCorpus reads string seeds from go test's corpus files:
+//
+//	go test fuzz v1
+//	string("42")
+func loadFuzzCorpus(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "*"))
+	if err != nil {
+		return nil, err
+	}
+	var seeds []string
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if quoted, ok := strings.CutPrefix(line, "string("); ok {
+				s, err := strconv.Unquote(strings.TrimSuffix(quoted, ")"))
+				if err != nil {
+					f.Close()
+					return nil, fmt.Errorf("%s: %v", file, err)
+				}
+				seeds = append(seeds, s)
+			}
+		}
+		f.Close()
+	}
+	return seeds, nil
+}
+
+// mutate makes a random small change, the same kinds of moves real fuzzers make
+func mutate(r *rand.Rand, s string, seeds []string) string {
+	b := []byte(s)
+	pos := 0
+	if len(b) > 0 {
+		pos = r.IntN(len(b))
+	}
+	switch r.IntN(6) {
+	case 0: // flip a bit
+		if len(b) > 0 {
+			b[pos] ^= 1 << r.IntN(8)
+		}
+	case 1: // insert a random byte
+		b = append(b[:pos], append([]byte{byte(r.IntN(256))}, b[pos:]...)...)
+	case 2: // insert something "interesting" for parsers
+		tokens := []string{".", "-", "e", "0x", "_", " ", `"`, "{}", "9999999999999999999"}
+		b = append(b[:pos], append([]byte(tokens[r.IntN(len(tokens))]), b[pos:]...)...)
+	case 3: // delete a chunk
+		if len(b) > 0 {
+			end := min(len(b), pos+1+r.IntN(4))
+			b = append(b[:pos], b[end:]...)
+		}
+	case 4: // truncate
+		b = b[:pos]
+	case 5: // splice with another seed
+		other := seeds[r.IntN(len(seeds))]
+		return string(b[:pos]) + other[r.IntN(len(other)+1):]
+	}
+	return string(b)
+}
+
+// runFuzzTarget feeds the target mutated inputs until it fails or runs out of time,
+// returning the failing input
+func runFuzzTarget(target fuzzTarget, seeds []string, budget time.Duration) (input string, tries int, err error) {
+	r := rand.New(rand.NewPCG(1, 2)) // fixed seed: the same run every time
+	call := func(s string) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = fmt.Errorf("panic: %v", p)
+			}
+		}()
+		return target(s)
+	}
+
+	deadline := time.Now().Add(budget)
+	corpus := append([]string(nil), seeds...)
+	for time.Now().Before(deadline) {
+		input = corpus[r.IntN(len(corpus))]
+		// Seeds get run as they are first, then mutated, sometimes several times over
+		if tries >= len(seeds) {
+			for n := 1 + r.IntN(3); n > 0; n-- {
+				input = mutate(r, input, seeds)
+			}
+		}
+		tries++
+		if err := call(input); err != nil {
+			return input, tries, err
+		}
+		if r.IntN(20) == 0 {
+			corpus = append(corpus, input) // keep some mutants to mutate further
+		}
+	}
+	return "", tries, nil
+}
+
+//// Targets
+
+// fuzzCalcNumber: never panics, and whatever it accepts survives printing and re-parsing
+func fuzzCalcNumber(s string) error {
+	n, err := parseCalcNumber(s)
+	if err != nil {
+		return nil // rejecting garbage is fine
+	}
+	again, err := parseCalcNumber(strconv.FormatFloat(n, 'g', -1, 64))
+	if err != nil || (again != n && !(math.IsNaN(n) && math.IsNaN(again))) {
+		return fmt.Errorf("%q parsed as %v but printing it gives back %v, %v", s, n, again, err)
+	}
+	return nil
+}
+
+// fuzzCalc: the parser never panics, and printing what it parsed and parsing that again
+// gives the same tree (String is fully parenthesized, so it has to survive a round trip)
+func fuzzCalc(s string) error {
+	node, err := parseCalc(s)
+	if err != nil {
+		return nil
+	}
+	again, err := parseCalc(node.String())
+	if err != nil || again.String() != node.String() {
+		return fmt.Errorf("%q parsed as %s, which parses back as %v, %v", s, node, again, err)
+	}
+	return nil
+}
+
+var fuzzJWTKey = []byte("super secret")
+
+// fuzzVerifyJWT: any string at all gets a clean answer, never a panic
+func fuzzVerifyJWT(s string) error {
+	verifyJWT(fuzzJWTKey, s, time.Unix(1546300800, 0))
+	return nil
+}
+
+// naiveJWTSubject is how NOT to peek at a token: it trusts the shape of its input,
+// exactly the kind of code fuzzing is good at breaking
+func naiveJWTSubject(token string) string {
+	payload, _ := jwtEncoding.DecodeString(strings.Split(token, ".")[1])
+	s := string(payload)
+	start := strings.Index(s, `"sub":"`) + len(`"sub":"`)
+	return s[start : start+strings.Index(s[start:], `"`)]
+}
+
+func fuzzNaiveJWTSubject(s string) error {
+	naiveJWTSubject(s)
+	return nil
+}
+
+func testFuzzing() {
+	for _, t := range []struct {
+		name   string
+		corpus string // testdata/fuzz/<corpus>
+		target fuzzTarget
+	}{
+		{"FuzzParseCalcNumber", "FuzzParseCalcNumber", fuzzCalcNumber},
+		{"FuzzCalc", "FuzzCalc", fuzzCalc},
+		{"FuzzVerifyJWT", "FuzzVerifyJWT", fuzzVerifyJWT},
+		{"FuzzNaiveJWTSubject", "FuzzVerifyJWT", fuzzNaiveJWTSubject}, // same seeds, fragile code
+	} {
+		seeds, err := loadFuzzCorpus(filepath.Join("testdata", "fuzz", t.corpus))
+		if err != nil || len(seeds) == 0 {
+			fmt.Println(t.name, "no seed corpus, run from the repo root:", err)
+			continue
+		}
+		input, tries, err := runFuzzTarget(t.target, seeds, 500*time.Millisecond)
+		if err != nil {
+			fmt.Printf("%s: failed after %d inputs on %q: %v\n", t.name, tries, input, err)
+			continue
+		}
+		fmt.Printf("%s: ok, %d inputs\n", t.name, tries)
+	}
+	// FuzzParseCalcNumber: ok, 361355 inputs
+	// FuzzCalc: ok, 201870 inputs
+	// FuzzVerifyJWT: ok, 363455 inputs
+	// FuzzNaiveJWTSubject: failed after 5 inputs on "eyJhb\x99Gci...": panic: runtime error: slice bounds out of range [8:7]
+}
@@ -0,0 +1,33 @@
+package main
+
+import "testing"
+
+// Plain go test runs each of these on its f.Add seeds and the files in
+// testdata/fuzz/<name>/; go test -fuzz <name> mutates from there
+
+func FuzzParseCalcNumber(f *testing.F) {
+	f.Add("42")
+	f.Fuzz(func(t *testing.T, s string) {
+		if err := fuzzCalcNumber(s); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func FuzzCalc(f *testing.F) {
+	f.Add("1 + 2")
+	f.Fuzz(func(t *testing.T, s string) {
+		if err := fuzzCalc(s); err != nil {
+			t.Error(err)
+		}
+	})
+}
+
+func FuzzVerifyJWT(f *testing.F) {
+	f.Add("a.b.c")
+	f.Fuzz(func(t *testing.T, s string) {
+		if err := fuzzVerifyJWT(s); err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+//////// The garbage collector
+// Go's GC runs concurrently with your code and only stops the world for tiny pauses
+// (well under a millisecond). What you control is HOW OFTEN it runs:
+//
+//	GOGC=100 (default)   collect when the heap has grown 100% since the last collection
+//	GOGC=400             let it grow 4x first: fewer GCs, more memory
+//	GOGC=off             never collect... unless GOMEMLIMIT says so
+//	GOMEMLIMIT=512MiB    a soft cap: the GC works harder as the heap gets near it
+//
+// debug.SetGCPercent / debug.SetMemoryLimit change the same knobs from code.
+// Fewer allocations beats any tuning though; see escape analysis and sync.Pool.
+
+type memSnapshot struct {
+	stats runtime.MemStats
+}
+
+func readMem() memSnapshot {
+	var s memSnapshot
+	runtime.ReadMemStats(&s.stats) // briefly stops the world, don't call it in a hot loop
+	return s
+}
+
+// since reports what happened between two snapshots
+func (s memSnapshot) since(before memSnapshot) string {
+	return fmt.Sprintf("allocated %5.1fMB in %6d objects, %3d GCs, heap now %5.1fMB",
+		mb(s.stats.TotalAlloc-before.stats.TotalAlloc),
+		s.stats.Mallocs-before.stats.Mallocs,
+		s.stats.NumGC-before.stats.NumGC,
+		mb(s.stats.HeapAlloc))
+}
+
+func mb(n uint64) float64 { return float64(n) / (1 << 20) }
+
+// churn allocates total bytes in chunks and keeps the last `keep` of them alive,
+// like a server holding a cache while making lots of short lived garbage per request
+func churn(total, chunk, keep int) [][]byte {
+	live := make([][]byte, 0, keep)
+	for i := 0; i < total/chunk; i++ {
+		b := make([]byte, chunk)
+		b[0] = byte(i)
+		switch {
+		case keep == 0: // dropped straight away
+		case len(live) < keep:
+			live = append(live, b)
+		default:
+			live[i%keep] = b
+		}
+	}
+	return live
+}
+
+var retained [][]byte
+
+func testAllocationPatterns() {
+	runtime.GC()
+	before := readMem()
+	churn(200<<20, 1<<10, 0) // 200MB of 1KB garbage, none kept
+	fmt.Println("garbage only:", readMem().since(before))
+
+	before = readMem()
+	retained = churn(200<<20, 1<<10, 50_000) // same, but ~50MB stays alive
+	fmt.Println("with 50MB live:", readMem().since(before))
+	// garbage only:   allocated 200.0MB in 204800 objects,  ~70 GCs, heap now ~2MB
+	// with 50MB live: allocated 201.1MB in 204801 objects,   ~7 GCs, heap now ~120MB
+	// More live data means a bigger target (live * 2 at GOGC=100), so FEWER collections
+	retained = nil
+}
+
+func testGCPercent() {
+	defer debug.SetGCPercent(debug.SetGCPercent(100)) // put back whatever it was
+
+	for _, percent := range []int{25, 100, 400} {
+		debug.SetGCPercent(percent)
+		runtime.GC()
+		before := readMem()
+		start := time.Now()
+		retained = churn(100<<20, 4<<10, 2_500) // ~10MB live
+		after := readMem()
+		// NextGC is the heap size that triggers the next collection: live data * (1 + GOGC/100)
+		fmt.Printf("GOGC=%-3d %3d GCs, heap goal %5.1fMB, %v\n",
+			percent, after.stats.NumGC-before.stats.NumGC, mb(after.stats.NextGC), time.Since(start).Round(time.Millisecond))
+		retained = nil
+	}
+	// GOGC=25   ~40 GCs, heap goal ~14MB
+	// GOGC=100   ~8 GCs, heap goal ~30MB
+	// GOGC=400   ~2 GCs, heap goal ~130MB
+	// Same work, same live data: you're trading CPU spent collecting for memory
+}
+
+func testMemoryLimit() {
+	defer debug.SetGCPercent(debug.SetGCPercent(-1)) // GOGC=off
+	defer debug.SetMemoryLimit(debug.SetMemoryLimit(64 << 20))
+
+	runtime.GC()
+	before := readMem()
+	retained = churn(300<<20, 4<<10, 5_000) // ~20MB live
+	fmt.Println("GOGC=off, GOMEMLIMIT=64MiB:", readMem().since(before))
+	// Without the limit GOGC=off would never collect and the heap would reach 300MB.
+	// With it the GC only runs when the heap nears 64MB: ~10 GCs, heap now ~45MB.
+	// Careful: if live data alone is over the limit, the GC runs constantly ("death spiral",
+	// capped at about 50% CPU), which is why it's a soft limit and not a hard one
+	retained = nil
+}
+
+// testGCPauses summarises stop-the-world pause times, the number people worry about
+func testGCPauses() {
+	var stats debug.GCStats
+	stats.PauseQuantiles = make([]time.Duration, 5) // min, 25%, 50%, 75%, max
+	debug.ReadGCStats(&stats)
+	if stats.NumGC == 0 {
+		fmt.Println("no GCs yet")
+		return
+	}
+	q := stats.PauseQuantiles
+	fmt.Printf("%d GCs, total pause %v\n", stats.NumGC, stats.PauseTotal)
+	fmt.Printf("pauses: min %v, median %v, max %v\n", q[0], q[2], q[4])
+	// pauses: min ~8µs, median ~10µs, max ~50µs; tiny next to the work done in between
+}
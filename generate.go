@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+)
+
+//////// go:generate and stringer
+// `go generate` runs the commands in //go:generate comments, in the directory of the file
+// they're in. It's never run by go build: whoever changes the input runs it and commits
+// the output, so users of the package don't need the generator installed.
+//
+//	go generate ./...          # regenerates enums_string.go (and fake_store.go, see fakes.go)
+//
+// The tool line in go.mod (added with go get -tool golang.org/x/tools/cmd/stringer)
+// pins stringer's version like any dependency, and `go tool stringer` builds and runs
+// that version; nobody has to go install it or keep it in step by hand.
+// stringer writes a String method for integer constants, which fmt then uses for %v.
+// Generated files start with "// Code generated ... DO NOT EDIT." which tools (and
+// reviewers) recognise; fix the input and regenerate instead of editing them.
+//
+// Why generate rather than use reflection? Reflection can't see constant names at all,
+// and even where it works (struct fields, JSON) it's checked at run time and slow-ish.
+// Generated code is plain Go: type checked, fast, and readable in the debugger.
+// The price is a build step someone has to remember; CI can run go generate and fail on
+// a git diff to catch that.
+
+//go:generate go tool stringer -type=Difficulty,errorCode -linecomment -output=enums_string.go
+
+// Difficulty of a lesson. -linecomment makes the comment the String() value
+type Difficulty int
+
+const (
+	Beginner     Difficulty = iota // beginner
+	Intermediate                   // intermediate
+	Advanced                       // advanced
+)
+
+// MarshalText makes JSON (and TOML, and flag.TextVar) use the name, not the number
+func (d Difficulty) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Difficulty) UnmarshalText(text []byte) error {
+	for c := Beginner; c <= Advanced; c++ {
+		if c.String() == string(text) {
+			*d = c
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown difficulty %q", text)
+}
+
+// lessonDifficulties rates the lesson files; anything not listed is Intermediate
+var lessonDifficulties = map[string]Difficulty{
+	"hello.go":      Beginner,
+	"fileread.go":   Beginner,
+	"filewrite.go":  Beginner,
+	"paths.go":      Beginner,
+	"strconv.go":    Beginner,
+	"timeformat.go": Beginner,
+	"buildtags.go":  Advanced,
+	"escape.go":     Advanced,
+	"fuzz.go":       Advanced,
+	"gc.go":         Advanced,
+	"generate.go":   Advanced,
+	"pprof.go":      Advanced,
+	"trace.go":      Advanced,
+}
+
+func lessonDifficulty(name string) Difficulty {
+	if d, ok := lessonDifficulties[name]; ok {
+		return d
+	}
+	return Intermediate
+}
+
+// errorCode is the machine readable part of an API error, the message is for humans
+type errorCode int
+
+const (
+	errCodeInternal    errorCode = iota // internal
+	errCodeBadRequest                   // bad_request
+	errCodeNotFound                     // not_found
+	errCodeRateLimited                  // rate_limited
+)
+
+func (c errorCode) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// status is the HTTP status that goes with each code
+func (c errorCode) status() int {
+	switch c {
+	case errCodeBadRequest:
+		return http.StatusBadRequest
+	case errCodeNotFound:
+		return http.StatusNotFound
+	case errCodeRateLimited:
+		return http.StatusTooManyRequests
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+type apiError struct {
+	Code    errorCode `json:"code"`
+	Message string    `json:"message"`
+}
+
+// writeAPIError sends {"code": "not_found", "message": "..."} with the matching status
+func writeAPIError(w http.ResponseWriter, code errorCode, message string) {
+	writeJSON(w, code.status(), apiError{code, message})
+}
+
+func testGenerate() {
+	fmt.Println(Advanced, errCodeNotFound)                 // advanced not_found
+	fmt.Printf("%v %d %q\n", Beginner, Beginner, Beginner) // beginner 0 "beginner"
+	fmt.Println(Difficulty(7))                             // Difficulty(7), out of range values still print
+
+	var d Difficulty
+	err := d.UnmarshalText([]byte("intermediate"))
+	fmt.Println(d, err)                            // intermediate <nil>
+	fmt.Println(d.UnmarshalText([]byte("expert"))) // unknown difficulty "expert"
+
+	counts := map[Difficulty]int{}
+	lessons, _ := embeddedLessons()
+	for _, l := range lessons {
+		counts[l.Difficulty]++
+	}
+	fmt.Println(counts[Beginner], counts[Advanced]) // 6 7
+
+	// What reflection can tell you about the same constant: the type and value, no name
+	v := reflect.ValueOf(Advanced)
+	fmt.Println(v.Type(), v.Int(), v.String()) // main.Difficulty 2 <main.Difficulty Value>
+}
@@ -0,0 +1,107 @@
+//go:build go1.21
+
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+)
+
+//////// Generics
+// Type parameters arrived in Go 1.18; the cmp and slices packages (used below) in 1.21, hence
+// the build tag. generics_pre121.go stands in on older toolchains (not that the rest of
+// this repo would build there, it uses mapSlice, broker[T] and range over ints).
+//
+// A type parameter's constraint is an interface, read as a SET of types:
+//
+//	any                      every type
+//	comparable               types that work with == (map keys)
+//	cmp.Ordered              types that work with < (ints, floats, strings)
+//	~int | ~float64          int, float64, AND types defined from them (type celsius float64)
+
+type number interface {
+	~int | ~int64 | ~float64
+}
+
+func sumOf[T number](values []T) T {
+	var total T
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+type celsius float64
+
+// maxBy returns the element with the biggest key; the zero T and false for an empty slice
+func maxBy[T any, K cmp.Ordered](items []T, key func(T) K) (T, bool) {
+	var best T
+	if len(items) == 0 {
+		return best, false
+	}
+	best = items[0]
+	for _, item := range items[1:] {
+		if key(item) > key(best) {
+			best = item
+		}
+	}
+	return best, true
+}
+
+// Generic types: the type parameter goes on the type, methods can't add their own
+type stack[T any] struct {
+	items []T
+}
+
+func (s *stack[T]) Push(v T) { s.items = append(s.items, v) }
+
+func (s *stack[T]) Pop() (T, bool) {
+	var zero T
+	if len(s.items) == 0 {
+		return zero, false
+	}
+	v := s.items[len(s.items)-1]
+	s.items = s.items[:len(s.items)-1]
+	return v, true
+}
+
+// Constraints can ask for methods too: comparable types with a String method
+type labelled interface {
+	comparable
+	String() string
+}
+
+func countLabels[T labelled](items []T) map[string]int {
+	counts := map[string]int{}
+	for _, item := range items {
+		counts[item.String()]++
+	}
+	return counts
+}
+
+type httpCode int
+
+func (c httpCode) String() string { return strconv.Itoa(int(c)/100) + "xx" }
+
+func testGenerics() {
+	fmt.Println(sumOf([]int{1, 2, 3}))                                                    // 6, T inferred as int
+	fmt.Println(sumOf([]celsius{20.5, 21}))                                               // 41.5, ~float64 lets celsius in
+	fmt.Println(sumOf[float64](nil))                                                      // 0, nothing to infer from so say it
+	fmt.Println(maxBy([]string{"go", "rust", "c"}, func(s string) int { return len(s) })) // rust true
+
+	var s stack[string]
+	s.Push("a")
+	s.Push("b")
+	fmt.Println(s.Pop()) // b true
+	fmt.Println(s.Pop()) // a true
+	fmt.Println(s.Pop()) //  false
+
+	fmt.Println(countLabels([]httpCode{200, 404, 201})) // map[2xx:2 4xx:1]
+
+	// The standard library does most of this already
+	words := []string{"pear", "fig", "apple"}
+	slices.SortFunc(words, func(a, b string) int { return cmp.Compare(len(a), len(b)) })
+	fmt.Println(words, slices.Max([]int{3, 9, 2}), slices.Index(words, "pear")) // [fig pear apple] 9 1
+}
@@ -0,0 +1,14 @@
+//go:build !go1.21
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Stands in for generics.go on toolchains without the cmp and slices packages
+
+func testGenerics() {
+	fmt.Println("the generics lesson needs go1.21 or newer, this is", runtime.Version())
+}
@@ -0,0 +1,345 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+//////// Mini project: a GitHub API client
+// Three things every real API client has to deal with:
+//
+// Pagination. A list comes back a page at a time, and the Link header says where the
+// next one is:
+//
+//	Link: <https://api.github.com/user/1/repos?page=2>; rel="next", <...?page=5>; rel="last"
+//
+// Follow rel="next" until there isn't one rather than building page URLs yourself; the
+// server is free to change how they look. ghListAll does that for any element type.
+//
+// Auth. A token from GITHUB_TOKEN goes in the Authorization header. Anonymous calls
+// work too, with a much lower rate limit (60 an hour rather than 5000).
+//
+// Rate limits. Every response says how many calls are left (X-RateLimit-Remaining) and
+// when the count resets (X-RateLimit-Reset, unix seconds). Run out and you get a 403 or
+// 429; the client waits for the reset if that's soon, and gives up with a clear error
+// if it isn't. Server errors get a few more tries through retry (retry.go).
+//
+// The lesson runs against newFakeGitHub, an httptest server with just enough of the API.
+//
+//	hellogo gh repos <user>
+//	hellogo gh issues <owner/repo> [--state open|closed|all]
+
+const githubAPI = "https://api.github.com"
+
+type ghRepo struct {
+	Name        string    `json:"name"`
+	FullName    string    `json:"full_name"`
+	Description string    `json:"description"`
+	Stars       int       `json:"stargazers_count"`
+	Fork        bool      `json:"fork"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+type ghIssue struct {
+	Number int    `json:"number"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	User   struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	PullRequest *struct{} `json:"pull_request"` // set on pull requests, which the issues API lists too
+}
+
+type githubClient struct {
+	http    *http.Client
+	baseURL string
+	token   string // "" for anonymous
+	clock   clock
+
+	attempts int           // per page, for server errors and rate limits
+	backoff  time.Duration // first pause between attempts
+	maxWait  time.Duration // longest rate limit reset worth waiting for
+
+	remaining atomic.Int64 // X-RateLimit-Remaining from the last response, -1 before any
+}
+
+func newGitHubClient(baseURL, token string, clk clock) *githubClient {
+	c := &githubClient{
+		http:     &http.Client{Timeout: 15 * time.Second},
+		baseURL:  strings.TrimSuffix(baseURL, "/"),
+		token:    token,
+		clock:    clk,
+		attempts: 4,
+		backoff:  time.Second,
+		maxWait:  time.Minute,
+	}
+	c.remaining.Store(-1)
+	return c
+}
+
+// parseLinkHeader maps each rel to its URL: <url>; rel="next", <url>; rel="last"
+func parseLinkHeader(h string) map[string]string {
+	links := map[string]string{}
+	for _, part := range strings.Split(h, ",") {
+		target, params, ok := strings.Cut(strings.TrimSpace(part), ";")
+		if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+			continue
+		}
+		for _, param := range strings.Split(params, ";") {
+			key, value, _ := strings.Cut(strings.TrimSpace(param), "=")
+			if key == "rel" {
+				for _, rel := range strings.Fields(strings.Trim(value, `"`)) { // rel="next last" is allowed
+					links[rel] = target[1 : len(target)-1]
+				}
+			}
+		}
+	}
+	return links
+}
+
+var errRateLimited = errors.New("rate limited")
+
+// rateLimitWait is how long the server asked us to wait, if the response is a rate limit
+func (c *githubClient) rateLimitWait(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if secs, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil {
+		return time.Duration(secs) * time.Second, true // the secondary limits, for bursts
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return 0, false // a plain 403: no access to this
+	}
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return max(0, time.Unix(reset, 0).Sub(c.clock.Now())), true
+}
+
+// getPage GETs u into v and returns the next page's URL, "" on the last page
+func (c *githubClient) getPage(ctx context.Context, u string, v any) (next string, err error) {
+	err = retry(ctx, c.attempts, c.backoff, func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return permanent(err)
+		}
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+		if c.token != "" {
+			req.Header.Set("Authorization", "Bearer "+c.token)
+		}
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		if n, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Remaining"), 10, 64); err == nil {
+			c.remaining.Store(n)
+		}
+
+		if wait, limited := c.rateLimitWait(resp); limited {
+			if wait > c.maxWait {
+				return permanent(fmt.Errorf("%w for another %v (a GITHUB_TOKEN raises the limit)", errRateLimited, wait.Round(time.Second)))
+			}
+			select {
+			case <-time.After(wait):
+			case <-ctx.Done():
+				return permanent(ctx.Err())
+			}
+			return errRateLimited // try again, the limit has reset
+		}
+		if resp.StatusCode != http.StatusOK {
+			body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			err := fmt.Errorf("GET %s: %s: %s", u, resp.Status, bytes.TrimSpace(body))
+			if resp.StatusCode >= 500 {
+				return err
+			}
+			return permanent(err) // 401, 404...: asking again won't help
+		}
+		if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+			return permanent(fmt.Errorf("GET %s: %w", u, err))
+		}
+		next = parseLinkHeader(resp.Header.Get("Link"))["next"]
+		return nil
+	})
+	return next, err
+}
+
+// ghListAll follows the pages from path until there are no more. It's a function
+// rather than a method because methods can't have type parameters of their own
+func ghListAll[T any](ctx context.Context, c *githubClient, path string) ([]T, error) {
+	var all []T
+	for u := c.baseURL + path; u != ""; {
+		var page []T
+		next, err := c.getPage(ctx, u, &page)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, page...)
+		u = next
+	}
+	return all, nil
+}
+
+func (c *githubClient) Repos(ctx context.Context, user string) ([]ghRepo, error) {
+	return ghListAll[ghRepo](ctx, c, "/users/"+url.PathEscape(user)+"/repos?per_page=100&sort=updated")
+}
+
+// Issues lists issues in state open, closed or all, leaving out pull requests
+func (c *githubClient) Issues(ctx context.Context, owner, repo, state string) ([]ghIssue, error) {
+	all, err := ghListAll[ghIssue](ctx, c, "/repos/"+url.PathEscape(owner)+"/"+url.PathEscape(repo)+"/issues?per_page=100&state="+url.QueryEscape(state))
+	if err != nil {
+		return nil, err
+	}
+	issues := all[:0] // filtering in place, reusing all's backing array
+	for _, issue := range all {
+		if issue.PullRequest == nil {
+			issues = append(issues, issue)
+		}
+	}
+	return issues, nil
+}
+
+// hellogo gh repos <user> | hellogo gh issues <owner/repo> [--state open|closed|all]
+func ghCommand(args []string) error {
+	if len(args) < 2 {
+		return errUsage
+	}
+	flags := flag.NewFlagSet("gh", flag.ContinueOnError)
+	state := flags.String("state", "open", "issues to list: open, closed or all")
+	if err := flags.Parse(args[2:]); err != nil || flags.NArg() > 0 {
+		return errUsage
+	}
+
+	c := newGitHubClient(githubAPI, os.Getenv("GITHUB_TOKEN"), realClock{})
+	ctx, stop := shutdownContext()
+	defer stop()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	defer tw.Flush()
+
+	switch args[0] {
+	case "repos":
+		repos, err := c.Repos(ctx, args[1])
+		if err != nil {
+			return err
+		}
+		for _, r := range repos {
+			fmt.Fprintf(tw, "%s\t★ %d\t%s\t%s\n", r.Name, r.Stars, r.UpdatedAt.Format(time.DateOnly), r.Description)
+		}
+	case "issues":
+		owner, repo, ok := strings.Cut(args[1], "/")
+		if !ok {
+			return errUsage
+		}
+		issues, err := c.Issues(ctx, owner, repo, *state)
+		if err != nil {
+			return err
+		}
+		for _, issue := range issues {
+			fmt.Fprintf(tw, "#%d\t%s\t%s\t%s\n", issue.Number, issue.State, issue.User.Login, issue.Title)
+		}
+	default:
+		return errUsage
+	}
+	fmt.Fprintf(os.Stderr, "%d API calls left this hour\n", c.remaining.Load())
+	return nil
+}
+
+//// A fake GitHub for the lesson
+
+type fakeGitHub struct {
+	*httptest.Server
+	repos    []ghRepo
+	issues   []ghIssue
+	perPage  int          // the server decides the page size, like the real one caps per_page
+	limitOn  atomic.Int64 // the request number that gets rate limited, 0 for never
+	requests atomic.Int64 // counted, so the lesson can see how many pages it took
+}
+
+func newFakeGitHub(repos []ghRepo, issues []ghIssue, perPage int) *fakeGitHub {
+	f := &fakeGitHub{repos: repos, issues: issues, perPage: perPage}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{user}/repos", func(w http.ResponseWriter, r *http.Request) {
+		serveFakePage(f, w, r, f.repos)
+	})
+	mux.HandleFunc("GET /repos/{owner}/{repo}/issues", func(w http.ResponseWriter, r *http.Request) {
+		serveFakePage(f, w, r, f.issues)
+	})
+	f.Server = httptest.NewServer(f.checks(mux))
+	return f
+}
+
+// checks does what every endpoint shares: auth and the rate limit
+func (f *fakeGitHub) checks(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := f.requests.Add(1)
+		if r.Header.Get("Authorization") == "Bearer bad" {
+			http.Error(w, `{"message":"Bad credentials"}`, http.StatusUnauthorized)
+			return
+		}
+		if n == f.limitOn.Load() {
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Unix(), 10)) // resets right away
+			http.Error(w, `{"message":"API rate limit exceeded"}`, http.StatusForbidden)
+			return
+		}
+		w.Header().Set("X-RateLimit-Remaining", strconv.FormatInt(5000-n, 10))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// serveFakePage writes the ?page= slice of items, with a Link to the next page if there is one
+func serveFakePage[T any](f *fakeGitHub, w http.ResponseWriter, r *http.Request, items []T) {
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	start := min((page-1)*f.perPage, len(items))
+	end := min(start+f.perPage, len(items))
+
+	if end < len(items) {
+		next := *r.URL
+		q := next.Query()
+		q.Set("page", strconv.Itoa(page+1))
+		next.RawQuery = q.Encode()
+		w.Header().Set("Link", fmt.Sprintf(`<%s%s>; rel="next"`, f.URL, next.RequestURI()))
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(items[start:end])
+}
+
+func testGitHubClient() {
+	links := parseLinkHeader(`<https://api.github.com/user/1/repos?page=2>; rel="next", <https://api.github.com/user/1/repos?page=5>; rel="last"`)
+	fmt.Println(links["next"], links["last"]) // https://api.github.com/user/1/repos?page=2 https://api.github.com/user/1/repos?page=5
+
+	var repos []ghRepo
+	for i := range 5 {
+		repos = append(repos, ghRepo{Name: fmt.Sprint("repo", i), Stars: i * 10})
+	}
+	issues := []ghIssue{{Number: 1, Title: "crash on empty input", State: "open"}, {Number: 2, Title: "fix the crash", State: "open", PullRequest: &struct{}{}}}
+	fake := newFakeGitHub(repos, issues, 2)
+	defer fake.Close()
+	clk := &fakeClock{now: time.Now()}
+	ctx := context.Background()
+
+	// Three pages of 2, and the remaining count from the last response's header
+	c := newGitHubClient(fake.URL, "", clk)
+	got, err := c.Repos(ctx, "gopher")
+	fmt.Println(len(got), err, fake.requests.Load(), c.remaining.Load()) // 5 <nil> 3 4997
+	// ghclient_test.go covers the rate limit, issues and a bad token against the same fake
+}
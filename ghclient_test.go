@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGitHubClient(t *testing.T) {
+	var repos []ghRepo
+	for i := range 5 {
+		repos = append(repos, ghRepo{Name: fmt.Sprint("repo", i), Stars: i * 10})
+	}
+	issues := []ghIssue{{Number: 1, Title: "crash on empty input", State: "open"}, {Number: 2, Title: "fix the crash", State: "open", PullRequest: &struct{}{}}}
+	newFake := func(t *testing.T) *fakeGitHub {
+		fake := newFakeGitHub(repos, issues, 2)
+		t.Cleanup(fake.Close)
+		return fake
+	}
+	clk := &fakeClock{now: time.Now()}
+	ctx := context.Background()
+
+	t.Run("pagination", func(t *testing.T) {
+		fake := newFake(t)
+		c := newGitHubClient(fake.URL, "", clk)
+		got, err := c.Repos(ctx, "gopher")
+		if err != nil || len(got) != 5 || got[4].Name != "repo4" {
+			t.Errorf("Repos = %v, %v; want repo0 to repo4", got, err)
+		}
+		if n := fake.requests.Load(); n != 3 {
+			t.Errorf("%d requests, want 3 pages of 2", n)
+		}
+		if n := c.remaining.Load(); n != 4997 {
+			t.Errorf("remaining = %d, want 4997", n)
+		}
+	})
+	t.Run("rate limited", func(t *testing.T) {
+		fake := newFake(t)
+		fake.limitOn.Store(2) // the second page
+		c := newGitHubClient(fake.URL, "", clk)
+		c.backoff = time.Millisecond
+		got, err := c.Repos(ctx, "gopher")
+		if err != nil || len(got) != 5 {
+			t.Errorf("Repos = %d repos, %v; want all 5 after waiting out the limit", len(got), err)
+		}
+		if n := fake.requests.Load(); n != 4 {
+			t.Errorf("%d requests, want 4 (3 pages, 1 retried)", n)
+		}
+	})
+	t.Run("rate limit too long", func(t *testing.T) {
+		fake := newFake(t)
+		fake.limitOn.Store(1)
+		c := newGitHubClient(fake.URL, "", &fakeClock{now: time.Now().Add(-time.Hour)}) // an hour behind, so the reset looks far off
+		_, err := c.Repos(ctx, "gopher")
+		if !errors.Is(err, errRateLimited) || fake.requests.Load() != 1 {
+			t.Errorf("Repos = %v after %d requests, want errRateLimited without retrying", err, fake.requests.Load())
+		}
+	})
+	t.Run("issues without pull requests", func(t *testing.T) {
+		got, err := newGitHubClient(newFake(t).URL, "", clk).Issues(ctx, "gopher", "hello", "open")
+		if err != nil || len(got) != 1 || got[0].Number != 1 {
+			t.Errorf("Issues = %+v, %v; want just #1", got, err)
+		}
+	})
+	t.Run("bad token", func(t *testing.T) {
+		fake := newFake(t)
+		_, err := newGitHubClient(fake.URL, "bad", clk).Repos(ctx, "gopher")
+		if err == nil || !strings.Contains(err.Error(), "401 Unauthorized") || fake.requests.Load() != 1 {
+			t.Errorf("Repos with a bad token = %v after %d requests, want a 401 and no retries", err, fake.requests.Load())
+		}
+	})
+}
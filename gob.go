@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+//////// gob and encoding/binary
+// Two ways to turn structs into bytes (to save to disk, send over the wire...)
+// gob: Go-only, self describing, handles nested types for you
+// binary: you decide the exact byte layout, tiny and fast but all the work is yours
+
+// A record of how far someone got through a lesson
+type progress struct {
+	Lesson    string
+	Completed bool
+	Attempts  int32
+	LastSeen  int64 // unix seconds
+}
+
+//// gob
+// Exported fields only (same rule as json/xml)
+
+func encodeProgressGob(ps []progress) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(ps); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func decodeProgressGob(data []byte) ([]progress, error) {
+	var ps []progress
+	err := gob.NewDecoder(bytes.NewReader(data)).Decode(&ps)
+	return ps, err
+}
+
+// The progress file is gob encoded and written atomically (see filewrite.go),
+// a crash mid save leaves the previous file intact
+func saveProgressFile(path string, ps []progress) error {
+	data, err := encodeProgressGob(ps)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, data, 0600)
+}
+
+// loadProgressFile returns no progress (and no error) if nothing has been saved yet
+func loadProgressFile(path string) ([]progress, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return decodeProgressGob(data)
+}
+
+//// encoding/binary
+// binary.Write only handles fixed size values, so the string gets a uvarint length prefix
+// Layout per record: len(lesson) | lesson | completed | attempts | lastSeen
+
+func encodeProgressBinary(ps []progress) []byte {
+	var b []byte
+	b = binary.AppendUvarint(b, uint64(len(ps)))
+	for _, p := range ps {
+		b = binary.AppendUvarint(b, uint64(len(p.Lesson)))
+		b = append(b, p.Lesson...)
+		if p.Completed {
+			b = append(b, 1)
+		} else {
+			b = append(b, 0)
+		}
+		b = binary.LittleEndian.AppendUint32(b, uint32(p.Attempts))
+		b = binary.LittleEndian.AppendUint64(b, uint64(p.LastSeen))
+	}
+	return b
+}
+
+var errShortProgress = errors.New("progress data too short")
+
+func decodeProgressBinary(data []byte) ([]progress, error) {
+	count, n := binary.Uvarint(data)
+	if n <= 0 {
+		return nil, errShortProgress
+	}
+	data = data[n:]
+
+	// count comes from the input, so it can't size the slice on its own: a few bytes
+	// could ask for gigabytes. Every record takes at least 14 bytes, which caps it
+	const minRecord = 1 + 1 + 4 + 8
+	ps := make([]progress, 0, min(count, uint64(len(data)/minRecord)))
+	for i := uint64(0); i < count; i++ {
+		size, n := binary.Uvarint(data)
+		// size can be anything up to 2^64-1, so size+13 could wrap; subtract instead
+		if n <= 0 || len(data)-n < 1+4+8 || size > uint64(len(data)-n-(1+4+8)) {
+			return nil, errShortProgress
+		}
+		data = data[n:]
+
+		var p progress
+		p.Lesson = string(data[:size])
+		data = data[size:]
+		p.Completed = data[0] == 1
+		p.Attempts = int32(binary.LittleEndian.Uint32(data[1:5]))
+		p.LastSeen = int64(binary.LittleEndian.Uint64(data[5:13]))
+		data = data[13:]
+		ps = append(ps, p)
+	}
+	return ps, nil
+}
+
+func sampleProgress(n int) []progress {
+	ps := make([]progress, n)
+	for i := range ps {
+		ps[i] = progress{
+			Lesson:    fmt.Sprintf("lesson-%d", i),
+			Completed: i%2 == 0,
+			Attempts:  int32(i % 5),
+			LastSeen:  1546300800 + int64(i), // 2019-01-01
+		}
+	}
+	return ps
+}
+
+func testGobAndBinaryRoundTrip() {
+	ps := sampleProgress(3)
+
+	gobData, err := encodeProgressGob(ps)
+	if err != nil {
+		panic(err)
+	}
+	fromGob, err := decodeProgressGob(gobData)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("gob round trip:", fromGob[2] == ps[2]) // true
+
+	fromBinary, err := decodeProgressBinary(encodeProgressBinary(ps))
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println("binary round trip:", fromBinary[2] == ps[2]) // true
+
+	// Chopping bytes off the end should give an error, not a panic
+	_, err = decodeProgressBinary(encodeProgressBinary(ps)[:10])
+	fmt.Println(err) // progress data too short
+	// So should lengths nobody could have written: a count of 12 billion records, or a
+	// lesson name 2^64-1 bytes long
+	_, err = decodeProgressBinary([]byte("\xe9\xe9\xe9\xe9-"))
+	fmt.Println(err) // progress data too short
+	_, err = decodeProgressBinary([]byte("\x01\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01abcdefghijklm"))
+	fmt.Println(err) // progress data too short
+}
+
+// Size and speed of each format vs plain JSON, as benchmarks (see benchmark.go);
+// bytes is how big 1000 records come out
+func compareProgressEncodings() {
+	ps := sampleProgress(1000)
+	for _, enc := range []struct {
+		name   string
+		encode func() []byte
+	}{
+		{"json", func() []byte {
+			b, _ := json.Marshal(ps)
+			return b
+		}},
+		{"gob", func() []byte {
+			b, _ := encodeProgressGob(ps)
+			return b
+		}},
+		{"binary", func() []byte { return encodeProgressBinary(ps) }},
+	} {
+		printBench("BenchmarkEncodeProgress/"+enc.name, func(b *testing.B) {
+			b.ReportAllocs()
+			var size int
+			for i := 0; i < b.N; i++ {
+				size = len(enc.encode())
+			}
+			b.ReportMetric(float64(size), "bytes")
+		})
+	}
+	// json is ~3.5x the size of the other two and the slowest; binary ~4x faster than gob,
+	// though gob comes out a little smaller (its integers are varints, binary's are fixed width)
+}
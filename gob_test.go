@@ -0,0 +1,32 @@
+package main
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestDecodeProgressBinary(t *testing.T) {
+	ps := sampleProgress(3)
+	got, err := decodeProgressBinary(encodeProgressBinary(ps))
+	if err != nil || !slices.Equal(got, ps) {
+		t.Errorf("round trip = %+v, %v; want %+v", got, err, ps)
+	}
+
+	for _, tc := range []struct {
+		name string
+		data string
+	}{
+		{"empty", ""},
+		{"truncated", string(encodeProgressBinary(ps)[:10])},
+		{"huge count", "\xe9\xe9\xe9\xe9-"},
+		{"huge name length", "\x01\xff\xff\xff\xff\xff\xff\xff\xff\xff\x01abcdefghijklm"},
+		{"record one byte short", "\x01\x00abcdefghijkl"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if got, err := decodeProgressBinary([]byte(tc.data)); !errors.Is(err, errShortProgress) {
+				t.Errorf("got %+v, %v; want errShortProgress", got, err)
+			}
+		})
+	}
+}
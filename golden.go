@@ -0,0 +1,179 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+//////// Golden files
+// For output too big to write out in a test (rendered templates, reports, a whole
+// lesson's printout) keep the expected output in a file and compare against that.
+// When the output changes on purpose, rerun with -update to rewrite the files, then
+// review the change in `git diff` like any other code change. golden_test.go does that
+// for every lesson in goldenLessons:
+//
+//	go test -run GoldenLessons/config           # compare with testdata/golden/config.golden
+//	go test -run GoldenLessons/config -update   # rewrite it
+//
+// hellogo verify [--update] does the same from the binary, without go test.
+
+const goldenDir = "testdata/golden"
+
+// updateGolden makes assertGolden write files instead of comparing; verify --update sets it
+var updateGolden bool
+
+// goldenMismatchError carries the diff, so callers can print it however they like
+type goldenMismatchError struct {
+	path string
+	diff string
+}
+
+func (e *goldenMismatchError) Error() string {
+	return fmt.Sprintf("output doesn't match %s (rerun with --update if that's intended):\n%s", e.path, strings.TrimSuffix(e.diff, "\n"))
+}
+
+// checkGolden compares got with dir/name.golden, or rewrites the file when update is set
+func checkGolden(dir, name string, got []byte, update bool) error {
+	path := filepath.Join(dir, name+".golden")
+	if update {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			return err
+		}
+		return writeFileAtomic(path, got, 0644)
+	}
+	want, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%s doesn't exist yet, create it with --update", path)
+	}
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(got, want) {
+		return &goldenMismatchError{path, unifiedDiff(path, "got", string(want), string(got), 2)}
+	}
+	return nil
+}
+
+// assertGolden is the test-helper shape: report and carry on
+func assertGolden(errorf errorfFunc, name string, got []byte) {
+	if err := checkGolden(goldenDir, name, got, updateGolden); err != nil {
+		errorf("%v", err)
+	}
+}
+
+//// hellogo verify
+
+// goldenLessons are lessons whose output never changes from run to run (no clocks, randomness or network)
+var goldenLessons = map[string]func(){
+	"ansi":        testANSI,
+	"backup":      testBackup,
+	"bignum":      testBigNumbers,
+	"bloom":       testBloom,
+	"buffers":     testBuffers, // without the benchmarks
+	"chain":       testChain,
+	"config":      testConfigParsing,
+	"defer":       testDefer, // without the benchmarks
+	"diff":        testDiff,
+	"dnsserver":   testDNSServer,
+	"env":         testEnvConfig,
+	"equality":    testStructEquality,
+	"examples":    testExamples,
+	"exercise":    testExercises,
+	"fakes":       testFakes,
+	"fileread":    lessons(testReadingFiles, testScannerTokenLimit, testReaderWrappers),
+	"filewrite":   testFileWrites,
+	"graphs":      testGraphs,
+	"heap":        testHeap,
+	"ids":         testIDs,
+	"interfaces":  testInterfaceValues,
+	"jwt":         testJWT,
+	"life":        testLife,
+	"mandelbrot":  testMandelbrot,
+	"maps":        testMapInternals,
+	"math":        lessons(testComplexNumbers, testMathTour),
+	"matrix":      testMatrix, // without the benchmarks
+	"migrate":     testMigrations,
+	"minigrep":    testMinigrep,
+	"minilang":    testMinilang,
+	"netip":       testNetip,
+	"proxy":       testProxy,
+	"ring":        testRing,
+	"slices":      testSliceInternals,
+	"sorting":     testSorting,
+	"streams":     testPipes,
+	"strconv":     testStrconv,
+	"tabledriven": testTableDriven,
+	"tables":      lessons(testTabwriter, testTables),
+	"termui":      testTermUI,
+	"timelayouts": testTimeLayouts,
+	"transfer":    testFileTransfer,
+	"trie":        testTrie,
+	"weather":     testWeather,
+}
+
+// hellogo verify [--update] [lesson...]; run from the repo root so testdata/ is found
+func verifyCommand(args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ContinueOnError)
+	flags.BoolVar(&updateGolden, "update", false, "rewrite the golden files with the current output")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+
+	names := flags.Args()
+	if len(names) == 0 {
+		for name := range goldenLessons {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+	}
+
+	failed := 0
+	for _, name := range names {
+		lesson, ok := goldenLessons[name]
+		if !ok {
+			return fmt.Errorf("no golden lesson %q", name)
+		}
+		out, err := captureStdout(lesson)
+		if err != nil {
+			return err
+		}
+		countLessonRun(name)
+		if !runCase(name, func(errorf errorfFunc) { assertGolden(errorf, name, []byte(out)) }) {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d lessons don't match their golden files", failed, len(names))
+	}
+	return nil
+}
+
+func testGoldenFiles() {
+	dir := makeTempDir("golden")
+	defer os.RemoveAll(dir)
+
+	report := "lessons: 3\ncompleted: 1\nnext: maps\n"
+	fmt.Println(checkGolden(dir, "report", []byte(report), false)) // .../report.golden doesn't exist yet, create it with --update
+	fmt.Println(checkGolden(dir, "report", []byte(report), true))  // <nil>
+	fmt.Println(checkGolden(dir, "report", []byte(report), false)) // <nil>
+
+	changed := strings.Replace(report, "completed: 1", "completed: 2", 1)
+	err := checkGolden(dir, "report", []byte(changed), false)
+	var mismatch *goldenMismatchError
+	if errors.As(err, &mismatch) {
+		fmt.Print(mismatch.diff)
+	}
+	// --- .../report.golden
+	// +++ got
+	// @@ -1,3 +1,3 @@
+	//  lessons: 3
+	// -completed: 1
+	// +completed: 2
+	//  next: maps
+}
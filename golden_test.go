@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the current output")
+
+// raceEnabled is set by race_test.go under go test -race
+var raceEnabled bool
+
+func TestGoldenLessons(t *testing.T) {
+	updateGolden = *update
+	for _, name := range sortedKeys(goldenLessons) {
+		t.Run(name, func(t *testing.T) {
+			if name == "slices" && raceEnabled {
+				t.Skip("the race detector changes the capacities append picks")
+			}
+			out, err := captureStdout(goldenLessons[name])
+			if err != nil {
+				t.Fatal(err)
+			}
+			assertGolden(t.Errorf, name, []byte(out))
+		})
+	}
+}
+
+func TestCheckGolden(t *testing.T) {
+	dir := t.TempDir()
+	report := []byte("lessons: 3\ncompleted: 1\n")
+
+	if err := checkGolden(dir, "report", report, false); err == nil || !strings.Contains(err.Error(), "doesn't exist yet") {
+		t.Errorf("before the file exists: %v, want a doesn't exist yet error", err)
+	}
+	if err := checkGolden(dir, "report", report, true); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if err := checkGolden(dir, "report", report, false); err != nil {
+		t.Errorf("same output: %v", err)
+	}
+
+	err := checkGolden(dir, "report", []byte("lessons: 3\ncompleted: 2\n"), false)
+	var mismatch *goldenMismatchError
+	if !errors.As(err, &mismatch) {
+		t.Fatalf("changed output: %v, want a goldenMismatchError", err)
+	}
+	if !strings.Contains(mismatch.diff, "-completed: 1\n+completed: 2\n") {
+		t.Errorf("diff doesn't show the change:\n%s", mismatch.diff)
+	}
+}
@@ -0,0 +1,333 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+//////// Graphs: BFS, DFS, topological sort
+// A graph is things (nodes) and connections between them (edges). The usual way to
+// store one is an adjacency list: for every node, the nodes it has edges to. Here edges
+// have a direction, a -> b, as in "a comes before b".
+//
+// Two ways to visit everything reachable from a node:
+//   - breadth first (BFS): a queue. All the neighbours, then their neighbours, and so
+//     on, so nodes come out in order of distance: that's how to find a shortest path
+//     when every edge counts the same
+//   - depth first (DFS): recursion, or a stack. Follow one path as far as it goes, then
+//     back up. Whether you've finished a node or are still inside it is what finds cycles
+//
+// A topological sort orders the nodes so every edge points forwards: build order,
+// course prerequisites, spreadsheet cells. It only exists if there's no cycle. Kahn's
+// algorithm takes nodes nothing points at, removes their edges, and repeats; if it
+// runs out before the end, what's left has a cycle.
+//
+// hellogo run --prereqs uses one on lessonPrereqs to run lessons after what they build on.
+
+// graph keeps nodes in the order they were added, so everything here comes out the same every run
+type graph[T comparable] struct {
+	nodes []T
+	edges map[T][]T
+}
+
+func newGraph[T comparable]() *graph[T] {
+	return &graph[T]{edges: map[T][]T{}}
+}
+
+func (g *graph[T]) AddNode(n T) {
+	if _, ok := g.edges[n]; !ok {
+		g.nodes = append(g.nodes, n)
+		g.edges[n] = nil
+	}
+}
+
+// AddEdge adds from -> to, and either node if it's new
+func (g *graph[T]) AddEdge(from, to T) {
+	g.AddNode(from)
+	g.AddNode(to)
+	if !slices.Contains(g.edges[from], to) {
+		g.edges[from] = append(g.edges[from], to)
+	}
+}
+
+// reversed has every edge the other way round
+func (g *graph[T]) reversed() *graph[T] {
+	r := newGraph[T]()
+	for _, n := range g.nodes {
+		r.AddNode(n)
+	}
+	for _, from := range g.nodes {
+		for _, to := range g.edges[from] {
+			r.AddEdge(to, from)
+		}
+	}
+	return r
+}
+
+// BFS visits every node reachable from start, nearest first, with its distance in edges
+func (g *graph[T]) BFS(start T, visit func(n T, depth int)) {
+	depth := map[T]int{start: 0}
+	queue := []T{start}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		visit(n, depth[n])
+		for _, next := range g.edges[n] {
+			if _, seen := depth[next]; !seen {
+				depth[next] = depth[n] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+}
+
+// ShortestPath is the path with the fewest edges from from to to, nil if there isn't one.
+// BFS reaches each node first by a shortest path, so remembering where it came from is enough
+func (g *graph[T]) ShortestPath(from, to T) []T {
+	cameFrom := map[T]T{}
+	seen := map[T]bool{from: true}
+	queue := []T{from}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if n == to {
+			path := []T{to}
+			for n != from {
+				n = cameFrom[n]
+				path = append(path, n)
+			}
+			slices.Reverse(path)
+			return path
+		}
+		for _, next := range g.edges[n] {
+			if !seen[next] {
+				seen[next] = true
+				cameFrom[next] = n
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
+
+// DFS visits every node reachable from start, following each path to its end before the next
+func (g *graph[T]) DFS(start T, visit func(n T)) {
+	seen := map[T]bool{}
+	var walk func(n T)
+	walk = func(n T) {
+		seen[n] = true
+		visit(n)
+		for _, next := range g.edges[n] {
+			if !seen[next] {
+				walk(next)
+			}
+		}
+	}
+	walk(start)
+}
+
+// FindCycle returns a cycle as a path that starts and ends on the same node, or nil.
+// A DFS marks nodes as it enters and leaves them; an edge to a node it's still inside
+// (one further up the current path) closes a loop
+func (g *graph[T]) FindCycle() []T {
+	const (
+		unvisited = iota
+		inside
+		done
+	)
+	state := map[T]int{}
+	var path []T
+	var walk func(n T) []T
+	walk = func(n T) []T {
+		state[n] = inside
+		path = append(path, n)
+		for _, next := range g.edges[n] {
+			switch state[next] {
+			case inside:
+				start := slices.Index(path, next)
+				return append(slices.Clone(path[start:]), next)
+			case unvisited:
+				if cycle := walk(next); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		path = path[:len(path)-1]
+		state[n] = done
+		return nil
+	}
+	for _, n := range g.nodes {
+		if state[n] == unvisited {
+			if cycle := walk(n); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	return nil
+}
+
+var errCycle = errors.New("graph has a cycle")
+
+// TopoSort orders the nodes so every edge goes from earlier to later (Kahn's algorithm)
+func (g *graph[T]) TopoSort() ([]T, error) {
+	incoming := map[T]int{}
+	for _, n := range g.nodes {
+		for _, to := range g.edges[n] {
+			incoming[to]++
+		}
+	}
+	var ready []T
+	for _, n := range g.nodes {
+		if incoming[n] == 0 {
+			ready = append(ready, n)
+		}
+	}
+	order := make([]T, 0, len(g.nodes))
+	for len(ready) > 0 {
+		n := ready[0]
+		ready = ready[1:]
+		order = append(order, n)
+		for _, to := range g.edges[n] {
+			if incoming[to]--; incoming[to] == 0 {
+				ready = append(ready, to)
+			}
+		}
+	}
+	if len(order) < len(g.nodes) {
+		cycle := g.FindCycle()
+		parts := make([]string, len(cycle))
+		for i, n := range cycle {
+			parts[i] = fmt.Sprint(n)
+		}
+		return nil, fmt.Errorf("%w: %s", errCycle, strings.Join(parts, " -> "))
+	}
+	return order, nil
+}
+
+//// Lesson prerequisites
+
+// lessonPrereqs lists, for some lessons, the lessons worth doing first
+var lessonPrereqs = map[string][]string{
+	"bloom":      {"hashing", "crawler"},
+	"cache":      {"generics"},
+	"chain":      {"hashing", "httpserver"},
+	"crawler":    {"httpclient"},
+	"fakes":      {"testing"},
+	"fuzz":       {"testing"},
+	"generics":   {"hello"},
+	"golden":     {"testing"},
+	"graphs":     {"generics"},
+	"heap":       {"generics"},
+	"httpclient": {"hello"},
+	"httpserver": {"hello"},
+	"httptest":   {"httpserver", "testing"},
+	"kvserver":   {"tcp"},
+	"loadtest":   {"httpclient"},
+	"lru":        {"cache"},
+	"middleware": {"httpserver"},
+	"migrate":    {"database"},
+	"proxy":      {"middleware", "httpclient"},
+	"ring":       {"generics"},
+	"shortener":  {"httpserver", "storage"},
+	"sse":        {"httpserver"},
+	"storage":    {"database"},
+	"tcp":        {"hello"},
+	"testing":    {"hello"},
+	"transfer":   {"tcp", "hashing"},
+	"websocket":  {"httpserver"},
+}
+
+// lessonOrder puts targets and everything they need in an order that does the
+// prerequisites first; no targets means every lesson
+func lessonOrder(targets []string) ([]string, error) {
+	g := newGraph[string]()
+	for _, name := range lessonNames() {
+		g.AddNode(name)
+	}
+	for _, name := range lessonNames() {
+		for _, before := range lessonPrereqs[name] {
+			g.AddEdge(before, name)
+		}
+	}
+	order, err := g.TopoSort()
+	if err != nil || len(targets) == 0 {
+		return order, err
+	}
+	needed := map[string]bool{} // the targets and whatever leads to them
+	back := g.reversed()
+	for _, t := range targets {
+		back.DFS(t, func(n string) { needed[n] = true })
+	}
+	return slices.DeleteFunc(order, func(n string) bool { return !needed[n] }), nil
+}
+
+func testGraphs() {
+	g := newGraph[string]()
+	for _, e := range [][2]string{
+		{"shirt", "tie"}, {"tie", "jacket"}, {"trousers", "shoes"}, {"trousers", "belt"},
+		{"belt", "jacket"}, {"shirt", "belt"}, {"socks", "shoes"}, {"undershorts", "trousers"},
+		{"undershorts", "shoes"},
+	} {
+		g.AddEdge(e[0], e[1])
+	}
+	g.AddNode("watch") // nothing before or after it
+
+	var visits []string
+	g.BFS("undershorts", func(n string, depth int) { visits = append(visits, fmt.Sprint(n, ":", depth)) })
+	fmt.Println(visits) // [undershorts:0 trousers:1 shoes:1 belt:2 jacket:3]
+	visits = visits[:0]
+	g.DFS("undershorts", func(n string) { visits = append(visits, n) })
+	fmt.Println(visits)                            // [undershorts trousers shoes belt jacket]: everything past trousers before undershorts' own edge to shoes
+	fmt.Println(g.ShortestPath("shirt", "jacket")) // [shirt tie jacket]
+	fmt.Println(g.ShortestPath("jacket", "shirt")) // []: edges only go one way
+
+	order, err := g.TopoSort()
+	fmt.Println(order, err) // [shirt socks undershorts watch tie trousers shoes belt jacket] <nil>
+
+	g.AddEdge("jacket", "shirt") // now you have to wear the jacket before the shirt
+	fmt.Println(g.FindCycle())   // [shirt tie jacket shirt]
+	_, err = g.TopoSort()
+	fmt.Println(err, errors.Is(err, errCycle)) // graph has a cycle: shirt -> tie -> jacket -> shirt true
+
+	runCase("TestGraphs/topological order", func(errorf errorfFunc) {
+		// Every edge of a chain of 100, added in a scrambled order
+		g := newGraph[int]()
+		for i := range 99 {
+			n := (i * 37) % 99
+			g.AddEdge(n, n+1)
+		}
+		order, err := g.TopoSort()
+		if err != nil || len(order) != 100 {
+			errorf("TopoSort: %d nodes, %v", len(order), err)
+			return
+		}
+		for i, n := range order {
+			if n != i {
+				errorf("order[%d] = %d", i, n)
+				break
+			}
+		}
+		g.AddEdge(99, 50)
+		if cycle := g.FindCycle(); len(cycle) != 51 || cycle[0] != cycle[50] {
+			errorf("FindCycle = %v, want 50 to 99 and back", cycle)
+		}
+	})
+
+	runCase("TestGraphs/lesson prerequisites", func(errorf errorfFunc) {
+		for lesson, before := range lessonPrereqs {
+			for _, name := range append([]string{lesson}, before...) {
+				if _, ok := lessonRegistry[name]; !ok {
+					errorf("lessonPrereqs mentions %q, which isn't a lesson", name)
+				}
+			}
+		}
+		all, err := lessonOrder(nil)
+		if err != nil || len(all) != len(lessonRegistry) {
+			errorf("lessonOrder: %d of %d lessons, %v", len(all), len(lessonRegistry), err)
+		}
+	})
+	fmt.Println(lessonOrder([]string{"proxy"})) // [hello httpclient httpserver middleware proxy] <nil>
+	fmt.Println(lessonOrder([]string{"bloom"})) // [hashing hello httpclient crawler bloom] <nil>
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"os"
+)
+
+//////// Hashing
+// crypto/sha256: cryptographic hash, use for checksums and integrity checks
+// hash/fnv: fast non-cryptographic hash, fine for bucketing keys (like a hash map does)
+
+func testSHA256() {
+	// One shot for data already in memory
+	sum := sha256.Sum256([]byte("hello, world\n"))
+	fmt.Printf("%x\n", sum) // 853ff93762a06ddbf722c4ebe9ddd66d8f63ddaea97f521c3ecc20da7c976020
+
+	// Or as a hash.Hash (an io.Writer), so you can feed it bit by bit
+	h := sha256.New()
+	h.Write([]byte("hello, "))
+	h.Write([]byte("world\n"))
+	fmt.Println(hex.EncodeToString(h.Sum(nil))) // same as above
+}
+
+// sha256File hashes a file of any size; io.Copy streams it through in small chunks
+// so a 10GB file doesn't need 10GB of memory
+func sha256File(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// fnvBucket picks which of n buckets a key belongs in
+func fnvBucket(key string, n int) int {
+	h := fnv.New32a()
+	h.Write([]byte(key)) // writes to a hash never fail
+	return int(h.Sum32() % uint32(n))
+}
+
+func testFNV() {
+	buckets := make([][]string, 4)
+	for _, k := range []string{"apple", "banana", "cherry", "date", "elderberry"} {
+		b := fnvBucket(k, len(buckets))
+		buckets[b] = append(buckets[b], k)
+	}
+	fmt.Println(buckets)
+
+	// Same key, same bucket, every time (unlike ranging over a map!)
+	fmt.Println(fnvBucket("apple", 4) == fnvBucket("apple", 4)) // true
+}
+
+// hellogo checksum <path>...; output matches sha256sum
+func checksumCommand(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	for _, path := range args {
+		sum, err := sha256File(path)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s  %s\n", sum, path)
+	}
+	return nil
+}
@@ -0,0 +1,319 @@
+package main
+
+import (
+	"container/heap"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+)
+
+//////// Priority queues with container/heap
+// A heap is a binary tree kept in a slice (the children of i are at 2i+1 and 2i+2)
+// where every parent comes before its children. So the first item is always the
+// smallest, and adding or removing one only has to fix one path from top to bottom:
+// O(log n), against O(n) to keep a whole slice sorted.
+//
+// container/heap has the algorithms but not the storage: you give it a type with
+// Len, Less, Swap (sort.Interface) plus Push and Pop, and call heap.Push, heap.Pop and
+// heap.Fix, never your own methods directly. To change an item's priority once it's in
+// the queue you need its position, so each item remembers its index and Swap keeps it
+// up to date.
+//
+// The scheduler below uses one to find the next job that's due.
+
+type pqItem[T, P any] struct {
+	Value    T
+	priority P
+	index    int // in the heap slice, -1 once it's out
+}
+
+func (it *pqItem[T, P]) Priority() P { return it.priority }
+
+// pqHeap is the heap.Interface; priorityQueue wraps it so nobody calls its Push by mistake
+type pqHeap[T, P any] struct {
+	items []*pqItem[T, P]
+	less  func(a, b P) bool
+}
+
+func (h *pqHeap[T, P]) Len() int           { return len(h.items) }
+func (h *pqHeap[T, P]) Less(i, j int) bool { return h.less(h.items[i].priority, h.items[j].priority) }
+
+func (h *pqHeap[T, P]) Swap(i, j int) {
+	h.items[i], h.items[j] = h.items[j], h.items[i]
+	h.items[i].index = i
+	h.items[j].index = j
+}
+
+// Push and Pop are for container/heap: they add at and take from the end of the slice,
+// and heap.Push and heap.Pop move things into place around them
+func (h *pqHeap[T, P]) Push(x any) {
+	it := x.(*pqItem[T, P])
+	it.index = len(h.items)
+	h.items = append(h.items, it)
+}
+
+func (h *pqHeap[T, P]) Pop() any {
+	last := len(h.items) - 1
+	it := h.items[last]
+	h.items[last] = nil // don't keep it alive from the spare capacity
+	h.items = h.items[:last]
+	it.index = -1
+	return it
+}
+
+// priorityQueue hands out values smallest priority first, by less. Not safe for
+// concurrent use; the scheduler holds a lock around it
+type priorityQueue[T, P any] struct {
+	h pqHeap[T, P]
+}
+
+func newPriorityQueue[T, P any](less func(a, b P) bool) *priorityQueue[T, P] {
+	return &priorityQueue[T, P]{h: pqHeap[T, P]{less: less}}
+}
+
+func (q *priorityQueue[T, P]) Len() int { return q.h.Len() }
+
+// Push returns the item, which Update and Remove need
+func (q *priorityQueue[T, P]) Push(value T, priority P) *pqItem[T, P] {
+	it := &pqItem[T, P]{Value: value, priority: priority}
+	heap.Push(&q.h, it)
+	return it
+}
+
+// Peek is the next item Pop would return, without removing it
+func (q *priorityQueue[T, P]) Peek() (*pqItem[T, P], bool) {
+	if q.h.Len() == 0 {
+		return nil, false
+	}
+	return q.h.items[0], true
+}
+
+func (q *priorityQueue[T, P]) Pop() (*pqItem[T, P], bool) {
+	if q.h.Len() == 0 {
+		return nil, false
+	}
+	return heap.Pop(&q.h).(*pqItem[T, P]), true
+}
+
+// Update changes an item's priority and moves it to its new place; false if it was popped already
+func (q *priorityQueue[T, P]) Update(it *pqItem[T, P], priority P) bool {
+	if it.index < 0 {
+		return false
+	}
+	it.priority = priority
+	heap.Fix(&q.h, it.index)
+	return true
+}
+
+func (q *priorityQueue[T, P]) Remove(it *pqItem[T, P]) bool {
+	if it.index < 0 {
+		return false
+	}
+	heap.Remove(&q.h, it.index)
+	return true
+}
+
+//// A scheduler
+// Jobs keyed by name, each due at some time and maybe repeating. RunDue runs whatever
+// is due, earliest first: with a heap ordered by due time that's popping until the top
+// isn't due yet, however many jobs are waiting further out.
+
+type scheduledJob struct {
+	name  string
+	every time.Duration // 0: run once
+	run   func(now time.Time)
+}
+
+type scheduler struct {
+	mu     sync.Mutex
+	clk    clock
+	queue  *priorityQueue[*scheduledJob, time.Time]
+	byName map[string]*pqItem[*scheduledJob, time.Time]
+}
+
+func newScheduler(clk clock) *scheduler {
+	return &scheduler{
+		clk:    clk,
+		queue:  newPriorityQueue[*scheduledJob](time.Time.Before),
+		byName: map[string]*pqItem[*scheduledJob, time.Time]{},
+	}
+}
+
+// Schedule adds a job due at at, repeating every every if that's more than 0. A job
+// with the same name is replaced
+func (s *scheduler) Schedule(name string, at time.Time, every time.Duration, run func(now time.Time)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if old, ok := s.byName[name]; ok {
+		s.queue.Remove(old)
+	}
+	s.byName[name] = s.queue.Push(&scheduledJob{name, every, run}, at)
+}
+
+// Reschedule moves a waiting job to a new time, false if there's no such job
+func (s *scheduler) Reschedule(name string, at time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	it, ok := s.byName[name]
+	return ok && s.queue.Update(it, at)
+}
+
+func (s *scheduler) Cancel(name string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	it, ok := s.byName[name]
+	if ok {
+		s.queue.Remove(it)
+		delete(s.byName, name)
+	}
+	return ok
+}
+
+// Next is when the earliest job is due
+func (s *scheduler) Next() (time.Time, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	it, ok := s.queue.Peek()
+	if !ok {
+		return time.Time{}, false
+	}
+	return it.Priority(), true
+}
+
+// RunDue runs every job that's due, earliest first, and returns their names. The jobs
+// run without the lock, so they can schedule more
+func (s *scheduler) RunDue() []string {
+	now := s.clk.Now()
+	var ran []string
+	for {
+		s.mu.Lock()
+		it, ok := s.queue.Peek()
+		if !ok || it.Priority().After(now) {
+			s.mu.Unlock()
+			return ran
+		}
+		s.queue.Pop()
+		job := it.Value
+		if job.every > 0 {
+			// From when it was due, not from now, so a late run doesn't push the rest back
+			s.byName[job.name] = s.queue.Push(job, it.Priority().Add(job.every))
+		} else {
+			delete(s.byName, job.name)
+		}
+		s.mu.Unlock()
+
+		job.run(now)
+		ran = append(ran, job.name)
+	}
+}
+
+func testHeap() {
+	q := newPriorityQueue[string](func(a, b int) bool { return a < b })
+	q.Push("write docs", 3)
+	fix := q.Push("fix bug", 2)
+	q.Push("coffee", 1)
+	q.Push("lunch", 4)
+	q.Update(fix, 0) // urgent after all
+	var order []string
+	for q.Len() > 0 {
+		it, _ := q.Pop()
+		order = append(order, fmt.Sprintf("%s(%d)", it.Value, it.Priority()))
+	}
+	fmt.Println(order)                           // [fix bug(0) coffee(1) write docs(3) lunch(4)]
+	fmt.Println(q.Update(fix, 5), q.Remove(fix)) // false false: it's been popped
+
+	// A max-heap is the same heap with less the other way round
+	top := newPriorityQueue[string](func(a, b int) bool { return a > b })
+	for name, score := range map[string]int{"ann": 71, "bob": 93, "cat": 85} {
+		top.Push(name, score)
+	}
+	best, _ := top.Pop()
+	fmt.Println(best.Value, best.Priority()) // bob 93
+
+	runCase("TestPriorityQueue/pop order", func(errorf errorfFunc) {
+		r := rand.New(rand.NewPCG(1, 2))
+		q := newPriorityQueue[int](func(a, b int) bool { return a < b })
+		var want []int
+		for i := range 1000 {
+			p := r.IntN(100) // plenty of ties
+			q.Push(i, p)
+			want = append(want, p)
+		}
+		slices.Sort(want)
+		for i, w := range want {
+			it, ok := q.Pop()
+			if !ok {
+				errorf("pop %d: queue empty, want priority %d", i, w)
+				return
+			}
+			if it.Priority() != w {
+				errorf("pop %d: got priority %d, want %d", i, it.Priority(), w)
+			}
+		}
+		if _, ok := q.Pop(); ok {
+			errorf("Pop on an empty queue returned an item")
+		}
+	})
+
+	runCase("TestPriorityQueue/update priority", func(errorf errorfFunc) {
+		r := rand.New(rand.NewPCG(3, 4))
+		q := newPriorityQueue[int](func(a, b int) bool { return a < b })
+		items := make([]*pqItem[int, int], 500)
+		for i := range items {
+			items[i] = q.Push(i, r.IntN(1000))
+		}
+		// Move half of them up or down, and remove a few
+		for _, it := range items[:250] {
+			q.Update(it, r.IntN(1000))
+		}
+		for _, it := range items[250:260] {
+			q.Remove(it)
+		}
+		want := map[int]int{} // value -> priority
+		for _, it := range items {
+			if it.index >= 0 {
+				want[it.Value] = it.Priority()
+			}
+		}
+		prev := -1
+		for q.Len() > 0 {
+			it, _ := q.Pop()
+			if it.Priority() < prev {
+				errorf("popped priority %d after %d", it.Priority(), prev)
+			}
+			if p, ok := want[it.Value]; !ok || p != it.Priority() {
+				errorf("popped %d with priority %d, want %d (present %v)", it.Value, it.Priority(), p, ok)
+			}
+			delete(want, it.Value)
+			prev = it.Priority()
+		}
+		if len(want) != 0 {
+			errorf("%d items never came out", len(want))
+		}
+	})
+
+	// The scheduler, on a fake clock
+	clk := &fakeClock{now: time.Date(2019, time.January, 1, 9, 0, 0, 0, time.UTC)}
+	s := newScheduler(clk)
+	noop := func(now time.Time) {}
+	s.Schedule("backup", clk.Now().Add(30*time.Minute), time.Hour, noop)
+	s.Schedule("report", clk.Now().Add(2*time.Hour), 0, noop)
+	s.Schedule("cleanup", clk.Now().Add(10*time.Minute), 0, noop)
+	s.Schedule("heartbeat", clk.Now(), 20*time.Minute, noop)
+	next, _ := s.Next()
+	fmt.Println(s.RunDue(), next.Format("15:04")) // [heartbeat] 09:00
+	s.Reschedule("report", clk.Now().Add(15*time.Minute))
+	for range 4 {
+		clk.Advance(20 * time.Minute)
+		fmt.Println(clk.Now().Format("15:04"), s.RunDue())
+	}
+	// 09:20 [cleanup report heartbeat]
+	// 09:40 [backup heartbeat]
+	// 10:00 [heartbeat]
+	// 10:20 [heartbeat]
+	fmt.Println(s.Cancel("report"), s.Cancel("heartbeat")) // false true: report ran once and is gone
+	clk.Advance(2 * time.Hour)
+	fmt.Println(s.RunDue()) // [backup backup]: missed runs catch up, each an hour after the last
+}
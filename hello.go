@@ -31,10 +31,15 @@ import (
 	"fmt"
 	"math"
 	"os"
+	"path/filepath"
 	"time"
 )
 
 func main() {
+	// hellogo <command> [args...] runs one of the utilities in commands.go
+	if len(os.Args) > 1 {
+		os.Exit(runCLI(os.Args[1:]))
+	}
 	fmt.Printf("hello, world\n")
 }
 
@@ -112,6 +117,7 @@ func arraysAndSlices() {
 	s[0] = "a"
 
 	// append (return a new slice with new element)
+	// (which may share the old one's array: testSliceInternals in slices.go)
 	s = append(s, "d")
 	s = append(s, "d")
 	s = append(s, "d")
@@ -227,7 +233,7 @@ func closureTester() {
 }
 
 func recursiveFunction(n int) int {
-	// factorial example
+	// factorial example; int overflows past 20!, bigFactorial (bignum.go) doesn't
 	if n == 0 {
 		return 1
 	}
@@ -426,6 +432,7 @@ func testErrors() {
 /////////// GoRoutines
 // A lightweight thread of execution
 // It is truly concurrent and can utilize separate cores on your machine (unline in say, python)
+// scheduler.go shows how many run at once, and CPU bound work speeding up with the cores
 
 func somethingToRun(name string, loops int) {
 	for i := 0; i < loops; i++ {
@@ -609,11 +616,15 @@ func showPanic() {
 // do something at the end of the enclosing function (kind of like 'finally' in other languages)
 
 func testFinally() {
-	f := createFile("/tmp/defer.txt")
+	// A fresh temp dir rather than a hard coded /tmp path, see tempfiles.go
+	dir := makeTempDir("defer")
+	defer os.RemoveAll(dir) // defers run last in first out, so this cleans up after closeFile
+
+	f := createFile(filepath.Join(dir, "defer.txt"))
 	defer closeFile(f) // Execute when this enclosing function ends
 	writeFile(f)
 
-	// Note defer wont be called if a panic happens before end of function
+	// A panic after the defers above still runs them; one before a defer statement skips it (defer.go)
 }
 
 func createFile(p string) *os.File {
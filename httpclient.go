@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"time"
+)
+
+//////// HTTP client
+// The lessons talk to a server started right here with httptest, so they work offline
+
+type greeting struct {
+	Name    string `json:"name"`
+	Message string `json:"message,omitempty"`
+}
+
+func startLessonServer() *httptest.Server {
+	var calls atomic.Int32
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("/hello", func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			http.Error(w, "name is required", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(greeting{Name: name, Message: "hello, " + name})
+	})
+	mux.HandleFunc("/echo", func(w http.ResponseWriter, r *http.Request) {
+		var g greeting
+		if err := json.NewDecoder(r.Body).Decode(&g); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		g.Message = "got it"
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(g)
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-time.After(2 * time.Second):
+			fmt.Fprintln(w, "finally")
+		case <-r.Context().Done(): // the client gave up
+		}
+	})
+	// Fails twice, then works; for the retry example
+	mux.HandleFunc("/flaky", func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+	return httptest.NewServer(mux)
+}
+
+// Never use http.Get/http.DefaultClient for real work: it has NO timeout,
+// a server that never answers hangs you forever
+var lessonClient = &http.Client{Timeout: 5 * time.Second}
+
+// getJSON decodes the response into v, treating anything but 200 as an error
+func getJSON(ctx context.Context, client *http.Client, u string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err // network problems, timeouts; NOT 4xx/5xx, those aren't errors to net/http
+	}
+	// Always close the body, or the connection can't be reused (and leaks)
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("GET %s: %s: %s", u, resp.Status, bytes.TrimSpace(body))
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+func testHTTPGet() {
+	srv := startLessonServer()
+	defer srv.Close()
+
+	// Build query strings with url.Values, it escapes for you
+	q := url.Values{}
+	q.Set("name", "Bob & Alice")
+	u := srv.URL + "/hello?" + q.Encode()
+	fmt.Println(u) // http://127.0.0.1:12345/hello?name=Bob+%26+Alice
+
+	var g greeting
+	err := getJSON(context.Background(), lessonClient, u, &g)
+	fmt.Println(g.Message, err) // hello, Bob & Alice <nil>
+
+	err = getJSON(context.Background(), lessonClient, srv.URL+"/hello", &g)
+	fmt.Println(err) // GET http://.../hello: 400 Bad Request: name is required
+}
+
+func testHTTPPost() {
+	srv := startLessonServer()
+	defer srv.Close()
+
+	body, _ := json.Marshal(greeting{Name: "Bob"})
+	resp, err := lessonClient.Post(srv.URL+"/echo", "application/json", bytes.NewReader(body))
+	if err != nil {
+		fmt.Println("post failed:", err)
+		return
+	}
+	defer resp.Body.Close()
+
+	var g greeting
+	json.NewDecoder(resp.Body).Decode(&g)
+	fmt.Println(resp.StatusCode, resp.Header.Get("Content-Type"), g) // 200 application/json {Bob got it}
+}
+
+func testHTTPTimeouts() {
+	srv := startLessonServer()
+	defer srv.Close()
+
+	// Client.Timeout covers the whole request, including reading the body
+	impatient := &http.Client{Timeout: 200 * time.Millisecond}
+	_, err := impatient.Get(srv.URL + "/slow")
+	fmt.Println(err) // Get "...": context deadline exceeded (Client.Timeout exceeded while awaiting headers)
+
+	// A context can cancel one request, from anywhere (user hit Ctrl+C, parent gave up...)
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(100*time.Millisecond, cancel)
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/slow", nil)
+	_, err = lessonClient.Do(req)
+	fmt.Println(errors.Is(err, context.Canceled)) // true
+}
+
+func testHTTPRetry() {
+	srv := startLessonServer()
+	defer srv.Close()
+
+	attempts := 0
+	err := retry(context.Background(), 5, 50*time.Millisecond, func() error {
+		attempts++
+		resp, err := lessonClient.Get(srv.URL + "/flaky")
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+
+		switch {
+		case resp.StatusCode >= 500:
+			return fmt.Errorf("server said %s", resp.Status) // worth another go
+		case resp.StatusCode != http.StatusOK:
+			return permanent(fmt.Errorf("server said %s", resp.Status)) // won't change, stop
+		}
+		return nil
+	})
+	fmt.Println(attempts, err) // 3 <nil>
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+//////// HTTP server
+// A tiny notes API: POST /notes, GET /notes/{id}, DELETE /notes/{id}
+// Since Go 1.22 ServeMux patterns can include the method and {wildcards},
+// no router library needed for most things
+
+type note struct {
+	ID   int    `json:"id"`
+	Text string `json:"text"`
+}
+
+type noteStore struct {
+	mu     sync.Mutex // handlers run on many goroutines at once!
+	nextID int
+	notes  map[int]note
+}
+
+func newNoteStore() *noteStore {
+	return &noteStore{nextID: 1, notes: map[int]note{}}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	// Headers must be set BEFORE WriteHeader/Write, after that they're already sent
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (s *noteStore) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /notes", s.create)
+	mux.HandleFunc("GET /notes/{id}", s.get)
+	mux.HandleFunc("DELETE /notes/{id}", s.delete)
+	// GET /notes/1 with PUT gets a 405 Method Not Allowed for free, unknown paths a 404
+	return mux
+}
+
+func (s *noteStore) create(w http.ResponseWriter, r *http.Request) {
+	// Cap the body size, otherwise a client can send gigabytes
+	r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields() // {"txt": "typo"} is an error, not an empty note
+
+	var n note
+	if err := dec.Decode(&n); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if n.Text == "" {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": "text is required"})
+		return
+	}
+
+	s.mu.Lock()
+	n.ID = s.nextID
+	s.nextID++
+	s.notes[n.ID] = n
+	s.mu.Unlock()
+
+	w.Header().Set("Location", fmt.Sprintf("/notes/%d", n.ID))
+	writeJSON(w, http.StatusCreated, n)
+}
+
+// noteID reads the {id} wildcard from the pattern
+func noteID(r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	return id, err == nil
+}
+
+func (s *noteStore) get(w http.ResponseWriter, r *http.Request) {
+	id, ok := noteID(r)
+	if !ok {
+		http.Error(w, "bad id", http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	n, found := s.notes[id]
+	s.mu.Unlock()
+	if !found {
+		http.NotFound(w, r)
+		return
+	}
+	writeJSON(w, http.StatusOK, n)
+}
+
+func (s *noteStore) delete(w http.ResponseWriter, r *http.Request) {
+	id, ok := noteID(r)
+	if !ok {
+		http.Error(w, "bad id", http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	delete(s.notes, id)
+	s.mu.Unlock()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//// Graceful shutdown
+// srv.Shutdown stops accepting new connections and waits for in-flight requests to
+// finish (up to the timeout), instead of cutting them off mid response
+
+func serveUntilDone(ctx context.Context, srv *http.Server) error {
+	errs := make(chan error, 1)
+	go func() {
+		// ListenAndServe always returns an error; ErrServerClosed means Shutdown was called
+		if err := srv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			errs <- err
+		}
+		close(errs)
+	}()
+
+	select {
+	case err := <-errs:
+		return err // couldn't even start, e.g. port in use
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutting down", "addr", srv.Addr)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	return srv.Shutdown(shutdownCtx)
+}
+
+// Always set timeouts on a real server; the zero values mean "wait forever",
+// and slow clients can tie up connections indefinitely
+func newLessonServer(addr string, h http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+}
+
+// Run it and try: curl -i -d '{"text":"buy milk"}' localhost:8080/notes
+// then Ctrl+C to watch it shut down cleanly
+func testHTTPServer() {
+	ctx, stop := shutdownContext()
+	defer stop()
+
+	srv := newLessonServer("localhost:8080", newNoteStore().routes())
+	fmt.Println("listening on", srv.Addr)
+	if err := serveUntilDone(ctx, srv); err != nil {
+		fmt.Println("server error:", err)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"time"
+)
+
+//////// Testing HTTP code with httptest
+// Two tools, for two jobs:
+//
+//	httptest.NewRecorder()  an in-memory ResponseWriter: call a handler directly, then
+//	                        look at rec.Code, rec.Header(), rec.Body. No network at all
+//	httptest.NewServer(h)   a real server on a random localhost port, for testing CLIENT
+//	                        code (timeouts, retries, headers) end to end
+//
+// httptest.NewRequest builds a server-side *http.Request without the error handling
+// http.NewRequest needs, perfect for feeding handlers.
+//
+// The tests are in httptest_test.go, with serveRecorded and checkJSON below as their
+// helpers; the lesson prints what those tests look at.
+
+// serveRecorded runs one request through h and returns what it wrote
+func serveRecorded(h http.Handler, method, target, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+// checkJSON compares JSON by meaning, not bytes: key order and spacing don't matter
+func checkJSON(errorf errorfFunc, rec *httptest.ResponseRecorder, wantStatus int, wantBody string) {
+	if rec.Code != wantStatus {
+		errorf("status = %d, want %d", rec.Code, wantStatus)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/json" {
+		errorf("Content-Type = %q, want application/json", ct)
+	}
+	var got, want any
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		errorf("body isn't JSON: %v: %q", err, rec.Body)
+		return
+	}
+	if err := json.Unmarshal([]byte(wantBody), &want); err != nil {
+		panic(err) // a broken test, not a broken handler
+	}
+	if !reflect.DeepEqual(got, want) {
+		errorf("body = %s, want %s", strings.TrimSpace(rec.Body.String()), wantBody)
+	}
+}
+
+func testHandlersWithRecorder() {
+	notes := newNoteStore().routes()
+	rec := serveRecorded(notes, "POST", "/notes", `{"text": "buy milk"}`)
+	fmt.Print(rec.Code, " ", rec.Header().Get("Location"), " ", rec.Body) // 201 /notes/1 {"id":1,"text":"buy milk"}
+	rec = serveRecorded(notes, "GET", "/notes/abc", "")
+	fmt.Print(rec.Code, " ", rec.Body) // 400 bad id
+}
+
+func testClientWithServer() {
+	srv := startLessonServer()
+	defer srv.Close()
+	ctx := context.Background()
+
+	var g greeting
+	err := getJSON(ctx, srv.Client(), srv.URL+"/hello?name=ann", &g)
+	fmt.Println(g, err)                                           // {ann hello, ann} <nil>
+	fmt.Println(getJSON(ctx, srv.Client(), srv.URL+"/hello", &g)) // ...: 400 Bad Request: name is required
+
+	// Timeouts: a server whose handler hangs, and a client that shouldn't
+	hang := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-hang:
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+	defer close(hang) // runs first: unblock handlers so slow.Close doesn't wait on them
+
+	// A copy: slow.Client() is shared, and it already trusts the server (matters for NewTLSServer)
+	client := *slow.Client()
+	client.Timeout = 50 * time.Millisecond
+	err = getJSON(ctx, &client, slow.URL, &greeting{})
+	var netErr net.Error
+	fmt.Println(errors.As(err, &netErr) && netErr.Timeout()) // true
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotes(t *testing.T) {
+	notes := newNoteStore().routes()
+
+	// In order: the later cases read and delete the note create makes
+	t.Run("create", func(t *testing.T) {
+		rec := serveRecorded(notes, "POST", "/notes", `{"text": "buy milk"}`)
+		checkJSON(t.Errorf, rec, http.StatusCreated, `{"id": 1, "text": "buy milk"}`)
+		if loc := rec.Header().Get("Location"); loc != "/notes/1" {
+			t.Errorf("Location = %q, want /notes/1", loc)
+		}
+	})
+	t.Run("get", func(t *testing.T) {
+		rec := serveRecorded(notes, "GET", "/notes/1", "")
+		checkJSON(t.Errorf, rec, http.StatusOK, `{"text": "buy milk", "id": 1}`)
+	})
+
+	for _, tc := range []struct {
+		name, method, target, body string
+		want                       int
+	}{
+		{"unknown field", "POST", "/notes", `{"txt": "typo"}`, http.StatusBadRequest},
+		{"empty text", "POST", "/notes", `{"text": ""}`, http.StatusUnprocessableEntity},
+		{"missing", "GET", "/notes/99", "", http.StatusNotFound},
+		{"bad id", "GET", "/notes/abc", "", http.StatusBadRequest},
+		{"wrong method", "PUT", "/notes/1", "", http.StatusMethodNotAllowed},
+		{"delete", "DELETE", "/notes/1", "", http.StatusNoContent},
+		{"gone after delete", "GET", "/notes/1", "", http.StatusNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			if rec := serveRecorded(notes, tc.method, tc.target, tc.body); rec.Code != tc.want {
+				t.Errorf("%s %s = %d, want %d", tc.method, tc.target, rec.Code, tc.want)
+			}
+		})
+	}
+}
+
+// The whole hellogo serve stack, middleware included
+func TestServeLessons(t *testing.T) {
+	rec := serveRecorded(standardMiddleware(serveRoutes()), "GET", "/lessons", "")
+	var lessons []lessonFile
+	if err := json.NewDecoder(rec.Body).Decode(&lessons); err != nil || rec.Code != http.StatusOK {
+		t.Fatalf("GET /lessons = %d, %v", rec.Code, err)
+	}
+	found := false
+	for _, l := range lessons {
+		found = found || l.Name == "httptest.go"
+	}
+	if !found {
+		t.Errorf("httptest.go missing from %d lessons", len(lessons))
+	}
+}
+
+func TestServeGzip(t *testing.T) {
+	h := standardMiddleware(serveRoutes())
+	source, err := lessonSources.ReadFile("httptest.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(method, acceptEncoding string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/lessons/httptest.go", nil)
+		if acceptEncoding != "" {
+			req.Header.Set("Accept-Encoding", acceptEncoding)
+		}
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("gzip", func(t *testing.T) {
+		rec := get("GET", "gzip, deflate")
+		if enc := rec.Header().Get("Content-Encoding"); enc != "gzip" {
+			t.Fatalf("Content-Encoding = %q, want gzip", enc)
+		}
+		zr, err := gzip.NewReader(rec.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := io.ReadAll(zr)
+		if err != nil || !bytes.Equal(body, source) {
+			t.Errorf("gunzipped body is %d bytes, %v; want httptest.go's %d", len(body), err, len(source))
+		}
+	})
+	t.Run("identity", func(t *testing.T) {
+		rec := get("GET", "")
+		if enc := rec.Header().Get("Content-Encoding"); enc != "" || !bytes.Equal(rec.Body.Bytes(), source) {
+			t.Errorf("without Accept-Encoding: Content-Encoding %q, %d bytes; want none, %d", enc, rec.Body.Len(), len(source))
+		}
+	})
+	t.Run("head", func(t *testing.T) {
+		if enc := get("HEAD", "gzip").Header().Get("Content-Encoding"); enc != "" {
+			t.Errorf("HEAD: Content-Encoding = %q, want none", enc)
+		}
+	})
+
+	for _, status := range []int{http.StatusNoContent, http.StatusNotModified} {
+		t.Run(http.StatusText(status), func(t *testing.T) {
+			h := gzipHandler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(status)
+			}))
+			req := httptest.NewRequest("GET", "/", nil)
+			req.Header.Set("Accept-Encoding", "gzip")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			if enc := rec.Header().Get("Content-Encoding"); enc != "" || rec.Body.Len() != 0 {
+				t.Errorf("%d: Content-Encoding %q, %d byte body; want neither", status, enc, rec.Body.Len())
+			}
+		})
+	}
+}
+
+func TestGetJSON(t *testing.T) {
+	srv := startLessonServer()
+	defer srv.Close()
+
+	t.Run("ok", func(t *testing.T) {
+		var g greeting
+		err := getJSON(context.Background(), srv.Client(), srv.URL+"/hello?name=ann", &g)
+		if want := (greeting{Name: "ann", Message: "hello, ann"}); err != nil || g != want {
+			t.Errorf("getJSON = %+v, %v; want %+v", g, err, want)
+		}
+	})
+	t.Run("status error", func(t *testing.T) {
+		err := getJSON(context.Background(), srv.Client(), srv.URL+"/hello", &greeting{})
+		if err == nil || !strings.Contains(err.Error(), "400 Bad Request: name is required") {
+			t.Errorf("getJSON without a name = %v, want a 400 error", err)
+		}
+	})
+
+	// A server whose handler hangs, and a client that shouldn't
+	hang := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-hang:
+		case <-r.Context().Done():
+		}
+	}))
+	defer slow.Close()
+	defer close(hang) // runs first: unblock handlers so slow.Close doesn't wait on them
+
+	t.Run("timeout", func(t *testing.T) {
+		client := *slow.Client()
+		client.Timeout = 50 * time.Millisecond
+		start := time.Now()
+		err := getJSON(context.Background(), &client, slow.URL, &greeting{})
+		var netErr net.Error
+		if !errors.As(err, &netErr) || !netErr.Timeout() {
+			t.Errorf("want a timeout error, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Errorf("took %v to time out", elapsed)
+		}
+	})
+	t.Run("cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		if err := getJSON(ctx, slow.Client(), slow.URL, &greeting{}); !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("want context.DeadlineExceeded, got %v", err)
+		}
+	})
+}
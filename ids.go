@@ -0,0 +1,228 @@
+package main
+
+import (
+	crand "crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+//////// UUIDs and ULIDs
+// Two ways to make an id nobody else will make, with no central counter to ask.
+//
+// A UUID (RFC 9562) is 128 bits, written as 32 hex digits in groups of 8-4-4-4-12.
+// Version 4 is random: 122 random bits, plus 4 bits saying "version 4" (the first hex
+// digit of the third group) and 2 bits for the variant (10, so the fourth group starts
+// 8, 9, a or b). 122 bits is enough that you'd need about 2⁶¹ of them before a
+// collision is likely.
+//
+// A ULID (github.com/ulid/spec) is also 128 bits: a 48-bit timestamp in milliseconds,
+// then 80 random bits. Written in Crockford's base32, 26 characters, it sorts as text
+// in the order the ids were made, which random UUIDs don't: handy for anything kept in
+// a list or a database index. Crockford's alphabet leaves out I, L, O and U, so ids
+// read aloud or copied by hand can't mix up 1 and l or 0 and O; decoding accepts lower
+// case and those look-alikes.
+//
+// Both take their randomness from crypto/rand: math/rand's output can be predicted,
+// and ids often end up in URLs where guessing one would be a problem.
+//
+// Quiz results (database.go) get a ULID as their submission id; recordings (ring.go)
+// get one as a session id.
+
+type uuid [16]byte
+
+func newUUIDv4() uuid {
+	var u uuid
+	crand.Read(u[:])
+	u[6] = u[6]&0x0f | 0x40 // version 4 in the top 4 bits
+	u[8] = u[8]&0x3f | 0x80 // variant 10 in the top 2
+	return u
+}
+
+func (u uuid) String() string {
+	h := hex.EncodeToString(u[:])
+	return h[:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+func (u uuid) Version() int { return int(u[6] >> 4) }
+
+var errBadID = errors.New("malformed id")
+
+func parseUUID(s string) (uuid, error) {
+	var u uuid
+	if len(s) != 36 {
+		return u, fmt.Errorf("%w: %q isn't 8-4-4-4-12 hex digits", errBadID, s)
+	}
+	// Dashes there and nowhere else, or stripping them would let "91-1-8f7-..." through
+	for i := range len(s) {
+		if (s[i] == '-') != (i == 8 || i == 13 || i == 18 || i == 23) {
+			return u, fmt.Errorf("%w: %q isn't 8-4-4-4-12 hex digits", errBadID, s)
+		}
+	}
+	if _, err := hex.Decode(u[:], []byte(strings.ReplaceAll(s, "-", ""))); err != nil {
+		return u, fmt.Errorf("%w: %q: %v", errBadID, s, err)
+	}
+	return u, nil
+}
+
+//// ULIDs
+
+type ulid [16]byte
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// crockfordValues decodes a character, 0xff for ones not in the alphabet
+var crockfordValues = func() [256]byte {
+	var v [256]byte
+	for i := range v {
+		v[i] = 0xff
+	}
+	for i, c := range crockford {
+		v[c] = byte(i)
+		if c >= 'A' {
+			v[c+'a'-'A'] = byte(i)
+		}
+	}
+	v['I'], v['i'], v['L'], v['l'] = 1, 1, 1, 1
+	v['O'], v['o'] = 0, 0
+	return v
+}()
+
+func newULID(t time.Time) ulid {
+	var entropy [10]byte
+	crand.Read(entropy[:])
+	return ulidFrom(t, entropy)
+}
+
+// ulidFrom puts the ms timestamp in the first 6 bytes, big-endian so the bytes sort
+// the way the times do, and the entropy in the other 10
+func ulidFrom(t time.Time, entropy [10]byte) ulid {
+	var u ulid
+	var ms [8]byte
+	binary.BigEndian.PutUint64(ms[:], uint64(t.UnixMilli()))
+	copy(u[:6], ms[2:])
+	copy(u[6:], entropy[:])
+	return u
+}
+
+func (u ulid) Time() time.Time {
+	var ms [8]byte
+	copy(ms[2:], u[:6])
+	return time.UnixMilli(int64(binary.BigEndian.Uint64(ms[:]))).UTC()
+}
+
+// String is 26 characters of 5 bits each: 130 bits for 128, so the first character
+// only carries 3 and is never more than 7
+func (u ulid) String() string {
+	var out [26]byte
+	hi, lo := binary.BigEndian.Uint64(u[:8]), binary.BigEndian.Uint64(u[8:])
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59 // shift the whole 128 bits right by 5
+		hi >>= 5
+	}
+	return string(out[:])
+}
+
+func parseULID(s string) (ulid, error) {
+	var u ulid
+	if len(s) != 26 {
+		return u, fmt.Errorf("%w: %q isn't 26 characters", errBadID, s)
+	}
+	// Compare the value, not the character: a look-alike such as O (for 0) is fine
+	if v := crockfordValues[s[0]]; v != 0xff && v > 7 {
+		return u, fmt.Errorf("%w: %q is more than 128 bits", errBadID, s)
+	}
+	var hi, lo uint64
+	for i := range len(s) {
+		v := crockfordValues[s[i]]
+		if v == 0xff {
+			return u, fmt.Errorf("%w: %q has %q, which isn't base32", errBadID, s, s[i])
+		}
+		hi = hi<<5 | lo>>59
+		lo = lo<<5 | uint64(v)
+	}
+	binary.BigEndian.PutUint64(u[:8], hi)
+	binary.BigEndian.PutUint64(u[8:], lo)
+	return u, nil
+}
+
+func testIDs() {
+	// Random, so only the shape can be shown
+	u := newUUIDv4()
+	s := u.String()
+	fmt.Println(len(s), s[14:15], strings.Contains("89ab", s[19:20])) // 36 4 true
+
+	// Known bytes, from RFC 9562's version 4 example
+	example, err := parseUUID("919108f7-52d1-4320-9bac-f847db4148a8")
+	fmt.Println(example, example.Version(), err) // 919108f7-52d1-4320-9bac-f847db4148a8 4 <nil>
+	_, err = parseUUID("919108f7-52d1-4320-9bac-f847db4148aZ")
+	fmt.Println(err) // malformed id: "919108f7-52d1-4320-9bac-f847db4148aZ": encoding/hex: invalid byte: U+005A 'Z'
+
+	// The timestamp comes first and reads back out
+	at := time.Date(2019, time.January, 1, 12, 0, 0, 0, time.UTC)
+	id := ulidFrom(at, [10]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff})
+	fmt.Println(id, id.Time())            // 01D04MHTG0ZZZZZZZZZZZZZZZZ 2019-01-01 12:00:00 +0000 UTC
+	fmt.Println(ulidFrom(at, [10]byte{})) // 01D04MHTG00000000000000000: same time, same first 10 characters
+
+	// Sorting as strings sorts by time; hand typed ids decode however they're written
+	var ids []string
+	for _, d := range []time.Duration{time.Hour, 0, time.Millisecond, 24 * time.Hour} {
+		ids = append(ids, newULID(at.Add(d)).String())
+	}
+	slices.Sort(ids)
+	var times []string
+	for _, s := range ids {
+		u, _ := parseULID(s)
+		times = append(times, u.Time().Format("15:04:05.000 Jan 2"))
+	}
+	fmt.Println(times) // [12:00:00.000 Jan 1 12:00:00.001 Jan 1 13:00:00.000 Jan 1 12:00:00.000 Jan 2]
+	typed, _ := parseULID("01d04mhtgozzzzzzzzzzzzzzzz")
+	fmt.Println(typed == id) // true: o read as 0
+	_, err = parseULID("81D04MHTG0ZZZZZZZZZZZZZZZZ")
+	fmt.Println(err) // malformed id: "81D04MHTG0ZZZZZZZZZZZZZZZZ" is more than 128 bits
+
+	runCase("TestIDs/uuid format", func(errorf errorfFunc) {
+		seen := map[uuid]bool{}
+		for range 1000 {
+			u := newUUIDv4()
+			if u.Version() != 4 || u[8]>>6 != 0b10 {
+				errorf("%v: version %d, variant bits %02b", u, u.Version(), u[8]>>6)
+			}
+			if back, err := parseUUID(u.String()); err != nil || back != u {
+				errorf("parseUUID(%v) = %v, %v", u, back, err)
+			}
+			if seen[u] {
+				errorf("%v twice", u)
+			}
+			seen[u] = true
+		}
+	})
+	runCase("TestIDs/ulid format", func(errorf errorfFunc) {
+		now := time.Now().Truncate(time.Millisecond).UTC()
+		for range 1000 {
+			u := newULID(now)
+			s := u.String()
+			if len(s) != 26 || strings.Trim(s, crockford) != "" {
+				errorf("%q isn't 26 Crockford base32 characters", s)
+			}
+			if back, err := parseULID(s); err != nil || back != u {
+				errorf("parseULID(%s) = %v, %v", s, back, err)
+			}
+			if !u.Time().Equal(now) {
+				errorf("%s has time %v, want %v", s, u.Time(), now)
+			}
+		}
+		var largest ulid
+		for i := range largest {
+			largest[i] = 0xff
+		}
+		if s := largest.String(); s != "7ZZZZZZZZZZZZZZZZZZZZZZZZZ" {
+			errorf("largest ULID = %s, want 7ZZZZZZZZZZZZZZZZZZZZZZZZZ", s)
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseUUID(t *testing.T) {
+	for _, s := range []string{
+		"",
+		"91b18f7a-52d1-4320-9bac-f847db4148a",   // one digit short
+		"91b18f7a-52d1-4320-9bac-f847db4148a8a", // one too many
+		"91b18f7a_52d1_4320_9bac_f847db4148a8",
+		"91-1-8f7-52d1-4320-9bac-f847db4148a8", // 36 long with the right dashes, and two extra
+		"91b18f7a-52d1-4320-9bac-f847db4148ag",
+	} {
+		if u, err := parseUUID(s); !errors.Is(err, errBadID) {
+			t.Errorf("parseUUID(%q) = %v, %v; want errBadID", s, u, err)
+		}
+	}
+	if u, err := parseUUID("91B18F7A-52D1-4320-9BAC-F847DB4148A8"); err != nil || u.String() != "91b18f7a-52d1-4320-9bac-f847db4148a8" {
+		t.Errorf("parseUUID of upper case = %v, %v", u, err)
+	}
+}
+
+func TestParseULID(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"01ARZ3NDEKTSV4RRFFQ69G5FAV", "01ARZ3NDEKTSV4RRFFQ69G5FAV"},
+		{"01arz3ndektsv4rrffq69g5fav", "01ARZ3NDEKTSV4RRFFQ69G5FAV"},
+		{"O1ARZ3NDEKTSV4RRFFQ69G5FAV", "01ARZ3NDEKTSV4RRFFQ69G5FAV"}, // O reads as 0, first character included
+		{"7ZZZZZZZZZZZZZZZZZZZZZZZZZ", "7ZZZZZZZZZZZZZZZZZZZZZZZZZ"},
+	} {
+		if u, err := parseULID(tc.in); err != nil || u.String() != tc.want {
+			t.Errorf("parseULID(%q) = %v, %v; want %s", tc.in, u, err, tc.want)
+		}
+	}
+	for _, s := range []string{
+		"",
+		"01ARZ3NDEKTSV4RRFFQ69G5FA",  // 25
+		"8ZZZZZZZZZZZZZZZZZZZZZZZZZ", // 130 bits
+		"01ARZ3NDEKTSV4RRFFQ69G5FAU", // U isn't in the alphabet
+		"U1ARZ3NDEKTSV4RRFFQ69G5FAV", // nor as the first character
+	} {
+		if u, err := parseULID(s); !errors.Is(err, errBadID) {
+			t.Errorf("parseULID(%q) = %v, %v; want errBadID", s, u, err)
+		}
+	}
+}
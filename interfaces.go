@@ -0,0 +1,157 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+)
+
+//////// Interface values and the nil error trap
+// An interface value is two words: the dynamic type of what's in it, and the value (a
+// pointer to it, or the value itself if it fits). It's nil only when both are: no type
+// and no value. Put a nil *customError in an error and the type word is *customError,
+// so err != nil is true, even though the pointer inside is nil.
+//
+// That's how this goes wrong:
+//
+//	func check(n int) error {
+//		var err *customError
+//		if n == 13 {
+//			err = &customError{n, "unlucky"}
+//		}
+//		return err // never nil, as an error
+//	}
+//
+// The caller sees a failure every time, and printing it calls Error on a nil pointer.
+// The fix is to return a literal nil on success and keep concrete error types out of
+// variables that get returned as error. testErrors in hello.go returns them the right way.
+//
+// Comparing two interface values compares the types first and then the values with
+// the type's ==. Types with no == (slices, maps, funcs) compile fine in an interface
+// and panic when compared, or used as a map key, at run time.
+
+// checkTyped is the bug: a nil *customError, returned as a non-nil error
+func checkTyped(n int) error {
+	var err *customError
+	if n == 13 {
+		err = &customError{n, "unlucky"}
+	}
+	return err
+}
+
+// checkFixed is the same check, returning nil itself when there's nothing wrong
+func checkFixed(n int) error {
+	if n == 13 {
+		return &customError{n, "unlucky"}
+	}
+	return nil
+}
+
+// isNilValue reports whether v is nil or an interface holding a nil pointer, map, slice,
+// chan or func. Needing it is usually a sign the nil should have been caught earlier.
+func isNilValue(v any) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Chan, reflect.Func, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+// panicMessage runs f and returns what it panicked with, "" if it didn't
+func panicMessage(f func()) (msg string) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg = fmt.Sprint(r)
+		}
+	}()
+	f()
+	return ""
+}
+
+func testInterfaceValues() {
+	//// Type and value
+	var err error
+	fmt.Printf("%v %T %v\n", err, err, err == nil) // <nil> <nil> true
+
+	var ce *customError
+	err = ce
+	fmt.Printf("%v %T %v\n", ce == nil, err, err == nil) // true *main.customError false
+
+	//// The trap
+	for _, n := range []int{1, 13} {
+		if err := checkTyped(n); err != nil {
+			fmt.Printf("checkTyped(%d) failed: %T %v\n", n, err, isNilValue(err))
+		}
+	}
+	// checkTyped(1) failed: *main.customError true
+	// checkTyped(13) failed: *main.customError false
+	fmt.Println(panicMessage(func() { _ = checkTyped(1).Error() }))
+	// runtime error: invalid memory address or nil pointer dereference: Error reads e.arg
+
+	for _, n := range []int{1, 13} {
+		fmt.Printf("checkFixed(%d): %v\n", n, checkFixed(n))
+	}
+	// checkFixed(1): <nil>
+	// checkFixed(13): 13 - unlucky
+
+	// The same happens with any interface: a nil *os.File is a non-nil io.Reader
+	var f *os.File
+	var r io.Reader = f
+	fmt.Println(r != nil) // true
+	_, err = r.Read(make([]byte, 1))
+	fmt.Println(err) // invalid argument: *os.File's methods check for nil, most don't
+
+	// errors.As still finds the type, nil pointer and all
+	var target *customError
+	fmt.Println(errors.As(checkTyped(1), &target), target == nil) // true true
+
+	//// Comparing interface values
+	var a, b any = 1, 1
+	fmt.Println(a == b)                             // true: same type, same value
+	fmt.Println(any(1) == any(int64(1)))            // false: int and int64 are different types
+	fmt.Println(errors.New("x") == errors.New("x")) // false: two different pointers
+	fmt.Println(io.EOF == io.EOF)                   // true: one variable, so one pointer; what errors.Is checks first
+
+	// Slices have no ==, and it's only found out when the program runs
+	fmt.Println(panicMessage(func() { _ = any([]int{1}) == any([]int{1}) }))
+	// runtime error: comparing uncomparable type []int
+	fmt.Println(panicMessage(func() { _ = map[any]bool{[]int{1}: true} }))
+	// runtime error: hash of unhashable type []int
+	// Different types compare without looking at the values, so this one is fine
+	fmt.Println(any([]int{1}) == any("x")) // false
+
+	runCase("TestInterfaceValues/nil checks", func(errorf errorfFunc) {
+		var ce *customError
+		var m map[string]int
+		var sb *strings.Builder
+		for _, tc := range []struct {
+			v    any
+			want bool
+		}{
+			{nil, true}, {ce, true}, {m, true}, {sb, true}, {error(nil), true},
+			{0, false}, {"", false}, {&customError{}, false}, {map[string]int{}, false},
+		} {
+			if got := isNilValue(tc.v); got != tc.want {
+				errorf("isNilValue(%#v) = %v, want %v", tc.v, got, tc.want)
+			}
+		}
+	})
+	runCase("TestInterfaceValues/fixed", func(errorf errorfFunc) {
+		for n := range 20 {
+			err := checkFixed(n)
+			if (err != nil) != (n == 13) {
+				errorf("checkFixed(%d) = %v", n, err)
+			}
+			if typed := checkTyped(n); typed == nil {
+				errorf("checkTyped(%d) is nil: the trap is gone", n)
+			}
+		}
+	})
+}
@@ -0,0 +1,88 @@
+//go:build go1.23
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/fs"
+	"iter"
+	"slices"
+	"strings"
+)
+
+//////// Iterators (range over functions)
+// Since Go 1.23 `for x := range f` works when f is a function that takes a yield callback:
+//
+//	iter.Seq[V]       func(yield func(V) bool)
+//	iter.Seq2[K, V]   func(yield func(K, V) bool)
+//
+// The loop body becomes yield. It returns false when the loop stops early (break, return),
+// and the iterator must stop calling it then. Older toolchains get iterators_pre123.go.
+//
+// slices.All/Values, maps.Keys/Values, strings.Lines and friends all return these, and
+// slices.Collect / slices.Sorted turn one back into a slice.
+
+// lessonFiles yields the embedded lesson file names
+func lessonFiles() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		names, _ := fs.Glob(lessonSources, "*.go")
+		for _, name := range names {
+			if !yield(name) {
+				return // the loop did a break
+			}
+		}
+	}
+}
+
+// banners yields the line number and title of every //////// banner in src
+func banners(src []byte) iter.Seq2[int, string] {
+	return func(yield func(int, string) bool) {
+		scanner := bufio.NewScanner(bytes.NewReader(src))
+		for n := 1; scanner.Scan(); n++ {
+			title, ok := strings.CutPrefix(scanner.Text(), "//////// ")
+			if ok && !yield(n, title) {
+				return
+			}
+		}
+	}
+}
+
+// filterSeq is the generic adapter: a Seq in, a Seq out, nothing collected in between
+func filterSeq[V any](seq iter.Seq[V], keep func(V) bool) iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for v := range seq {
+			if keep(v) && !yield(v) {
+				return
+			}
+		}
+	}
+}
+
+func testIterators() {
+	for name := range lessonFiles() {
+		fmt.Println("first file:", name) // first file: archive.go
+		break                            // yield returns false, lessonFiles stops reading
+	}
+
+	src, _ := lessonSources.ReadFile("iterators.go")
+	for n, title := range banners(src) {
+		fmt.Println(n, title) // 15 Iterators (range over functions)
+	}
+
+	platform := filterSeq(lessonFiles(), func(name string) bool { return strings.HasPrefix(name, "platform_") })
+	fmt.Println(slices.Collect(platform)) // all four: //go:embed ignores build tags, only the compiler cares
+
+	// Adapters chain without building slices in between; only Sorted collects
+	byLength := slices.SortedFunc(filterSeq(lessonFiles(), func(name string) bool { return strings.HasPrefix(name, "g") }),
+		func(a, b string) int { return len(a) - len(b) })
+	fmt.Println(byLength) // [gc.go gob.go golden.go generics.go generics_pre121.go]
+
+	// iter.Pull turns a push iterator into next()/stop(), for walking two at once
+	next, stop := iter.Pull(lessonFiles())
+	defer stop()
+	a, _ := next()
+	b, _ := next()
+	fmt.Println(a, b) // archive.go benchmark.go
+}
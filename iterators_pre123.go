@@ -0,0 +1,14 @@
+//go:build !go1.23
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Stands in for iterators.go on toolchains without range over functions
+
+func testIterators() {
+	fmt.Println("the iterators lesson needs go1.23 or newer, this is", runtime.Version())
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//////// Mini project: a JSON pretty-printer and query tool
+//
+//	hellogo json pretty  < in.json     indent with two spaces
+//	hellogo json minify  < in.json     drop all the whitespace
+//	hellogo json get .items[2].name < in.json
+//
+// None of them unmarshal the whole input. json.Decoder.Token hands out one token at a
+// time ({, "key", 42, ]...), like the XML streaming in xml.go, so a 2GB file takes as
+// much memory as its deepest nesting. Several values one after another (JSON lines)
+// are fine too: each is handled on its own.
+// Numbers go through UseNumber, so 1.10 and 12345678901234567890 come out as they went in
+// rather than round-tripping through float64.
+
+// jsonFrame is one open object or array while reformatting
+type jsonFrame struct {
+	object bool
+	count  int // tokens seen inside it; in an object the even ones are keys
+}
+
+// reformatJSON copies every value from r to w token by token, indented when indent isn't ""
+func reformatJSON(r io.Reader, w io.Writer, indent string) error {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	bw := bufio.NewWriter(w)
+	var stack []jsonFrame
+	newline := func(depth int) {
+		if indent != "" {
+			bw.WriteString("\n" + strings.Repeat(indent, depth))
+		}
+	}
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return bw.Flush()
+		}
+		if err != nil {
+			return err
+		}
+
+		if d, ok := tok.(json.Delim); ok && (d == '}' || d == ']') {
+			if stack[len(stack)-1].count > 0 {
+				newline(len(stack) - 1)
+			}
+			bw.WriteRune(rune(d))
+			stack = stack[:len(stack)-1]
+		} else {
+			if n := len(stack); n > 0 {
+				top := &stack[n-1]
+				isValue := top.object && top.count%2 == 1
+				if isValue {
+					bw.WriteString(":")
+					if indent != "" {
+						bw.WriteString(" ")
+					}
+				} else {
+					if top.count > 0 {
+						bw.WriteString(",")
+					}
+					newline(n)
+				}
+				top.count++
+			}
+			if d, ok := tok.(json.Delim); ok {
+				bw.WriteRune(rune(d))
+				stack = append(stack, jsonFrame{object: d == '{'})
+			} else if err := writeJSONScalar(bw, tok); err != nil {
+				return err
+			}
+		}
+		if len(stack) == 0 {
+			bw.WriteString("\n") // a whole value done, one per line
+		}
+	}
+}
+
+// writeJSONScalar writes a string, json.Number, bool or nil token back out as JSON
+func writeJSONScalar(w *bufio.Writer, tok json.Token) error {
+	switch v := tok.(type) {
+	case string:
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		enc.SetEscapeHTML(false) // otherwise < > & become \u003c..., valid but not what came in
+		if err := enc.Encode(v); err != nil {
+			return err
+		}
+		w.Write(bytes.TrimSuffix(buf.Bytes(), []byte("\n")))
+	case json.Number:
+		w.WriteString(v.String())
+	case bool:
+		w.WriteString(strconv.FormatBool(v))
+	case nil:
+		w.WriteString("null")
+	default:
+		return fmt.Errorf("unexpected token %v", tok)
+	}
+	return nil
+}
+
+// jsonStep is one step of a path: an object key, or an array index when key is ""
+type jsonStep struct {
+	key   string
+	index int
+}
+
+// parseJSONPath turns .items[2].name into steps; "." alone is the whole value
+func parseJSONPath(path string) ([]jsonStep, error) {
+	if !strings.HasPrefix(path, ".") {
+		return nil, fmt.Errorf("path %q should start with .", path)
+	}
+	var steps []jsonStep
+	rest := path
+	if rest == "." {
+		return nil, nil
+	}
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[") + 1
+			if end == 0 {
+				end = len(rest)
+			}
+			if end == 1 {
+				return nil, fmt.Errorf("path %q has an empty key", path)
+			}
+			steps = append(steps, jsonStep{key: rest[1:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("path %q: missing ]", path)
+			}
+			i, err := strconv.Atoi(rest[1:end])
+			if err != nil || i < 0 {
+				return nil, fmt.Errorf("path %q: bad index %q", path, rest[1:end])
+			}
+			steps = append(steps, jsonStep{index: i})
+			rest = rest[end+1:]
+		default:
+			return nil, fmt.Errorf("path %q: expected . or [ at %q", path, rest)
+		}
+	}
+	return steps, nil
+}
+
+// errJSONPathNotFound: the value is fine, the path just isn't in it
+var errJSONPathNotFound = errors.New("path not found")
+
+// skipJSONValue reads past the next value, however deeply nested, without keeping it
+func skipJSONValue(dec *json.Decoder) error {
+	depth := 0
+	for {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if d, ok := tok.(json.Delim); ok {
+			if d == '{' || d == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
+
+// queryJSONValue follows steps into the decoder's next value and returns what's there.
+// Whatever comes after it in that value is skipped, not decoded, and so is everything
+// before it: only the part at the end of the path is ever held in memory.
+func queryJSONValue(dec *json.Decoder, steps []jsonStep) (json.RawMessage, error) {
+	open := 0 // containers entered, to be closed before returning
+	found := true
+	for _, step := range steps {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+		want := json.Delim('[')
+		if step.key != "" {
+			want = '{'
+		}
+		if d, ok := tok.(json.Delim); !ok || d != want {
+			if ok {
+				open++ // the wrong kind of container: still has to be read to its end
+			}
+			found = false
+			break
+		}
+		open++
+
+		found = false
+		for i := 0; dec.More(); i++ {
+			if step.key != "" {
+				key, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				found = key == step.key
+			} else {
+				found = i == step.index
+			}
+			if found {
+				break
+			}
+			if err := skipJSONValue(dec); err != nil {
+				return nil, err
+			}
+		}
+		if !found {
+			break
+		}
+	}
+
+	var raw json.RawMessage
+	if found {
+		if err := dec.Decode(&raw); err != nil { // Decode and Token can be mixed on one decoder
+			return nil, err
+		}
+	}
+	for ; open > 0; open-- {
+		for dec.More() {
+			if err := skipJSONValue(dec); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := dec.Token(); err != nil { // the closing } or ]
+			return nil, err
+		}
+	}
+	if !found {
+		return nil, errJSONPathNotFound
+	}
+	return raw, nil
+}
+
+// queryJSON prints what's at path in every value in r, compacted, one per line
+func queryJSON(r io.Reader, w io.Writer, path string) error {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	for n := 1; ; n++ {
+		raw, err := queryJSONValue(dec, steps)
+		if err == io.EOF {
+			return nil
+		}
+		if errors.Is(err, errJSONPathNotFound) {
+			return fmt.Errorf("value %d: %s: %w", n, path, err)
+		}
+		if err != nil {
+			return err
+		}
+		var buf bytes.Buffer
+		if err := json.Compact(&buf, raw); err != nil {
+			return err
+		}
+		buf.WriteByte('\n')
+		if _, err := buf.WriteTo(w); err != nil {
+			return err
+		}
+	}
+}
+
+// hellogo json pretty|minify|get <path>, reading stdin
+func jsonToolCommand(args []string) error {
+	switch {
+	case len(args) == 1 && args[0] == "pretty":
+		return reformatJSON(os.Stdin, os.Stdout, "  ")
+	case len(args) == 1 && args[0] == "minify":
+		return reformatJSON(os.Stdin, os.Stdout, "")
+	case len(args) == 2 && args[0] == "get":
+		return queryJSON(os.Stdin, os.Stdout, args[1])
+	default:
+		return errUsage
+	}
+}
+
+func testJSONTool() {
+	const doc = `{"name": "hellogo", "version": 1.10, "tags": [], "items": [
+		{"name": "hello", "done": true},
+		{"name": "maps", "done": false, "notes": null},
+		{"name": "json <& tokens>", "done": false, "sizes": [1, 12345678901234567890]}
+	], "empty": {}}`
+
+	reformatJSON(strings.NewReader(doc), os.Stdout, "")
+	// {"name":"hellogo","version":1.10,"tags":[],"items":[{"name":"hello","done":true},{"name":"maps",...}],"empty":{}}
+	reformatJSON(strings.NewReader(`{"a": [1, {"b": null}], "c": {}}`), os.Stdout, "  ")
+	// {
+	//   "a": [
+	//     1,
+	//     {
+	//       "b": null
+	//     }
+	//   ],
+	//   "c": {}
+	// }
+
+	for _, path := range []string{".name", ".items[2].name", ".items[2].sizes", ".items[1]", ".items[5]", ".name.first", "items"} {
+		var out strings.Builder
+		err := queryJSON(strings.NewReader(doc), &out, path)
+		fmt.Printf("%-16s %s %v\n", path, strings.TrimSpace(out.String()), err)
+	}
+	// .name            "hellogo" <nil>
+	// .items[2].name   "json <& tokens>" <nil>
+	// .items[2].sizes  [1,12345678901234567890] <nil>
+	// .items[1]        {"name":"maps","done":false,"notes":null} <nil>
+	// .items[5]         value 1: .items[5]: path not found
+	// .name.first       value 1: .name.first: path not found
+	// items             path "items" should start with .
+
+	// JSON lines: one answer per value
+	queryJSON(strings.NewReader(`{"level": "info"} {"level": "warn"} {"level": "error"}`), os.Stdout, ".level")
+	// "info"
+	// "warn"
+	// "error"
+
+	fmt.Println(reformatJSON(strings.NewReader(`{"a": 1,}`), io.Discard, "")) // invalid character ',' looking for beginning of value
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+//////// HMAC and JWTs
+// HMAC = hash mixed with a secret key, so only someone holding the key can make
+// (or check) the signature. A JWT is just header.payload.signature, each part
+// base64url encoded, with the signature being an HMAC of the first two parts.
+// Note, the payload is only encoded, NOT encrypted; anyone can read it.
+
+func signHMAC(key, message []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(message)
+	return mac.Sum(nil)
+}
+
+func verifyHMAC(key, message, signature []byte) bool {
+	// hmac.Equal is constant time, bytes.Equal would leak timing info
+	return hmac.Equal(signHMAC(key, message), signature)
+}
+
+func testHMAC() {
+	key := []byte("super secret")
+	sig := signHMAC(key, []byte("transfer $10 to bob"))
+	fmt.Printf("%x\n", sig)
+
+	fmt.Println(verifyHMAC(key, []byte("transfer $10 to bob"), sig))             // true
+	fmt.Println(verifyHMAC(key, []byte("transfer $10000 to bob"), sig))          // false
+	fmt.Println(verifyHMAC([]byte("guess"), []byte("transfer $10 to bob"), sig)) // false
+}
+
+//// Minimal JWT (HS256 only)
+
+type jwtClaims struct {
+	Subject   string `json:"sub"`
+	ExpiresAt int64  `json:"exp"` // unix seconds
+}
+
+var (
+	errJWTMalformed = errors.New("jwt: malformed token")
+	errJWTSignature = errors.New("jwt: bad signature")
+	errJWTExpired   = errors.New("jwt: token expired")
+)
+
+var jwtEncoding = base64.RawURLEncoding
+
+// The header never changes for HS256, so encode it once
+var jwtHeader = jwtEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+func encodeJWT(key []byte, claims jwtClaims) (string, error) {
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	unsigned := jwtHeader + "." + jwtEncoding.EncodeToString(payload)
+	return unsigned + "." + jwtEncoding.EncodeToString(signHMAC(key, []byte(unsigned))), nil
+}
+
+// verifyJWT checks the signature BEFORE trusting anything in the token, then the expiry
+func verifyJWT(key []byte, token string, now time.Time) (jwtClaims, error) {
+	var claims jwtClaims
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, errJWTMalformed
+	}
+
+	// Only accept our own header; trusting the token's "alg" is the classic
+	// alg:none vulnerability
+	if parts[0] != jwtHeader {
+		return claims, errJWTMalformed
+	}
+
+	sig, err := jwtEncoding.DecodeString(parts[2])
+	if err != nil {
+		return claims, errJWTMalformed
+	}
+	if !verifyHMAC(key, []byte(parts[0]+"."+parts[1]), sig) {
+		return claims, errJWTSignature
+	}
+
+	payload, err := jwtEncoding.DecodeString(parts[1])
+	if err != nil {
+		return claims, errJWTMalformed
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, errJWTMalformed
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return claims, errJWTExpired
+	}
+	return claims, nil
+}
+
+func testJWT() {
+	key := []byte("super secret")
+	now := time.Unix(1546300800, 0) // fixed clock so the output doesn't change
+
+	token, err := encodeJWT(key, jwtClaims{Subject: "bob", ExpiresAt: now.Add(time.Hour).Unix()})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(token)
+
+	claims, err := verifyJWT(key, token, now)
+	fmt.Println(claims.Subject, err) // bob <nil>
+
+	// Tampering: swap the payload for one claiming to be admin, keep the old signature
+	parts := strings.Split(token, ".")
+	forged, _ := json.Marshal(jwtClaims{Subject: "admin", ExpiresAt: now.Add(time.Hour).Unix()})
+	tampered := parts[0] + "." + jwtEncoding.EncodeToString(forged) + "." + parts[2]
+	_, err = verifyJWT(key, tampered, now)
+	fmt.Println(err) // jwt: bad signature
+
+	// Flip a single bit of the signature
+	sig, _ := jwtEncoding.DecodeString(parts[2])
+	sig[0] ^= 1
+	_, err = verifyJWT(key, parts[0]+"."+parts[1]+"."+jwtEncoding.EncodeToString(sig), now)
+	fmt.Println(err) // jwt: bad signature
+
+	// alg:none trick
+	none := jwtEncoding.EncodeToString([]byte(`{"alg":"none","typ":"JWT"}`))
+	_, err = verifyJWT(key, none+"."+parts[1]+".", now)
+	fmt.Println(err) // jwt: malformed token
+
+	// Wrong key
+	_, err = verifyJWT([]byte("other secret"), token, now)
+	fmt.Println(err) // jwt: bad signature
+
+	// Two hours later
+	_, err = verifyJWT(key, token, now.Add(2*time.Hour))
+	fmt.Println(err) // jwt: token expired
+
+	// Anyone can read the payload though!
+	payload, _ := jwtEncoding.DecodeString(parts[1])
+	fmt.Println(string(payload)) // {"sub":"bob","exp":1546304400}
+}
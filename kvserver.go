@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//////// Mini project: a key-value server
+// A tiny Redis: the TCP server from tcp.go, the cache from cache.go as the storage,
+// and a line based text protocol, one command per line, one reply line per command:
+//
+//	SET key value [EX seconds]   OK
+//	GET key                      "value", or (nil)
+//	DEL key                      (integer) 1 if it existed, else 0
+//	TTL key                      (integer) seconds left, -1 if it never expires, -2 if missing
+//	QUIT                         closes the connection
+//
+// Keys and values with spaces go in double quotes, Go style: SET greeting "hello there\n".
+// Try it: hellogo kv --serve, then hellogo kv (or nc localhost 6380) in another terminal.
+
+const kvDefaultAddr = "localhost:6380"
+
+type kvRequest struct {
+	Name string
+	Args []string
+}
+
+// parseKVLine splits a line into words; a word in double quotes may contain spaces and
+// Go escapes (\", \n, \t...). Command names are case insensitive, like Redis
+func parseKVLine(line string) (kvRequest, error) {
+	var words []string
+	rest := strings.TrimSpace(line)
+	for rest != "" {
+		var word string
+		if rest[0] == '"' {
+			quoted, err := strconv.QuotedPrefix(rest)
+			if err != nil {
+				return kvRequest{}, errors.New("unterminated or bad quoted string")
+			}
+			word, _ = strconv.Unquote(quoted)
+			rest = rest[len(quoted):]
+			if rest != "" && rest[0] != ' ' && rest[0] != '\t' {
+				return kvRequest{}, fmt.Errorf("expected a space after %s", quoted)
+			}
+		} else {
+			end := strings.IndexAny(rest, " \t")
+			if end < 0 {
+				end = len(rest)
+			}
+			word, rest = rest[:end], rest[end:]
+		}
+		words = append(words, word)
+		rest = strings.TrimLeft(rest, " \t")
+	}
+	if len(words) == 0 {
+		return kvRequest{}, errors.New("empty command")
+	}
+	return kvRequest{strings.ToUpper(words[0]), words[1:]}, nil
+}
+
+type kvServer struct {
+	data  *ttlCache[string, string]
+	clock clock
+}
+
+func newKVServer(clk clock) *kvServer {
+	return &kvServer{data: newTTLCache[string, string](clk), clock: clk}
+}
+
+// execute runs one command and returns the reply line
+func (s *kvServer) execute(cmd kvRequest) string {
+	wrongArgs := "ERR wrong number of arguments for " + cmd.Name
+	switch cmd.Name {
+	case "GET":
+		if len(cmd.Args) != 1 {
+			return wrongArgs
+		}
+		if v, ok := s.data.Get(cmd.Args[0]); ok {
+			return strconv.Quote(v)
+		}
+		return "(nil)"
+	case "SET":
+		var ttl time.Duration
+		switch {
+		case len(cmd.Args) == 2:
+		case len(cmd.Args) == 4 && strings.EqualFold(cmd.Args[2], "EX"):
+			seconds, err := strconv.Atoi(cmd.Args[3])
+			if err != nil || seconds <= 0 {
+				return "ERR EX wants a positive number of seconds"
+			}
+			ttl = time.Duration(seconds) * time.Second
+		default:
+			return wrongArgs
+		}
+		s.data.Set(cmd.Args[0], cmd.Args[1], ttl)
+		return "OK"
+	case "DEL":
+		if len(cmd.Args) != 1 {
+			return wrongArgs
+		}
+		if s.data.Delete(cmd.Args[0]) {
+			return "(integer) 1"
+		}
+		return "(integer) 0"
+	case "TTL":
+		if len(cmd.Args) != 1 {
+			return wrongArgs
+		}
+		expires, ok := s.data.Expires(cmd.Args[0])
+		switch {
+		case !ok:
+			return "(integer) -2"
+		case expires.IsZero():
+			return "(integer) -1"
+		}
+		left := expires.Sub(s.clock.Now())
+		return fmt.Sprintf("(integer) %d", int((left+time.Second-1)/time.Second)) // round up, like Redis
+	default:
+		return "ERR unknown command " + strconv.Quote(cmd.Name)
+	}
+}
+
+func (s *kvServer) handle(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	w := bufio.NewWriter(conn)
+	for scanner.Scan() {
+		cmd, err := parseKVLine(scanner.Text())
+		var reply string
+		switch {
+		case err != nil:
+			reply = "ERR " + err.Error()
+		case cmd.Name == "QUIT":
+			return
+		default:
+			reply = s.execute(cmd)
+		}
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		w.WriteString(reply + "\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// kvClient sends each line from in and prints the reply, with a prompt when interactive
+func kvClient(addr string, in io.Reader, out io.Writer, prompt string) error {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	replies := bufio.NewScanner(conn)
+	lines := bufio.NewScanner(in)
+	for fmt.Fprint(out, prompt); lines.Scan(); fmt.Fprint(out, prompt) {
+		line := lines.Text()
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if _, err := fmt.Fprintln(conn, line); err != nil {
+			return err
+		}
+		if strings.EqualFold(strings.TrimSpace(line), "QUIT") {
+			return nil
+		}
+		if !replies.Scan() {
+			if err := replies.Err(); err != nil {
+				return err
+			}
+			return errors.New("server closed the connection")
+		}
+		fmt.Fprintln(out, replies.Text())
+	}
+	fmt.Fprintln(out)
+	return lines.Err()
+}
+
+// hellogo kv --serve [addr] | hellogo kv [addr]
+func kvCommand(args []string) error {
+	flags := flag.NewFlagSet("kv", flag.ContinueOnError)
+	serve := flags.Bool("serve", false, "run the server instead of the client")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 1 {
+		return errUsage
+	}
+	addr := kvDefaultAddr
+	if flags.NArg() == 1 {
+		addr = flags.Arg(0)
+	}
+
+	if !*serve {
+		return kvClient(addr, os.Stdin, os.Stdout, "kv> ")
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+	ctx, stop := shutdownContext() // Ctrl+C stops the janitor and closes the listener
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	srv := newKVServer(realClock{})
+	go srv.data.runJanitor(ctx, time.Second)
+	fmt.Fprintln(os.Stderr, "kv server on", ln.Addr())
+	return serveTCP(ln, srv.handle)
+}
+
+func testKVServer() {
+	for _, line := range []string{`SET a 1`, `set "two words" "and a\ttab" ex 5`, `GET`, `SET a "oops`} {
+		cmd, err := parseKVLine(line)
+		fmt.Printf("%q %v\n", cmd, err)
+	}
+	// {"SET" ["a" "1"]} <nil>
+	// {"SET" ["two words" "and a\ttab" "ex" "5"]} <nil>
+	// {"GET" []} <nil>
+	// {"" []} unterminated or bad quoted string
+
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		fmt.Println("listen failed:", err)
+		return
+	}
+	defer ln.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	srv := newKVServer(realClock{})
+	go srv.data.runJanitor(ctx, time.Second)
+	go serveTCP(ln, srv.handle)
+
+	script := strings.Join([]string{
+		`SET greeting "hello there"`,
+		`GET greeting`,
+		`SET session abc EX 30`,
+		`TTL session`,
+		`TTL greeting`,
+		`DEL greeting`,
+		`DEL greeting`,
+		`GET greeting`,
+		`INCR counter`,
+		`QUIT`,
+	}, "\n")
+	if err := kvClient(ln.Addr().String(), strings.NewReader(script), os.Stdout, ""); err != nil {
+		fmt.Println(err)
+	}
+	// OK
+	// "hello there"
+	// OK
+	// (integer) 30
+	// (integer) -1
+	// (integer) 1
+	// (integer) 0
+	// (nil)
+	// ERR unknown command "INCR"
+}
@@ -0,0 +1,276 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+//////// Mini project: Conway's Game of Life
+// A grid of cells, each alive or dead. Every generation, all at once:
+//   - a live cell with 2 or 3 live neighbours stays alive, any other live cell dies
+//   - a dead cell with exactly 3 live neighbours comes alive
+// The board is a 2D slice, [][]bool, indexed board[y][x]. A slice of slices is a slice
+// of row headers, each pointing at its own backing array; newLifeBoard allocates one
+// array for all the cells and slices rows out of it instead, so the rows sit next to
+// each other in memory. The edges wrap around, so a glider leaving on the right comes
+// back on the left.
+//
+// "All at once" matters: counting neighbours on the board you're writing to would see
+// half of the next generation. step reads one board and writes the other, then they swap.
+//
+// hellogo life [-pattern glider] plays in the terminal. ANSI escape codes (ansi.go) move the cursor
+// back to the top left before each frame, so it redraws in place instead of scrolling.
+// --headless prints plain frames one after another, for piping and tests.
+
+type lifeBoard [][]bool
+
+func newLifeBoard(width, height int) lifeBoard {
+	cells := make([]bool, width*height)
+	board := make(lifeBoard, height)
+	for y := range board {
+		board[y] = cells[y*width : (y+1)*width : (y+1)*width] // full slice expression: appending to a row can't spill into the next
+	}
+	return board
+}
+
+func (b lifeBoard) width() int  { return len(b[0]) }
+func (b lifeBoard) height() int { return len(b) }
+
+// alive wraps x and y around the edges
+func (b lifeBoard) alive(x, y int) bool {
+	w, h := b.width(), b.height()
+	return b[(y+h)%h][(x+w)%w]
+}
+
+func (b lifeBoard) neighbours(x, y int) int {
+	n := 0
+	for dy := -1; dy <= 1; dy++ {
+		for dx := -1; dx <= 1; dx++ {
+			if (dx != 0 || dy != 0) && b.alive(x+dx, y+dy) {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+// step writes the generation after b into next, which must be the same size
+func (b lifeBoard) step(next lifeBoard) {
+	for y, row := range b {
+		for x, alive := range row {
+			n := b.neighbours(x, y)
+			next[y][x] = n == 3 || alive && n == 2
+		}
+	}
+}
+
+func (b lifeBoard) population() int {
+	n := 0
+	for _, row := range b {
+		for _, alive := range row {
+			if alive {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func (b lifeBoard) String() string {
+	var sb strings.Builder
+	for _, row := range b {
+		for _, alive := range row {
+			if alive {
+				sb.WriteByte('O')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// Patterns in the plaintext format most Life sites use: O is alive, . is dead
+var lifePatterns = map[string]string{
+	"blinker": "OOO",
+	"glider":  ".O.\n..O\nOOO",
+	"lwss":    ".O..O\nO....\nO...O\nOOOO.", // lightweight spaceship
+	"rpent":   ".OO\nOO.\n.O.",              // R-pentomino: 5 cells, chaos for 1103 generations
+	"pulsar": "..OOO...OOO..\n.............\nO....O.O....O\nO....O.O....O\nO....O.O....O\n..OOO...OOO..\n" +
+		".............\n..OOO...OOO..\nO....O.O....O\nO....O.O....O\nO....O.O....O\n.............\n..OOO...OOO..",
+}
+
+// place draws a plaintext pattern with its top left corner at x, y
+func (b lifeBoard) place(pattern string, x, y int) error {
+	rows := strings.Split(pattern, "\n")
+	if len(rows) > b.height() || slices.ContainsFunc(rows, func(r string) bool { return len(r) > b.width() }) {
+		return fmt.Errorf("pattern doesn't fit on a %dx%d board", b.width(), b.height())
+	}
+	for dy, row := range rows {
+		for dx, c := range row {
+			b[(y+dy)%b.height()][(x+dx)%b.width()] = c == 'O'
+		}
+	}
+	return nil
+}
+
+// randomize brings each cell to life with probability density
+func (b lifeBoard) randomize(seed uint64, density float64) {
+	r := rand.New(rand.NewPCG(seed, seed))
+	for _, row := range b {
+		for x := range row {
+			row[x] = r.Float64() < density
+		}
+	}
+}
+
+type lifeGame struct {
+	board, next lifeBoard
+	generation  int
+}
+
+func newLifeGame(board lifeBoard) *lifeGame {
+	return &lifeGame{board: board, next: newLifeBoard(board.width(), board.height())}
+}
+
+func (g *lifeGame) advance() {
+	g.board.step(g.next)
+	g.board, g.next = g.next, g.board // the old board is the scratch space for the one after
+	g.generation++
+}
+
+// writeFrame prints the board under a status line; animated frames start at the top left
+func (g *lifeGame) writeFrame(w io.Writer, animated bool) error {
+	prefix := ""
+	if animated {
+		prefix = ansiCursorHome
+	}
+	_, err := fmt.Fprintf(w, "%sgeneration %d, population %d\n%s", prefix, g.generation, g.board.population(), g.board)
+	return err
+}
+
+// playLife shows generations frames, one per tick; 0 runs until ctx is cancelled.
+// Headless games don't wait for the ticker or print escape codes, so the output only
+// depends on the board
+func playLife(ctx context.Context, w io.Writer, g *lifeGame, generations int, every time.Duration, headless bool) error {
+	out := bufio.NewWriter(w) // one write per frame, so the terminal never shows half of one
+	defer out.Flush()
+	if !headless {
+		fmt.Fprint(out, ansiHideCursor, ansiClearScreen)
+		defer fmt.Fprint(out, ansiShowCursor) // even on Ctrl+C, or the user's cursor stays hidden
+	}
+
+	var tick <-chan time.Time
+	if !headless {
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+	for {
+		if err := g.writeFrame(out, !headless); err != nil {
+			return err
+		}
+		if err := out.Flush(); err != nil {
+			return err
+		}
+		if generations > 0 && g.generation >= generations-1 {
+			return nil
+		}
+		if !headless {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-tick:
+			}
+		}
+		g.advance()
+	}
+}
+
+// hellogo life [-width n] [-height n] [-pattern name|random] [-seed n] [-generations n] [-every d] [--headless]
+func lifeCommand(args []string) error {
+	flags := flag.NewFlagSet("life", flag.ContinueOnError)
+	width := flags.Int("width", 40, "board width")
+	height := flags.Int("height", 20, "board height")
+	names := make([]string, 0, len(lifePatterns))
+	for name := range lifePatterns {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	pattern := flags.String("pattern", "random", "random, or one of: "+strings.Join(names, ", "))
+	seed := flags.Uint64("seed", uint64(time.Now().UnixNano()), "seed for the random pattern")
+	generations := flags.Int("generations", 0, "stop after this many generations (0: until Ctrl+C)")
+	every := flags.Duration("every", 100*time.Millisecond, "time between generations")
+	headless := flags.Bool("headless", false, "print plain frames as fast as possible, no escape codes")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 || *width < 1 || *height < 1 || *every <= 0 {
+		return errUsage
+	}
+	if *headless && *generations == 0 {
+		*generations = 10 // there's no Ctrl+C to wait for when the output is going into a pipe
+	}
+
+	board := newLifeBoard(*width, *height)
+	if *pattern == "random" {
+		board.randomize(*seed, 0.3)
+	} else {
+		p, ok := lifePatterns[*pattern]
+		if !ok {
+			return fmt.Errorf("no pattern %q", *pattern)
+		}
+		if err := board.place(p, 1, 1); err != nil {
+			return err
+		}
+	}
+
+	ctx, stop := shutdownContext()
+	defer stop()
+	return playLife(ctx, os.Stdout, newLifeGame(board), *generations, *every, *headless)
+}
+
+func testLife() {
+	board := newLifeBoard(5, 5)
+	board.place(lifePatterns["blinker"], 1, 2)
+	g := newLifeGame(board)
+	playLife(context.Background(), os.Stdout, g, 3, 0, true)
+	// generation 0, population 3
+	// .....
+	// .....
+	// .OOO.
+	// .....
+	// .....
+	// generation 1, population 3
+	// .....
+	// ..O..
+	// ..O..
+	// ..O..
+	// .....
+	// generation 2, population 3
+	// (back to the first frame: a blinker has period 2)
+
+	// A glider moves one cell diagonally every 4 generations, and wraps at the edges
+	board = newLifeBoard(6, 6)
+	board.place(lifePatterns["glider"], 0, 0)
+	g = newLifeGame(board)
+	start := board.String()
+	for range 4 * 6 {
+		g.advance()
+	}
+	fmt.Println("glider home after 24 generations:", g.board.String() == start) // true
+
+	// Random boards are reproducible from their seed
+	a, b := newLifeBoard(8, 4), newLifeBoard(8, 4)
+	a.randomize(42, 0.3)
+	b.randomize(42, 0.3)
+	fmt.Println("same seed, same board:", a.String() == b.String()) // true
+
+	fmt.Println(newLifeBoard(3, 3).place(lifePatterns["pulsar"], 0, 0)) // pattern doesn't fit on a 3x3 board
+}
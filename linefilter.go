@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"syscall"
+)
+
+//////// Line filters
+// Read stdin, transform each line, write stdout; like grep/sed/tr
+// Try: cat hello.go | hellogo filter upper | head
+
+var lineFilters = map[string]func(string) string{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+}
+
+func runLineFilter(r io.Reader, w io.Writer, fn func(string) string) error {
+	scanner := bufio.NewScanner(r)
+	// The default 64KB limit fails on a long line (see testScannerTokenLimit), allow up to 1MB
+	scanner.Buffer(make([]byte, 64*1024), 1<<20)
+	out := bufio.NewWriter(w)
+
+	for scanner.Scan() {
+		// Only the last write error matters here; bufio.Writer keeps returning it
+		out.WriteString(fn(scanner.Text()))
+		out.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	return out.Flush()
+}
+
+// hellogo filter <name>
+func filterCommand(args []string) error {
+	if len(args) != 1 {
+		return errUsage
+	}
+	fn, ok := lineFilters[args[0]]
+	if !ok {
+		return errUsage
+	}
+
+	err := runLineFilter(os.Stdin, os.Stdout, fn)
+	// Broken pipe means whoever was reading (like `head`) has had enough; that's not a failure
+	// Note, Go normally just exits on SIGPIPE when stdout breaks, this covers other writers
+	if errors.Is(err, syscall.EPIPE) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunLineFilter(t *testing.T) {
+	long := strings.Repeat("x", 100_000) // over bufio.Scanner's default 64KB
+	for _, tc := range []struct {
+		name, in, want string
+	}{
+		{"lines", "one\n Two \nthree", "ONE\n TWO \nTHREE\n"},
+		{"long line", long + "\nend\n", strings.ToUpper(long) + "\nEND\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := runLineFilter(strings.NewReader(tc.in), &out, strings.ToUpper); err != nil {
+				t.Fatal(err)
+			}
+			if out.String() != tc.want {
+				t.Errorf("got %d bytes, want %d", out.Len(), len(tc.want))
+			}
+		})
+	}
+}
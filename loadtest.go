@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+)
+
+//////// Mini project: an HTTP load tester
+// A fixed set of workers send requests as fast as they can (or as fast as a rate limit
+// allows) until a deadline, each keeping its own latencies, merged at the end. It's
+// the worker pool from trace.go with no job channel: every job is "send one more
+// request", so the workers just loop until the context runs out. The rate limit is
+// crawler.go's hostLimiter with a single host, so the workers share one queue of slots.
+//
+// Averages hide the slow requests people complain about, so the report gives
+// percentiles: p99 is the latency 99% of requests beat. And a histogram, since two
+// humps (say, cache hits and misses) look like one number in any summary.
+//
+//	hellogo loadtest -c 20 -d 10s -rate 500 http://localhost:8080/notes
+//
+// Only point it at servers you run yourself.
+
+type loadTest struct {
+	url         string
+	concurrency int
+	duration    time.Duration
+	rate        int // requests per second across all workers, 0 for no limit
+	client      *http.Client
+	sent        *atomic.Int64 // if set, counts requests as they finish, for a live display
+}
+
+type loadReport struct {
+	latencies []time.Duration // successful requests only, sorted
+	errors    map[string]int  // "status 503", or the error, with counts
+	elapsed   time.Duration
+}
+
+func (r loadReport) requests() int {
+	n := len(r.latencies)
+	for _, count := range r.errors {
+		n += count
+	}
+	return n
+}
+
+// run sends requests until the duration is up or ctx is cancelled (Ctrl+C still gets a report)
+func (lt loadTest) run(ctx context.Context) loadReport {
+	ctx, cancel := context.WithTimeout(ctx, lt.duration)
+	defer cancel()
+	limiter := newHostLimiter(0)
+	if lt.rate > 0 {
+		limiter = newHostLimiter(time.Second / time.Duration(lt.rate))
+	}
+
+	type workerResult struct {
+		latencies []time.Duration
+		errors    map[string]int
+	}
+	results := make([]workerResult, lt.concurrency) // one each, so no locking while it runs
+	start := time.Now()
+	var wg sync.WaitGroup
+	for w := range lt.concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			res := &results[w]
+			res.errors = map[string]int{}
+			for {
+				if err := limiter.wait(ctx, ""); err != nil {
+					return
+				}
+				latency, err := lt.send(ctx)
+				if lt.sent != nil {
+					lt.sent.Add(1)
+				}
+				switch {
+				case ctx.Err() != nil:
+					return // cut off by the deadline, not the server's fault
+				case err != nil:
+					res.errors[err.Error()]++
+				default:
+					res.latencies = append(res.latencies, latency)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	report := loadReport{errors: map[string]int{}, elapsed: time.Since(start)}
+	for _, res := range results {
+		report.latencies = append(report.latencies, res.latencies...)
+		for msg, n := range res.errors {
+			report.errors[msg] += n
+		}
+	}
+	slices.Sort(report.latencies)
+	return report
+}
+
+// send makes one request and reads the whole body, which is part of the latency
+func (lt loadTest) send(ctx context.Context) (time.Duration, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, lt.url, nil)
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	resp, err := lt.client.Do(req)
+	if err != nil {
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err // drop the `Get "http://...":` prefix, it's the same every time
+		}
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return 0, err
+	}
+	if resp.StatusCode >= 400 {
+		return 0, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return time.Since(start), nil
+}
+
+// percentile is the nearest-rank percentile of sorted: the smallest value that at
+// least p% of the values are less than or equal to
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// writeHistogram draws sorted as buckets equal width apart from the fastest to the slowest
+func writeHistogram(w io.Writer, sorted []time.Duration, buckets, width int) {
+	if len(sorted) == 0 {
+		return
+	}
+	lo, hi := sorted[0], sorted[len(sorted)-1]
+	lo = lo.Truncate(time.Microsecond) // nanoseconds are noise at this scale, and hard to read
+	step := max(((hi - lo) / time.Duration(buckets)).Round(time.Microsecond), time.Microsecond)
+	counts := make([]int, buckets)
+	for _, d := range sorted {
+		counts[min(int((d-lo)/step), buckets-1)]++ // the slowest can land on or past the top edge
+	}
+	most := slices.Max(counts)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	for i, n := range counts {
+		bar := strings.Repeat("#", n*width/most)
+		fmt.Fprintf(tw, "%v\t%d\t |%s\n", lo+time.Duration(i)*step, n, bar)
+	}
+	tw.Flush()
+}
+
+func writeLoadReport(w io.Writer, r loadReport) {
+	total := r.requests()
+	fmt.Fprintf(w, "%d requests in %v, %.1f req/s\n", total, r.elapsed.Round(time.Millisecond), float64(total)/r.elapsed.Seconds())
+	fmt.Fprintf(w, "%d ok, %d failed\n", len(r.latencies), total-len(r.latencies))
+
+	msgs := make([]string, 0, len(r.errors))
+	for msg := range r.errors {
+		msgs = append(msgs, msg)
+	}
+	slices.Sort(msgs)
+	for _, msg := range msgs {
+		fmt.Fprintf(w, "  %6d  %s\n", r.errors[msg], msg)
+	}
+	if len(r.latencies) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w)
+	t := newTable("min", "p50", "p95", "p99", "max").AlignRight(0, 1, 2, 3, 4)
+	var row []any
+	for _, p := range []float64{0, 50, 95, 99, 100} { // the 0th and 100th percentiles are the min and max
+		row = append(row, percentile(r.latencies, p).Round(time.Microsecond))
+	}
+	t.Row(row...)
+	t.Render(w)
+	fmt.Fprintln(w)
+	writeHistogram(w, r.latencies, 10, 40)
+}
+
+// hellogo loadtest [-c n] [-d duration] [-rate n] <url>
+func loadtestCommand(args []string) error {
+	flags := flag.NewFlagSet("loadtest", flag.ContinueOnError)
+	lt := loadTest{client: &http.Client{Timeout: 10 * time.Second}}
+	flags.IntVar(&lt.concurrency, "c", 10, "concurrent workers")
+	flags.DurationVar(&lt.duration, "d", 5*time.Second, "how long to keep sending")
+	flags.IntVar(&lt.rate, "rate", 0, "requests per second across all workers, 0 for as fast as possible")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 || lt.concurrency < 1 || lt.duration <= 0 || lt.rate < 0 {
+		return errUsage
+	}
+	lt.url = flags.Arg(0)
+	// The default transport keeps only 2 idle connections per host, so most workers would
+	// open a new connection per request and the test would mostly measure TCP handshakes
+	lt.client.Transport = &http.Transport{MaxIdleConnsPerHost: lt.concurrency}
+
+	ctx, stop := shutdownContext()
+	defer stop()
+	fmt.Fprintf(os.Stderr, "%d workers for %v against %s\n", lt.concurrency, lt.duration, lt.url)
+	lt.sent = new(atomic.Int64)
+	start := time.Now()
+	spin := newSpinner(os.Stderr, "sending:", func() string {
+		left := max(lt.duration-time.Since(start), 0)
+		return fmt.Sprintf("%d requests, %v to go", lt.sent.Load(), left.Round(time.Second))
+	})
+	spin.Start()
+	report := lt.run(ctx)
+	spin.Stop(fmt.Sprintf("sent %d requests", report.requests()))
+	writeLoadReport(os.Stdout, report)
+	if len(report.latencies) == 0 {
+		return errors.New("no request succeeded")
+	}
+	return nil
+}
+
+func testLoadTest() {
+	ms := func(ns ...int) []time.Duration {
+		var ds []time.Duration
+		for _, n := range ns {
+			ds = append(ds, time.Duration(n)*time.Millisecond)
+		}
+		return ds
+	}
+	sorted := ms(1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 100)
+	fmt.Println(percentile(sorted, 50), percentile(sorted, 95), percentile(sorted, 99)) // 10ms 19ms 100ms, one slow request owns the p99
+
+	writeHistogram(os.Stdout, ms(10, 11, 11, 12, 12, 12, 13, 13, 30), 4, 12)
+	//  10ms  8 |############
+	//  15ms  0 |
+	//  20ms  0 |
+	//  25ms  1 |#
+
+	// Every tenth request fails, so the server's handler counts in an atomic
+	var served atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if served.Add(1)%10 == 0 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	}))
+	defer srv.Close()
+	lt := loadTest{url: srv.URL, concurrency: 4, duration: 200 * time.Millisecond, rate: 200, client: srv.Client()}
+
+	writeLoadReport(os.Stdout, lt.run(context.Background()))
+	// 40 requests in 200ms, 200.0 req/s
+	// 36 ok, 4 failed
+	//        4  status 503
+	// ...then the percentiles and a histogram; loadtest_test.go checks the counts
+	fmt.Println("requests served:", served.Load() > 0) // requests served: true
+}
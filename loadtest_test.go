@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestLoadTest(t *testing.T) {
+	// Every tenth request fails
+	var served atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if served.Add(1)%10 == 0 {
+			http.Error(w, "try again", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	}))
+	defer srv.Close()
+	lt := loadTest{url: srv.URL, concurrency: 4, duration: 200 * time.Millisecond, rate: 200, client: srv.Client()}
+
+	t.Run("rate limited", func(t *testing.T) {
+		report := lt.run(context.Background())
+		// 200/s for 0.2s is 40, give or take the first slot and the deadline
+		if n := report.requests(); n < 30 || n > 42 {
+			t.Errorf("%d requests, want about 40", n)
+		}
+		if n := report.errors["status 503"]; n == 0 || n > report.requests()/10+1 {
+			t.Errorf("%d 503s out of %d requests, want about every tenth", n, report.requests())
+		}
+	})
+	t.Run("server down", func(t *testing.T) {
+		down := lt
+		down.url = "http://127.0.0.1:1" // nothing listens on port 1
+		down.duration = 50 * time.Millisecond
+		report := down.run(context.Background())
+		if len(report.latencies) != 0 || len(report.errors) == 0 {
+			t.Errorf("%d ok, errors %v; want only errors", len(report.latencies), report.errors)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"os"
+)
+
+//////// The log package
+// Everything else in this repo prints with fmt.Println, which is fine for lesson output
+// but a poor fit for diagnostics:
+// * fmt goes to stdout, mixed in with real output; log goes to stderr by default
+// * no timestamps or source locations
+// * fmt calls from many goroutines can interleave; each log call is written in one piece
+
+func testStandardLogger() {
+	log.Println("standard logger") // 2019/01/01 12:00:00 standard logger
+
+	// Flags pick what goes in front of each line
+	log.SetFlags(log.Ldate | log.Ltime | log.Lmicroseconds | log.Lshortfile)
+	log.Println("with micros and file:line") // 2019/01/01 12:00:00.123456 logging.go:23: ...
+
+	log.SetFlags(log.LstdFlags | log.LUTC | log.Lmsgprefix)
+	log.SetPrefix("hellogo: ")
+	log.Println("Lmsgprefix puts the prefix after the time") // 2019/01/01 12:00:00 hellogo: ...
+
+	// Put the defaults back, the standard logger is shared by the whole program
+	log.SetFlags(log.LstdFlags)
+	log.SetPrefix("")
+}
+
+func testLogDestinations() {
+	// Any io.Writer will do: a buffer, a file, several at once
+	var buf bytes.Buffer
+	logger := log.New(&buf, "buffered: ", 0)
+	logger.Printf("%d apples", 3)
+	fmt.Print(buf.String()) // buffered: 3 apples
+
+	f, err := os.CreateTemp("", "hellogo-*.log")
+	if err != nil {
+		log.Println("no log file:", err)
+		return
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	both := log.New(io.MultiWriter(os.Stderr, f), "", log.LstdFlags)
+	both.Println("to stderr and", f.Name())
+
+	// Turning a logger off
+	quiet := log.New(io.Discard, "", 0)
+	quiet.Println("nobody hears this")
+}
+
+//// Per subsystem loggers
+// A logger per part of the program makes it obvious where a line came from
+
+func newSubsystemLogger(name string) *log.Logger {
+	return log.New(os.Stderr, "["+name+"] ", log.LstdFlags|log.Lmsgprefix)
+}
+
+var (
+	configLog   = newSubsystemLogger("config")
+	progressLog = newSubsystemLogger("progress")
+)
+
+func testSubsystemLoggers() {
+	configLog.Println("loaded defaults")  // 2019/01/01 12:00:00 [config] loaded defaults
+	progressLog.Println("3 lessons done") // 2019/01/01 12:00:00 [progress] 3 lessons done
+}
+
+//// Fatal and Panic
+// log.Fatal = log + os.Exit(1): no defers run, can't be recovered. Only for main()
+// log.Panic = log + panic(): defers run and it can be recovered, like showPanic in hello.go
+
+func showLogFatal() {
+	defer fmt.Println("never printed, os.Exit skips defers")
+	log.Fatal("giving up") // exit status 1
+}
+
+func showLogPanic() {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Println("recovered:", r) // recovered: giving up
+		}
+	}()
+	log.Panic("giving up")
+}
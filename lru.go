@@ -0,0 +1,176 @@
+package main
+
+import (
+	"container/list"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+//////// An LRU cache
+// A cache with a size limit has to pick what to throw out when it's full. "Least
+// recently used" is the usual answer: whatever nobody has asked for in the longest time.
+// That needs two things at once, both O(1):
+//   - find an entry by key: a map
+//   - keep entries in order of use, moving one to the front on every Get and taking
+//     one off the back when full: a doubly linked list, container/list
+// The map points at list elements, and each element holds its key, so evicting from the
+// back can delete from the map too.
+//
+// Compared with ttlCache (cache.go): that one forgets by age and can grow without limit,
+// this one forgets by size and keeps entries forever if there's room. Real caches often
+// do both. And Get here changes the list, so it takes the full lock, where ttlCache's
+// readers share an RLock.
+
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+type lruCache[K comparable, V any] struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List          // front is the most recently used; values are *lruEntry[K, V]
+	items    map[K]*list.Element // into order
+}
+
+func newLRUCache[K comparable, V any](capacity int) *lruCache[K, V] {
+	return &lruCache[K, V]{capacity: max(capacity, 1), order: list.New(), items: map[K]*list.Element{}}
+}
+
+// Get counts as a use, so it moves key to the front
+func (c *lruCache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		var zero V
+		return zero, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*lruEntry[K, V]).value, true
+}
+
+// Put adds or updates key, and reports the key it evicted to make room, if any
+func (c *lruCache[K, V]) Put(key K, value V) (evicted K, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, found := c.items[key]; found {
+		el.Value.(*lruEntry[K, V]).value = value
+		c.order.MoveToFront(el)
+		return evicted, false
+	}
+	c.items[key] = c.order.PushFront(&lruEntry[K, V]{key, value})
+	if c.order.Len() <= c.capacity {
+		return evicted, false
+	}
+	oldest := c.order.Remove(c.order.Back()).(*lruEntry[K, V])
+	delete(c.items, oldest.key)
+	return oldest.key, true
+}
+
+func (c *lruCache[K, V]) Delete(key K) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if ok {
+		c.order.Remove(el)
+		delete(c.items, key)
+	}
+	return ok
+}
+
+func (c *lruCache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Keys lists the keys from most to least recently used
+func (c *lruCache[K, V]) Keys() []K {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	keys := make([]K, 0, c.order.Len())
+	for el := c.order.Front(); el != nil; el = el.Next() {
+		keys = append(keys, el.Value.(*lruEntry[K, V]).key)
+	}
+	return keys
+}
+
+//// Without the list
+// The obvious version keeps keys in a slice in order of use. Correct, but every Get has
+// to find the key in the slice and shift everything after it: O(n)
+
+type sliceLRU[K comparable, V any] struct {
+	capacity int
+	keys     []K // most recent last
+	values   map[K]V
+}
+
+func (c *sliceLRU[K, V]) Get(key K) (V, bool) {
+	v, ok := c.values[key]
+	if ok {
+		i := slices.Index(c.keys, key)
+		c.keys = append(slices.Delete(c.keys, i, i+1), key)
+	}
+	return v, ok
+}
+
+func (c *sliceLRU[K, V]) Put(key K, value V) {
+	if _, ok := c.values[key]; ok {
+		c.Get(key)
+	} else {
+		c.keys = append(c.keys, key)
+		if len(c.keys) > c.capacity {
+			delete(c.values, c.keys[0])
+			c.keys = slices.Delete(c.keys, 0, 1)
+		}
+	}
+	c.values[key] = value
+}
+
+// benchLRUGet times a Get on a full cache of n ints, asking for keys oldest first so
+// each one is the least recently used when it's asked for
+func benchLRUGet(n int, get func(int), put func(int)) time.Duration {
+	for i := range n {
+		put(i)
+	}
+	const gets = 10_000 // a fixed count rather than testing.Benchmark's full second, four of those drag
+	start := time.Now()
+	for i := range gets {
+		get(i % n)
+	}
+	return time.Since(start) / gets
+}
+
+func testLRU() {
+	c := newLRUCache[string, int](3)
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("c", 3)
+	fmt.Println(c.Keys()) // [c b a]
+	c.Get("a")
+	fmt.Println(c.Keys()) // [a c b], a was just used
+	fmt.Println(c.Put("d", 4))
+	// b true: full, and b is the least recently used
+	fmt.Println(c.Get("b"))             // 0 false
+	fmt.Println(c.Put("c", 30))         //  false: an update evicts nothing
+	fmt.Println(c.Keys())               // [c d a]
+	fmt.Println(c.Delete("a"), c.Len()) // true 2
+
+	// O(1) means the time per Get doesn't grow with the size, O(n) means it grows in step
+	small, big := 1_000, 100_000
+	lruTime := func(n int) time.Duration {
+		c := newLRUCache[int, int](n)
+		return benchLRUGet(n, func(k int) { c.Get(k) }, func(k int) { c.Put(k, k) })
+	}
+	sliceTime := func(n int) time.Duration {
+		c := &sliceLRU[int, int]{capacity: n, values: map[int]int{}}
+		return benchLRUGet(n, func(k int) { c.Get(k) }, func(k int) { c.Put(k, k) })
+	}
+	lruSmall, lruBig := lruTime(small), lruTime(big)
+	sliceSmall, sliceBig := sliceTime(small), sliceTime(big)
+	fmt.Printf("list+map: %v per Get at %d entries, %v at %d\n", lruSmall, small, lruBig, big)     // around 50ns at both
+	fmt.Printf("slice:    %v per Get at %d entries, %v at %d\n", sliceSmall, small, sliceBig, big) // around 1µs, then 100 times that
+}
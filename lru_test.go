@@ -0,0 +1,46 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLRU(t *testing.T) {
+	t.Run("eviction order", func(t *testing.T) {
+		c := newLRUCache[int, int](100)
+		for i := range 1000 {
+			c.Put(i, i)
+			c.Get(0) // 0 keeps getting used, so it's never the oldest
+		}
+		if _, ok := c.Get(0); !ok {
+			t.Errorf("0 was evicted although it was used every time")
+		}
+		if keys := c.Keys(); len(keys) != 100 || keys[1] != 999 || keys[99] != 901 {
+			t.Errorf("keys = %v..., want 0, 999 down to 901", keys[:3])
+		}
+	})
+
+	t.Run("constant time", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("timing test")
+		}
+		small, big := 1_000, 100_000
+		lruTime := func(n int) time.Duration {
+			c := newLRUCache[int, int](n)
+			return benchLRUGet(n, func(k int) { c.Get(k) }, func(k int) { c.Put(k, k) })
+		}
+		sliceTime := func(n int) time.Duration {
+			c := &sliceLRU[int, int]{capacity: n, values: map[int]int{}}
+			return benchLRUGet(n, func(k int) { c.Get(k) }, func(k int) { c.Put(k, k) })
+		}
+		// 100 times the entries. 100,000 of them don't fit in the CPU caches, and with other
+		// tests running alongside, a miss can cost several times a hit: allow up to 20 times
+		// the time, still well short of the 100 that O(n) would take
+		if lruSmall, lruBig := lruTime(small), lruTime(big); lruBig > 20*lruSmall {
+			t.Errorf("Get took %v at %d entries but %v at %d, that's not O(1)", lruSmall, small, lruBig, big)
+		}
+		if sliceSmall, sliceBig := sliceTime(small), sliceTime(big); sliceBig < 10*sliceSmall {
+			t.Errorf("sliceLRU Get took %v at %d entries and %v at %d, expected it to grow", sliceSmall, small, sliceBig, big)
+		}
+	})
+}
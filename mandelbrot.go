@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"math"
+	"math/cmplx"
+	"net/http"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//////// Mandelbrot and Julia sets
+// Take a point c in the complex plane and iterate z = z² + c from z = 0. For some c
+// z stays small forever: those points are the Mandelbrot set. For the rest |z| passes
+// 2 sooner or later, after which it's gone for good, and how many steps that took is
+// what gives the pictures their colour. A Julia set is the same sum with c fixed and
+// the starting z being the point.
+//
+// Every pixel is independent, which makes it a textbook parallel problem: split the
+// image into rows and hand them to a worker pool (runPool, trace.go). Workers write
+// straight into the image, each to its own rows, so nothing needs a lock. The rows
+// aren't equal work (the black ones run to maxIter), which is why a pool taking rows
+// one at a time beats cutting the image into one slab per core.
+//
+//	hellogo fractal [-o file.png] [-width n] [-height n] [-palette name] [-julia re,im]
+//	hellogo serve, then /fractal.png?cx=-0.75&cy=0.1&zoom=20&palette=ocean
+
+type fractal struct {
+	width, height int
+	center        complex128
+	zoom          float64 // 1 shows 3 units across
+	maxIter       int
+	julia         bool
+	c             complex128 // for julia
+	palette       func(t float64) color.RGBA
+}
+
+func defaultFractal() fractal {
+	return fractal{width: 800, height: 600, center: -0.5, zoom: 1, maxIter: 500, palette: fractalPalettes["fire"]}
+}
+
+// point is the complex number under pixel (x, y)
+func (f fractal) point(x, y int) complex128 {
+	scale := 3 / f.zoom / float64(f.width)
+	return f.center + complex(float64(x-f.width/2)*scale, float64(f.height/2-y)*scale)
+}
+
+// escape is how long z took to pass 2, smoothed to a fraction so the colours blend
+// instead of banding; maxIter means it never did
+func (f fractal) escape(p complex128) float64 {
+	z, c := complex128(0), p
+	if f.julia {
+		z, c = p, f.c
+	}
+	for i := range f.maxIter {
+		z = z*z + c
+		if r := cmplx.Abs(z); r > 2 {
+			return float64(i) + 1 - math.Log2(math.Log(r)/math.Ln2)
+		}
+	}
+	return float64(f.maxIter)
+}
+
+// fractalPalettes map 0..1 (how soon a point escaped) to a colour; points in the set are black
+var fractalPalettes = map[string]func(t float64) color.RGBA{
+	"fire": func(t float64) color.RGBA {
+		return color.RGBA{clampByte(t * 3 * 255), clampByte((t*3 - 1) * 255), clampByte((t*3 - 2) * 255), 255}
+	},
+	"ocean": func(t float64) color.RGBA {
+		return color.RGBA{clampByte((t*2 - 1) * 255), clampByte(t * 1.5 * 255), clampByte(64 + t*2*191), 255}
+	},
+	"gray": func(t float64) color.RGBA {
+		v := clampByte(t * 255)
+		return color.RGBA{v, v, v, 255}
+	},
+}
+
+func clampByte(v float64) uint8 { return uint8(max(0, min(255, v))) }
+
+func (f fractal) color(n float64) color.RGBA {
+	if n >= float64(f.maxIter) {
+		return color.RGBA{0, 0, 0, 255}
+	}
+	// Most points escape within a few steps; a square root spreads those colours out
+	return f.palette(math.Sqrt(n / float64(f.maxIter)))
+}
+
+func (f fractal) renderRow(img *image.RGBA, y int) {
+	for x := range f.width {
+		img.SetRGBA(x, y, f.color(f.escape(f.point(x, y))))
+	}
+}
+
+// render draws f with workers goroutines taking a row at a time
+func (f fractal) render(ctx context.Context, workers int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, f.width, f.height))
+	rows := make([]int, f.height)
+	for y := range rows {
+		rows[y] = y
+	}
+	runPool(ctx, workers, rows, func(ctx context.Context, _ int, y int) struct{} {
+		if ctx.Err() == nil {
+			f.renderRow(img, y)
+		}
+		return struct{}{}
+	})
+	return img
+}
+
+// parseComplex reads "re,im"
+func parseComplex(s string) (complex128, error) {
+	re, im, ok := strings.Cut(s, ",")
+	if !ok {
+		return 0, fmt.Errorf("%q: want re,im", s)
+	}
+	r, err := strconv.ParseFloat(strings.TrimSpace(re), 64)
+	if err != nil {
+		return 0, err
+	}
+	i, err := strconv.ParseFloat(strings.TrimSpace(im), 64)
+	if err != nil {
+		return 0, err
+	}
+	return complex(r, i), nil
+}
+
+// writeSpeedup renders f with 1, 2, 4... workers up to the number of CPUs and reports the times
+func writeSpeedup(ctx context.Context, w io.Writer, f fractal) {
+	var base time.Duration
+	for workers := 1; ; workers *= 2 {
+		workers = min(workers, runtime.NumCPU())
+		start := time.Now()
+		f.render(ctx, workers)
+		took := time.Since(start)
+		if workers == 1 {
+			base = took
+		}
+		fmt.Fprintf(w, "%2d workers: %v, %.1fx\n", workers, took.Round(time.Millisecond), float64(base)/float64(took))
+		if workers == runtime.NumCPU() {
+			return
+		}
+	}
+}
+
+// hellogo fractal [-o file.png] [-width n] [-height n] [-center re,im] [-zoom z] [-iter n] [-palette name] [-julia re,im] [--speedup]
+func fractalCommand(args []string) error {
+	f := defaultFractal()
+	flags := flag.NewFlagSet("fractal", flag.ContinueOnError)
+	out := flags.String("o", "fractal.png", "PNG file to write")
+	flags.IntVar(&f.width, "width", f.width, "image width")
+	flags.IntVar(&f.height, "height", f.height, "image height")
+	center := flags.String("center", "-0.5,0", "point in the middle of the image, re,im")
+	flags.Float64Var(&f.zoom, "zoom", f.zoom, "magnification")
+	flags.IntVar(&f.maxIter, "iter", f.maxIter, "iterations before a point counts as in the set")
+	palette := flags.String("palette", "fire", "fire, ocean or gray")
+	julia := flags.String("julia", "", "draw the Julia set for this c, re,im (try -0.8,0.156)")
+	workers := flags.Int("workers", runtime.NumCPU(), "goroutines rendering rows")
+	speedup := flags.Bool("speedup", false, "time the render with 1 worker up to one per CPU")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 || f.width < 1 || f.height < 1 || f.maxIter < 1 || f.zoom <= 0 || *workers < 1 {
+		return errUsage
+	}
+	var err error
+	if f.center, err = parseComplex(*center); err != nil {
+		return err
+	}
+	if *julia != "" {
+		if f.c, err = parseComplex(*julia); err != nil {
+			return err
+		}
+		f.julia = true
+		if !flagPassed(flags, "center") {
+			f.center = 0
+		}
+	}
+	var ok bool
+	if f.palette, ok = fractalPalettes[*palette]; !ok {
+		return fmt.Errorf("no palette %q", *palette)
+	}
+
+	ctx, stop := shutdownContext()
+	defer stop()
+	if *speedup {
+		writeSpeedup(ctx, os.Stdout, f)
+		return nil
+	}
+	start := time.Now()
+	img := f.render(ctx, *workers)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	file, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	bw := bufio.NewWriter(file)
+	if err := png.Encode(bw, img); err != nil {
+		file.Close()
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		file.Close()
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "wrote %s (%dx%d) in %v\n", *out, f.width, f.height, time.Since(start).Round(time.Millisecond))
+	return file.Close()
+}
+
+func flagPassed(flags *flag.FlagSet, name string) bool {
+	passed := false
+	flags.Visit(func(fl *flag.Flag) { passed = passed || fl.Name == name })
+	return passed
+}
+
+// fractalHandler serves GET /fractal.png for hellogo serve; the query can set cx, cy,
+// zoom, palette, julia (re,im), width and height (at most 1600x1200)
+func fractalHandler(w http.ResponseWriter, r *http.Request) {
+	f := defaultFractal()
+	f.width, f.height = 640, 480
+	q := r.URL.Query()
+	bad := func(msg string) { writeAPIError(w, errCodeBadRequest, msg) }
+	floatParam := func(name string, dst *float64) bool {
+		if v := q.Get(name); v != "" {
+			n, err := strconv.ParseFloat(v, 64)
+			if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+				bad(name + " must be a number")
+				return false
+			}
+			*dst = n
+		}
+		return true
+	}
+	cx, cy := real(f.center), imag(f.center)
+	if !floatParam("cx", &cx) || !floatParam("cy", &cy) || !floatParam("zoom", &f.zoom) {
+		return
+	}
+	f.center = complex(cx, cy)
+	for _, dim := range []struct {
+		name  string
+		dst   *int
+		limit int
+	}{{"width", &f.width, 1600}, {"height", &f.height, 1200}} {
+		if v := q.Get(dim.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil || n < 1 || n > dim.limit {
+				bad(fmt.Sprintf("%s must be 1 to %d", dim.name, dim.limit))
+				return
+			}
+			*dim.dst = n
+		}
+	}
+	if f.zoom <= 0 {
+		bad("zoom must be more than 0")
+		return
+	}
+	if name := q.Get("palette"); name != "" {
+		p, ok := fractalPalettes[name]
+		if !ok {
+			bad("no palette " + name)
+			return
+		}
+		f.palette = p
+	}
+	if c := q.Get("julia"); c != "" {
+		var err error
+		if f.c, err = parseComplex(c); err != nil {
+			bad("julia: " + err.Error())
+			return
+		}
+		f.julia = true
+		if q.Get("cx") == "" && q.Get("cy") == "" {
+			f.center = 0
+		}
+	}
+	img := f.render(r.Context(), runtime.NumCPU())
+	w.Header().Set("Content-Type", "image/png")
+	png.Encode(w, img)
+}
+
+func testMandelbrot() {
+	// Small enough to print, one character per pixel by how soon it escaped
+	// Characters are about twice as tall as wide, so only every other row
+	f := fractal{width: 60, height: 48, center: -0.6, zoom: 1.2, maxIter: 60}
+	shades := " .:-=+*#%@"
+	for y := 1; y < f.height; y += 2 {
+		var line strings.Builder
+		for x := range f.width {
+			n := f.escape(f.point(x, y))
+			if n >= float64(f.maxIter) {
+				line.WriteByte('@')
+			} else {
+				line.WriteByte(shades[min(int(n/6), len(shades)-2)])
+			}
+		}
+		fmt.Println(strings.TrimRight(line.String(), " "))
+	}
+
+	// The same image whatever the number of workers
+	f = defaultFractal()
+	f.width, f.height = 200, 150
+	runCase("TestMandelbrot/workers", func(errorf errorfFunc) {
+		want := sha256.Sum256(f.render(context.Background(), 1).Pix)
+		for _, workers := range []int{2, 3, 8} {
+			if got := sha256.Sum256(f.render(context.Background(), workers).Pix); got != want {
+				errorf("%d workers drew a different image than 1", workers)
+			}
+		}
+	})
+	runCase("TestMandelbrot/points", func(errorf errorfFunc) {
+		for _, c := range []complex128{0, -1, -2, 0.25, 1i} {
+			if n := f.escape(c); n < float64(f.maxIter) {
+				errorf("%v is in the set, but escaped after %.1f", c, n)
+			}
+		}
+		for _, c := range []complex128{1, 0.5, -2.1, 2i} {
+			if n := f.escape(c); n >= float64(f.maxIter) {
+				errorf("%v isn't in the set, but never escaped", c)
+			}
+		}
+	})
+	palettes := make([]string, 0, len(fractalPalettes))
+	for name := range fractalPalettes {
+		palettes = append(palettes, name)
+	}
+	slices.Sort(palettes)
+	fmt.Println(palettes) // [fire gray ocean]
+	// hellogo fractal --speedup times it: with 8 CPUs, around 6x
+}
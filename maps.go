@@ -0,0 +1,144 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"testing"
+)
+
+//////// Maps: keys, nil maps, sizing and clear
+// maps in hello.go is the two-key tour. Underneath, a map is a hash table: the key is
+// hashed to pick a slot and then compared with == to make sure, so keys have to be
+// comparable: numbers, strings, pointers, arrays and structs of those. Slices, maps
+// and funcs can't be keys. An interface key type compiles with anything, and panics
+// when a slice is used as a key (interfaces.go).
+//
+// Things worth knowing:
+//   - a struct key (snakePoint in snake.go) beats gluing a string together ("3,4") or
+//     nesting maps: no formatting, no parsing, and it's one lookup
+//   - reading a missing key gives the zero value, which makes counters (m[k]++) and
+//     maps of slices (m[k] = append(m[k], v)) work with no "is it there yet" check.
+//     Use v, ok := m[k] when zero is a real value
+//   - a nil map (a var, or a struct field nobody made) reads as empty, but writing to
+//     it panics. Fields that hold maps need a constructor or a make before use
+//   - map values aren't addressable: m[k].field = v doesn't compile. Store a pointer,
+//     or read the struct, change it and write it back
+//   - make(map[K]V, n) sizes the table for n entries up front, skipping the rehashing
+//     as it grows. It's a hint: the map still grows past n
+//   - iteration order is random on purpose, different each range, so nothing comes to
+//     depend on it. Sort the keys when the order shows
+//   - clear(m) empties a map and keeps its memory for reuse. It's also the only way to
+//     delete a NaN key: NaN != NaN, so delete(m, NaN) can never find it
+//
+// Go 1.24 changed the table to a "Swiss table", which probes groups of 8 slots at once.
+// None of the above changed with it.
+
+// groupBy puts each v in the list for its key, in order
+func groupBy[K comparable, V any](vs []V, key func(V) K) map[K][]V {
+	groups := make(map[K][]V)
+	for _, v := range vs {
+		k := key(v)
+		groups[k] = append(groups[k], v) // nil for a new key, and append makes it
+	}
+	return groups
+}
+
+// sortedKeys is the map's keys in order, for printing it the same way every time
+func sortedKeys[K cmp.Ordered, V any](m map[K]V) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func testMapInternals() {
+	//// Struct keys
+	walls := map[snakePoint]bool{{0, 0}: true, {3, 4}: true}
+	fmt.Println(walls[snakePoint{3, 4}], walls[snakePoint{4, 3}]) // true false
+	// The alternative: walls[fmt.Sprintf("%d,%d", x, y)], formatted on every lookup
+
+	//// Missing keys and maps of slices
+	words := strings.Fields("go gopher map make mutex goroutine slice")
+	byLetter := groupBy(words, func(w string) string { return w[:1] })
+	for _, letter := range sortedKeys(byLetter) {
+		fmt.Println(letter, byLetter[letter])
+	}
+	// g [go gopher goroutine]
+	// m [map make mutex]
+	// s [slice]
+
+	ages := map[string]int{"newborn": 0}
+	_, known := ages["newborn"]
+	_, unknown := ages["stranger"]
+	fmt.Println(ages["newborn"], ages["stranger"], known, unknown) // 0 0 true false: only ok tells them apart
+
+	// Struct values can't be changed in place; read, change, write back
+	dogs := map[string]dog{"Sam": {name: "Sam", age: 2}}
+	// dogs["Sam"].age++ doesn't compile: cannot assign to struct field dogs["Sam"].age in map
+	sam := dogs["Sam"]
+	sam.age++
+	dogs["Sam"] = sam
+	fmt.Println(dogs["Sam"].age) // 3
+
+	//// nil maps
+	var nilMap map[string]int
+	fmt.Println(nilMap["x"], len(nilMap), nilMap == nil) // 0 0 true: reads are fine
+	delete(nilMap, "x")                                  // so is delete
+	fmt.Println(panicMessage(func() { nilMap["x"] = 1 }))
+	// assignment to entry in nil map
+
+	//// Sizing
+	grow := testing.AllocsPerRun(10, func() {
+		m := map[int]int{}
+		for i := range 1000 {
+			m[i] = i
+		}
+	})
+	sized := testing.AllocsPerRun(10, func() {
+		m := make(map[int]int, 1000)
+		for i := range 1000 {
+			m[i] = i
+		}
+	})
+	fmt.Println(sized < grow) // true: the hinted map never rehashes
+
+	//// clear
+	seen := map[float64]int{}
+	seen[math.NaN()]++
+	seen[math.NaN()]++ // a new key: this NaN doesn't equal the last one
+	seen[1]++
+	delete(seen, math.NaN())
+	fmt.Println(len(seen), seen[math.NaN()]) // 3 0: two NaN keys nobody can look up or delete
+	clear(seen)
+	fmt.Println(len(seen)) // 0
+
+	// clear on a slice zeroes it and keeps the length
+	counts := []int{4, 5, 6}
+	clear(counts)
+	fmt.Println(counts) // [0 0 0]
+
+	runCase("TestMapInternals/group", func(errorf errorfFunc) {
+		groups := groupBy([]int{1, 2, 3, 4, 5, 6}, func(n int) bool { return n%2 == 0 })
+		if !slices.Equal(groups[true], []int{2, 4, 6}) || !slices.Equal(groups[false], []int{1, 3, 5}) {
+			errorf("groupBy evens = %v", groups)
+		}
+		if empty := groupBy(nil, func(s string) string { return s }); empty == nil || len(empty) != 0 {
+			errorf("groupBy(nil) = %#v, want an empty map that can be written to", empty)
+		}
+	})
+	runCase("TestMapInternals/sorted keys", func(errorf errorfFunc) {
+		m := map[string]int{}
+		for i := range 100 {
+			m[fmt.Sprintf("k%03d", 99-i)] = i
+		}
+		keys := sortedKeys(m)
+		if len(keys) != 100 || !slices.IsSorted(keys) || keys[0] != "k000" {
+			errorf("sortedKeys gave %d keys starting %q, sorted %v", len(keys), keys[0], slices.IsSorted(keys))
+		}
+	})
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/cmplx"
+	"slices"
+	"sort"
+)
+
+//////// Complex numbers and a tour of math
+// Go has complex numbers built in: complex128 (two float64s, the real and imaginary
+// parts) and complex64 (two float32s). Literals use i: 3 + 4i. real, imag and complex
+// are builtins; everything else (Abs, Phase, Sqrt, Exp, Pow...) is in math/cmplx.
+//
+// The rest is the corners of math that trip people up:
+//   - % and math.Mod keep the sign of the dividend, math.Remainder rounds the quotient
+//     to nearest, so it can come out negative for positive inputs
+//   - converting a float to an int truncates towards zero; math.Round rounds halves
+//     away from zero, math.RoundToEven to the even neighbour (banker's rounding)
+//   - floats have infinities and NaN, not-a-number, which is not equal to anything,
+//     itself included
+
+func testComplexNumbers() {
+	z := 3 + 4i
+	fmt.Println(z, real(z), imag(z))         // (3+4i) 3 4
+	fmt.Println(cmplx.Abs(z), cmplx.Conj(z)) // 5 (3-4i): the length, and the mirror image
+	fmt.Println(z*z, z*cmplx.Conj(z))        // (-7+24i) (25+0i): times its conjugate is always real
+	fmt.Printf("%T %T\n", z, complex64(z))   // complex128 complex64
+
+	// Polar form: a length and an angle. Multiplying multiplies lengths and adds angles
+	r, theta := cmplx.Polar(1i)
+	fmt.Println(r, theta == math.Pi/2)             // 1 true
+	fmt.Printf("%.3f\n", cmplx.Rect(2, math.Pi/3)) // (1.000+1.732i)
+
+	// Square roots of negative numbers exist here; math.Sqrt(-1) is NaN
+	fmt.Println(cmplx.Sqrt(-1), math.Sqrt(-1)) // (0+1i) NaN
+
+	// e^(iπ) + 1 = 0, give or take the last bit of a float64
+	fmt.Println(cmplx.Exp(complex(0, math.Pi)) + 1) // (0+1.2246467991473515e-16i)
+
+	// The n n-th roots of 1 are evenly spaced round the unit circle
+	var roots []complex128
+	for k := range 4 {
+		roots = append(roots, cmplx.Rect(1, 2*math.Pi*float64(k)/4))
+	}
+	fmt.Printf("%.0f\n", roots) // [(1+0i) (0+1i) (-1+0i) (-0-1i)]
+
+	// Quadratics: with complex numbers every one has two roots
+	solve := func(a, b, c float64) (complex128, complex128) {
+		d := cmplx.Sqrt(complex(b*b-4*a*c, 0))
+		return (complex(-b, 0) + d) / complex(2*a, 0), (complex(-b, 0) - d) / complex(2*a, 0)
+	}
+	fmt.Println(solve(1, -3, 2)) // (2+0i) (1+0i)
+	fmt.Println(solve(1, 2, 5))  // (-1+2i) (-1-2i): x² + 2x + 5 never touches zero on the real line
+
+	runCase("TestComplex/roots", func(errorf errorfFunc) {
+		for _, q := range [][3]float64{{1, -3, 2}, {1, 2, 5}, {2, 0, 8}, {1, 0, 0}} {
+			x1, x2 := solve(q[0], q[1], q[2])
+			for _, x := range []complex128{x1, x2} {
+				if y := complex(q[0], 0)*x*x + complex(q[1], 0)*x + complex(q[2], 0); cmplx.Abs(y) > 1e-9 {
+					errorf("%vx² + %vx + %v at %v = %v, want 0", q[0], q[1], q[2], x, y)
+				}
+			}
+		}
+	})
+}
+
+func testMathTour() {
+	//// Remainders
+	fmt.Println(7%3, -7%3, 7%-3)                           // 1 -1 1: the sign follows the left side
+	fmt.Println(math.Mod(-7, 3), math.Remainder(-7, 3))    // -1 -1
+	fmt.Println(math.Mod(5, 3), math.Remainder(5, 3))      // 2 -1: 5/3 rounds to 2, and 5 - 2·3 = -1
+	mod := func(a, n int) int { return ((a % n) + n) % n } // what you usually want for wrapping round
+	fmt.Println(mod(-7, 3), mod(-1, 24))                   // 2 23: an hour before midnight is 23
+
+	//// Rounding
+	fmt.Println("x     int  Floor Ceil Trunc Round RoundToEven")
+	for _, x := range []float64{2.5, 3.5, -2.5, 2.7, -2.7} {
+		fmt.Printf("%-5v %-4d %-5v %-4v %-5v %-5v %v\n", x, int(x), math.Floor(x), math.Ceil(x), math.Trunc(x), math.Round(x), math.RoundToEven(x))
+	}
+	// x     int  Floor Ceil Trunc Round RoundToEven
+	// 2.5   2    2     3    2     3     2
+	// 3.5   3    3     4    3     4     4
+	// -2.5  -2   -3    -2   -2    -3    -2
+	// 2.7   2    2     3    2     3     3
+	// -2.7  -2   -3    -2   -2    -3    -3
+	// RoundToEven doesn't drift up when you round lots of halves and add them up
+	// Rounding to 2 places: scale, round, scale back, and the float may still not be exact
+	price := 2.675
+	fmt.Println(math.Round(price*100)/100, fmt.Sprintf("%.2f", price)) // 2.68 2.67
+	// The float64 nearest 2.675 is 2.67499999...: %.2f rounds that exact value down, while
+	// price*100 happens to round up to 267.5 exactly. Two right answers to two different
+	// questions, and a reason to keep money in big.Rat or integer cents (bignum.go)
+
+	//// Infinity and NaN
+	zero := 0.0 // a variable: 1/0 in constants doesn't compile
+	inf, nan := 1/zero, zero/zero
+	fmt.Println(inf, -inf, nan, math.IsInf(inf, 1), math.IsNaN(nan)) // +Inf -Inf NaN true true
+	huge := math.MaxFloat64
+	fmt.Println(huge*2, inf-inf, inf*0)             // +Inf NaN NaN
+	fmt.Println(nan == nan, nan < 1, nan > 1)       // false false false: every comparison with NaN is false
+	fmt.Println(math.Max(1, nan), math.Min(1, nan)) // NaN NaN: NaN wins, with the builtin max and min too
+
+	// NaN breaks things that assume x == x
+	m := map[float64]string{}
+	m[nan] = "a"
+	m[nan] = "b"                      // a second key: the first can't be found, so isn't replaced
+	fmt.Println(len(m), m[nan] == "") // 2 true
+	nums := []float64{3, nan, 1, 2}
+	sort.Float64s(nums)                        // sorts NaN first, as does slices.Sort
+	fmt.Println(nums, slices.Index(nums, nan)) // [NaN 1 2 3] -1: Index uses ==
+
+	//// Useful and less known
+	fmt.Println(math.Hypot(3, 4), math.Cbrt(27), math.Pow(2, 0.5) == math.Sqrt2) // 5 3 true
+	// Log1p and Expm1 keep precision near zero, where 1+x loses x's digits
+	tiny := 1e-17
+	fmt.Println(math.Log(1+tiny), math.Log1p(tiny)) // 0 1e-17
+	// Floats are evenly spaced only between powers of two; Nextafter is the neighbour
+	fmt.Println(math.Nextafter(1, 2)-1, math.Nextafter(1e16, 2e16)-1e16) // 2.220446049250313e-16 2
+	big := 1e16
+	fmt.Println(big+1 == big) // true: at 1e16 the gap is 2
+	// The bits of a float64: sign, 11 bits of exponent, 52 of mantissa
+	fmt.Printf("%064b\n", math.Float64bits(-2))
+	// 1100000000000000000000000000000000000000000000000000000000000000
+	fmt.Println(math.Signbit(math.Copysign(0, -1)), 0.0 == math.Copysign(0, -1)) // true true: -0 exists, and equals 0
+}
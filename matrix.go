@@ -0,0 +1,299 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+//////// Matrices on 2D slices
+// hello.go builds a 2D slice row by row, each row its own allocation, and life.go
+// slices all the rows out of one array. A matrix wants the second: one allocation, and
+// the rows sit next to each other in memory.
+//
+// That matters for multiplication. The textbook loop, for each i, j: sum over k of
+// a[i][k]*b[k][j], walks down a column of b for every result cell, a jump of a whole row
+// at every step, and each jump is likely a cache miss once b is bigger than the cache.
+// Swap the inner two loops (i, k, j) and it reads b and writes the result a row at a time,
+// the order they're laid out in. Same arithmetic, same answer, several times faster on
+// big matrices; see testMatrixBenchmarks.
+//
+// Operations on the wrong shapes return errMatrixShape rather than panicking on an index
+// out of range halfway through.
+
+type matrix [][]float64
+
+func newMatrix(rows, cols int) matrix {
+	cells := make([]float64, rows*cols)
+	m := make(matrix, rows)
+	for i := range m {
+		m[i] = cells[i*cols : (i+1)*cols : (i+1)*cols]
+	}
+	return m
+}
+
+// matrixFrom copies rows into a matrix; they all have to be the same length
+func matrixFrom(rows [][]float64) (matrix, error) {
+	if len(rows) == 0 {
+		return matrix{}, nil
+	}
+	m := newMatrix(len(rows), len(rows[0]))
+	for i, row := range rows {
+		if len(row) != len(rows[0]) {
+			return nil, fmt.Errorf("%w: row %d has %d columns, row 0 has %d", errMatrixShape, i, len(row), len(rows[0]))
+		}
+		copy(m[i], row)
+	}
+	return m, nil
+}
+
+func identityMatrix(n int) matrix {
+	m := newMatrix(n, n)
+	for i := range n {
+		m[i][i] = 1
+	}
+	return m
+}
+
+func (m matrix) rows() int { return len(m) }
+
+func (m matrix) cols() int {
+	if len(m) == 0 {
+		return 0
+	}
+	return len(m[0])
+}
+
+func (m matrix) shape() string { return fmt.Sprintf("%dx%d", m.rows(), m.cols()) }
+
+var errMatrixShape = errors.New("matrix shapes don't fit")
+
+func (m matrix) Add(o matrix) (matrix, error) {
+	if m.rows() != o.rows() || m.cols() != o.cols() {
+		return nil, fmt.Errorf("%w: adding %s and %s", errMatrixShape, m.shape(), o.shape())
+	}
+	sum := newMatrix(m.rows(), m.cols())
+	for i := range m {
+		for j := range m[i] {
+			sum[i][j] = m[i][j] + o[i][j]
+		}
+	}
+	return sum, nil
+}
+
+func (m matrix) Transpose() matrix {
+	t := newMatrix(m.cols(), m.rows())
+	for i := range m {
+		for j, v := range m[i] {
+			t[j][i] = v
+		}
+	}
+	return t
+}
+
+// Multiply is the cache-friendly i, k, j order
+func (m matrix) Multiply(o matrix) (matrix, error) {
+	if m.cols() != o.rows() {
+		return nil, fmt.Errorf("%w: multiplying %s by %s", errMatrixShape, m.shape(), o.shape())
+	}
+	product := newMatrix(m.rows(), o.cols())
+	for i := range m {
+		row := product[i]
+		for k, a := range m[i] {
+			for j, b := range o[k] {
+				row[j] += a * b
+			}
+		}
+	}
+	return product, nil
+}
+
+// multiplyNaive is the textbook i, j, k order, here to be compared with Multiply
+func (m matrix) multiplyNaive(o matrix) (matrix, error) {
+	if m.cols() != o.rows() {
+		return nil, fmt.Errorf("%w: multiplying %s by %s", errMatrixShape, m.shape(), o.shape())
+	}
+	product := newMatrix(m.rows(), o.cols())
+	for i := range m {
+		for j := range o.cols() {
+			var sum float64
+			for k := range m[i] {
+				sum += m[i][k] * o[k][j]
+			}
+			product[i][j] = sum
+		}
+	}
+	return product, nil
+}
+
+// maxDeterminantSize: cofactor expansion does n! multiplications, fine for the small
+// matrices people check by hand and hopeless past about 10. Big ones want LU decomposition
+const maxDeterminantSize = 8
+
+// Determinant expands along the first row: each entry, signs alternating, times the
+// determinant of what's left without its row and column
+func (m matrix) Determinant() (float64, error) {
+	if m.rows() != m.cols() {
+		return 0, fmt.Errorf("%w: a %s matrix has no determinant", errMatrixShape, m.shape())
+	}
+	if m.rows() > maxDeterminantSize {
+		return 0, fmt.Errorf("determinant of a %s matrix: more than %dx%d is too slow this way", m.shape(), maxDeterminantSize, maxDeterminantSize)
+	}
+	return m.det(), nil
+}
+
+func (m matrix) det() float64 {
+	switch len(m) {
+	case 0:
+		return 1
+	case 1:
+		return m[0][0]
+	case 2:
+		return m[0][0]*m[1][1] - m[0][1]*m[1][0]
+	}
+	var d float64
+	sign := 1.0
+	for col, v := range m[0] {
+		if v != 0 {
+			d += sign * v * m.minor(0, col).det()
+		}
+		sign = -sign
+	}
+	return d
+}
+
+// minor is m without row r and column c
+func (m matrix) minor(r, c int) matrix {
+	out := newMatrix(len(m)-1, len(m)-1)
+	for i, oi := 0, 0; i < len(m); i++ {
+		if i == r {
+			continue
+		}
+		copy(out[oi], m[i][:c])
+		copy(out[oi][c:], m[i][c+1:])
+		oi++
+	}
+	return out
+}
+
+func (m matrix) equal(o matrix) bool {
+	if m.rows() != o.rows() || m.cols() != o.cols() {
+		return false
+	}
+	for i := range m {
+		for j := range m[i] {
+			if m[i][j] != o[i][j] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func (m matrix) String() string {
+	var b strings.Builder
+	for _, row := range m {
+		for j, v := range row {
+			if j > 0 {
+				b.WriteByte(' ')
+			}
+			fmt.Fprintf(&b, "%6.4g", v)
+		}
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+func randomMatrix(rows, cols int, r *rand.Rand) matrix {
+	m := newMatrix(rows, cols)
+	for i := range m {
+		for j := range m[i] {
+			m[i][j] = float64(r.IntN(19) - 9) // small integers, so float sums are exact
+		}
+	}
+	return m
+}
+
+func testMatrix() {
+	a, _ := matrixFrom([][]float64{{1, 2, 3}, {4, 5, 6}})
+	b, _ := matrixFrom([][]float64{{7, 8}, {9, 10}, {11, 12}})
+	p, err := a.Multiply(b)
+	fmt.Print(p)
+	fmt.Println(err)
+	//     58     64
+	//    139    154
+	// <nil>
+	fmt.Print(a.Transpose())
+	//      1      4
+	//      2      5
+	//      3      6
+	_, err = a.Multiply(a)
+	fmt.Println(err) // matrix shapes don't fit: multiplying 2x3 by 2x3
+	_, err = a.Add(b)
+	fmt.Println(err) // matrix shapes don't fit: adding 2x3 and 3x2
+	_, err = matrixFrom([][]float64{{1, 2}, {3}})
+	fmt.Println(err) // matrix shapes don't fit: row 1 has 1 columns, row 0 has 2
+
+	sq, _ := matrixFrom([][]float64{{2, 0, 1}, {1, 3, 2}, {1, 1, 2}})
+	fmt.Println(sq.Determinant()) // 6 <nil>: 2·(3·2-2·1) - 0 + 1·(1·1-3·1)
+	fmt.Println(p.Determinant())  // 36 <nil>
+	_, err = a.Determinant()
+	fmt.Println(errors.Is(err, errMatrixShape)) // true
+
+	runCase("TestMatrix/properties", func(errorf errorfFunc) {
+		r := rand.New(rand.NewPCG(1, 2))
+		for n := 1; n <= 6; n++ {
+			x, y := randomMatrix(n, n+1, r), randomMatrix(n+1, n+2, r)
+			xy, _ := x.Multiply(y)
+			naive, _ := x.multiplyNaive(y)
+			if !xy.equal(naive) {
+				errorf("n=%d: Multiply and multiplyNaive disagree", n)
+			}
+			// (xy)ᵀ = yᵀxᵀ
+			yx, _ := y.Transpose().Multiply(x.Transpose())
+			if !xy.Transpose().equal(yx) {
+				errorf("n=%d: (xy)ᵀ != yᵀxᵀ", n)
+			}
+			if id, _ := x.Multiply(identityMatrix(n + 1)); !id.equal(x) {
+				errorf("n=%d: x times the identity isn't x", n)
+			}
+			// det(st) = det(s)·det(t) for square s and t; past 4x4 the products outgrow
+			// the 53 bits a float64 holds exactly, so only the small ones compare equal
+			if n > 4 {
+				continue
+			}
+			s, t := randomMatrix(n, n, r), randomMatrix(n, n, r)
+			st, _ := s.Multiply(t)
+			ds, _ := s.Determinant()
+			dt, _ := t.Determinant()
+			if dst, _ := st.Determinant(); dst != ds*dt {
+				errorf("n=%d: det(st) = %v, det(s)·det(t) = %v", n, dst, ds*dt)
+			}
+		}
+	})
+}
+
+func testMatrixBenchmarks() {
+	r := rand.New(rand.NewPCG(3, 4))
+	for _, n := range []int{64, 512} {
+		x, y := randomMatrix(n, n, r), randomMatrix(n, n, r)
+		printBench(fmt.Sprintf("BenchmarkMultiply/naive/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				m, _ := x.multiplyNaive(y)
+				sinkFloat = m[0][0]
+			}
+		})
+		printBench(fmt.Sprintf("BenchmarkMultiply/ikj/%d", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				m, _ := x.Multiply(y)
+				sinkFloat = m[0][0]
+			}
+		})
+	}
+	// BenchmarkMultiply/naive/64     ~250 µs/op
+	// BenchmarkMultiply/ikj/64       ~210 µs/op  <- 32KB each, everything fits in cache either way
+	// BenchmarkMultiply/naive/512    ~600 ms/op
+	// BenchmarkMultiply/ikj/512      ~130 ms/op  <- 2MB each, and the column walk misses
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//////// Metrics
+// Logs tell you what happened to ONE request; metrics tell you how the whole thing is
+// doing: counters that only go up (requests served), gauges that go up and down
+// (requests in flight), and sums you divide by a count (average latency).
+//
+// expvar is the standard library's take: publish variables by name and they appear
+// as JSON at /debug/vars. Prometheus, the usual monitoring system, scrapes a plain
+// text format from /metrics instead:
+//
+//	# HELP hellogo_http_requests_total HTTP requests served.
+//	# TYPE hellogo_http_requests_total counter
+//	hellogo_http_requests_total{method="GET",code="200"} 7
+//
+// Both are served by hellogo serve, from the same variables. The real client library
+// (prometheus/client_golang) adds histograms and a registry, but the idea is this.
+
+var (
+	lessonsRun        = expvar.NewInt("lessons_run_total")
+	lessonRuns        = expvar.NewMap("lesson_runs_total") // keyed by lesson
+	quizResults       = expvar.NewInt("quiz_results_total")
+	quizScoreSum      = expvar.NewInt("quiz_score_sum")
+	httpRequests      = expvar.NewMap("http_requests_total") // keyed "METHOD code"
+	httpInFlight      = expvar.NewInt("http_requests_in_flight")
+	httpDurationTotal = expvar.NewFloat("http_request_duration_seconds_sum")
+)
+
+type metricDef struct {
+	name   string
+	help   string
+	kind   string // counter or gauge
+	v      expvar.Var
+	labels []string // label names, for *expvar.Map vars whose keys hold the values
+}
+
+var metricDefs = []metricDef{
+	{"lessons_run_total", "Lessons run by hellogo run, verify or serve.", "counter", lessonsRun, nil},
+	{"lesson_runs_total", "Lessons run, by lesson.", "counter", lessonRuns, []string{"lesson"}},
+	{"quiz_results_total", "Quiz results saved.", "counter", quizResults, nil},
+	{"quiz_score_sum", "Sum of saved quiz scores; divide by quiz_results_total for the average.", "counter", quizScoreSum, nil},
+	{"http_requests_total", "HTTP requests served.", "counter", httpRequests, []string{"method", "code"}},
+	{"http_requests_in_flight", "HTTP requests being served right now.", "gauge", httpInFlight, nil},
+	{"http_request_duration_seconds_sum", "Total time spent serving HTTP requests.", "counter", httpDurationTotal, nil},
+}
+
+// countLessonRun counts a run of name. The lesson's own count goes up first, so whatever
+// sees lessons_run_total change (the leaderboard in sse.go) sees the new count with it
+func countLessonRun(name string) {
+	lessonRuns.Add(name, 1)
+	lessonsRun.Add(1)
+}
+
+// writePrometheus writes every metric in the Prometheus text exposition format
+func writePrometheus(w io.Writer) {
+	for _, m := range metricDefs {
+		name := "hellogo_" + m.name
+		fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", name, m.help, name, m.kind)
+		labelled, ok := m.v.(*expvar.Map)
+		if !ok {
+			fmt.Fprintf(w, "%s %s\n", name, m.v.String())
+			continue
+		}
+		labelled.Do(func(kv expvar.KeyValue) { // Do goes through keys in sorted order
+			values := strings.Split(kv.Key, " ")
+			pairs := make([]string, len(m.labels))
+			for i, label := range m.labels {
+				pairs[i] = label + "=" + strconv.Quote(values[i])
+			}
+			fmt.Fprintf(w, "%s{%s} %s\n", name, strings.Join(pairs, ","), kv.Value.String())
+		})
+	}
+}
+
+func metricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	writePrometheus(w)
+}
+
+// withMetrics counts requests by method and status, and how long they took
+func withMetrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpInFlight.Add(1)
+		defer httpInFlight.Add(-1)
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		httpRequests.Add(r.Method+" "+strconv.Itoa(rec.status), 1)
+		httpDurationTotal.Add(time.Since(start).Seconds())
+	})
+}
+
+// meteredStore counts quiz results on their way into any store
+type meteredStore struct {
+	store
+}
+
+func (s meteredStore) SaveQuizResult(ctx context.Context, r quizResult) error {
+	if err := s.store.SaveQuizResult(ctx, r); err != nil {
+		return err
+	}
+	quizResults.Add(1)
+	quizScoreSum.Add(int64(r.Score))
+	return nil
+}
+
+func testMetrics() {
+	h := standardMiddleware(serveRoutes())
+	for _, target := range []string{"/lessons", "/lessons", "/lessons/nope.go", "/metrics"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", target, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	for _, line := range strings.Split(rec.Body.String(), "\n") {
+		if strings.HasPrefix(line, "hellogo_http_requests_total") {
+			fmt.Println(line)
+		}
+	}
+	// hellogo_http_requests_total{method="GET",code="200"} 3
+	// hellogo_http_requests_total{method="GET",code="404"} 1
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/debug/vars", nil))
+	fmt.Println(strings.Contains(rec.Body.String(), `"http_requests_total": {"GET 200": `)) // true
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"time"
+)
+
+//////// HTTP middleware
+// A middleware is a func(http.Handler) http.Handler: it gets the next handler and
+// returns a new one that does something before and/or after calling it.
+// Same shape as gzipHandler in compression.go
+
+type middleware func(http.Handler) http.Handler
+
+// chain applies middlewares so the first one listed is the outermost:
+// chain(h, a, b) == a(b(h)), a request goes through a, then b, then h
+func chain(h http.Handler, mws ...middleware) http.Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+	return h
+}
+
+//// Request IDs
+// Stored in the request's context so any handler (or log line) further down can get it
+
+type requestIDKey struct{} // unexported type, so no other package can clash with our key
+
+func withRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("X-Request-Id")
+		if id == "" {
+			id = secureToken(8)
+		}
+		w.Header().Set("X-Request-Id", id)
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func requestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+//// Logging and timing
+// The status code isn't returned by ServeHTTP, so wrap the ResponseWriter to catch it
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap lets http.ResponseController reach the real writer (for Flush etc.)
+func (s *statusRecorder) Unwrap() http.ResponseWriter {
+	return s.ResponseWriter
+}
+
+func withLogging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		slog.Info("request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration", time.Since(start),
+			"request_id", requestID(r.Context()))
+	})
+}
+
+// withTiming adds a Server-Timing header, which browser dev tools display.
+// Headers have to go out before the body, so this can only time up to the first write
+func withTiming(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(&timingWriter{ResponseWriter: w, start: start}, r)
+	})
+}
+
+type timingWriter struct {
+	http.ResponseWriter
+	start       time.Time
+	wroteHeader bool
+}
+
+func (t *timingWriter) WriteHeader(code int) {
+	if !t.wroteHeader {
+		t.wroteHeader = true
+		ms := float64(time.Since(t.start).Microseconds()) / 1000
+		t.Header().Set("Server-Timing", fmt.Sprintf("app;dur=%.2f", ms))
+	}
+	t.ResponseWriter.WriteHeader(code)
+}
+
+func (t *timingWriter) Write(b []byte) (int, error) {
+	if !t.wroteHeader {
+		t.WriteHeader(http.StatusOK)
+	}
+	return t.ResponseWriter.Write(b)
+}
+
+func (t *timingWriter) Unwrap() http.ResponseWriter {
+	return t.ResponseWriter
+}
+
+//// Recovery
+// net/http already recovers panics per request, but it just drops the connection.
+// This turns them into a proper 500 and a log line
+
+func withRecovery(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if p := recover(); p != nil {
+				if p == http.ErrAbortHandler { // deliberate abort, let net/http have it
+					panic(p)
+				}
+				slog.Error("handler panicked", "panic", p, "path", r.URL.Path, "request_id", requestID(r.Context()))
+				http.Error(w, "internal server error", http.StatusInternalServerError)
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// The stack every hellogo server uses. Order matters: request IDs first so everything
+// else can log them, recovery innermost so a panic is still logged with its 500
+func standardMiddleware(h http.Handler) http.Handler {
+	return chain(h, withRequestID, withLogging, withMetrics, withTiming, withRecovery)
+}
+
+func testMiddleware() {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "hello, request", requestID(r.Context()))
+	})
+	mux.HandleFunc("/boom", func(w http.ResponseWriter, r *http.Request) {
+		panic("something broke")
+	})
+	h := standardMiddleware(mux)
+
+	// httptest.NewRecorder lets us call a handler without any network at all
+	for _, path := range []string{"/ok", "/boom", "/missing"} {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.Header.Set("X-Request-Id", "lesson-"+path[1:])
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		fmt.Println(path, rec.Code, rec.Header().Get("X-Request-Id"), rec.Header().Get("Server-Timing"))
+	}
+}
@@ -0,0 +1,243 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+)
+
+//////// Database migrations
+// CREATE TABLE IF NOT EXISTS works until the day a table needs a new column.
+// Migrations are numbered schema changes, each with an up (apply) and a down (undo).
+// The database remembers which ones it has in a schema_versions table, so upgrading
+// any old copy is just "apply everything after the version it's at".
+//
+// Rules of thumb: never edit a migration once it has shipped (add a new one),
+// and run each one in a transaction so a failure leaves no half applied schema.
+// sqlite can roll back CREATE/DROP TABLE too; not every database can.
+
+type migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// storeMigrations builds the sqlStore schema, in order. Only ever append to this
+var storeMigrations = []migration{
+	{1, "progress", `
+		CREATE TABLE progress (
+			lesson    TEXT PRIMARY KEY,
+			completed INTEGER NOT NULL,
+			attempts  INTEGER NOT NULL,
+			last_seen INTEGER          -- NULL if never opened
+		)`,
+		`DROP TABLE progress`},
+	{2, "quiz results", `
+		CREATE TABLE quiz_results (
+			id       INTEGER PRIMARY KEY,
+			lesson   TEXT NOT NULL,
+			score    INTEGER NOT NULL,
+			taken_at INTEGER NOT NULL,
+			comment  TEXT              -- NULL if none
+		)`,
+		`DROP TABLE quiz_results`},
+	{3, "achievements", `
+		CREATE TABLE achievements (
+			id          INTEGER PRIMARY KEY,
+			name        TEXT NOT NULL UNIQUE,
+			unlocked_at INTEGER NOT NULL
+		)`,
+		`DROP TABLE achievements`},
+	{4, "todos", `
+		CREATE TABLE todos (
+			id      INTEGER PRIMARY KEY, -- without AUTOINCREMENT sqlite reuses the highest id + 1
+			title   TEXT NOT NULL,
+			done    INTEGER NOT NULL,
+			created INTEGER NOT NULL
+		)`,
+		`DROP TABLE todos`},
+	{5, "links", `
+		CREATE TABLE links (
+			id      INTEGER PRIMARY KEY AUTOINCREMENT, -- AUTOINCREMENT: ids are never reused
+			url     TEXT NOT NULL,
+			created INTEGER NOT NULL,
+			visits  INTEGER NOT NULL DEFAULT 0
+		)`,
+		`DROP TABLE links`},
+	// submission_id is a ULID, NULL on older rows. No -- comment in the SQL here: sqlite
+	// keeps the column definition as written, and a comment at the end of it breaks the
+	// CREATE TABLE it saves into the schema
+	{6, "quiz submission ids", `
+		ALTER TABLE quiz_results ADD COLUMN submission_id TEXT`,
+		`ALTER TABLE quiz_results DROP COLUMN submission_id`},
+}
+
+func schemaVersion(ctx context.Context, db *sql.DB) (int, error) {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_versions (
+			version    INTEGER PRIMARY KEY,
+			applied_at INTEGER NOT NULL
+		)`)
+	if err != nil {
+		return 0, err
+	}
+	var version int
+	// MAX of no rows is NULL, COALESCE turns that into 0
+	err = db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_versions`).Scan(&version)
+	return version, err
+}
+
+// migrateTo moves the schema up or down to target, one transaction per migration,
+// calling done after each step. It stops at the first failure
+func migrateTo(ctx context.Context, db *sql.DB, ms []migration, target int, done func(m migration, up bool)) error {
+	if target < 0 || target > len(ms) {
+		return fmt.Errorf("no schema version %d, latest is %d", target, len(ms))
+	}
+	current, err := schemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	if current > len(ms) {
+		return fmt.Errorf("database is at version %d, newer than this program knows (%d)", current, len(ms))
+	}
+
+	for current != target {
+		// Version n lives at index n-1: going up applies the one after current, going
+		// down reverts current itself
+		up := current < target
+		var m migration
+		if up {
+			m = ms[current]
+		} else {
+			m = ms[current-1]
+		}
+		if err := applyMigration(ctx, db, m, up); err != nil {
+			return fmt.Errorf("migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		if done != nil {
+			done(m, up)
+		}
+		if up {
+			current++
+		} else {
+			current--
+		}
+	}
+	return nil
+}
+
+func applyMigration(ctx context.Context, db *sql.DB, m migration, up bool) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	script, bookkeeping, args := m.Up, `INSERT INTO schema_versions (version, applied_at) VALUES (?, ?)`, []any{m.Version, time.Now().Unix()}
+	if !up {
+		script, bookkeeping, args = m.Down, `DELETE FROM schema_versions WHERE version = ?`, []any{m.Version}
+	}
+	if _, err := tx.ExecContext(ctx, script); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, bookkeeping, args...); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// hellogo migrate [status|up|down] [version]
+// up goes to the latest version (or the one given), down undoes one step (or goes to the one given)
+func migrateCommand(args []string) error {
+	if len(args) > 2 {
+		return errUsage
+	}
+	action := "status"
+	if len(args) > 0 {
+		action = args[0]
+	}
+	if (action != "status" && action != "up" && action != "down") || (action == "status" && len(args) > 1) {
+		return errUsage
+	}
+
+	cfg, err := loadConfig(configFile)
+	if err != nil {
+		return err
+	}
+	if cfg.Storage.Backend != "sqlite" {
+		return fmt.Errorf("storage.backend is %q, migrations only apply to sqlite", cfg.Storage.Backend)
+	}
+	path := cfg.Storage.Path
+	if path == "" {
+		path = storeOpeners["sqlite"].defaultPath
+	}
+
+	ctx := context.Background()
+	db, err := openSQLite(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	current, err := schemaVersion(ctx, db)
+	if err != nil {
+		return err
+	}
+	var target int
+	switch action {
+	case "status":
+		fmt.Printf("%s is at version %d of %d\n", path, current, len(storeMigrations))
+		return nil
+	case "up":
+		target = len(storeMigrations)
+	case "down":
+		target = max(current-1, 0)
+	}
+	if len(args) > 1 {
+		if target, err = strconv.Atoi(args[1]); err != nil {
+			return errUsage
+		}
+	}
+	if (action == "up" && target < current) || (action == "down" && target > current) {
+		return fmt.Errorf("can't go %s from version %d to %d", action, current, target)
+	}
+
+	return migrateTo(ctx, db, storeMigrations, target, func(m migration, up bool) {
+		direction := "applied"
+		if !up {
+			direction = "reverted"
+		}
+		fmt.Printf("%s %d %s\n", direction, m.Version, m.Name)
+	})
+}
+
+func testMigrations() {
+	dir := makeTempDir("migrate")
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+
+	db, err := openSQLite(ctx, filepath.Join(dir, "hellogo.db"))
+	if err != nil {
+		fmt.Println("open failed:", err)
+		return
+	}
+	defer db.Close()
+
+	report := func(m migration, up bool) { fmt.Println(" ", up, m.Version, m.Name) }
+
+	fmt.Println(migrateTo(ctx, db, storeMigrations, 2, report)) // true 1 progress, true 2 quiz results, <nil>
+	fmt.Println(schemaVersion(ctx, db))                         // 2 <nil>
+	fmt.Println(migrateTo(ctx, db, storeMigrations, 3, report)) // only the new one: true 3 achievements
+	fmt.Println(migrateTo(ctx, db, storeMigrations, 1, report)) // false 3 achievements, false 2 quiz results
+	fmt.Println(migrateTo(ctx, db, storeMigrations, 9, report)) // no schema version 9, latest is 6
+
+	// A broken migration rolls back completely, the version doesn't move
+	broken := append(storeMigrations[:1:1], migration{2, "oops", `CREATE TABLE quiz_results (`, ``})
+	fmt.Println(migrateTo(ctx, db, broken, 2, report)) // migration 2 (oops): SQL logic error: incomplete input (1)
+	fmt.Println(schemaVersion(ctx, db))                // 1 <nil>
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+// All the way up and all the way back down, one version at a time, including the
+// step down from the latest version
+func TestMigrateUpAndDown(t *testing.T) {
+	ctx := context.Background()
+	db, err := openSQLite(ctx, filepath.Join(t.TempDir(), "hellogo.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	latest := len(storeMigrations)
+	var steps []int
+	for v := 1; v <= latest; v++ {
+		steps = append(steps, v)
+	}
+	for v := latest - 1; v >= 0; v-- {
+		steps = append(steps, v)
+	}
+	for _, target := range steps {
+		if err := migrateTo(ctx, db, storeMigrations, target, nil); err != nil {
+			t.Fatalf("migrating to %d: %v", target, err)
+		}
+		if got, err := schemaVersion(ctx, db); err != nil || got != target {
+			t.Fatalf("after migrating to %d the schema is at %d, %v", target, got, err)
+		}
+	}
+
+	// And in one go each way
+	for _, target := range []int{latest, 0} {
+		if err := migrateTo(ctx, db, storeMigrations, target, nil); err != nil {
+			t.Fatalf("migrating to %d: %v", target, err)
+		}
+	}
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+//////// Mini project: a grep clone
+// hellogo minigrep [-i] [-n] [-r] [-E] pattern [path...]
+//   -i  ignore case
+//   -n  show line numbers
+//   -r  search directories recursively (filepath.WalkDir, see paths.go); hidden ones are skipped
+//   -E  pattern is a regexp instead of a plain string
+// With no paths it reads stdin, so it works at the end of a pipe too.
+// Files are searched concurrently, but the output still comes out in the order the files
+// were given: every file writes into its own buffer and the buffers are printed in turn.
+// The output is checked against testdata/golden/minigrep.golden: hellogo verify minigrep.
+
+type grepOptions struct {
+	ignoreCase  bool
+	lineNumbers bool
+	recursive   bool
+	regex       bool
+}
+
+// errNoMatch is grep's exit status 1: nothing went wrong, nothing matched either
+var errNoMatch = errors.New("no lines matched")
+
+type minigrep struct {
+	opts   grepOptions
+	match  func(line string) bool
+	out    io.Writer
+	errOut io.Writer // unreadable files are reported here and skipped, like grep does
+}
+
+func newMinigrep(pattern string, opts grepOptions, out, errOut io.Writer) (*minigrep, error) {
+	g := &minigrep{opts: opts, out: out, errOut: errOut}
+	switch {
+	case opts.regex:
+		if opts.ignoreCase {
+			pattern = "(?i)" + pattern // regexp's flag syntax, no separate option needed
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, err
+		}
+		g.match = re.MatchString
+	case opts.ignoreCase:
+		// Lowering both sides is good enough for a lesson; it misses a few Unicode
+		// cases (the Kelvin sign K folds to k) that (?i) gets right
+		lower := strings.ToLower(pattern)
+		g.match = func(line string) bool { return strings.Contains(strings.ToLower(line), lower) }
+	default:
+		g.match = func(line string) bool { return strings.Contains(line, pattern) }
+	}
+	return g, nil
+}
+
+// searchReader writes r's matching lines to w, prefixed with "name:" unless name is empty
+func (g *minigrep) searchReader(r io.Reader, name string, w io.Writer) (int, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20) // lines up to 1MB, see fileread.go for the default limit
+	matches := 0
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if !g.match(line) {
+			continue
+		}
+		matches++
+		if name != "" {
+			fmt.Fprint(w, name, ":")
+		}
+		if g.opts.lineNumbers {
+			fmt.Fprint(w, n, ":")
+		}
+		fmt.Fprintln(w, line)
+	}
+	return matches, scanner.Err()
+}
+
+// expand turns the paths into the files to search, walking directories with -r
+func (g *minigrep) expand(paths []string) ([]string, bool) {
+	var files []string
+	ok := true
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			fmt.Fprintln(g.errOut, "minigrep:", err)
+			ok = false
+			continue
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+		if !g.opts.recursive {
+			fmt.Fprintf(g.errOut, "minigrep: %s: is a directory\n", path)
+			ok = false
+			continue
+		}
+		err = filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				fmt.Fprintln(g.errOut, "minigrep:", err)
+				ok = false
+				return nil // carry on with the rest of the tree
+			}
+			if d.IsDir() && p != path && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			if d.Type().IsRegular() {
+				files = append(files, p)
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(g.errOut, "minigrep:", err)
+			ok = false
+		}
+	}
+	return files, ok
+}
+
+// run searches paths, or stdin when there are none; errNoMatch if nothing matched
+func (g *minigrep) run(paths []string, stdin io.Reader) error {
+	if len(paths) == 0 {
+		n, err := g.searchReader(stdin, "", g.out)
+		if err == nil && n == 0 {
+			err = errNoMatch
+		}
+		return err
+	}
+
+	files, ok := g.expand(paths)
+	showNames := len(paths) > 1 || g.opts.recursive // grep names files whenever more than one could match
+
+	type result struct {
+		out     bytes.Buffer
+		matches int
+		err     error
+	}
+	results := make([]result, len(files))
+	sem := make(chan struct{}, runtime.NumCPU()) // no point opening more files than we can search
+	var wg sync.WaitGroup
+	for i, file := range files {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			res := &results[i] // each goroutine only touches its own element, so no lock
+			f, err := os.Open(file)
+			if err != nil {
+				res.err = err
+				return
+			}
+			defer f.Close()
+			name := ""
+			if showNames {
+				name = file
+			}
+			res.matches, res.err = g.searchReader(f, name, &res.out)
+		}()
+	}
+	wg.Wait()
+
+	matches := 0
+	for i := range results {
+		if results[i].err != nil {
+			fmt.Fprintln(g.errOut, "minigrep:", results[i].err)
+			ok = false
+		}
+		matches += results[i].matches
+		if _, err := results[i].out.WriteTo(g.out); err != nil {
+			return err
+		}
+	}
+	switch {
+	case !ok:
+		return errors.New("some files couldn't be searched")
+	case matches == 0:
+		return errNoMatch
+	}
+	return nil
+}
+
+// parseGrepArgs splits [flags] pattern [path...]; like grep, flags go before the pattern
+func parseGrepArgs(args []string) (opts grepOptions, pattern string, paths []string, err error) {
+	flags := flag.NewFlagSet("minigrep", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.BoolVar(&opts.ignoreCase, "i", false, "ignore case")
+	flags.BoolVar(&opts.lineNumbers, "n", false, "show line numbers")
+	flags.BoolVar(&opts.recursive, "r", false, "search directories recursively")
+	flags.BoolVar(&opts.regex, "E", false, "the pattern is a regular expression")
+	if err := flags.Parse(args); err != nil || flags.NArg() == 0 {
+		return opts, "", nil, errUsage
+	}
+	return opts, flags.Arg(0), flags.Args()[1:], nil
+}
+
+// hellogo minigrep [-i] [-n] [-r] [-E] pattern [path...]
+func minigrepCommand(args []string) error {
+	opts, pattern, paths, err := parseGrepArgs(args)
+	if err != nil {
+		return err
+	}
+	g, err := newMinigrep(pattern, opts, os.Stdout, os.Stderr)
+	if err != nil {
+		return err
+	}
+	return g.run(paths, os.Stdin)
+}
+
+// testMinigrep runs from the repo root, against the files in testdata/minigrep
+func testMinigrep() {
+	const dir = "testdata/minigrep"
+	cases := []struct {
+		args  []string
+		stdin string
+	}{
+		{[]string{"gopher", dir + "/gopher.txt"}, ""},
+		{[]string{"-i", "gopher", dir + "/gopher.txt"}, ""},
+		{[]string{"-n", "memory", dir + "/proverbs.md"}, ""},
+		{[]string{"-r", "-n", "gopher", dir}, ""},
+		{[]string{"-E", "-i", `^(don't|errors)\b`, dir + "/proverbs.md"}, ""},
+		{[]string{"-E", `func \w+\(`, dir + "/src/hello.go", dir + "/gopher.txt"}, ""},
+		{[]string{"-n", "go"}, "stop\ngo\ngopher\n"},
+		{[]string{"gopher", dir}, ""},
+		{[]string{"gopher", dir + "/missing.txt", dir + "/gopher.txt"}, ""},
+		{[]string{"rust", dir + "/gopher.txt"}, ""},
+		{[]string{"-E", "(", dir}, ""},
+	}
+	for _, tc := range cases {
+		fmt.Println("$ minigrep", strings.Join(tc.args, " "))
+		opts, pattern, paths, err := parseGrepArgs(tc.args)
+		var g *minigrep
+		if err == nil {
+			g, err = newMinigrep(pattern, opts, os.Stdout, os.Stdout) // errors inline, so they're in the golden file too
+		}
+		if err == nil {
+			err = g.run(paths, strings.NewReader(tc.stdin))
+		}
+		if err != nil {
+			fmt.Println("error:", err)
+		}
+	}
+	// $ minigrep -r -n gopher testdata/minigrep
+	// testdata/minigrep/gopher.txt:1:The Go gopher was drawn by Renee French.
+	// testdata/minigrep/gopher.txt:3:Nobody knows how many gophers there are.
+	// testdata/minigrep/src/hello.go:6:	fmt.Println("hello, gopher")
+	// ...the rest is in testdata/golden/minigrep.golden
+}
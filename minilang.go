@@ -0,0 +1,873 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+//////// Mini project: a tiny interpreted language
+// calc.go grown into a language with variables, if/else, while loops and functions.
+// It keeps the calculator's tokens, AST nodes and parser helpers and adds statements
+// on top; running a program is still a walk over the tree with type switches, just
+// with an environment (the variables in scope) threaded through instead of one map.
+//
+//	program   = { statement }
+//	statement = "let" name "=" expr ";"
+//	          | name "=" expr ";"                    the name must already be declared
+//	          | "if" expr block [ "else" ( block | if statement ) ]
+//	          | "while" expr block
+//	          | "fn" name "(" [ name { "," name } ] ")" block
+//	          | "return" [ expr ] ";"
+//	          | expr ";"
+//	block     = "{" { statement } "}"                 each block is a new scope
+//	expr      = and { "||" and }
+//	and       = compare { "&&" compare }
+//	compare   = sum [ ( "==" | "!=" | "<" | "<=" | ">" | ">=" ) sum ]
+//	sum       = term { ("+" | "-") term }             then term, unary and power as in calc.go,
+//	call      = primary { "(" [ expr { "," expr } ] ")" }  with "!" as another unary
+//	primary   = number | string | name | "(" expr ")"
+//
+// Values are numbers, strings, functions and nil. Comparisons give 1 or 0, and 0, ""
+// and nil count as false. Functions close over the scope they're defined in, so a
+// function can return another one that keeps its own counter (see the example).
+// hellogo minilang testdata/minilang/example.mini runs a program, # starts a comment.
+
+// mlString is the one token kind the calculator didn't need
+const mlString = calcOp + 1
+
+// Two byte operators come first so == isn't read as = then =
+var mlOperators = []string{
+	"==", "!=", "<=", ">=", "&&", "||",
+	"+", "-", "*", "/", "%", "^", "(", ")", "{", "}", ",", ";", "=", "<", ">", "!",
+}
+
+var mlKeywords = map[string]bool{"let": true, "if": true, "else": true, "while": true, "fn": true, "return": true}
+
+// lexMinilang is lexCalc plus newlines, comments, strings and the extra operators
+func lexMinilang(src string) ([]calcToken, error) {
+	var tokens []calcToken
+	for i := 0; i < len(src); {
+		c := src[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '#':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+		case c == '"':
+			quoted, err := strconv.QuotedPrefix(src[i:])
+			if err != nil {
+				return nil, &calcError{i, "unterminated or bad string"}
+			}
+			s, _ := strconv.Unquote(quoted)
+			tokens = append(tokens, calcToken{mlString, s, i})
+			i += len(quoted)
+		case isCalcNumberStart(c):
+			start := i
+			i = scanCalcNumber(src, i)
+			tokens = append(tokens, calcToken{calcNumber, src[start:i], start})
+		case isCalcNameByte(c):
+			start := i
+			for i < len(src) && isCalcNameByte(src[i]) {
+				i++
+			}
+			tokens = append(tokens, calcToken{calcName, src[start:i], start})
+		default:
+			op := ""
+			for _, candidate := range mlOperators {
+				if strings.HasPrefix(src[i:], candidate) {
+					op = candidate
+					break
+				}
+			}
+			if op == "" {
+				return nil, &calcError{i, fmt.Sprintf("unexpected %q", rune(c))}
+			}
+			tokens = append(tokens, calcToken{calcOp, op, i})
+			i += len(op)
+		}
+	}
+	return append(tokens, calcToken{calcEOF, "", len(src)}), nil
+}
+
+//// The AST
+// Expressions are calcNodes: the calculator's nodes plus strings and calls. Assignment
+// reuses assignNode, which here only updates a variable that let already declared.
+// Statements remember where they start, so runtime errors can say which line failed.
+
+type (
+	stringNode struct{ value string }
+	callNode   struct {
+		fn   calcNode
+		args []calcNode
+	}
+)
+
+func (n stringNode) String() string { return strconv.Quote(n.value) }
+func (n callNode) String() string {
+	args := make([]string, len(n.args))
+	for i, arg := range n.args {
+		args[i] = arg.String()
+	}
+	return n.fn.String() + "(" + strings.Join(args, ", ") + ")"
+}
+
+type mlStmt interface {
+	pos() int
+}
+
+// mlPos is embedded in every statement to implement mlStmt
+type mlPos int
+
+func (p mlPos) pos() int { return int(p) }
+
+type (
+	letStmt struct {
+		mlPos
+		name  string
+		value calcNode
+	}
+	exprStmt struct {
+		mlPos
+		expr calcNode
+	}
+	ifStmt struct {
+		mlPos
+		cond      calcNode
+		then, els []mlStmt
+	}
+	whileStmt struct {
+		mlPos
+		cond calcNode
+		body []mlStmt
+	}
+	fnStmt struct {
+		mlPos
+		name   string
+		params []string
+		body   []mlStmt
+	}
+	returnStmt struct {
+		mlPos
+		value calcNode // nil for a bare return
+	}
+)
+
+//// The parser
+// mlParser embeds calcParser for peek, next, accept and binaryLevel. Its own expr, term,
+// unary, power and primary shadow the calculator's, one level per line of the grammar.
+
+type mlParser struct {
+	calcParser
+}
+
+func parseMinilang(src string) ([]mlStmt, error) {
+	tokens, err := lexMinilang(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &mlParser{calcParser{tokens: tokens}}
+	var program []mlStmt
+	for p.peek().kind != calcEOF {
+		stmt, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		program = append(program, stmt)
+	}
+	return program, nil
+}
+
+// expect consumes op, or fails pointing at whatever is there instead
+func (p *mlParser) expect(op string) error {
+	if _, ok := p.accept(op); ok {
+		return nil
+	}
+	tok := p.peek()
+	if tok.kind == calcEOF {
+		return &calcError{tok.pos, fmt.Sprintf("expected %q, got end of input", op)}
+	}
+	return &calcError{tok.pos, fmt.Sprintf("expected %q, got %q", op, tok.text)}
+}
+
+// keyword consumes the next token if it's the keyword word
+func (p *mlParser) keyword(word string) bool {
+	if tok := p.peek(); tok.kind == calcName && tok.text == word {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *mlParser) name() (string, error) {
+	tok := p.next()
+	if tok.kind != calcName || mlKeywords[tok.text] {
+		return "", &calcError{tok.pos, fmt.Sprintf("expected a name, got %q", tok.text)}
+	}
+	return tok.text, nil
+}
+
+func (p *mlParser) statement() (mlStmt, error) {
+	at := mlPos(p.peek().pos)
+	switch {
+	case p.keyword("let"):
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		value, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		return letStmt{at, name, value}, p.expect(";")
+	case p.keyword("if"):
+		return p.ifStatement(at)
+	case p.keyword("while"):
+		cond, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		body, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+		return whileStmt{at, cond, body}, nil
+	case p.keyword("fn"):
+		return p.fnStatement(at)
+	case p.keyword("return"):
+		if _, ok := p.accept(";"); ok {
+			return returnStmt{at, nil}, nil
+		}
+		value, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		return returnStmt{at, value}, p.expect(";")
+	}
+
+	// An assignment starts out looking like an expression: only the = after x says otherwise
+	expr, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("="); ok {
+		target, ok := expr.(nameNode)
+		if !ok {
+			return nil, &calcError{int(at), fmt.Sprintf("can't assign to %s", expr)}
+		}
+		value, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		expr = assignNode{target.name, value}
+	}
+	return exprStmt{at, expr}, p.expect(";")
+}
+
+// ifStatement parses what follows "if"; else if is an else block holding one if statement
+func (p *mlParser) ifStatement(at mlPos) (mlStmt, error) {
+	cond, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	then, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	stmt := ifStmt{mlPos: at, cond: cond, then: then}
+	if !p.keyword("else") {
+		return stmt, nil
+	}
+	if elseAt := mlPos(p.peek().pos); p.keyword("if") {
+		elseIf, err := p.ifStatement(elseAt)
+		if err != nil {
+			return nil, err
+		}
+		stmt.els = []mlStmt{elseIf}
+		return stmt, nil
+	}
+	stmt.els, err = p.block()
+	return stmt, err
+}
+
+func (p *mlParser) fnStatement(at mlPos) (mlStmt, error) {
+	name, err := p.name()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var params []string
+	if _, ok := p.accept(")"); !ok {
+		for {
+			param, err := p.name()
+			if err != nil {
+				return nil, err
+			}
+			params = append(params, param)
+			if _, ok := p.accept(","); !ok {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+	}
+	body, err := p.block()
+	if err != nil {
+		return nil, err
+	}
+	return fnStmt{at, name, params, body}, nil
+}
+
+func (p *mlParser) block() ([]mlStmt, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	var stmts []mlStmt
+	for {
+		if _, ok := p.accept("}"); ok {
+			return stmts, nil
+		}
+		if tok := p.peek(); tok.kind == calcEOF {
+			return nil, &calcError{tok.pos, "missing }"}
+		}
+		stmt, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		stmts = append(stmts, stmt)
+	}
+}
+
+func (p *mlParser) expr() (calcNode, error) { return p.binaryLevel(p.and, "||") }
+func (p *mlParser) and() (calcNode, error)  { return p.binaryLevel(p.compare, "&&") }
+
+// compare doesn't loop: a < b < c is a syntax error rather than a surprise
+func (p *mlParser) compare() (calcNode, error) {
+	left, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	op, ok := p.accept("==", "!=", "<=", ">=", "<", ">")
+	if !ok {
+		return left, nil
+	}
+	right, err := p.sum()
+	if err != nil {
+		return nil, err
+	}
+	return binaryNode{op, left, right}, nil
+}
+
+func (p *mlParser) sum() (calcNode, error)  { return p.binaryLevel(p.term, "+", "-") }
+func (p *mlParser) term() (calcNode, error) { return p.binaryLevel(p.unary, "*", "/", "%") }
+
+func (p *mlParser) unary() (calcNode, error) {
+	if op, ok := p.accept("-", "!"); ok {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return unaryNode{op, operand}, nil
+	}
+	return p.power()
+}
+
+func (p *mlParser) power() (calcNode, error) {
+	base, err := p.call()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := p.accept("^"); !ok {
+		return base, nil
+	}
+	exp, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	return binaryNode{"^", base, exp}, nil
+}
+
+func (p *mlParser) call() (calcNode, error) {
+	fn, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		if _, ok := p.accept("("); !ok {
+			return fn, nil
+		}
+		var args []calcNode
+		if _, ok := p.accept(")"); !ok {
+			for {
+				arg, err := p.expr()
+				if err != nil {
+					return nil, err
+				}
+				args = append(args, arg)
+				if _, ok := p.accept(","); !ok {
+					break
+				}
+			}
+			if err := p.expect(")"); err != nil {
+				return nil, err
+			}
+		}
+		fn = callNode{fn, args}
+	}
+}
+
+func (p *mlParser) primary() (calcNode, error) {
+	tok := p.peek()
+	switch {
+	case tok.kind == mlString:
+		p.next()
+		return stringNode{tok.text}, nil
+	case tok.kind == calcName && mlKeywords[tok.text]:
+		return nil, &calcError{tok.pos, fmt.Sprintf("unexpected %s", tok.text)}
+	case tok.kind == calcOp && tok.text == "(":
+		p.next()
+		inner, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		return inner, p.expect(")")
+	}
+	return p.calcParser.primary() // numbers, names and the error messages for anything else
+}
+
+//// The interpreter
+
+// mlValue is a float64, a string, a *mlFunc, a *mlBuiltin or nil
+type mlValue = any
+
+type mlFunc struct {
+	name    string
+	params  []string
+	body    []mlStmt
+	closure *mlEnv // the scope the fn statement ran in
+}
+
+type mlBuiltin struct {
+	name string
+	call func(in *mlInterp, args []mlValue) (mlValue, error)
+}
+
+// mlEnv is one scope; lookups that miss walk out to the enclosing ones
+type mlEnv struct {
+	vars   map[string]mlValue
+	parent *mlEnv
+}
+
+func newMLEnv(parent *mlEnv) *mlEnv {
+	return &mlEnv{vars: map[string]mlValue{}, parent: parent}
+}
+
+// scopeOf finds the innermost scope that declares name, nil if none does
+func (e *mlEnv) scopeOf(name string) *mlEnv {
+	for ; e != nil; e = e.parent {
+		if _, ok := e.vars[name]; ok {
+			return e
+		}
+	}
+	return nil
+}
+
+// Each minilang call is a few Go calls deep; this stops runaway recursion with an
+// error long before Go's stack limit would stop it with a crash
+const mlMaxDepth = 1000
+
+type mlInterp struct {
+	out   io.Writer // where print writes
+	depth int       // calls in progress
+}
+
+var mlBuiltins = []*mlBuiltin{
+	{"print", func(in *mlInterp, args []mlValue) (mlValue, error) {
+		words := make([]string, len(args))
+		for i, arg := range args {
+			words[i] = formatML(arg)
+		}
+		_, err := fmt.Fprintln(in.out, strings.Join(words, " "))
+		return nil, err
+	}},
+	{"len", func(in *mlInterp, args []mlValue) (mlValue, error) {
+		if len(args) != 1 {
+			return nil, fmt.Errorf("len takes 1 argument, got %d", len(args))
+		}
+		s, ok := args[0].(string)
+		if !ok {
+			return nil, fmt.Errorf("len of a %s", mlTypeName(args[0]))
+		}
+		return float64(len([]rune(s))), nil
+	}},
+}
+
+func formatML(v mlValue) string {
+	switch v := v.(type) {
+	case float64:
+		if v == math.Trunc(v) && math.Abs(v) < 1e15 {
+			return strconv.FormatFloat(v, 'f', -1, 64) // whole numbers in full: 3628800, not 3.6288e+06
+		}
+		return formatCalc(v)
+	case string:
+		return v
+	case *mlFunc:
+		return "<fn " + v.name + ">"
+	case *mlBuiltin:
+		return "<builtin " + v.name + ">"
+	}
+	return "nil"
+}
+
+func mlTypeName(v mlValue) string {
+	switch v.(type) {
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case *mlFunc, *mlBuiltin:
+		return "function"
+	}
+	return "nil"
+}
+
+func mlTruthy(v mlValue) bool {
+	return v != nil && v != 0.0 && v != ""
+}
+
+func mlBool(b bool) mlValue {
+	if b {
+		return 1.0
+	}
+	return 0.0
+}
+
+// runMinilang runs a whole program; errors say line:column
+func runMinilang(src string, out io.Writer) error {
+	program, err := parseMinilang(src)
+	if err == nil {
+		globals := newMLEnv(nil)
+		for _, b := range mlBuiltins {
+			globals.vars[b.name] = b
+		}
+		in := &mlInterp{out: out}
+		_, _, err = in.execBlock(program, globals)
+	}
+	var ce *calcError
+	if errors.As(err, &ce) {
+		line := 1 + strings.Count(src[:ce.pos], "\n")
+		col := ce.pos - strings.LastIndexByte(src[:ce.pos], '\n')
+		return fmt.Errorf("line %d:%d: %s", line, col, ce.msg)
+	}
+	return err
+}
+
+// execBlock runs stmts in env until one returns. A runtime error gets the position of
+// the innermost statement it came from; the blocks around that leave it alone
+func (in *mlInterp) execBlock(stmts []mlStmt, env *mlEnv) (result mlValue, returned bool, err error) {
+	for _, stmt := range stmts {
+		result, returned, err = in.exec(stmt, env)
+		if err != nil {
+			var ce *calcError
+			if !errors.As(err, &ce) {
+				err = &calcError{stmt.pos(), err.Error()}
+			}
+			return nil, false, err
+		}
+		if returned {
+			return result, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+func (in *mlInterp) exec(stmt mlStmt, env *mlEnv) (mlValue, bool, error) {
+	switch s := stmt.(type) {
+	case letStmt:
+		if _, ok := env.vars[s.name]; ok {
+			return nil, false, fmt.Errorf("%s is already declared in this scope", s.name)
+		}
+		v, err := in.eval(s.value, env)
+		if err != nil {
+			return nil, false, err
+		}
+		env.vars[s.name] = v
+		return nil, false, nil
+	case exprStmt:
+		_, err := in.eval(s.expr, env)
+		return nil, false, err
+	case ifStmt:
+		cond, err := in.eval(s.cond, env)
+		if err != nil {
+			return nil, false, err
+		}
+		if mlTruthy(cond) {
+			return in.execBlock(s.then, newMLEnv(env))
+		}
+		return in.execBlock(s.els, newMLEnv(env))
+	case whileStmt:
+		for {
+			cond, err := in.eval(s.cond, env)
+			if err != nil || !mlTruthy(cond) {
+				return nil, false, err
+			}
+			if v, returned, err := in.execBlock(s.body, newMLEnv(env)); err != nil || returned {
+				return v, returned, err
+			}
+		}
+	case fnStmt:
+		// Declared before the body can run, so the function can call itself
+		env.vars[s.name] = &mlFunc{s.name, s.params, s.body, env}
+		return nil, false, nil
+	case returnStmt:
+		if s.value == nil {
+			return nil, true, nil
+		}
+		v, err := in.eval(s.value, env)
+		return v, err == nil, err
+	}
+	return nil, false, fmt.Errorf("unknown statement %T", stmt)
+}
+
+func (in *mlInterp) eval(n calcNode, env *mlEnv) (mlValue, error) {
+	switch n := n.(type) {
+	case numberNode:
+		return n.value, nil
+	case stringNode:
+		return n.value, nil
+	case nameNode:
+		scope := env.scopeOf(n.name)
+		if scope == nil {
+			return nil, fmt.Errorf("undefined variable %s", n.name)
+		}
+		return scope.vars[n.name], nil
+	case assignNode:
+		scope := env.scopeOf(n.name)
+		if scope == nil {
+			return nil, fmt.Errorf("%s isn't declared (use let %s = ...)", n.name, n.name)
+		}
+		v, err := in.eval(n.value, env)
+		if err != nil {
+			return nil, err
+		}
+		scope.vars[n.name] = v
+		return v, nil
+	case unaryNode:
+		v, err := in.eval(n.operand, env)
+		if err != nil {
+			return nil, err
+		}
+		if n.op == "!" {
+			return mlBool(!mlTruthy(v)), nil
+		}
+		f, ok := v.(float64)
+		if !ok {
+			return nil, fmt.Errorf("can't negate a %s", mlTypeName(v))
+		}
+		return -f, nil
+	case binaryNode:
+		return in.evalBinary(n, env)
+	case callNode:
+		return in.evalCall(n, env)
+	}
+	return nil, fmt.Errorf("unknown node %T", n)
+}
+
+func (in *mlInterp) evalBinary(n binaryNode, env *mlEnv) (mlValue, error) {
+	l, err := in.eval(n.left, env)
+	if err != nil {
+		return nil, err
+	}
+	// && and || only evaluate the right side when the left one doesn't decide it
+	switch {
+	case n.op == "&&" && !mlTruthy(l):
+		return mlBool(false), nil
+	case n.op == "||" && mlTruthy(l):
+		return mlBool(true), nil
+	}
+	r, err := in.eval(n.right, env)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n.op {
+	case "&&", "||":
+		return mlBool(mlTruthy(r)), nil
+	case "==":
+		return mlBool(l == r), nil // interface comparison: same type and same value
+	case "!=":
+		return mlBool(l != r), nil
+	}
+	switch l := l.(type) {
+	case float64:
+		if r, ok := r.(float64); ok {
+			if b, ok := mlCompare(n.op, cmp.Compare(l, r)); ok {
+				return b, nil
+			}
+			v, err := calcArith(n.op, l, r)
+			if err != nil {
+				return nil, err
+			}
+			return v, nil
+		}
+	case string:
+		if r, ok := r.(string); ok {
+			if n.op == "+" {
+				return l + r, nil
+			}
+			if b, ok := mlCompare(n.op, strings.Compare(l, r)); ok {
+				return b, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("can't use %s on a %s and a %s", n.op, mlTypeName(l), mlTypeName(r))
+}
+
+// mlCompare turns a cmp.Compare style result into the value of a < <= > >= operator
+func mlCompare(op string, c int) (mlValue, bool) {
+	switch op {
+	case "<":
+		return mlBool(c < 0), true
+	case "<=":
+		return mlBool(c <= 0), true
+	case ">":
+		return mlBool(c > 0), true
+	case ">=":
+		return mlBool(c >= 0), true
+	}
+	return nil, false
+}
+
+func (in *mlInterp) evalCall(n callNode, env *mlEnv) (mlValue, error) {
+	callee, err := in.eval(n.fn, env)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]mlValue, len(n.args))
+	for i, arg := range n.args {
+		if args[i], err = in.eval(arg, env); err != nil {
+			return nil, err
+		}
+	}
+
+	switch fn := callee.(type) {
+	case *mlBuiltin:
+		return fn.call(in, args)
+	case *mlFunc:
+		if len(args) != len(fn.params) {
+			return nil, fmt.Errorf("%s takes %d arguments, got %d", fn.name, len(fn.params), len(args))
+		}
+		if in.depth == mlMaxDepth {
+			return nil, fmt.Errorf("more than %d calls deep in %s, is the recursion missing a base case?", mlMaxDepth, fn.name)
+		}
+		in.depth++
+		defer func() { in.depth-- }()
+
+		scope := newMLEnv(fn.closure)
+		for i, param := range fn.params {
+			scope.vars[param] = args[i]
+		}
+		result, _, err := in.execBlock(fn.body, scope)
+		return result, err
+	}
+	return nil, fmt.Errorf("can't call a %s", mlTypeName(callee))
+}
+
+// hellogo minilang [file]; reads the program from stdin without one
+func minilangCommand(args []string) error {
+	if len(args) > 1 {
+		return errUsage
+	}
+	in := io.Reader(os.Stdin)
+	if len(args) == 1 {
+		f, err := os.Open(args[0])
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	src, err := io.ReadAll(in)
+	if err != nil {
+		return err
+	}
+	return runMinilang(string(src), os.Stdout)
+}
+
+type minilangCase struct {
+	name string
+	src  string
+	want string // the printed output, or the error
+}
+
+var minilangCases = []minilangCase{
+	{"let and print", `let x = 2; print(x * 3, "apples");`, "6 apples\n"},
+	{"assignment", `let x = 1; x = x + 1; print(x);`, "2\n"},
+	{"if else", `if 2 > 3 { print("no"); } else if 2 == 2 { print("yes"); } else { print("no"); }`, "yes\n"},
+	{"while", `let i = 0; let s = ""; while i < 3 { s = s + "ab"; i = i + 1; } print(s, len(s));`, "ababab 6\n"},
+	{"recursion", `fn fact(n) { if n <= 1 { return 1; } return n * fact(n - 1); } print(fact(10));`, "3628800\n"},
+	{"block scope", `let x = 1; if 1 { let x = 2; print(x); } print(x);`, "2\n1\n"},
+	{"short circuit", `fn boom() { return 1 / 0; } print(0 && boom(), 1 || boom());`, "0 1\n"},
+	{"closure", `fn adder(n) { fn add(x) { return x + n; } return add; } let add2 = adder(2); print(add2(40), adder(1)(1));`, "42 2\n"},
+	{"string compare", `print("apple" < "banana", "a" == "a", "1" == 1);`, "1 1 0\n"},
+	{"undeclared", "let x = 1;\ny = 2;", "line 2:1: y isn't declared (use let y = ...)"},
+	{"redeclared", `let x = 1; let x = 2;`, "line 1:12: x is already declared in this scope"},
+	{"mixed types", "let s = \"a\";\nprint(s - 1);", "line 2:1: can't use - on a string and a number"},
+	{"error in a call", "fn f() {\n  return nope;\n}\nf();", "line 2:3: undefined variable nope"},
+	{"arity", `fn f(a, b) { return a; } f(1);`, "line 1:26: f takes 2 arguments, got 1"},
+	{"runaway recursion", `fn f() { return f(); } f();`, "line 1:10: more than 1000 calls deep in f, is the recursion missing a base case?"},
+	{"missing semicolon", `let x = 1 print(x);`, `line 1:11: expected ";", got "print"`},
+	{"missing brace", `while 1 { print(1);`, "line 1:20: missing }"},
+	{"keyword as name", `let while = 1;`, `line 1:5: expected a name, got "while"`},
+	{"chained compare", `print(1 < 2 < 3);`, `line 1:13: expected ")", got "<"`},
+}
+
+func testMinilang() {
+	for _, src := range []string{"a || b && !c", "x == 1 + 2 * 3", "f(1, g(2))(3)", "-2 ^ 2 < \"s\""} {
+		tokens, _ := lexMinilang(src)
+		p := &mlParser{calcParser{tokens: tokens}}
+		node, _ := p.expr()
+		fmt.Printf("%-16s parses as %s\n", src, node)
+	}
+	// a || b && !c     parses as (a || (b && (!c)))
+	// x == 1 + 2 * 3   parses as (x == (1 + (2 * 3)))
+	// f(1, g(2))(3)    parses as f(1, g(2))(3)
+	// -2 ^ 2 < "s"     parses as ((-(2 ^ 2)) < "s")
+
+	for _, tc := range minilangCases {
+		runCase("TestMinilang/"+tc.name, func(errorf errorfFunc) {
+			var out strings.Builder
+			got := ""
+			if err := runMinilang(tc.src, &out); err != nil {
+				got = err.Error()
+			} else {
+				got = out.String()
+			}
+			if got != tc.want {
+				errorf("runMinilang(%q)\ngot:  %q\nwant: %q", tc.src, got, tc.want)
+			}
+		})
+	}
+
+	// The example program, as hellogo minilang testdata/minilang/example.mini runs it
+	src, err := os.ReadFile("testdata/minilang/example.mini")
+	if err == nil {
+		err = runMinilang(string(src), os.Stdout)
+	}
+	if err != nil {
+		fmt.Println("example:", err)
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//////// Password hashing
+// Don't store sha256(password)! SHA is built to be fast, so an attacker with your
+// database can try billions of guesses a second, and identical passwords give identical
+// hashes (hello rainbow tables).
+// Password hashes fix both: a random salt per password, and a tunable cost that makes
+// every guess slow. bcrypt/argon2 (golang.org/x/crypto) are the usual picks, the standard
+// library has PBKDF2 which works the same way.
+
+const (
+	passwordIterations = 600_000 // OWASP's 2023 recommendation for PBKDF2-SHA256
+	passwordSaltLen    = 16
+	passwordKeyLen     = 32
+)
+
+var errBadPasswordHash = errors.New("malformed password hash")
+
+// hashPassword returns "pbkdf2-sha256$<iterations>$<salt>$<key>"
+// Everything needed to check it later is in the string, so the cost can be raised
+// for new passwords without breaking old ones
+func hashPassword(password string, iterations int) (string, error) {
+	salt := make([]byte, passwordSaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, passwordKeyLen)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawStdEncoding
+	return fmt.Sprintf("pbkdf2-sha256$%d$%s$%s", iterations, enc.EncodeToString(salt), enc.EncodeToString(key)), nil
+}
+
+func checkPassword(password, encoded string) (bool, error) {
+	parts := strings.Split(encoded, "$")
+	if len(parts) != 4 || parts[0] != "pbkdf2-sha256" {
+		return false, errBadPasswordHash
+	}
+	iterations, err := strconv.Atoi(parts[1])
+	if err != nil || iterations <= 0 {
+		return false, errBadPasswordHash
+	}
+	enc := base64.RawStdEncoding
+	salt, err := enc.DecodeString(parts[2])
+	if err != nil {
+		return false, errBadPasswordHash
+	}
+	want, err := enc.DecodeString(parts[3])
+	if err != nil {
+		return false, errBadPasswordHash
+	}
+
+	got, err := pbkdf2.Key(sha256.New, password, salt, iterations, len(want))
+	if err != nil {
+		return false, err
+	}
+	// Constant time compare, == would leak how many leading bytes matched via timing
+	return subtle.ConstantTimeCompare(got, want) == 1, nil
+}
+
+func testPasswordHashing() {
+	// Plain SHA: same input, same output, and very fast
+	fmt.Printf("%x\n", sha256.Sum256([]byte("hunter2")))
+	fmt.Printf("%x\n", sha256.Sum256([]byte("hunter2"))) // identical
+
+	start := time.Now()
+	for i := 0; i < 100_000; i++ {
+		sha256.Sum256([]byte("hunter2"))
+	}
+	fmt.Println("100k sha256 guesses took", time.Since(start))
+
+	// Salted: same password, different hash each time
+	h1, err := hashPassword("hunter2", passwordIterations)
+	if err != nil {
+		panic(err)
+	}
+	h2, _ := hashPassword("hunter2", passwordIterations)
+	fmt.Println(h1)
+	fmt.Println(h2)
+
+	ok, _ := checkPassword("hunter2", h1)
+	fmt.Println(ok) // true
+	ok, _ = checkPassword("hunter3", h1)
+	fmt.Println(ok) // false
+	_, err = checkPassword("hunter2", "sha256$abc")
+	fmt.Println(err) // malformed password hash
+}
+
+// Cost tuning: pick the biggest cost you can afford per login (~100ms-ish is common)
+// and re-tune as hardware gets faster
+func tunePasswordCost() {
+	for _, iterations := range []int{10_000, 100_000, 600_000, 1_000_000} {
+		start := time.Now()
+		if _, err := hashPassword("hunter2", iterations); err != nil {
+			panic(err)
+		}
+		fmt.Printf("%9d iterations: %v\n", iterations, time.Since(start))
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+//////// File paths and directory walking
+// path/filepath uses the OS separator (/ or \), plain "path" is always / (for URLs etc.)
+// Building paths by hand with "a" + "/" + "b" breaks on Windows, let filepath do it
+
+func testFilepath() {
+	p := filepath.Join("lessons", "files", "..", "paths.go")
+	fmt.Println(p) // lessons/paths.go on unix, lessons\paths.go on Windows (Join also cleans)
+
+	fmt.Println(filepath.Dir(p))                                       // lessons
+	fmt.Println(filepath.Base(p))                                      // paths.go
+	fmt.Println(filepath.Ext(p))                                       // .go
+	fmt.Println(strings.TrimSuffix(filepath.Base(p), filepath.Ext(p))) // paths
+
+	dir, file := filepath.Split(p)
+	fmt.Println(dir, file) // lessons/ paths.go
+
+	fmt.Println(filepath.IsAbs(p)) // false
+	abs, _ := filepath.Abs(p)
+	fmt.Println(abs)
+
+	rel, _ := filepath.Rel("/a/b", "/a/b/c/d.txt")
+	fmt.Println(rel) // c/d.txt
+
+	// Converting to/from forward slashes, for things like config files and URLs
+	fmt.Println(filepath.ToSlash(p), string(filepath.Separator))
+	fmt.Println(filepath.FromSlash("a/b/c")) // a\b\c on Windows
+
+	// Glob matching: * doesn't cross separators, no ** support
+	ok, _ := filepath.Match("*.go", "hello.go")
+	fmt.Println(ok) // true
+	ok, _ = filepath.Match("*.go", "dir/hello.go")
+	fmt.Println(ok) // false
+	matches, _ := filepath.Glob("*.go")
+	fmt.Println(len(matches), "go files here")
+}
+
+type goFile struct {
+	path string
+	size int64
+}
+
+// findGoFiles walks everything under root and returns the .go files and their total size
+// Hidden directories (.git etc) are skipped entirely via fs.SkipDir
+func findGoFiles(root string) ([]goFile, int64, error) {
+	var files []goFile
+	var total int64
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err // couldn't read this dir; returning the error stops the walk
+		}
+		if d.IsDir() {
+			if path != root && strings.HasPrefix(d.Name(), ".") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if filepath.Ext(path) != ".go" {
+			return nil
+		}
+
+		// WalkDir doesn't stat every file (that's why it's faster than Walk), ask when needed
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files = append(files, goFile{path, info.Size()})
+		total += info.Size()
+		return nil
+	})
+	return files, total, err
+}
+
+func testWalkDir() {
+	files, total, err := findGoFiles(".")
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "walk failed:", err)
+		return
+	}
+	for _, f := range files {
+		fmt.Printf("%8d %s\n", f.size, f.path)
+	}
+	fmt.Printf("%8d total in %d files\n", total, len(files))
+}
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// macOS: notifications through AppleScript. The text ends up inside an AppleScript
+// string literal, so quotes and backslashes need escaping the AppleScript way
+
+const platformName = "darwin"
+
+func homeDir() (string, error) {
+	if home := os.Getenv("HOME"); home != "" {
+		return home, nil
+	}
+	return "", errors.New("$HOME is not set")
+}
+
+var appleScriptQuoter = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func notify(title, message string) error {
+	script := `display notification "` + appleScriptQuoter.Replace(message) +
+		`" with title "` + appleScriptQuoter.Replace(title) + `"`
+	return exec.Command("osascript", "-e", script).Run()
+}
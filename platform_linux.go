@@ -0,0 +1,23 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// The _linux.go suffix is a build constraint by itself, no //go:build line needed.
+// Desktop notifications go through notify-send (libnotify), which most desktops ship
+
+const platformName = "linux"
+
+func homeDir() (string, error) {
+	if home := os.Getenv("HOME"); home != "" {
+		return home, nil
+	}
+	return "", errors.New("$HOME is not set")
+}
+
+func notify(title, message string) error {
+	return exec.Command("notify-send", title, message).Run()
+}
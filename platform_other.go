@@ -0,0 +1,23 @@
+//go:build !linux && !darwin && !windows
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// Everything else (the BSDs, plan9, wasm...) gets the portable version. Without this
+// file the build would fail there with "undefined: homeDir"
+
+const platformName = "other"
+
+func homeDir() (string, error) {
+	return os.UserHomeDir()
+}
+
+func notify(title, message string) error {
+	return fmt.Errorf("notify on %s: %w", runtime.GOOS, errors.ErrUnsupported)
+}
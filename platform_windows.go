@@ -0,0 +1,23 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+)
+
+// Windows has no $HOME, it's %USERPROFILE%. Real toast notifications need COM or a
+// PowerShell module, msg.exe pops up a plain message box (not on Home editions)
+
+const platformName = "windows"
+
+func homeDir() (string, error) {
+	if home := os.Getenv("USERPROFILE"); home != "" {
+		return home, nil
+	}
+	return "", errors.New("%USERPROFILE% is not set")
+}
+
+func notify(title, message string) error {
+	return exec.Command("msg", "*", "/TIME:10", title+": "+message).Run()
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+//////// Plugins: loading Go code at run time
+// go build -buildmode=plugin turns a main package into a .so; plugin.Open loads it into
+// the running program and Lookup finds its exported functions and variables by name
+// (see plugins_linux.go and the example in plugins/extra):
+//
+//	go build -buildmode=plugin -o extra.so ./plugins/extra
+//	hellogo run --plugin ./extra.so extra
+//
+// The catch list is long, which is why few projects use it:
+//   - Linux, macOS and FreeBSD only, and cgo has to be on
+//   - the plugin and the program must be built by the same Go version, with the same
+//     flags, and with the exact same version of every package they both use
+//   - a plugin can never be unloaded, and loading the same one twice just returns it again
+//
+// The usual alternatives: plugins as separate programs talking over RPC or stdin/stdout
+// (hashicorp/go-plugin, see rpc.go), WebAssembly (see wasm.go), or a scripting language.
+
+func testPlugins() {
+	dir := makeTempDir("plugins")
+	defer os.RemoveAll(dir)
+
+	so := filepath.Join(dir, "extra.so")
+	out, err := exec.Command("go", "build", "-buildmode=plugin", "-o", so, "plugins/extra/extra.go").CombinedOutput()
+	if err != nil { // run from the repo root; -buildmode=plugin needs cgo, so a C compiler
+		fmt.Printf("building the plugin failed: %v\n%s", err, out)
+		return
+	}
+
+	before := len(lessonRegistry)
+	if err := runLessonCommand([]string{"--plugin", so, "extra"}); err != nil {
+		fmt.Println(err) // plugin was built with a different version of package ...: see the catch list
+		return
+	}
+	fmt.Println(len(lessonRegistry)-before, "lesson added")
+	// hello from a plugin, built by go1.x
+	// 1 lesson added
+
+	fmt.Println(registerLesson("extra", func() {})) // lesson "extra" is already registered
+}
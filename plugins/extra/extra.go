@@ -0,0 +1,22 @@
+// Command extra is a lesson built as a Go plugin for hellogo run:
+//
+//	go build -buildmode=plugin -o extra.so ./plugins/extra
+//	hellogo run --plugin ./extra.so extra
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Register is looked up by name when the plugin is loaded, see plugins_linux.go
+func Register(add func(name string, lesson func()) error) error {
+	return add("extra", testExtra)
+}
+
+func testExtra() {
+	fmt.Println("hello from a plugin, built by", runtime.Version())
+}
+
+// Plugins are package main but main never runs; it's here so go build ./... works
+func main() {}
@@ -0,0 +1,32 @@
+//go:build cgo
+
+package main
+
+import (
+	"fmt"
+	"plugin"
+)
+
+// The plugin package works on Linux, macOS and FreeBSD, with cgo. Only Linux is
+// tried here, hence the _linux name, and cgo for the tag
+
+// registerFunc is the signature a plugin's Register must have. Only built in types,
+// so the plugin doesn't have to import anything from hellogo (it couldn't anyway,
+// nobody can import a main package)
+type registerFunc = func(add func(name string, lesson func()) error) error
+
+func loadLessonPlugin(path string) error {
+	p, err := plugin.Open(path) // runs the plugin's init functions; there's no Close
+	if err != nil {
+		return err
+	}
+	sym, err := p.Lookup("Register")
+	if err != nil {
+		return err
+	}
+	register, ok := sym.(registerFunc) // functions come back as func values, variables as pointers
+	if !ok {
+		return fmt.Errorf("%s: Register is a %T, not a %T", path, sym, registerFunc(nil))
+	}
+	return register(registerLesson)
+}
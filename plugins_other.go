@@ -0,0 +1,14 @@
+//go:build !linux || !cgo
+
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Windows, wasm, and any build with CGO_ENABLED=0: no plugins
+
+func loadLessonPlugin(path string) error {
+	return fmt.Errorf("can't load %s, plugins need linux and cgo: %w", path, errors.ErrUnsupported)
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+)
+
+//////// Profiling with pprof
+// Don't guess where the time goes, measure it. runtime/pprof writes profiles that
+// `go tool pprof` reads:
+//
+//	CPU profile   samples the running goroutines' stacks 100 times a second while it's on
+//	heap profile  where the live memory (and, with -sample_index=alloc_space, all the
+//	              memory ever allocated) came from
+//
+// Reading one:
+//
+//	go tool pprof -top cpu.pprof        # hottest functions first
+//	go tool pprof -list wordCounts cpu.pprof
+//	go tool pprof -http :8081 cpu.pprof # flame graph in the browser
+//
+// In -top, flat is time spent in the function itself and cum includes everything it
+// called. A big cum with a small flat means look at what it calls.
+//
+// Any command can be profiled: hellogo --profile <dir> <command> [args...]
+// Servers usually import _ "net/http/pprof" instead and fetch profiles over HTTP.
+
+// startProfiling starts a CPU profile into dir/cpu.pprof; calling stop ends it
+// and writes dir/heap.pprof
+func startProfiling(dir string) (stop func() error, err error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	cpu, err := os.Create(filepath.Join(dir, "cpu.pprof"))
+	if err != nil {
+		return nil, err
+	}
+	if err := pprof.StartCPUProfile(cpu); err != nil {
+		cpu.Close()
+		return nil, err
+	}
+
+	return func() error {
+		pprof.StopCPUProfile()
+		if err := cpu.Close(); err != nil {
+			return err
+		}
+		heap, err := os.Create(filepath.Join(dir, "heap.pprof"))
+		if err != nil {
+			return err
+		}
+		defer heap.Close()
+		runtime.GC() // the heap profile shows the state as of the last GC, get an up to date one
+		return pprof.Lookup("heap").WriteTo(heap, 0)
+	}, nil
+}
+
+//// Something worth profiling
+// Counting words the wasteful way: lowercase copies, a fresh slice per line,
+// a string key built with concatenation for every pair of words
+
+func wordCounts(text string, rounds int) map[string]int {
+	counts := map[string]int{}
+	for range rounds {
+		for _, line := range strings.Split(text, "\n") {
+			words := strings.Fields(strings.ToLower(line))
+			for i, w := range words {
+				counts[w]++
+				if i > 0 {
+					counts[words[i-1]+" "+w]++ // a new string every time
+				}
+			}
+		}
+	}
+	return counts
+}
+
+// keepHeap holds on to memory so it shows up as live in the heap profile
+var keepHeap [][]byte
+
+func allocationHeavyWorkload() int {
+	src, _ := lessonSources.ReadFile("hello.go")
+	counts := wordCounts(string(src), 2000) // about half a second
+	for range 100 {
+		keepHeap = append(keepHeap, make([]byte, 64<<10))
+	}
+	return len(counts)
+}
+
+func testProfiling() {
+	dir := makeTempDir("pprof")
+	defer os.RemoveAll(dir)
+
+	stop, err := startProfiling(dir)
+	if err != nil {
+		fmt.Println("profiling failed:", err)
+		return
+	}
+	fmt.Println(allocationHeavyWorkload(), "distinct words and pairs")
+	if err := stop(); err != nil {
+		fmt.Println("profiling failed:", err)
+		return
+	}
+	keepHeap = nil
+
+	for _, name := range []string{"cpu.pprof", "heap.pprof"} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Println(name, info.Size() > 0) // cpu.pprof true, heap.pprof true
+	}
+	// go tool pprof -top cpu.pprof on this shows something like:
+	//      flat  flat%        cum   cum%
+	//     130ms 19.70%      180ms 27.27%  strings.Fields
+	//     100ms 15.15%      230ms 34.85%  runtime.mapassign_faststr  <- counts[...]++
+	//      60ms  9.09%      110ms 16.67%  runtime.concatstrings      <- words[i-1]+" "+w
+	//      30ms  4.55%       80ms 12.12%  strings.ToLower
+	//      20ms  3.03%      660ms   100%  main.wordCounts
+	// and go tool pprof -sample_index=inuse_space -top heap.pprof points straight at
+	// the 64KB buffers allocationHeavyWorkload keeps alive
+}
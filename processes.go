@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"os"
+)
+
+//////// Exiting and replacing processes
+// Spawning (os/exec, see exec.go) starts a child and you carry on running.
+// Exec (syscall.Exec, see processes_unix.go) REPLACES your process with another program:
+// same pid, your code is gone, nothing after the call ever runs.
+
+//// os.Exit vs returning from main
+// Returning from main exits with code 0 after main's defers have run.
+// os.Exit(n) stops right there: no defers, no flushing of bufio writers, nothing.
+// That's why main() only calls os.Exit with the result of runCommand, after the real
+// work (and all its defers) has finished.
+
+func showExitSkipsDefers() {
+	defer fmt.Println("you will never see this")
+	fmt.Println("exiting with 3")
+	os.Exit(3) // echo $? in your shell afterwards shows 3
+}
+
+// Same thing, the right way round: do the work in a function that returns the code,
+// its defers run on the way out, then exit
+func exitAfterDefers() {
+	os.Exit(func() int {
+		defer fmt.Println("cleanup ran")
+		return 3
+	}())
+}
+
+//// Exit codes
+// 0 means success, anything else failure; the meaning of each number is up to you
+// (but 2 for bad usage and 128+n for "killed by signal n" are common conventions).
+// hellogo uses the codes in commands.go; in a CI script:
+//
+//	hellogo checksum missing.txt
+//	case $? in
+//	  0) echo ok ;;
+//	  2) echo "called it wrong" ;;
+//	  *) echo "checksum failed" ;;
+//	esac
+
+type lessonFailure struct {
+	lesson string
+}
+
+func (e *lessonFailure) Error() string { return "lesson " + e.lesson + " failed" }
+
+// ExitCode makes runCommand exit with 3 instead of the generic 1
+func (e *lessonFailure) ExitCode() int { return 3 }
+
+func testExitCodes() {
+	var err error = &lessonFailure{"channels"}
+	if coder, ok := err.(exitCoder); ok {
+		fmt.Println(err, "-> exit", coder.ExitCode()) // lesson channels failed -> exit 3
+	}
+}
@@ -0,0 +1,23 @@
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// syscall.Exec only exists on unix-like systems, hence the build tag (and _unix file name)
+
+// execReplace becomes `ls -l`; on success it never returns, so the only
+// possible return value is an error
+func execReplace() error {
+	// Exec wants the full path, it doesn't search $PATH
+	binary, err := exec.LookPath("ls")
+	if err != nil {
+		return err
+	}
+	// args[0] is the program name by convention
+	return syscall.Exec(binary, []string{"ls", "-l"}, os.Environ())
+}
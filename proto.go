@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+//////// Protocol Buffers
+// Language neutral wire format; messages are declared in proto/hellogo.proto and
+// normally `protoc --go_out=.` generates the Go structs + Marshal/Unmarshal for you
+// (google.golang.org/protobuf). This repo has no dependencies, so instead the encoding
+// is written out by hand below; it is byte for byte what the generated code produces.
+//
+// Every field goes over the wire as: key (field number << 3 | wire type) then the value
+// wire type 0 = varint (ints, bools), 2 = length prefixed (strings, bytes, nested messages)
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+type protoLesson struct {
+	Name  string
+	Title string
+	Order int32
+}
+
+var errBadProto = errors.New("malformed protobuf data")
+
+func appendProtoKey(b []byte, field, wireType int) []byte {
+	return binary.AppendUvarint(b, uint64(field<<3|wireType))
+}
+
+// proto3 doesn't send fields holding the zero value, hence all the ifs
+func appendProtoString(b []byte, field int, s string) []byte {
+	if s == "" {
+		return b
+	}
+	b = appendProtoKey(b, field, wireBytes)
+	b = binary.AppendUvarint(b, uint64(len(s)))
+	return append(b, s...)
+}
+
+func appendProtoVarint(b []byte, field int, v uint64) []byte {
+	if v == 0 {
+		return b
+	}
+	b = appendProtoKey(b, field, wireVarint)
+	return binary.AppendUvarint(b, v)
+}
+
+func (l protoLesson) Marshal() []byte {
+	var b []byte
+	b = appendProtoString(b, 1, l.Name)
+	b = appendProtoString(b, 2, l.Title)
+	b = appendProtoVarint(b, 3, uint64(int64(l.Order))) // negative int32s are sign extended to 10 bytes
+	return b
+}
+
+func (p progress) MarshalProto() []byte {
+	var b []byte
+	b = appendProtoString(b, 1, p.Lesson)
+	if p.Completed {
+		b = appendProtoVarint(b, 2, 1)
+	}
+	b = appendProtoVarint(b, 3, uint64(int64(p.Attempts)))
+	b = appendProtoVarint(b, 4, uint64(p.LastSeen))
+	return b
+}
+
+// walkProto calls fn for every field in the message; fn gets the raw varint or bytes
+// Unknown fields are skipped, which is what lets old readers handle newer messages
+func walkProto(data []byte, fn func(field int, varint uint64, bytes []byte)) error {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return errBadProto
+		}
+		data = data[n:]
+		field, wireType := int(key>>3), int(key&7)
+
+		switch wireType {
+		case wireVarint:
+			v, n := binary.Uvarint(data)
+			if n <= 0 {
+				return errBadProto
+			}
+			data = data[n:]
+			fn(field, v, nil)
+		case wireBytes:
+			size, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < size {
+				return errBadProto
+			}
+			fn(field, 0, data[n:n+int(size)])
+			data = data[n+int(size):]
+		case wireFixed64, wireFixed32:
+			size := 8
+			if wireType == wireFixed32 {
+				size = 4
+			}
+			if len(data) < size {
+				return errBadProto
+			}
+			data = data[size:]
+		default:
+			return errBadProto
+		}
+	}
+	return nil
+}
+
+func (l *protoLesson) Unmarshal(data []byte) error {
+	*l = protoLesson{}
+	return walkProto(data, func(field int, v uint64, b []byte) {
+		switch field {
+		case 1:
+			l.Name = string(b)
+		case 2:
+			l.Title = string(b)
+		case 3:
+			l.Order = int32(v)
+		}
+	})
+}
+
+func (p *progress) UnmarshalProto(data []byte) error {
+	*p = progress{}
+	return walkProto(data, func(field int, v uint64, b []byte) {
+		switch field {
+		case 1:
+			p.Lesson = string(b)
+		case 2:
+			p.Completed = v != 0
+		case 3:
+			p.Attempts = int32(v)
+		case 4:
+			p.LastSeen = int64(v)
+		}
+	})
+}
+
+func testProtoRoundTrip() {
+	lesson := protoLesson{Name: "channels", Title: "Channels", Order: 12}
+	data := lesson.Marshal()
+	fmt.Printf("% x\n", data) // 0a 08 63 68 61 6e 6e 65 6c 73 12 08 43 68 61 6e 6e 65 6c 73 18 0c
+
+	var back protoLesson
+	if err := back.Unmarshal(data); err != nil {
+		panic(err)
+	}
+	fmt.Println(back == lesson) // true
+
+	p := progress{Lesson: "channels", Completed: true, Attempts: 3, LastSeen: 1546300800}
+	var pBack progress
+	if err := pBack.UnmarshalProto(p.MarshalProto()); err != nil {
+		panic(err)
+	}
+	fmt.Println(pBack == p) // true
+
+	// A Progress read as a Lesson still "works"; fields 1 and 3 have the same types in both
+	// That's the cross language deal: only field numbers and types matter, not names
+	var confused protoLesson
+	_ = confused.Unmarshal(p.MarshalProto())
+	fmt.Printf("%+v\n", confused) // {Name:channels Title: Order:3}
+
+	// Compare with the same record as gob/binary/json in gob.go
+	fmt.Println(len(p.MarshalProto()), "bytes")
+}
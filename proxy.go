@@ -0,0 +1,270 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"net/url"
+	"strings"
+)
+
+//////// Mini project: a reverse proxy
+// A reverse proxy sits in front of servers and passes each request on to one of them,
+// picked here by path prefix. The standard library has one, httputil.ReverseProxy;
+// handRolledProxy below does the same job by hand to show what that involves:
+//   - build a new request for the upstream, copying the method, body and headers
+//   - drop hop-by-hop headers (Connection and friends), which describe one connection
+//     and mustn't be passed along, plus any header Connection names
+//   - say who the client was with X-Forwarded-For/-Host/-Proto, since the upstream
+//     only sees the proxy's address
+//   - copy the response back, again without the hop-by-hop headers
+//   - answer 502 Bad Gateway when the upstream can't be reached
+//
+// Both variants rewrite the same headers: Via on the way in and out, and Server and
+// X-Powered-By removed from responses so clients don't learn what runs behind.
+// Routes come from the config, prefixes ending in / match everything under them as
+// with http.ServeMux (which does the matching):
+//
+//	[proxy]
+//	addr = "localhost:8000"
+//	routes = "/api/=http://localhost:8080 /=http://localhost:3000"
+//
+//	hellogo proxy [--hand-rolled] [-routes "..."] [addr]
+//
+// Every request goes through standardMiddleware, so each one gets an access log line.
+
+const proxyVia = "1.1 hellogo"
+
+type proxyRoute struct {
+	prefix   string
+	upstream *url.URL
+}
+
+// parseProxyRoutes reads space separated prefix=upstream pairs
+func parseProxyRoutes(s string) ([]proxyRoute, error) {
+	var routes []proxyRoute
+	for _, pair := range strings.Fields(s) {
+		prefix, raw, ok := strings.Cut(pair, "=")
+		if !ok || !strings.HasPrefix(prefix, "/") {
+			return nil, fmt.Errorf("route %q should be /prefix=http://host:port", pair)
+		}
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("route %s: %w", prefix, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+			return nil, fmt.Errorf("route %s: upstream %q should be an http or https URL", prefix, raw)
+		}
+		routes = append(routes, proxyRoute{prefix, u})
+	}
+	return routes, nil
+}
+
+// rewriteProxyResponse removes the headers that give away the upstream software, and
+// adds Via
+func rewriteProxyResponse(h http.Header) {
+	h.Del("Server")
+	h.Del("X-Powered-By")
+	h.Add("Via", proxyVia)
+}
+
+func proxyErrorHandler(w http.ResponseWriter, r *http.Request, err error) {
+	slog.Warn("upstream failed", "path", r.URL.Path, "err", err, "request_id", requestID(r.Context()))
+	http.Error(w, "bad gateway", http.StatusBadGateway)
+}
+
+//// With httputil.ReverseProxy
+// Rewrite gets the incoming request and a copy to send, with hop-by-hop headers
+// already removed. SetURL points the copy at the upstream, SetXForwarded adds the
+// X-Forwarded-* headers (replacing any the client sent, unless copied over first).
+
+func newStdlibProxy(upstream *url.URL) http.Handler {
+	return &httputil.ReverseProxy{
+		Rewrite: func(r *httputil.ProxyRequest) {
+			r.SetURL(upstream)
+			r.Out.Header["X-Forwarded-For"] = r.In.Header["X-Forwarded-For"] // keep the chain from earlier proxies
+			r.SetXForwarded()
+			r.Out.Header.Add("Via", proxyVia)
+		},
+		ModifyResponse: func(resp *http.Response) error {
+			rewriteProxyResponse(resp.Header)
+			return nil
+		},
+		ErrorHandler: proxyErrorHandler, // the default logs with the log package and says nothing to the client
+	}
+}
+
+//// By hand
+
+// Headers that only mean something for one connection, from RFC 9110 section 7.6.1
+var hopHeaders = []string{
+	"Connection", "Proxy-Connection", "Keep-Alive", "Proxy-Authenticate", "Proxy-Authorization",
+	"Te", "Trailer", "Transfer-Encoding", "Upgrade",
+}
+
+func removeHopHeaders(h http.Header) {
+	for _, field := range h.Values("Connection") { // Connection: X-Secret means X-Secret is hop-by-hop too
+		for _, name := range strings.Split(field, ",") {
+			h.Del(strings.TrimSpace(name))
+		}
+	}
+	for _, name := range hopHeaders {
+		h.Del(name)
+	}
+}
+
+type handRolledProxy struct {
+	upstream *url.URL
+	client   *http.Client
+}
+
+func newHandRolledProxy(upstream *url.URL) *handRolledProxy {
+	return &handRolledProxy{upstream, &http.Client{
+		// A redirect is the client's business: pass it back rather than following it here
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}}
+}
+
+func (p *handRolledProxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	target := *p.upstream
+	target.Path = strings.TrimSuffix(target.Path, "/") + r.URL.Path
+	target.RawQuery = r.URL.RawQuery
+
+	out, err := http.NewRequestWithContext(r.Context(), r.Method, target.String(), r.Body)
+	if err != nil {
+		proxyErrorHandler(w, r, err)
+		return
+	}
+	out.ContentLength = r.ContentLength
+	out.Header = r.Header.Clone()
+	removeHopHeaders(out.Header)
+
+	if ip, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		if prior := out.Header.Get("X-Forwarded-For"); prior != "" {
+			ip = prior + ", " + ip
+		}
+		out.Header.Set("X-Forwarded-For", ip)
+	}
+	out.Header.Set("X-Forwarded-Host", r.Host)
+	proto := "http"
+	if r.TLS != nil {
+		proto = "https"
+	}
+	out.Header.Set("X-Forwarded-Proto", proto)
+	out.Header.Add("Via", proxyVia)
+
+	resp, err := p.client.Do(out)
+	if err != nil {
+		proxyErrorHandler(w, r, err)
+		return
+	}
+	defer resp.Body.Close()
+	removeHopHeaders(resp.Header)
+	rewriteProxyResponse(resp.Header)
+	for name, values := range resp.Header {
+		w.Header()[name] = values
+	}
+	w.WriteHeader(resp.StatusCode)
+	io.Copy(w, resp.Body) // too late for an error status once the body has started
+}
+
+// proxyRoutes sends each route's prefix to its upstream, through newProxy
+func proxyRoutes(routes []proxyRoute, newProxy func(*url.URL) http.Handler) http.Handler {
+	mux := http.NewServeMux()
+	for _, route := range routes {
+		mux.Handle(route.prefix, newProxy(route.upstream))
+	}
+	return standardMiddleware(mux)
+}
+
+// hellogo proxy [--hand-rolled] [-routes "prefix=url ..."] [addr]
+func proxyCommand(args []string) error {
+	cfg, err := loadConfig(configFile)
+	if err != nil {
+		return err
+	}
+	flags := flag.NewFlagSet("proxy", flag.ContinueOnError)
+	handRolled := flags.Bool("hand-rolled", false, "use handRolledProxy rather than httputil.ReverseProxy")
+	routeList := flags.String("routes", cfg.Proxy.Routes, "space separated prefix=upstream pairs")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 1 {
+		return errUsage
+	}
+	addr := cfg.Proxy.Addr
+	if flags.NArg() == 1 {
+		addr = flags.Arg(0)
+	}
+	routes, err := parseProxyRoutes(*routeList)
+	if err != nil {
+		return err
+	}
+	if len(routes) == 0 {
+		return errors.New("no routes: set proxy.routes in " + configFile + " or pass -routes")
+	}
+
+	newProxy := newStdlibProxy
+	if *handRolled {
+		newProxy = func(u *url.URL) http.Handler { return newHandRolledProxy(u) }
+	}
+	for _, route := range routes {
+		slog.Info("route", "prefix", route.prefix, "upstream", route.upstream)
+	}
+	ctx, stop := shutdownContext()
+	defer stop()
+	return serveUntilDone(ctx, newLessonServer(addr, proxyRoutes(routes, newProxy)))
+}
+
+func testProxy() {
+	// The upstreams say what they were sent, and give away their software like servers do
+	upstream := func(name string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Server", "nginx/1.2.3")
+			w.Header().Set("X-Powered-By", "PHP/5.6")
+			fmt.Fprintf(w, "%s got %s, for %s, via %q, secret %q\n",
+				name, r.URL.RequestURI(), r.Header.Get("X-Forwarded-For"), r.Header.Get("Via"), r.Header.Get("X-Secret"))
+		}))
+	}
+	api, static := upstream("api"), upstream("static")
+	defer api.Close()
+	defer static.Close()
+	down := httptest.NewServer(nil)
+	down.Close() // a port nothing listens on any more
+
+	routes, err := parseProxyRoutes("/api/=" + api.URL + " /=" + static.URL + " /down/=" + down.URL)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	variants := []struct {
+		name     string
+		newProxy func(*url.URL) http.Handler
+	}{
+		{"httputil.ReverseProxy", newStdlibProxy},
+		{"hand rolled", func(u *url.URL) http.Handler { return newHandRolledProxy(u) }},
+	}
+	for _, v := range variants {
+		fmt.Println(v.name)
+		h := proxyRoutes(routes, v.newProxy)
+		for _, path := range []string{"/api/notes?n=2", "/app.js", "/down/"} {
+			req := httptest.NewRequest(http.MethodGet, path, nil) // from 192.0.2.1
+			req.Header.Set("X-Forwarded-For", "203.0.113.7")
+			req.Header.Set("Connection", "X-Secret")
+			req.Header.Set("X-Secret", "for this hop only")
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, req)
+			fmt.Printf("  %d server=%q via=%q %s", rec.Code, rec.Header().Get("Server"), rec.Header().Get("Via"), rec.Body)
+		}
+	}
+	// Both print:
+	//   200 server="" via="1.1 hellogo" api got /api/notes?n=2, for 203.0.113.7, 192.0.2.1, via "1.1 hellogo", secret ""
+	//   200 server="" via="1.1 hellogo" static got /app.js, for 203.0.113.7, 192.0.2.1, via "1.1 hellogo", secret ""
+	//   502 server="" via="" bad gateway
+
+	_, err = parseProxyRoutes("/api/=localhost:8080")
+	fmt.Println(err) // route /api/: upstream "localhost:8080" should be an http or https URL
+}
@@ -0,0 +1,48 @@
+package main
+
+import "sync"
+
+//////// Pub/sub
+// A broker fans each published message out to every subscriber's channel.
+// Generic, so it works for chat lines, progress events, whatever
+
+type broker[T any] struct {
+	mu   sync.Mutex
+	subs map[chan T]struct{}
+}
+
+func newBroker[T any]() *broker[T] {
+	return &broker[T]{subs: map[chan T]struct{}{}}
+}
+
+// Subscribe returns a channel that receives every message published from now on
+func (b *broker[T]) Subscribe(buffer int) chan T {
+	ch := make(chan T, buffer)
+	b.mu.Lock()
+	b.subs[ch] = struct{}{}
+	b.mu.Unlock()
+	return ch
+}
+
+// Unsubscribe stops delivery and closes the channel, so a range over it ends
+func (b *broker[T]) Unsubscribe(ch chan T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if _, ok := b.subs[ch]; ok {
+		delete(b.subs, ch)
+		close(ch)
+	}
+}
+
+// Publish never blocks: a subscriber whose buffer is full misses the message,
+// rather than one slow reader holding everybody else up
+func (b *broker[T]) Publish(msg T) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- msg:
+		default:
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	crand "crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+//////// Random numbers
+// math/rand/v2: fast, predictable if you know the seed; for games, shuffles, sampling
+// crypto/rand: unpredictable; for anything an attacker must not guess (tokens, keys, salts)
+
+func testMathRand() {
+	// Top level functions use a randomly seeded source, different every run
+	fmt.Println(rand.IntN(100))    // 0 <= n < 100
+	fmt.Println(rand.Float64())    // 0.0 <= f < 1.0
+	fmt.Println(rand.N(time.Hour)) // works with any integer type, including Durations
+
+	// Your own generator with a fixed seed gives the same sequence every time
+	r1 := rand.New(rand.NewPCG(1, 2))
+	r2 := rand.New(rand.NewPCG(1, 2))
+	fmt.Println(r1.IntN(100), r1.IntN(100))
+	fmt.Println(r2.IntN(100), r2.IntN(100)) // same two numbers as above
+
+	// Shuffle takes a swap func so it works on any slice
+	words := []string{"apple", "banana", "cherry", "date"}
+	r1.Shuffle(len(words), func(i, j int) {
+		words[i], words[j] = words[j], words[i]
+	})
+	fmt.Println(words)
+	fmt.Println(r1.Perm(5)) // a shuffled [0 1 2 3 4]
+}
+
+// secureToken returns n random bytes as hex, suitable for session ids, reset links...
+func secureToken(n int) string {
+	b := make([]byte, n)
+	crand.Read(b) // never returns an error (Go 1.24+), it crashes the program instead
+	return hex.EncodeToString(b)
+}
+
+func testCryptoRand() {
+	fmt.Println(secureToken(16))
+	fmt.Println(crand.Text()) // base32 string with 128 bits of randomness
+}
+
+//// Seedable randomness for testability
+// Code that calls the global rand.IntN can't be tested for exact output.
+// Passing the *rand.Rand in lets tests (and the quiz.seed config option) pin it down
+
+func newQuizRand(cfg config) *rand.Rand {
+	seed := uint64(cfg.Quiz.Seed)
+	if seed == 0 {
+		seed = uint64(time.Now().UnixNano())
+	}
+	return rand.New(rand.NewPCG(seed, seed))
+}
+
+// pickQuestions chooses n of the questions without repeats
+func pickQuestions(r *rand.Rand, questions []string, n int) []string {
+	if n > len(questions) {
+		n = len(questions)
+	}
+	picked := make([]string, n)
+	for i, idx := range r.Perm(len(questions))[:n] {
+		picked[i] = questions[idx]
+	}
+	return picked
+}
+
+// dailyLesson is the same all day long for everyone: the seed is the date
+func dailyLesson(lessons []string, day time.Time) string {
+	y, m, d := day.Date()
+	seed := uint64(y*10000 + int(m)*100 + d)
+	return lessons[rand.New(rand.NewPCG(seed, 0)).IntN(len(lessons))]
+}
+
+func testSeededQuiz() {
+	questions := []string{"What is a slice?", "What does defer do?", "What is a goroutine?", "What is an interface?"}
+
+	cfg := defaultConfig()
+	cfg.Quiz.Seed = 42
+	fmt.Println(pickQuestions(newQuizRand(cfg), questions, 2))
+	fmt.Println(pickQuestions(newQuizRand(cfg), questions, 2)) // same, so it can be asserted on
+
+	day := time.Date(2019, time.January, 1, 9, 0, 0, 0, time.UTC)
+	fmt.Println(dailyLesson(questions, day) == dailyLesson(questions, day.Add(8*time.Hour))) // true
+}
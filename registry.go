@@ -0,0 +1,255 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+)
+
+//////// hellogo run
+// Every lesson file's lessons under one name, so hellogo run gc runs gc.go's lessons
+// in order. Plugins (see plugins.go) add more at run time through registerLesson.
+// Lessons that need something outside the repo (the network, a C compiler) print
+// what went wrong and carry on.
+
+// lessons runs fns in order, for files with more than one lesson
+func lessons(fns ...func()) func() {
+	return func() {
+		for _, fn := range fns {
+			fn()
+		}
+	}
+}
+
+// lessonEntry is a registered lesson. Interactive ones run until Ctrl+C or need a terminal
+// (a server, a game), so run --all leaves them out and they only run when named
+type lessonEntry struct {
+	run         func()
+	interactive bool
+}
+
+var lessonRegistry map[string]lessonEntry
+
+// Filled in by init rather than a var initializer: runLessonCommand uses the registry and
+// the plugins lesson calls runLessonCommand, which Go reports as an initialization cycle
+func init() {
+	lessonRegistry = map[string]lessonEntry{
+		"ansi":        {run: testANSI},
+		"archive":     {run: testArchives},
+		"backup":      {run: testBackup},
+		"bank":        {run: testBank},
+		"benchmark":   {run: testBenchmarks},
+		"bignum":      {run: testBigNumbers},
+		"bloom":       {run: testBloom},
+		"buffers":     {run: lessons(testBuffers, testBufferBenchmarks)},
+		"buildtags":   {run: testBuildTags},
+		"cache":       {run: testCache},
+		"calc":        {run: testCalc},
+		"cgo":         {run: testCgo},
+		"chain":       {run: testChain},
+		"compression": {run: lessons(testGzipRoundTrip, testCompressionRatios)},
+		"config":      {run: testConfigParsing},
+		"crawler":     {run: testCrawler},
+		"database":    {run: testDatabase},
+		"defer":       {run: lessons(testDefer, testDeferBenchmarks)},
+		"diff":        {run: testDiff},
+		"dns":         {run: lessons(testDNSLookups, testNetip)},
+		"dnsserver":   {run: testDNSServer},
+		"embed":       {run: testEmbed},
+		"encryption":  {run: testAESGCM},
+		"env":         {run: lessons(testEnvVars, testEnvConfig)},
+		"equality":    {run: testStructEquality},
+		"escape":      {run: lessons(testEscapeDiagnostics, testEscapeBenchmarks)},
+		"examples":    {run: testExamples},
+		"exec":        {run: lessons(testExecBasics, testExecPlumbing, testExecTimeout)},
+		"exercise":    {run: testExercises},
+		"fakes":       {run: testFakes},
+		"fileread":    {run: lessons(testReadingFiles, testScannerTokenLimit, testReaderWrappers)},
+		"filewrite":   {run: testFileWrites},
+		"fuzz":        {run: testFuzzing},
+		"gc":          {run: lessons(testAllocationPatterns, testGCPercent, testMemoryLimit, testGCPauses)},
+		"generate":    {run: testGenerate},
+		"generics":    {run: testGenerics},
+		"gh":          {run: testGitHubClient},
+		"gob":         {run: lessons(testGobAndBinaryRoundTrip, compareProgressEncodings)},
+		"golden":      {run: testGoldenFiles},
+		"graphs":      {run: testGraphs},
+		"hashing":     {run: lessons(testSHA256, testFNV)},
+		"heap":        {run: testHeap},
+		"hello": {run: lessons(testMultipleReturns, testVariadicFunction, testPointers, testStructs, testMethodStruct,
+			testInteraface, testErrors, testGoRoutines, testChannels, testSyncWithWorker, testChannelDirections,
+			testSelect, testNonBlockingChannelsWithSelect, testClosingChannels, testFinally)},
+		"httpclient": {run: lessons(testHTTPGet, testHTTPPost, testHTTPTimeouts, testHTTPRetry)},
+		"httpserver": {run: testHTTPServer, interactive: true},
+		"httptest":   {run: lessons(testHandlersWithRecorder, testClientWithServer)},
+		"ids":        {run: testIDs},
+		"interfaces": {run: testInterfaceValues},
+		"iterators":  {run: testIterators},
+		"jsontool":   {run: testJSONTool},
+		"jwt":        {run: lessons(testHMAC, testJWT)},
+		"kvserver":   {run: testKVServer},
+		"life":       {run: testLife},
+		"loadtest":   {run: testLoadTest},
+		"logging":    {run: lessons(testStandardLogger, testLogDestinations, testSubsystemLoggers)},
+		"lru":        {run: testLRU},
+		"mandelbrot": {run: testMandelbrot},
+		"maps":       {run: testMapInternals},
+		"math":       {run: lessons(testComplexNumbers, testMathTour)},
+		"matrix":     {run: lessons(testMatrix, testMatrixBenchmarks)},
+		"metrics":    {run: testMetrics},
+		"middleware": {run: testMiddleware},
+		"migrate":    {run: testMigrations},
+		"minigrep":   {run: testMinigrep},
+		"minilang":   {run: testMinilang},
+		"passwords":  {run: testPasswordHashing},
+		"paths":      {run: lessons(testFilepath, testWalkDir)},
+		"plugins":    {run: testPlugins},
+		"pprof":      {run: testProfiling},
+		"processes":  {run: testExitCodes},
+		"proto":      {run: testProtoRoundTrip},
+		"proxy":      {run: testProxy},
+		"random":     {run: lessons(testMathRand, testCryptoRand, testSeededQuiz)},
+		"ring":       {run: testRing},
+		"rpc":        {run: testLessonService},
+		"scheduler":  {run: testScheduler},
+		"shortener":  {run: testShortener},
+		"signals":    {run: lessons(testSignalLoop, testIgnoreSignals, testInFlightGoroutines), interactive: true},
+		"slices":     {run: testSliceInternals},
+		"slog":       {run: lessons(testSlogBasics, testSlogJSON, testCustomSlogHandler)},
+		"snake":      {run: testSnake, interactive: true},
+		"sorting":    {run: testSorting},
+		"sse":        {run: testSSE},
+		"static":     {run: testStaticFiles},
+		"storage":    {run: testStorage},
+		"streams":    {run: testPipes},
+		"strconv":    {run: lessons(testStrconv, testStrconvErrors, testParseCalcNumber)},
+		"tables":     {run: lessons(testTabwriter, testTables)},
+		"tcp":        {run: testTCPEcho},
+		"tempfiles":  {run: testTempFiles},
+		"termui":     {run: testTermUI},
+		"testing":    {run: testTableDriven},
+		"textutils":  {run: testTextUtils},
+		"timeformat": {run: lessons(testTimeLayouts, testTimeParsing, testDurations, testTimeZones, testUnixTime)},
+		"todo":       {run: testTodo},
+		"trace":      {run: testTracing},
+		"transfer":   {run: testFileTransfer},
+		"trie":       {run: testTrie},
+		"udp":        {run: lessons(testUDPBurst, testUDPTime)},
+		"wasm":       {run: testWasm},
+		"watch":      {run: testWatch},
+		"weather":    {run: testWeather},
+		"websocket":  {run: testWebSocketEcho},
+		"xml":        {run: lessons(testXMLMarshal, testXMLStreaming)},
+	}
+}
+
+// registerLesson adds a lesson; names are unique so a plugin can't replace a built in one
+func registerLesson(name string, fn func()) error {
+	if _, ok := lessonRegistry[name]; ok {
+		return fmt.Errorf("lesson %q is already registered", name)
+	}
+	lessonRegistry[name] = lessonEntry{run: fn}
+	return nil
+}
+
+func lessonNames() []string {
+	names := make([]string, 0, len(lessonRegistry))
+	for name := range lessonRegistry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// hellogo run [--plugin file.so]... [--all] [--prereqs] [--record file [-keep n]] [lesson...]; lists the lessons when none are given
+func runLessonCommand(args []string) error {
+	flags := flag.NewFlagSet("run", flag.ContinueOnError)
+	var plugins []string
+	flags.Func("plugin", "load more lessons from a Go plugin (repeatable)", func(path string) error {
+		plugins = append(plugins, path)
+		return nil
+	})
+	all := flags.Bool("all", false, "run every lesson but the interactive ones (a server, a game), with a progress bar on stderr when stdout isn't the terminal")
+	prereqs := flags.Bool("prereqs", false, "run the lessons' prerequisites (graphs.go) first; with no lessons, list them all in that order")
+	record := flags.String("record", "", "save the last lines of output to this file, for hellogo replay")
+	keep := flags.Int("keep", 100, "lines -record keeps")
+	if err := flags.Parse(args); err != nil || *all && flags.NArg() > 0 {
+		return errUsage
+	}
+	for _, path := range plugins {
+		if err := loadLessonPlugin(path); err != nil {
+			return err
+		}
+	}
+
+	if flags.NArg() == 0 && !*all {
+		names := lessonNames()
+		if *prereqs {
+			var err error
+			if names, err = lessonOrder(nil); err != nil {
+				return err
+			}
+		}
+		for _, name := range names {
+			fmt.Println(name)
+		}
+		return nil
+	}
+	names := flags.Args()
+	if *all {
+		names = lessonNames()
+	}
+	for i, name := range names {
+		resolved, err := resolveLessonName(name) // a unique prefix will do
+		if err != nil {
+			return err
+		}
+		names[i] = resolved
+	}
+	// Interactive lessons only run when named: not under --all, and not pulled in as
+	// another lesson's prerequisite (proxy needs httpserver, which would never return)
+	named := map[string]bool{}
+	if !*all {
+		for _, name := range names {
+			named[name] = true
+		}
+	}
+	if *prereqs {
+		var err error
+		if names, err = lessonOrder(names); err != nil {
+			return err
+		}
+	}
+	names = slices.DeleteFunc(names, func(name string) bool {
+		return lessonRegistry[name].interactive && !named[name]
+	})
+	// The bar only when the lessons' output goes elsewhere: on the same terminal the two
+	// would draw over each other
+	var bar *progressBar
+	if *all && !isTerminal(os.Stdout) {
+		bar = newProgressBar(os.Stderr, "lessons", int64(len(names)))
+	}
+	run := func() {
+		for _, name := range names {
+			lessonRegistry[name].run()
+			countLessonRun(name)
+			if bar != nil {
+				bar.Add(1)
+			}
+		}
+		if bar != nil {
+			bar.Finish()
+		}
+	}
+	if *record == "" {
+		run()
+		return nil
+	}
+	rec := newLineRing(*keep)
+	if err := teeStdout(run, rec); err != nil {
+		return err
+	}
+	return saveRecording(*record, rec)
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestRunAllSkipsInteractive(t *testing.T) {
+	var ran []string
+	block := make(chan struct{})
+	saved := lessonRegistry
+	lessonRegistry = map[string]lessonEntry{
+		"first":  {run: func() { ran = append(ran, "first") }},
+		"server": {run: func() { ran = append(ran, "server"); <-block }, interactive: true},
+		"second": {run: func() { ran = append(ran, "second") }},
+	}
+	t.Cleanup(func() { lessonRegistry = saved })
+
+	done := make(chan error, 1)
+	go func() { done <- runLessonCommand([]string{"--all"}) }()
+	select {
+	case err := <-done:
+		if err != nil || !slices.Equal(ran, []string{"first", "second"}) {
+			t.Errorf("run --all ran %v, %v; want [first second]", ran, err)
+		}
+	case <-time.After(5 * time.Second):
+		close(block)
+		t.Fatal("run --all didn't return, it started the interactive lesson")
+	}
+
+	// Named, it runs like any other
+	close(block)
+	ran = nil
+	if err := runLessonCommand([]string{"server"}); err != nil || !slices.Equal(ran, []string{"server"}) {
+		t.Errorf("run server ran %v, %v; want [server]", ran, err)
+	}
+}
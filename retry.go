@@ -0,0 +1,49 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+//////// Retrying
+// Networks fail for a moment all the time; trying again after a short, growing
+// pause (exponential backoff) gets most flaky calls through
+
+// permanentError wraps errors that retrying won't fix (a 404, bad input...)
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+
+func (e *permanentError) Unwrap() error { return e.err }
+
+func permanent(err error) error {
+	return &permanentError{err}
+}
+
+// retry calls fn up to attempts times, sleeping backoff, 2*backoff, 4*backoff... between
+// tries. It stops early on success, on a permanent error, or when ctx is done
+func retry(ctx context.Context, attempts int, backoff time.Duration, fn func() error) error {
+	var err error
+	for i := 0; i < attempts; i++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+		var perm *permanentError
+		if errors.As(err, &perm) {
+			return perm.err
+		}
+		if i == attempts-1 {
+			break
+		}
+
+		select {
+		case <-time.After(backoff << i):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return err
+}
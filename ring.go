@@ -0,0 +1,269 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"container/ring"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+//////// Ring buffers
+// A fixed-size buffer that keeps the last n things it was given: once full, each new
+// one overwrites the oldest. It's a slice plus the index where the oldest item is;
+// writing goes at (start+len) % n, and when that lands on start, start moves on. No
+// copying, no growing, no allocation after the first. Logs, recent history, audio
+// samples and network queues all want exactly that.
+//
+// container/ring is the other kind: a circular linked list, every element pointing at
+// the next and previous. You walk it with Next and Prev (there's no end) and can splice
+// rings together with Link. It's rarely what you want, as a slice is simpler and faster,
+// but it's in the standard library, so there's a look at it below.
+//
+// hellogo run --record file keeps the last lines of the lessons' output in one, and
+// hellogo replay file prints them again:
+//
+//	hellogo run --record /tmp/out -keep 20 chain heap
+//	hellogo replay /tmp/out
+
+type ringBuffer[T any] struct {
+	items []T
+	start int // index of the oldest item
+	n     int // items held, up to len(items)
+}
+
+func newRingBuffer[T any](size int) *ringBuffer[T] {
+	return &ringBuffer[T]{items: make([]T, max(size, 1))}
+}
+
+func (r *ringBuffer[T]) Len() int { return r.n }
+func (r *ringBuffer[T]) Cap() int { return len(r.items) }
+
+// Push adds v, overwriting the oldest item when full; it reports whether one was overwritten
+func (r *ringBuffer[T]) Push(v T) bool {
+	if r.n < len(r.items) {
+		r.items[(r.start+r.n)%len(r.items)] = v
+		r.n++
+		return false
+	}
+	r.items[r.start] = v // the oldest slot, which is now the newest
+	r.start = (r.start + 1) % len(r.items)
+	return true
+}
+
+// At is the i'th item, oldest first
+func (r *ringBuffer[T]) At(i int) T {
+	if i < 0 || i >= r.n {
+		panic(fmt.Sprintf("ringBuffer: index %d out of range with length %d", i, r.n))
+	}
+	return r.items[(r.start+i)%len(r.items)]
+}
+
+// Items copies the items out, oldest first: the part from start to the end of the
+// slice, then the part that wrapped around to the front
+func (r *ringBuffer[T]) Items() []T {
+	out := make([]T, 0, r.n)
+	end := min(r.start+r.n, len(r.items))
+	out = append(out, r.items[r.start:end]...)
+	return append(out, r.items[:r.n-(end-r.start)]...)
+}
+
+//// Keeping the last lines of output
+
+// lineRing is an io.Writer that keeps the last lines written to it
+type lineRing struct {
+	lines   *ringBuffer[string]
+	partial []byte // written since the last newline
+	dropped int    // lines that fell off the front
+}
+
+func newLineRing(keep int) *lineRing { return &lineRing{lines: newRingBuffer[string](keep)} }
+
+func (l *lineRing) Write(p []byte) (int, error) {
+	n := len(p)
+	for {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			l.partial = append(l.partial, p...)
+			return n, nil
+		}
+		if l.lines.Push(string(append(l.partial, p[:i]...))) {
+			l.dropped++
+		}
+		l.partial = l.partial[:0]
+		p = p[i+1:]
+	}
+}
+
+// Lines is what's kept, including an unfinished last line
+func (l *lineRing) Lines() []string {
+	lines := l.lines.Items()
+	if len(l.partial) > 0 {
+		lines = append(lines, string(l.partial))
+	}
+	return lines
+}
+
+// teeStdout runs fn with everything it prints going to the real stdout and to w as well
+func teeStdout(fn func(), w io.Writer) error {
+	return pipeStdout(fn, io.MultiWriter(os.Stdout, w))
+}
+
+// pipeStdout runs fn with everything it prints going to w instead, as it's printed
+func pipeStdout(fn func(), w io.Writer) error {
+	r, pw, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	stdout := os.Stdout
+	os.Stdout = pw
+	done := make(chan struct{})
+	go func() {
+		io.Copy(w, r)
+		close(done)
+	}()
+	defer func() { os.Stdout = stdout }()
+	fn()
+	pw.Close()
+	<-done
+	return nil
+}
+
+// saveRecording writes the kept lines, with a first line giving the session a ULID
+// (ids.go) and saying how many lines were cut
+func saveRecording(path string, l *lineRing) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# hellogo recording %s, %d earlier lines dropped\n", newULID(time.Now()), l.dropped)
+	for _, line := range l.Lines() {
+		b.WriteString(line + "\n")
+	}
+	return writeFileAtomic(path, []byte(b.String()), 0644)
+}
+
+// hellogo replay [-n lines] file
+func replayCommand(args []string) error {
+	flags := flag.NewFlagSet("replay", flag.ContinueOnError)
+	n := flags.Int("n", 0, "only the last n lines (0: all)")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 || *n < 0 {
+		return errUsage
+	}
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	sc := bufio.NewScanner(f)
+	if !sc.Scan() || !strings.HasPrefix(sc.Text(), "# hellogo recording") {
+		return fmt.Errorf("%s is not a hellogo recording", flags.Arg(0))
+	}
+	// Older recordings have no session id
+	session, _, _ := strings.Cut(strings.TrimPrefix(sc.Text(), "# hellogo recording "), ",")
+	if id, err := parseULID(session); err == nil {
+		fmt.Fprintf(os.Stderr, "session %s, recorded %s\n", id, id.Time().Local().Format(time.DateTime))
+	}
+	if *n == 0 {
+		for sc.Scan() {
+			fmt.Println(sc.Text())
+		}
+		return sc.Err()
+	}
+	lines := newLineRing(*n)
+	for sc.Scan() {
+		fmt.Fprintln(lines, sc.Text())
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	for _, line := range lines.Lines() {
+		fmt.Println(line)
+	}
+	return nil
+}
+
+func testRing() {
+	r := newRingBuffer[int](4)
+	var overwrote []bool
+	for i := range 6 {
+		overwrote = append(overwrote, r.Push(i))
+	}
+	fmt.Println(overwrote)                            // [false false false false true true]: full after four
+	fmt.Println(r.Items(), r.Len(), r.Cap(), r.start) // [2 3 4 5] 4 4 2
+	fmt.Println(r.items)                              // [4 5 2 3]: the slice itself, wrapped
+	fmt.Println(r.At(0), r.At(3))                     // 2 5
+
+	runCase("TestRingBuffer/wraparound", func(errorf errorfFunc) {
+		for _, size := range []int{1, 2, 3, 7} {
+			r := newRingBuffer[int](size)
+			for i := range 20 {
+				r.Push(i)
+				// Always the last min(i+1, size) numbers, in order
+				first := max(0, i+1-size)
+				items := r.Items()
+				if len(items) != i+1-first || r.Len() != len(items) {
+					errorf("size %d after %d: %d items, Len %d", size, i, len(items), r.Len())
+					continue
+				}
+				for j, v := range items {
+					if v != first+j || r.At(j) != v {
+						errorf("size %d after %d: items %v, want %d onwards", size, i, items, first)
+						break
+					}
+				}
+			}
+		}
+	})
+
+	runCase("TestRingBuffer/lines", func(errorf errorfFunc) {
+		l := newLineRing(3)
+		io.WriteString(l, "one\ntwo\nth")
+		io.WriteString(l, "ree\nfour\n")
+		io.WriteString(l, "fi")
+		got := strings.Join(l.Lines(), "|")
+		if want := "two|three|four|fi"; got != want || l.dropped != 1 {
+			errorf("lines %q, dropped %d; want %q, 1", got, l.dropped, want)
+		}
+	})
+
+	// Recording a lesson: its output still shows, and the last 3 lines are saved
+	dir := makeTempDir("ring")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "rec")
+	rec := newLineRing(3)
+	teeStdout(func() {
+		for i := range 5 {
+			fmt.Println("line", i)
+		}
+	}, rec)
+	// line 0
+	// ...
+	// line 4
+	saveRecording(path, rec)
+	replayCommand([]string{"-n", "2", path})
+	// line 3
+	// line 4
+
+	//// container/ring
+	// Five elements in a circle; Value is an any
+	cr := ring.New(5)
+	for i := range cr.Len() {
+		cr.Value = i * i
+		cr = cr.Next()
+	}
+	values := func(r *ring.Ring) []any {
+		var vs []any
+		r.Do(func(v any) { vs = append(vs, v) })
+		return vs
+	}
+	fmt.Println(values(cr)) // [0 1 4 9 16]
+	// There's no start or end, only where you're holding it
+	cr = cr.Move(2)
+	fmt.Println(values(cr)) // [4 9 16 0 1]
+	// Unlink takes out the n elements after this one, as a ring of their own
+	removed := cr.Unlink(2)
+	fmt.Println(cr.Len(), removed.Len(), removed.Value) // 3 2 9
+}
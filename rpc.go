@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+//////// RPC from an IDL
+// gRPC: describe the service in a .proto file (see LessonService in proto/hellogo.proto),
+// protoc generates a Go interface for the server and a client stub, and you just fill
+// in the methods. The client calls client.GetLesson(ctx, req) like a local function.
+//
+// The real thing needs google.golang.org/grpc and HTTP/2, which this repo doesn't pull in,
+// so below is a hand written version of the generated code over a much simpler transport.
+// The shape (interface, stubs, unary + streaming calls) is what you'd get from protoc.
+//
+// Transport: each message is a gRPC-style frame: 1 flag byte + 4 byte length + bytes.
+// A call is: method frame, request frame, then zero or more response frames, then a
+// status frame (flag 1) holding "" for success or the error text.
+
+//// "Generated" code
+
+type getLessonRequest struct {
+	Name string
+}
+
+func (r getLessonRequest) Marshal() []byte {
+	return appendProtoString(nil, 1, r.Name)
+}
+
+func (r *getLessonRequest) Unmarshal(data []byte) error {
+	*r = getLessonRequest{}
+	return walkProto(data, func(field int, _ uint64, b []byte) {
+		if field == 1 {
+			r.Name = string(b)
+		}
+	})
+}
+
+// lessonServiceServer is what you implement
+type lessonServiceServer interface {
+	GetLesson(ctx context.Context, req getLessonRequest) (protoLesson, error)
+	ListLessons(ctx context.Context, send func(protoLesson) error) error
+}
+
+const (
+	rpcGetLesson   = "/hellogo.LessonService/GetLesson"
+	rpcListLessons = "/hellogo.LessonService/ListLessons"
+
+	rpcFlagMessage = 0
+	rpcFlagStatus  = 1
+)
+
+func writeRPCFrame(w io.Writer, flag byte, data []byte) error {
+	frame := []byte{flag}
+	frame = binary.BigEndian.AppendUint32(frame, uint32(len(data)))
+	_, err := w.Write(append(frame, data...))
+	return err
+}
+
+func readRPCFrame(r io.Reader) (flag byte, data []byte, err error) {
+	var head [5]byte
+	if _, err = io.ReadFull(r, head[:]); err != nil {
+		return
+	}
+	size := binary.BigEndian.Uint32(head[1:])
+	if size > 4<<20 {
+		return 0, nil, errors.New("rpc: frame too large")
+	}
+	data = make([]byte, size)
+	_, err = io.ReadFull(r, data)
+	return head[0], data, err
+}
+
+// serveLessonService dispatches calls on one connection to srv
+func serveLessonService(conn net.Conn, srv lessonServiceServer) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	w := bufio.NewWriter(conn)
+	ctx := context.Background()
+
+	for {
+		_, method, err := readRPCFrame(r)
+		if err != nil {
+			return
+		}
+		_, reqData, err := readRPCFrame(r)
+		if err != nil {
+			return
+		}
+
+		var callErr error
+		switch string(method) {
+		case rpcGetLesson:
+			var req getLessonRequest
+			if callErr = req.Unmarshal(reqData); callErr == nil {
+				var resp protoLesson
+				if resp, callErr = srv.GetLesson(ctx, req); callErr == nil {
+					callErr = writeRPCFrame(w, rpcFlagMessage, resp.Marshal())
+				}
+			}
+		case rpcListLessons:
+			callErr = srv.ListLessons(ctx, func(l protoLesson) error {
+				if err := writeRPCFrame(w, rpcFlagMessage, l.Marshal()); err != nil {
+					return err
+				}
+				return w.Flush() // stream it out now, don't wait for the end
+			})
+		default:
+			callErr = fmt.Errorf("unknown method %s", method)
+		}
+
+		status := ""
+		if callErr != nil {
+			status = callErr.Error()
+		}
+		writeRPCFrame(w, rpcFlagStatus, []byte(status))
+		if w.Flush() != nil {
+			return
+		}
+	}
+}
+
+// lessonServiceClient is the stub you call. One call at a time per client
+type lessonServiceClient struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialLessonService(addr string) (*lessonServiceClient, error) {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &lessonServiceClient{conn: conn, r: bufio.NewReader(conn)}, nil
+}
+
+func (c *lessonServiceClient) Close() error { return c.conn.Close() }
+
+// call sends a request then hands every response message to recv until the status arrives
+func (c *lessonServiceClient) call(ctx context.Context, method string, req []byte, recv func([]byte) error) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		c.conn.SetDeadline(deadline)
+	}
+	if err := writeRPCFrame(c.conn, rpcFlagMessage, []byte(method)); err != nil {
+		return err
+	}
+	if err := writeRPCFrame(c.conn, rpcFlagMessage, req); err != nil {
+		return err
+	}
+	for {
+		flag, data, err := readRPCFrame(c.r)
+		if err != nil {
+			return err
+		}
+		if flag == rpcFlagStatus {
+			if len(data) > 0 {
+				return errors.New("rpc error: " + string(data))
+			}
+			return nil
+		}
+		if err := recv(data); err != nil {
+			return err
+		}
+	}
+}
+
+func (c *lessonServiceClient) GetLesson(ctx context.Context, req getLessonRequest) (protoLesson, error) {
+	var resp protoLesson
+	err := c.call(ctx, rpcGetLesson, req.Marshal(), resp.Unmarshal)
+	return resp, err
+}
+
+// ListLessons calls fn for each lesson as it arrives off the stream
+func (c *lessonServiceClient) ListLessons(ctx context.Context, fn func(protoLesson)) error {
+	return c.call(ctx, rpcListLessons, nil, func(data []byte) error {
+		var l protoLesson
+		if err := l.Unmarshal(data); err != nil {
+			return err
+		}
+		fn(l)
+		return nil
+	})
+}
+
+//// The part you'd actually write: the server implementation
+
+type embeddedLessonService struct{}
+
+func (embeddedLessonService) GetLesson(_ context.Context, req getLessonRequest) (protoLesson, error) {
+	lessons, err := embeddedLessons()
+	if err != nil {
+		return protoLesson{}, err
+	}
+	for i, l := range lessons {
+		if l.Name == req.Name {
+			return protoLesson{Name: l.Name, Title: strings.TrimSuffix(l.Name, ".go"), Order: int32(i + 1)}, nil
+		}
+	}
+	return protoLesson{}, fmt.Errorf("lesson %q not found", req.Name)
+}
+
+func (embeddedLessonService) ListLessons(_ context.Context, send func(protoLesson) error) error {
+	lessons, err := embeddedLessons()
+	if err != nil {
+		return err
+	}
+	for i, l := range lessons {
+		if err := send(protoLesson{Name: l.Name, Title: strings.TrimSuffix(l.Name, ".go"), Order: int32(i + 1)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func testLessonService() {
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		fmt.Println("listen failed:", err)
+		return
+	}
+	defer ln.Close()
+	go serveTCP(ln, func(conn net.Conn) { serveLessonService(conn, embeddedLessonService{}) })
+
+	client, err := dialLessonService(ln.Addr().String())
+	if err != nil {
+		fmt.Println("dial failed:", err)
+		return
+	}
+	defer client.Close()
+
+	ctx, cancel := lookupContext() // 3 second deadline
+	defer cancel()
+
+	lesson, err := client.GetLesson(ctx, getLessonRequest{Name: "rpc.go"})
+	fmt.Printf("%+v %v\n", lesson, err) // {Name:rpc.go Title:rpc Order:...} <nil>
+
+	_, err = client.GetLesson(ctx, getLessonRequest{Name: "nope.go"})
+	fmt.Println(err) // rpc error: lesson "nope.go" not found
+
+	count := 0
+	err = client.ListLessons(ctx, func(l protoLesson) { count++ })
+	fmt.Println(count, "lessons streamed", err)
+}
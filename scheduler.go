@@ -0,0 +1,181 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sync"
+	"time"
+)
+
+//////// Goroutines and the scheduler
+// The runtime runs goroutines on GOMAXPROCS threads at a time, one per CPU unless you
+// set it lower (or a container's CPU limit does). Each thread takes goroutines from a
+// queue of its own, steals from the others' when it runs out, and hands off the CPU
+// when one blocks: on a channel, a lock, a sleep or a system call. A goroutine that
+// computes without blocking gets preempted after about 10ms so it can't hog a thread.
+// runtime.Gosched gives the thread up voluntarily, which is rarely needed now.
+//
+// A goroutine starts with a small stack (2KB or so) that's copied to a bigger one when
+// it runs out, so deep recursion works and a hundred thousand goroutines fit in a few
+// hundred MB. Threads get a fixed stack of a MB or more each, which is why "one thread
+// per connection" runs out long before "one goroutine per connection" does.
+//
+// That makes goroutines concurrent (many in progress at once) and, with more than one
+// CPU, parallel (more than one running at the same instant). CPU bound work gets faster
+// with more goroutines up to GOMAXPROCS and no further; work that mostly waits (network,
+// disk) keeps getting faster well past it. The timings below show the first kind, so on
+// a machine with one CPU they won't speed up at all.
+
+// countPrimes counts the primes in [lo, hi) by trial division: slow on purpose
+func countPrimes(lo, hi int) int {
+	count := 0
+	for n := max(lo, 2); n < hi; n++ {
+		prime := true
+		for d := 2; d*d <= n; d++ {
+			if n%d == 0 {
+				prime = false
+				break
+			}
+		}
+		if prime {
+			count++
+		}
+	}
+	return count
+}
+
+// parallelCount splits [0, n) between workers goroutines and adds up what count says
+// about each part. The parts are interleaved chunks, not one block each: primes get
+// slower to check as they get bigger, and one worker shouldn't get all the slow ones.
+func parallelCount(n, workers int, count func(lo, hi int) int) int {
+	const chunk = 1000
+	var wg sync.WaitGroup
+	totals := make([]int, workers)
+	for w := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for lo := w * chunk; lo < n; lo += workers * chunk {
+				totals[w] += count(lo, min(lo+chunk, n))
+			}
+		}()
+	}
+	wg.Wait()
+	sum := 0
+	for _, t := range totals {
+		sum += t
+	}
+	return sum
+}
+
+// waitForGoroutines polls until at most n goroutines are left: a goroutine that has
+// called wg.Done may not have quite exited yet
+func waitForGoroutines(n int, timeout time.Duration) int {
+	deadline := time.Now().Add(timeout)
+	for runtime.NumGoroutine() > n && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	return runtime.NumGoroutine()
+}
+
+// recurse uses about 100 bytes of stack per level
+func recurse(depth int) int {
+	var pad [64]byte
+	if depth == 0 {
+		return int(pad[0])
+	}
+	return recurse(depth-1) + int(pad[depth%64])
+}
+
+func stackInUse() uint64 {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	return m.StackInuse
+}
+
+func testScheduler() {
+	//// What there is to run on
+	fmt.Println(runtime.NumCPU(), runtime.GOMAXPROCS(0)) // e.g. 8 8: GOMAXPROCS(0) reads it without changing it
+
+	//// Counting goroutines
+	before := runtime.NumGoroutine()
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 1000 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-release
+		}()
+	}
+	during := runtime.NumGoroutine()
+	stacks := stackInUse()
+	close(release)
+	wg.Wait()
+	fmt.Println(during-before, waitForGoroutines(before, time.Second)-before) // 1000 0
+
+	//// Stacks grow
+	// 1000 goroutines parked on a channel, each with its starting stack
+	fmt.Printf("about %dKB of stack each\n", stacks/1000/1024) // about 2KB of stack each
+	done := make(chan int)
+	go func() { done <- recurse(100_000) }() // ~10MB of stack: fine, it gets copied bigger as it goes
+	<-done
+	fmt.Println("recursed 100000 deep")
+
+	//// Gosched, with one thread
+	// Two goroutines taking turns on one thread. Gosched puts the caller at the back of
+	// the queue; without it, the first would likely finish before the second starts
+	prev := runtime.GOMAXPROCS(1)
+	var mu sync.Mutex
+	var order []string
+	wg.Add(2)
+	for _, name := range []string{"a", "b"} {
+		go func() {
+			defer wg.Done()
+			for range 3 {
+				mu.Lock()
+				order = append(order, name)
+				mu.Unlock()
+				runtime.Gosched()
+			}
+		}()
+	}
+	wg.Wait()
+	runtime.GOMAXPROCS(prev)
+	fmt.Println(order) // usually [b a b a b a]: the newest goroutine tends to go first
+
+	//// CPU bound work across cores
+	// Speedup is time with 1 worker over time with n: about n until GOMAXPROCS, flat after
+	const n = 1_000_000
+	want := countPrimes(0, n)
+	procs := runtime.GOMAXPROCS(0)
+	var counts []int
+	for w := 1; w < procs; w *= 2 {
+		counts = append(counts, w)
+	}
+	counts = append(counts, procs, 2*procs)
+	var base time.Duration
+	t := newTable("workers", "time", "speedup").AlignRight(0, 1, 2)
+	for _, workers := range counts {
+		start := time.Now()
+		if got := parallelCount(n, workers, countPrimes); got != want {
+			fmt.Println("wrong count:", got, want)
+			return
+		}
+		took := time.Since(start)
+		if workers == 1 {
+			base = took
+		}
+		t.Row(workers, took.Round(time.Millisecond), fmt.Sprintf("%.1fx", float64(base)/float64(took)))
+	}
+	t.Render(os.Stdout)
+	// On 8 CPUs:
+	// workers   time  speedup
+	// -------  -----  -------
+	//       1  280ms     1.0x
+	//       2  145ms     1.9x
+	//       4   77ms     3.6x
+	//       8   47ms     6.0x
+	//      16   46ms     6.1x: more goroutines than threads only adds switching
+}
@@ -0,0 +1,13 @@
+package main
+
+import "testing"
+
+func TestParallelCount(t *testing.T) {
+	for _, tc := range []struct{ n, want int }{{0, 0}, {2, 0}, {3, 1}, {100, 25}, {10_007, 1229}, {10_008, 1230}} {
+		for _, workers := range []int{1, 3, 16} {
+			if got := parallelCount(tc.n, workers, countPrimes); got != tc.want {
+				t.Errorf("parallelCount(%d, %d) = %d, want %d", tc.n, workers, got, tc.want)
+			}
+		}
+	}
+}
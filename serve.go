@@ -0,0 +1,91 @@
+package main
+
+import (
+	"expvar"
+	"html/template"
+	"net/http"
+	"os"
+	"time"
+)
+
+//////// hellogo serve
+// Serves the built in lessons over HTTP, using the pieces from httpserver.go
+// and the middleware stack from middleware.go:
+//
+//	GET /                a page to run lessons from, with their output streamed live
+//	GET /run/{name}      the websocket behind it: runs a lesson, sending what it prints (websocket.go)
+//	GET /leaderboard     the most run lessons, updated live from /events/leaderboard (sse.go)
+//	GET /lessons         list of lesson files (JSON)
+//	GET /lessons/{name}  source of one lesson
+//	GET /src/{file}      the lesson files as static files (see static.go)
+//	GET /wasm/play.html  the geometry and generics lessons running in the browser (see wasm.go)
+//	GET /metrics         Prometheus metrics (see metrics.go)
+//	GET /debug/vars      the same metrics, and Go runtime stats, as expvar JSON
+//
+// Responses are gzipped for clients that accept it (gzipHandler, compression.go).
+
+// The pages are html/template, not text/template: it escapes what goes into them
+var pages = template.Must(template.ParseFS(templateFS, "templates/*.html"))
+
+func serveRoutes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		var names []string
+		for _, name := range lessonNames() {
+			if !lessonRegistry[name].interactive {
+				names = append(names, name)
+			}
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		pages.ExecuteTemplate(w, "index.html", names)
+	})
+	mux.HandleFunc("GET /run/{name}", wsRunHandler)
+	mux.HandleFunc("GET /leaderboard", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		pages.ExecuteTemplate(w, "leaderboard.html", nil)
+	})
+	mux.HandleFunc("GET /events/leaderboard", leaderboardEvents(time.Second))
+	mux.HandleFunc("GET /lessons", func(w http.ResponseWriter, r *http.Request) {
+		lessons, err := embeddedLessons()
+		if err != nil {
+			writeAPIError(w, errCodeInternal, err.Error())
+			return
+		}
+		writeJSON(w, http.StatusOK, lessons)
+	})
+	mux.HandleFunc("GET /lessons/{name}", func(w http.ResponseWriter, r *http.Request) {
+		data, err := lessonSources.ReadFile(r.PathValue("name"))
+		if err != nil {
+			writeAPIError(w, errCodeNotFound, "no lesson "+r.PathValue("name"))
+			return
+		}
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Write(data)
+	})
+	mux.Handle("GET /src/", staticFiles("/src/", lessonSources))
+	mux.Handle("GET /wasm/", staticFiles("/wasm/", os.DirFS(wasmDir))) // from disk, hellogo buildwasm fills it
+	mux.HandleFunc("GET /fractal.png", fractalHandler)                 // mandelbrot.go
+	mux.HandleFunc("GET /metrics", metricsHandler)
+	mux.Handle("GET /debug/vars", expvar.Handler())
+	return gzipHandler(mux)
+}
+
+// hellogo serve [addr]; the address defaults to server.addr from the config
+func serveCommand(args []string) error {
+	if len(args) > 1 {
+		return errUsage
+	}
+	cfg, err := loadConfig(configFile)
+	if err != nil {
+		return err
+	}
+	addr := cfg.Server.Addr
+	if len(args) == 1 {
+		addr = args[0]
+	}
+
+	ctx, stop := shutdownContext()
+	defer stop()
+	srv := newLessonServer(addr, standardMiddleware(serveRoutes()))
+	return serveUntilDone(ctx, srv)
+}
@@ -0,0 +1,228 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+//////// Mini project: a URL shortener
+// HTTP routing (httpserver.go), JSON in and out, the store interface for persistence
+// (storage.go), and httptest for the tests, all in one service:
+//
+//	POST /links               {"url": "https://go.dev/doc"} -> 201 {"code": "b", "short_url": ...}
+//	GET  /{code}              302 redirect to the URL, and counts the visit
+//	GET  /links/{code}/stats  {"code": "b", "url": ..., "created": ..., "visits": 3}
+//
+// Codes are just the link's id in base 62 (0-9a-zA-Z), so a million links still only
+// need 4 characters. They're guessable, which is fine for a lesson; real services mix
+// in randomness so nobody can walk through everyone's links.
+// Run it: hellogo shortener, then curl -i -d '{"url":"https://go.dev"}' localhost:8090/links
+
+const base62Alphabet = "0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+func encodeBase62(n int) string {
+	if n == 0 {
+		return "0"
+	}
+	var digits []byte
+	for ; n > 0; n /= 62 {
+		digits = append(digits, base62Alphabet[n%62])
+	}
+	for i, j := 0, len(digits)-1; i < j; i, j = i+1, j-1 {
+		digits[i], digits[j] = digits[j], digits[i] // least significant came out first
+	}
+	return string(digits)
+}
+
+func decodeBase62(s string) (int, error) {
+	// 10 digits go up to 62^10-1, about 8.4e17, which fits in an int64; 11 could overflow.
+	// A leading 0 would give a second code ("0b" as well as "b") for the same link
+	if s == "" || len(s) > 10 || (len(s) > 1 && s[0] == '0') {
+		return 0, fmt.Errorf("bad code %q", s)
+	}
+	n := 0
+	for _, c := range []byte(s) {
+		d := strings.IndexByte(base62Alphabet, c)
+		if d < 0 {
+			return 0, fmt.Errorf("bad code %q", s)
+		}
+		n = n*62 + d
+	}
+	return n, nil
+}
+
+type shortener struct {
+	store store
+	clock clock
+}
+
+type linkStats struct {
+	Code    string    `json:"code"`
+	URL     string    `json:"url"`
+	Created time.Time `json:"created"`
+	Visits  int       `json:"visits"`
+}
+
+func (s *shortener) routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /links", s.create)
+	mux.HandleFunc("GET /links/{code}/stats", s.stats)
+	mux.HandleFunc("GET /{code}", s.redirect) // the more specific pattern above wins
+	return mux
+}
+
+// validTarget only lets absolute http(s) URLs in; anything else (javascript:, relative
+// paths) would turn the redirect into a hole
+func validTarget(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("want an absolute http or https URL")
+	}
+	return nil
+}
+
+func (s *shortener) create(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, 8<<10)
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	var req struct {
+		URL string `json:"url"`
+	}
+	if err := dec.Decode(&req); err != nil {
+		writeAPIError(w, errCodeBadRequest, err.Error())
+		return
+	}
+	if err := validTarget(req.URL); err != nil {
+		writeAPIError(w, errCodeBadRequest, fmt.Sprintf("url %q: %v", req.URL, err))
+		return
+	}
+
+	l, err := s.store.CreateLink(r.Context(), req.URL, s.clock.Now())
+	if err != nil {
+		writeAPIError(w, errCodeInternal, err.Error())
+		return
+	}
+	code := encodeBase62(l.ID)
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	w.Header().Set("Location", "/links/"+code+"/stats")
+	writeJSON(w, http.StatusCreated, map[string]string{
+		"code":      code,
+		"short_url": scheme + "://" + r.Host + "/" + code,
+		"url":       l.URL,
+	})
+}
+
+// lookup finds the link for the {code} in the path, writing the error response if there isn't one
+func (s *shortener) lookup(w http.ResponseWriter, r *http.Request) (link, bool) {
+	id, err := decodeBase62(r.PathValue("code"))
+	if err != nil {
+		writeAPIError(w, errCodeNotFound, err.Error())
+		return link{}, false
+	}
+	l, err := s.store.Link(r.Context(), id)
+	if errors.Is(err, errLinkNotFound) {
+		writeAPIError(w, errCodeNotFound, "no link "+r.PathValue("code"))
+		return link{}, false
+	}
+	if err != nil {
+		writeAPIError(w, errCodeInternal, err.Error())
+		return link{}, false
+	}
+	return l, true
+}
+
+func (s *shortener) redirect(w http.ResponseWriter, r *http.Request) {
+	l, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	if err := s.store.RecordVisit(r.Context(), l.ID); err != nil {
+		slog.Warn("can't record visit", "link", l.ID, "err", err) // still redirect
+	}
+	// 302, not 301: browsers cache a 301 forever and the visits would stop being counted
+	http.Redirect(w, r, l.URL, http.StatusFound)
+}
+
+func (s *shortener) stats(w http.ResponseWriter, r *http.Request) {
+	l, ok := s.lookup(w, r)
+	if !ok {
+		return
+	}
+	writeJSON(w, http.StatusOK, linkStats{encodeBase62(l.ID), l.URL, l.Created, l.Visits})
+}
+
+// hellogo shortener [addr]; links are kept in the configured store
+func shortenerCommand(args []string) error {
+	if len(args) > 1 {
+		return errUsage
+	}
+	addr := "localhost:8090"
+	if len(args) == 1 {
+		addr = args[0]
+	}
+	cfg, err := loadConfig(configFile)
+	if err != nil {
+		return err
+	}
+	ctx, stop := shutdownContext()
+	defer stop()
+	st, err := openStore(ctx, cfg.Storage)
+	if err != nil {
+		return err
+	}
+	defer st.Close()
+
+	s := &shortener{store: st, clock: realClock{}}
+	return serveUntilDone(ctx, newLessonServer(addr, standardMiddleware(s.routes())))
+}
+
+func testShortener() {
+	for _, n := range []int{0, 1, 61, 62, 1_000_000} {
+		code := encodeBase62(n)
+		back, _ := decodeBase62(code)
+		fmt.Print(code, "=", back, " ")
+	}
+	fmt.Println() // 0=0 1=1 Z=61 10=62 4c92=1000000
+
+	dir := makeTempDir("shortener")
+	defer os.RemoveAll(dir)
+	clk := &fakeClock{now: time.Date(2019, time.January, 1, 12, 0, 0, 0, time.UTC)}
+	s := &shortener{store: openJSONStore(filepath.Join(dir, "links.json")), clock: clk}
+	h := s.routes()
+
+	rec := serveRecorded(h, "POST", "/links", `{"url": "https://go.dev/doc"}`)
+	fmt.Print(rec.Code, " ", rec.Body.String()) // 201 {"code":"1","short_url":"http://example.com/1","url":"https://go.dev/doc"}
+	// shortener_test.go checks the other endpoints and the errors through the same handler
+
+	// End to end with a real server: a client that doesn't follow redirects sees the 302
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	client := *srv.Client()
+	client.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", srv.URL+"/1", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	resp.Body.Close()
+	fmt.Println(resp.StatusCode, resp.Header.Get("Location")) // 302 https://go.dev/doc
+}
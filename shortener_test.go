@@ -0,0 +1,68 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestShortener(t *testing.T) {
+	clk := &fakeClock{now: time.Date(2019, time.January, 1, 12, 0, 0, 0, time.UTC)}
+	s := &shortener{store: openJSONStore(filepath.Join(t.TempDir(), "links.json")), clock: clk}
+	h := s.routes()
+
+	// In order: each one uses the link the one before made or visited
+	t.Run("create", func(t *testing.T) {
+		rec := serveRecorded(h, "POST", "/links", `{"url": "https://go.dev/doc"}`)
+		checkJSON(t.Errorf, rec, http.StatusCreated,
+			`{"code": "1", "short_url": "http://example.com/1", "url": "https://go.dev/doc"}`)
+	})
+	t.Run("redirect", func(t *testing.T) {
+		rec := serveRecorded(h, "GET", "/1", "")
+		if rec.Code != http.StatusFound || rec.Header().Get("Location") != "https://go.dev/doc" {
+			t.Errorf("got %d to %q, want 302 to https://go.dev/doc", rec.Code, rec.Header().Get("Location"))
+		}
+	})
+	t.Run("stats", func(t *testing.T) {
+		rec := serveRecorded(h, "GET", "/links/1/stats", "")
+		checkJSON(t.Errorf, rec, http.StatusOK,
+			`{"code": "1", "url": "https://go.dev/doc", "created": "2019-01-01T12:00:00Z", "visits": 1}`)
+	})
+	for _, tc := range []struct {
+		name, method, target, body string
+		want                       string
+	}{
+		{"not a URL", "POST", "/links", `{"url": "go.dev"}`, "bad_request"},
+		{"javascript", "POST", "/links", `{"url": "javascript:alert(1)"}`, "bad_request"},
+		{"unknown field", "POST", "/links", `{"link": "https://go.dev"}`, "bad_request"},
+		{"unknown code", "GET", "/zz", "", "not_found"},
+		{"bad code", "GET", "/links/no-such/stats", "", "not_found"},
+		{"leading zero", "GET", "/01", "", "not_found"}, // /1 is the link, /01 isn't another name for it
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := serveRecorded(h, tc.method, tc.target, tc.body)
+			var got struct{ Code string }
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil || got.Code != tc.want {
+				t.Errorf("got %d %s, want a %s error", rec.Code, strings.TrimSpace(rec.Body.String()), tc.want)
+			}
+		})
+	}
+}
+
+func TestBase62(t *testing.T) {
+	for _, n := range []int{0, 1, 61, 62, 1_000_000, 62*62*62*62*62*62*62*62*62*62 - 1} {
+		code := encodeBase62(n)
+		if back, err := decodeBase62(code); err != nil || back != n {
+			t.Errorf("decodeBase62(encodeBase62(%d) = %q) = %d, %v", n, code, back, err)
+		}
+	}
+	// One code per link: anything encodeBase62 can't produce is rejected
+	for _, code := range []string{"", "00", "0b", "00b", "b-c", "ZZZZZZZZZZZ"} {
+		if n, err := decodeBase62(code); err == nil {
+			t.Errorf("decodeBase62(%q) = %d, want an error", code, n)
+		}
+	}
+}
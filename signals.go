@@ -0,0 +1,88 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+//////// Signals
+// Ctrl+C sends SIGINT, `kill <pid>` sends SIGTERM. By default Go just dies on both,
+// mid whatever it was doing. signal.Notify turns them into values on a channel instead.
+
+func testSignalLoop() {
+	// Buffered! signal delivery doesn't block, a signal arriving while nobody is
+	// receiving on an unbuffered channel is simply dropped
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigs) // back to default behaviour when we're done
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+	fmt.Printf("working, press Ctrl+C to stop (pid %d)\n", os.Getpid())
+	for {
+		select {
+		case <-ticker.C:
+			fmt.Print(".")
+		case sig := <-sigs:
+			fmt.Println("\ngot", sig, "- stopping nicely")
+			return
+		}
+	}
+}
+
+func testIgnoreSignals() {
+	// Ignore: Ctrl+C does nothing at all for a while
+	signal.Ignore(syscall.SIGINT)
+	fmt.Println("Ctrl+C is ignored for 3 seconds...")
+	time.Sleep(3 * time.Second)
+
+	// Reset: back to the default (die on Ctrl+C)
+	signal.Reset(syscall.SIGINT)
+	fmt.Println("Ctrl+C works again")
+}
+
+//// What happens to goroutines?
+// When main returns (or os.Exit runs) every other goroutine is stopped instantly,
+// wherever it is. No defers, no cleanup. So on a signal: tell them to stop, then WAIT.
+
+// shutdownContext is cancelled on the first SIGINT/SIGTERM; the graceful shutdown
+// lesson hands it to everything that should wind down. Call stop to unregister
+func shutdownContext() (ctx context.Context, stop context.CancelFunc) {
+	return signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+}
+
+func testInFlightGoroutines() {
+	ctx, stop := shutdownContext()
+	defer stop()
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 3; i++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			defer fmt.Println("worker", id, "cleaned up")
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-time.After(time.Duration(id) * 300 * time.Millisecond):
+					fmt.Println("worker", id, "did a job")
+				}
+			}
+		}(i)
+	}
+
+	fmt.Println("Ctrl+C to stop the workers")
+	<-ctx.Done()
+	fmt.Println("shutting down:", context.Cause(ctx))
+
+	// Without this Wait, main could return first and the "cleaned up" lines might never print
+	wg.Wait()
+	fmt.Println("all workers done")
+}
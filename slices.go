@@ -0,0 +1,137 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+)
+
+//////// Slice internals and aliasing
+// A slice is three words: a pointer into an array, a length and a capacity (how far
+// the array goes past the pointer). Slicing makes a new three words pointing into the
+// same array; nothing is copied. arraysAndSlices in hello.go shows the operations; this
+// is what they do underneath.
+//
+// append writes into the array when len < cap, and the result shares it with the
+// slice it came from. When the array is full it allocates a bigger one, copies, and the
+// result shares nothing. Which one happens depends on the capacity, which code that
+// was handed a slice usually doesn't know, so:
+//   - always use append's result (s = append(s, x)), since it may be a new array
+//   - a sub-slice is a window on its parent: writes through it change the parent, and
+//     appending to it overwrites whatever the parent has after the window
+//   - s[lo:hi:max] caps the window at max, so the first append past it copies.
+//     s[lo:hi:hi] is a window that can't write past its end
+//   - a small slice of a big array keeps the whole array alive; slices.Clone the bit
+//     you need if the rest should be garbage collected
+//
+// Growth: capacity doubles until 256, then grows by about 1.25× plus 192, and is
+// rounded up to a size the allocator has, so the exact numbers depend on the element
+// size too.
+
+// capTrace appends n ints one at a time and returns each capacity the slice had
+func capTrace(n int) []int {
+	var s []int
+	caps := []int{cap(s)}
+	for i := range n {
+		s = append(s, i)
+		if cap(s) != caps[len(caps)-1] {
+			caps = append(caps, cap(s))
+		}
+	}
+	return caps
+}
+
+// without returns s with s[i] left out, in a new array; slices.Delete does the same in
+// place, which changes what every other slice of that array sees
+func without[T any](s []T, i int) []T {
+	out := make([]T, 0, len(s)-1)
+	return append(append(out, s[:i]...), s[i+1:]...)
+}
+
+func sliceInfo[T any](s []T) string {
+	return fmt.Sprintf("len=%d cap=%d %v", len(s), cap(s), s)
+}
+
+func testSliceInternals() {
+	//// Growth
+	fmt.Println(capTrace(2000)) // [0 4 8 16 32 64 128 256 512 848 1280 1792 2560]: 12 arrays for 2000 appends
+	// The first append already leaves room for a few more; how many depends on the
+	// element size (and the Go version: older ones started at 1)
+	var b []byte
+	b = append(b, 'x')
+	fmt.Println(cap(b)) // 32
+
+	//// append: sometimes shared, sometimes copied
+	a := make([]int, 3, 4)
+	x := append(a, 1)                       // room for one more: x shares a's array
+	y := append(a, 2)                       // so does y, and it writes the same element
+	fmt.Println(sliceInfo(x), sliceInfo(y)) // len=4 cap=4 [0 0 0 2] len=4 cap=4 [0 0 0 2]: x's 1 is gone
+	z := append(x, 3)                       // x is full: z gets a new array
+	z[0] = 99
+	fmt.Println(sliceInfo(x), sliceInfo(z)) // len=4 cap=4 [0 0 0 2] len=5 cap=8 [99 0 0 2 3]
+
+	//// Sub-slices are windows
+	parent := []int{1, 2, 3, 4, 5}
+	window := parent[1:3]
+	fmt.Println(sliceInfo(window)) // len=2 cap=4 [2 3]: cap runs to the end of parent
+	window[0] = 20
+	window = append(window, 30) // fits in the cap, so it lands on parent[3]
+	fmt.Println(parent)         // [1 20 3 30 5]
+
+	// The three-index form caps the window at its own end
+	parent = []int{1, 2, 3, 4, 5}
+	capped := parent[1:3:3]
+	fmt.Println(sliceInfo(capped)) // len=2 cap=2 [2 3]
+	capped = append(capped, 30)    // no room: copied
+	capped[0] = 20
+	fmt.Println(parent, capped) // [1 2 3 4 5] [20 3 30]
+
+	//// The classic bug: removing from a slice someone else still has
+	scores := []int{90, 75, 60, 85}
+	passed := scores[:0] // filter in place, reusing the array
+	for _, s := range scores {
+		if s >= 70 {
+			passed = append(passed, s)
+		}
+	}
+	fmt.Println(passed, scores) // [90 75 85] [90 75 85 85]: scores was overwritten as it was read
+
+	names := []string{"ann", "bob", "cat", "dan"}
+	kept := slices.Delete(names, 1, 2)
+	fmt.Printf("%v %q\n", kept, names) // [ann cat dan] ["ann" "cat" "dan" ""]: Delete zeroes the end, but names still has 4
+	names = []string{"ann", "bob", "cat", "dan"}
+	fmt.Println(without(names, 1), names) // [ann cat dan] [ann bob cat dan]
+
+	// Keeping 3 bytes of a 1MB array keeps the 1MB
+	big := make([]byte, 1<<20)
+	header := big[:3]
+	fmt.Println(cap(header), cap(slices.Clone(header))) // 1048576 8: the clone is a new small array
+
+	runCase("TestSliceInternals/without", func(errorf errorfFunc) {
+		orig := []int{1, 2, 3, 4}
+		for i := range orig {
+			got := without(orig, i)
+			want := slices.Delete(slices.Clone(orig), i, i+1)
+			if !slices.Equal(got, want) {
+				errorf("without(%v, %d) = %v, want %v", orig, i, got, want)
+			}
+			for j := range got {
+				got[j] = -1 // must not reach orig
+			}
+		}
+		if !slices.Equal(orig, []int{1, 2, 3, 4}) {
+			errorf("without changed its input to %v", orig)
+		}
+	})
+	runCase("TestSliceInternals/growth", func(errorf errorfFunc) {
+		caps := capTrace(10000)
+		for i := 1; i < len(caps); i++ {
+			if caps[i] <= caps[i-1] {
+				errorf("capacity went from %d to %d", caps[i-1], caps[i])
+			}
+		}
+		// Amortised O(1): far fewer allocations than appends
+		if len(caps) > 30 {
+			errorf("%d different capacities for 10000 appends", len(caps))
+		}
+	})
+}
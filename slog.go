@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+//////// Structured logging with log/slog
+// log.Printf gives you a string; slog gives you a message plus key/value pairs,
+// which a handler turns into text for humans or JSON for machines
+
+func testSlogBasics() {
+	// The default logger writes through the log package: 2019/01/01 12:00:00 INFO hello count=3
+	slog.Info("hello", "count", 3)
+
+	// Levels: Debug < Info < Warn < Error; the text handler hides Debug by default
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	logger.Debug("debug shows up now")
+	logger.Warn("disk nearly full", "free_mb", 42)
+	// time=2019-01-01T12:00:00.000Z level=WARN msg="disk nearly full" free_mb=42
+
+	// Typed attrs avoid the allocation of "key", value pairs and can't get out of step
+	logger.Info("lesson done", slog.String("lesson", "slog"), slog.Int("attempts", 2))
+
+	// With returns a logger that adds the attrs to every line
+	reqLogger := logger.With("request_id", "abc123")
+	reqLogger.Info("started")
+	reqLogger.Info("finished") // both lines have request_id=abc123
+
+	// Groups nest keys: user.name=bob user.age=20 (or {"user":{"name":...}} in JSON)
+	logger.Info("login", slog.Group("user", "name", "bob", "age", 20))
+	logger.WithGroup("db").Info("query", "rows", 7) // db.rows=7
+}
+
+func testSlogJSON() {
+	logger := slog.New(slog.NewJSONHandler(os.Stdout, nil))
+	logger.Info("lesson done", "lesson", "slog", slog.Group("user", "name", "bob"))
+	// {"time":"...","level":"INFO","msg":"lesson done","lesson":"slog","user":{"name":"bob"}}
+
+	// A LevelVar can be changed while the program runs (e.g. from a signal or an admin endpoint)
+	var level slog.LevelVar
+	level.Set(slog.LevelError)
+	logger = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: &level}))
+	logger.Info("hidden")
+	level.Set(slog.LevelInfo)
+	logger.Info("visible now")
+}
+
+//// A custom handler
+// Implement slog.Handler's four methods and you control the output completely.
+// This one prints terse lines like: WARN disk nearly full free_mb=42
+
+type terseHandler struct {
+	mu    *sync.Mutex // shared by the copies made in WithAttrs, writes must not interleave
+	w     io.Writer
+	level slog.Leveler
+	attrs string // preformatted attrs from With
+	group string // key prefix from WithGroup
+}
+
+func newTerseHandler(w io.Writer, level slog.Leveler) *terseHandler {
+	return &terseHandler{mu: &sync.Mutex{}, w: w, level: level}
+}
+
+func (h *terseHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *terseHandler) Handle(_ context.Context, r slog.Record) error {
+	var b strings.Builder
+	b.WriteString(r.Level.String())
+	b.WriteString(" ")
+	b.WriteString(r.Message)
+	b.WriteString(h.attrs)
+	r.Attrs(func(a slog.Attr) bool {
+		h.appendAttr(&b, h.group, a)
+		return true
+	})
+	b.WriteString("\n")
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *terseHandler) appendAttr(b *strings.Builder, prefix string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() == slog.KindGroup {
+		for _, ga := range a.Value.Group() {
+			h.appendAttr(b, prefix+a.Key+".", ga)
+		}
+		return
+	}
+	fmt.Fprintf(b, " %s%s=%v", prefix, a.Key, a.Value)
+}
+
+func (h *terseHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	var b strings.Builder
+	for _, a := range attrs {
+		h.appendAttr(&b, h.group, a)
+	}
+	h2 := *h
+	h2.attrs += b.String()
+	return &h2
+}
+
+func (h *terseHandler) WithGroup(name string) slog.Handler {
+	h2 := *h
+	h2.group += name + "."
+	return &h2
+}
+
+func testCustomSlogHandler() {
+	logger := slog.New(newTerseHandler(os.Stdout, slog.LevelInfo))
+	logger.Debug("hidden")
+	logger.Warn("disk nearly full", "free_mb", 42)                            // WARN disk nearly full free_mb=42
+	logger.With("lesson", "slog").WithGroup("user").Info("hi", "name", "bob") // INFO hi lesson=slog user.name=bob
+}
+
+//// hellogo's own diagnostics
+// The CLI logs through slog; --log-level picks the level and --log-json switches to
+// JSON so scripts can parse failures instead of scraping text
+
+func newCLILogger(w io.Writer, level slog.Level, json bool) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level}
+	if json {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(newTerseHandler(w, level))
+}
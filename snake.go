@@ -0,0 +1,324 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+//////// Mini project: snake
+// Three goroutines' worth of jobs, joined up with channels in one select:
+//   - reading the keyboard blocks, so a goroutine does only that and sends keys on a channel
+//   - a ticker says when the snake moves
+//   - Ctrl+C cancels a context
+// Pausing is the nil channel trick: receiving from a nil channel blocks forever, so a
+// select case on one never fires. Setting tick to nil pauses the game, putting the
+// ticker's channel back resumes it, and the keys case keeps working all the while.
+//
+// The terminal normally hands a program whole lines, after Enter, and echoes what you
+// type. Games want every key as it's pressed, which is "raw" mode; stty switches it on
+// and back off, so this needs a unix-like terminal. In raw mode \n only moves down a
+// line, so frames end lines with \r\n to get back to the left edge too.
+//
+// hellogo snake: arrow keys or WASD to steer, p to pause, q to quit.
+
+type snakePoint struct{ x, y int }
+
+type snakeDir snakePoint
+
+var (
+	snakeUp    = snakeDir{0, -1}
+	snakeDown  = snakeDir{0, 1}
+	snakeLeft  = snakeDir{-1, 0}
+	snakeRight = snakeDir{1, 0}
+)
+
+var errSnakeCrashed = errors.New("game over")
+
+type snakeGame struct {
+	width, height int
+	body          []snakePoint // the head is body[0]
+	dir, next     snakeDir     // next is the last turn asked for, taken on the next step
+	food          snakePoint
+	score         int
+	over          bool
+	rand          *rand.Rand
+}
+
+func newSnakeGame(width, height int, seed uint64) *snakeGame {
+	g := &snakeGame{width: width, height: height, dir: snakeRight, next: snakeRight, rand: rand.New(rand.NewPCG(seed, seed))}
+	mid := snakePoint{width / 2, height / 2}
+	g.body = []snakePoint{mid, {mid.x - 1, mid.y}, {mid.x - 2, mid.y}}
+	g.placeFood()
+	return g
+}
+
+func (g *snakeGame) occupied(p snakePoint) bool { return slices.Contains(g.body, p) }
+
+// placeFood picks a random free cell; false when the snake fills the board
+func (g *snakeGame) placeFood() bool {
+	free := g.width*g.height - len(g.body)
+	if free == 0 {
+		return false
+	}
+	n := g.rand.IntN(free) // the nth free cell, so no retry loop when the board is nearly full
+	for y := range g.height {
+		for x := range g.width {
+			if p := (snakePoint{x, y}); !g.occupied(p) {
+				if n == 0 {
+					g.food = p
+					return true
+				}
+				n--
+			}
+		}
+	}
+	return false
+}
+
+// turn asks to head in d; turning straight back into your own neck is ignored
+func (g *snakeGame) turn(d snakeDir) {
+	if d.x != -g.dir.x || d.y != -g.dir.y {
+		g.next = d
+	}
+}
+
+// step moves the snake one cell, growing it if it eats
+func (g *snakeGame) step() error {
+	if g.over {
+		return errSnakeCrashed
+	}
+	g.dir = g.next
+	head := snakePoint{g.body[0].x + g.dir.x, g.body[0].y + g.dir.y}
+	eating := head == g.food
+	tail := g.body[:len(g.body)-1] // the tail moves out of the way, unless the snake is growing
+	if eating {
+		tail = g.body
+	}
+	hitWall := head.x < 0 || head.y < 0 || head.x >= g.width || head.y >= g.height
+	if hitWall || slices.Contains(tail, head) {
+		g.over = true
+		return errSnakeCrashed
+	}
+
+	g.body = append([]snakePoint{head}, tail...)
+	if eating {
+		g.score++
+		if !g.placeFood() {
+			g.over = true // nowhere left to go: that's a win, but still the end
+		}
+	}
+	return nil
+}
+
+func (g *snakeGame) String() string {
+	var sb strings.Builder
+	border := "+" + strings.Repeat("-", g.width) + "+\n"
+	sb.WriteString(border)
+	for y := range g.height {
+		sb.WriteByte('|')
+		for x := range g.width {
+			p := snakePoint{x, y}
+			switch {
+			case p == g.body[0]:
+				sb.WriteByte('@')
+			case g.occupied(p):
+				sb.WriteByte('o')
+			case p == g.food:
+				sb.WriteByte('*')
+			default:
+				sb.WriteByte(' ')
+			}
+		}
+		sb.WriteString("|\n")
+	}
+	sb.WriteString(border)
+	fmt.Fprintf(&sb, "score %d\n", g.score)
+	return sb.String()
+}
+
+//// The keyboard
+
+type snakeKey int
+
+const (
+	keyNone snakeKey = iota
+	keyUp
+	keyDown
+	keyLeft
+	keyRight
+	keyPause
+	keyQuit
+)
+
+// parseSnakeKeys turns what a raw terminal sent into keys. Arrow keys arrive as
+// three bytes, ESC [ A to ESC [ D; Ctrl+C is byte 3 since raw mode turns off signals
+func parseSnakeKeys(buf []byte) []snakeKey {
+	var keys []snakeKey
+	for i := 0; i < len(buf); i++ {
+		key := keyNone
+		switch buf[i] {
+		case 0x1b:
+			if i+2 < len(buf) && buf[i+1] == '[' {
+				key = map[byte]snakeKey{'A': keyUp, 'B': keyDown, 'C': keyRight, 'D': keyLeft}[buf[i+2]]
+				i += 2
+			}
+		case 'w', 'W':
+			key = keyUp
+		case 's', 'S':
+			key = keyDown
+		case 'a', 'A':
+			key = keyLeft
+		case 'd', 'D':
+			key = keyRight
+		case 'p', 'P', ' ':
+			key = keyPause
+		case 'q', 'Q', 3:
+			key = keyQuit
+		}
+		if key != keyNone {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+// readSnakeKeys sends keys from r until it fails. It can't be stopped from outside,
+// a blocked Read doesn't take a context, so it's simply left behind when the game ends
+func readSnakeKeys(r io.Reader, keys chan<- snakeKey) {
+	buf := make([]byte, 16)
+	for {
+		n, err := r.Read(buf)
+		for _, key := range parseSnakeKeys(buf[:n]) {
+			keys <- key
+		}
+		if err != nil {
+			close(keys)
+			return
+		}
+	}
+}
+
+// rawTerminal puts the terminal on stdin in raw mode; restore puts back how it was
+func rawTerminal() (restore func() error, err error) {
+	stty := func(args ...string) ([]byte, error) {
+		cmd := exec.Command("stty", args...)
+		cmd.Stdin = os.Stdin // stty works on the terminal it's given as stdin
+		return cmd.Output()
+	}
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, fmt.Errorf("stdin isn't a terminal stty can set up: %w", err)
+	}
+	if _, err := stty("raw", "-echo"); err != nil {
+		return nil, err
+	}
+	return func() error {
+		_, err := stty(strings.TrimSpace(string(saved)))
+		return err
+	}, nil
+}
+
+//// The game loop
+
+// playSnake runs g until it ends, the keys channel closes or ctx is cancelled
+func playSnake(ctx context.Context, out io.Writer, g *snakeGame, keys <-chan snakeKey, every time.Duration) error {
+	draw := func(status string) {
+		frame := ansiCursorHome + g.String() + bold(status) + ansiClearLine + "\n" // clearing what's left of a longer status line
+		fmt.Fprint(out, strings.ReplaceAll(frame, "\n", "\r\n"))
+	}
+	fmt.Fprint(out, ansiHideCursor, ansiClearScreen)
+	defer fmt.Fprint(out, ansiShowCursor)
+
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	tick := ticker.C
+	draw("arrows/WASD steer, p pauses, q quits")
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case key, ok := <-keys:
+			switch {
+			case !ok || key == keyQuit:
+				return nil
+			case key == keyPause && tick != nil:
+				tick = nil // nothing arrives on a nil channel, so the snake stops
+				draw("paused, p to resume")
+			case key == keyPause:
+				tick = ticker.C
+				draw("")
+			case tick != nil: // no steering while paused
+				g.turn(map[snakeKey]snakeDir{keyUp: snakeUp, keyDown: snakeDown, keyLeft: snakeLeft, keyRight: snakeRight}[key])
+			}
+		case <-tick:
+			err := g.step()
+			if err != nil {
+				draw(fmt.Sprintf("%v, you scored %d", err, g.score))
+				return nil
+			}
+			draw("")
+		}
+	}
+}
+
+// hellogo snake [-width n] [-height n] [-every d]
+func snakeCommand(args []string) error {
+	flags := flag.NewFlagSet("snake", flag.ContinueOnError)
+	width := flags.Int("width", 30, "board width")
+	height := flags.Int("height", 15, "board height")
+	every := flags.Duration("every", 150*time.Millisecond, "time between moves")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 || *width < 5 || *height < 2 || *every <= 0 {
+		return errUsage
+	}
+
+	restore, err := rawTerminal()
+	if err != nil {
+		return err
+	}
+	defer restore()
+
+	ctx, stop := shutdownContext()
+	defer stop()
+	keys := make(chan snakeKey)
+	go readSnakeKeys(os.Stdin, keys)
+	return playSnake(ctx, os.Stdout, newSnakeGame(*width, *height, uint64(time.Now().UnixNano())), keys, *every)
+}
+
+func testSnake() {
+	fmt.Println(parseSnakeKeys([]byte("w\x1b[Bxq"))) // [1 2 6]: up, down (an arrow), nothing for x, quit
+
+	g := newSnakeGame(8, 3, 1)
+	g.food = snakePoint{6, 1} // two cells ahead, so the game below is the same every run
+	g.step()
+	g.step()
+	fmt.Print(g)
+	// +--------+
+	// |  *     |
+	// |   ooo@ |
+	// |        |
+	// +--------+
+	// score 1
+
+	g.turn(snakeLeft)                       // straight back: ignored
+	fmt.Println(g.step(), g.step(), g.over) // <nil> game over true, the second step is into the right wall
+
+	// Chasing your own tail is fine, the tail moves out of the way in time
+	g = newSnakeGame(5, 5, 1)
+	g.food = snakePoint{0, 0}
+	g.body = []snakePoint{{2, 2}, {2, 3}, {3, 3}, {3, 2}}
+	g.dir, g.next = snakeUp, snakeUp
+	for _, d := range []snakeDir{snakeRight, snakeDown, snakeLeft} {
+		g.turn(d)
+		fmt.Print(g.step(), " ") // <nil> <nil> <nil>
+	}
+	fmt.Println()
+}
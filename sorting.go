@@ -0,0 +1,339 @@
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+//////// Sorting algorithms
+// Three classics, generic over anything cmp.Ordered:
+//   - insertion sort: take each item and slide it left past everything bigger. O(n²)
+//     compares, but almost nothing per step, and O(n) on input that's nearly sorted
+//     already, so it wins on small or almost-sorted slices
+//   - merge sort: sort each half, then merge the two sorted halves through a buffer.
+//     O(n log n) whatever the input, stable (equal items keep their order), but needs
+//     n extra space
+//   - quicksort: pick a pivot, move smaller items before it and bigger ones after, sort
+//     both sides. O(n log n) on average and in place, but a bad pivot every time makes
+//     it O(n²): the first item as pivot on sorted input does exactly that, so this one
+//     takes the median of the first, middle and last
+//
+// slices.Sort is pattern-defeating quicksort: quicksort that switches to insertion sort
+// for short runs, notices sorted and reversed input, and falls back to heapsort if the
+// pivots keep going badly. It's usually the fastest of the lot.
+//
+//	hellogo sort [-algo insertion|merge|quick] [-n items] [-every d]   watch one work
+//	hellogo sort --bench [-n items]                                    time them all
+//
+// Each algorithm takes a step func, called after every write to the slice, which the
+// animation uses to draw a frame; pass nil to just sort.
+
+func insertionSort[E cmp.Ordered](s []E, step func()) {
+	for i := 1; i < len(s); i++ {
+		for j := i; j > 0 && s[j] < s[j-1]; j-- {
+			s[j], s[j-1] = s[j-1], s[j]
+			if step != nil {
+				step()
+			}
+		}
+	}
+}
+
+func mergeSort[E cmp.Ordered](s []E, step func()) {
+	buf := make([]E, len(s)) // one buffer for every merge, rather than one per call
+	var sortRange func(lo, hi int)
+	sortRange = func(lo, hi int) {
+		if hi-lo < 2 {
+			return
+		}
+		mid := (lo + hi) / 2
+		sortRange(lo, mid)
+		sortRange(mid, hi)
+		copy(buf[lo:hi], s[lo:hi])
+		i, j := lo, mid
+		for k := lo; k < hi; k++ {
+			// <= takes from the left on ties: that's what makes it stable
+			if j >= hi || (i < mid && buf[i] <= buf[j]) {
+				s[k] = buf[i]
+				i++
+			} else {
+				s[k] = buf[j]
+				j++
+			}
+			if step != nil {
+				step()
+			}
+		}
+	}
+	sortRange(0, len(s))
+}
+
+func quickSort[E cmp.Ordered](s []E, step func()) {
+	swap := func(i, j int) {
+		s[i], s[j] = s[j], s[i]
+		if step != nil {
+			step()
+		}
+	}
+	var sortRange func(lo, hi int) // hi inclusive
+	sortRange = func(lo, hi int) {
+		for lo < hi {
+			// Median of three, moved to the end to be the pivot
+			mid := lo + (hi-lo)/2
+			if s[mid] < s[lo] {
+				swap(mid, lo)
+			}
+			if s[hi] < s[lo] {
+				swap(hi, lo)
+			}
+			if s[mid] < s[hi] {
+				swap(mid, hi)
+			}
+			pivot := s[hi]
+			// Lomuto partition: everything before p is smaller than the pivot
+			p := lo
+			for i := lo; i < hi; i++ {
+				if s[i] < pivot {
+					swap(i, p)
+					p++
+				}
+			}
+			swap(p, hi)
+			// Recurse into the smaller side and loop on the bigger one, so the stack
+			// stays O(log n) deep even when the pivots are bad
+			if p-lo < hi-p {
+				sortRange(lo, p-1)
+				lo = p + 1
+			} else {
+				sortRange(p+1, hi)
+				hi = p - 1
+			}
+		}
+	}
+	sortRange(0, len(s)-1)
+}
+
+type sortAlgorithm struct {
+	name string
+	sort func(s []int, step func())
+}
+
+var sortAlgorithms = []sortAlgorithm{
+	{"insertion", insertionSort[int]},
+	{"merge", mergeSort[int]},
+	{"quick", quickSort[int]},
+	{"slices.Sort", func(s []int, _ func()) { slices.Sort(s) }},
+}
+
+// sortInput makes n ints from 1 to n in the given shape
+func sortInput(shape string, n int, r *rand.Rand) []int {
+	s := make([]int, n)
+	for i := range s {
+		s[i] = i + 1
+	}
+	switch shape {
+	case "random":
+		r.Shuffle(n, func(i, j int) { s[i], s[j] = s[j], s[i] })
+	case "reversed":
+		slices.Reverse(s)
+	case "nearly": // sorted, with one in twenty swapped out of place
+		for range n / 20 {
+			i, j := r.IntN(n), r.IntN(n)
+			s[i], s[j] = s[j], s[i]
+		}
+	case "few": // only five different values
+		for i := range s {
+			s[i] = r.IntN(5) + 1
+		}
+	}
+	return s
+}
+
+var sortShapes = []string{"random", "sorted", "reversed", "nearly", "few"}
+
+//// Watching
+
+// drawBars draws s as vertical bars, one column per item, tallest at the top
+func drawBars(s []int, height int) string {
+	var b strings.Builder
+	for row := height; row > 0; row-- {
+		line := make([]byte, len(s))
+		for i, v := range s {
+			line[i] = ' '
+			if v >= row {
+				line[i] = '#'
+			}
+		}
+		b.WriteString(strings.TrimRight(string(line), " "))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// animateSort sorts s, drawing it after every write; every 0 draws as fast as it can
+func animateSort(ctx context.Context, w io.Writer, name string, sortFn func([]int, func()), s []int, every time.Duration) {
+	out := bufio.NewWriter(w)
+	defer out.Flush()
+	fmt.Fprint(out, ansiHideCursor, ansiClearScreen)
+	defer fmt.Fprint(out, ansiShowCursor)
+	height := slices.Max(s)
+	steps := 0
+	frame := func() {
+		fmt.Fprintf(out, "%s%s, %d items, %d writes\n%s", ansiCursorHome, name, len(s), steps, drawBars(s, height))
+		out.Flush()
+	}
+	frame()
+	sortFn(s, func() {
+		steps++
+		if ctx.Err() != nil {
+			return // Ctrl+C: stop drawing and let it finish on its own
+		}
+		frame()
+		time.Sleep(every)
+	})
+	frame()
+}
+
+//// Timing
+
+// timeSort is the average time to sort a fresh copy of input, over enough runs to take about 100ms
+func timeSort(sortFn func([]int, func()), input []int) time.Duration {
+	s := make([]int, len(input))
+	var total time.Duration
+	runs := 0
+	for total < 100*time.Millisecond {
+		copy(s, input)
+		start := time.Now()
+		sortFn(s, nil)
+		total += time.Since(start)
+		runs++
+	}
+	return total / time.Duration(runs)
+}
+
+func writeSortBench(w io.Writer, n int) {
+	r := rand.New(rand.NewPCG(1, 2))
+	headers := []string{"input"}
+	for _, a := range sortAlgorithms {
+		headers = append(headers, a.name)
+	}
+	t := newTable(headers...)
+	for i := range sortAlgorithms {
+		t.AlignRight(i + 1) // the times; the shapes stay on the left
+	}
+	for _, shape := range sortShapes {
+		input := sortInput(shape, n, r)
+		row := []any{shape}
+		for _, a := range sortAlgorithms {
+			row = append(row, timeSort(a.sort, input).Round(time.Microsecond/10))
+		}
+		t.Row(row...)
+	}
+	t.Render(w)
+}
+
+// hellogo sort [--bench] [-algo name] [-shape shape] [-n items] [-every d]
+func sortCommand(args []string) error {
+	flags := flag.NewFlagSet("sort", flag.ContinueOnError)
+	bench := flags.Bool("bench", false, "time every algorithm on every input shape instead")
+	algo := flags.String("algo", "quick", "insertion, merge or quick")
+	shape := flags.String("shape", "random", strings.Join(sortShapes, ", "))
+	n := flags.Int("n", 0, "items to sort (default 60 to watch, 5000 to time)")
+	every := flags.Duration("every", 10*time.Millisecond, "pause after each write")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 || *n < 0 || !slices.Contains(sortShapes, *shape) {
+		return errUsage
+	}
+	if *bench {
+		writeSortBench(os.Stdout, cmp.Or(*n, 5000))
+		return nil
+	}
+	i := slices.IndexFunc(sortAlgorithms, func(a sortAlgorithm) bool { return a.name == *algo })
+	if i < 0 {
+		return fmt.Errorf("no sort algorithm %q", *algo)
+	}
+	ctx, stop := shutdownContext()
+	defer stop()
+	s := sortInput(*shape, cmp.Or(*n, 60), rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64())))
+	animateSort(ctx, os.Stdout, *algo, sortAlgorithms[i].sort, s, *every)
+	return nil
+}
+
+func testSorting() {
+	words := []string{"pear", "fig", "apple", "kiwi", "date"}
+	mergeSort(words, nil)
+	fmt.Println(words) // [apple date fig kiwi pear]
+
+	// Insertion sort, one line per write
+	s := []int{5, 2, 4, 6, 1, 3}
+	fmt.Println(s) // [5 2 4 6 1 3]
+	insertionSort(s, func() { fmt.Println(s) })
+	// [2 5 4 6 1 3]
+	// [2 4 5 6 1 3]
+	// [2 4 5 1 6 3]: 1 slides all the way to the front
+	// [2 4 1 5 6 3]
+	// [2 1 4 5 6 3]
+	// [1 2 4 5 6 3]
+	// [1 2 4 5 3 6]
+	// [1 2 4 3 5 6]
+	// [1 2 3 4 5 6]
+
+	// What hellogo sort draws, as one frame
+	fmt.Print(drawBars([]int{3, 1, 4, 1, 5, 2}, 5))
+	//     #
+	//   # #
+	// # # #
+	// # # ##
+	// ######
+
+	// Writes to sort 200 items of each shape: the work each one does
+	writes := func(sortFn func([]int, func()), shape string) int {
+		n := 0
+		sortFn(sortInput(shape, 200, rand.New(rand.NewPCG(1, 2))), func() { n++ })
+		return n
+	}
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "\tinsertion\tmerge\tquick\t")
+	for _, shape := range sortShapes {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t\n", shape, writes(insertionSort[int], shape), writes(mergeSort[int], shape), writes(quickSort[int], shape))
+	}
+	tw.Flush()
+	//             insertion  merge  quick
+	//     random       9048   1544    925
+	//     sorted          0   1544    789
+	//   reversed      19900   1544   1228
+	//     nearly        924   1544    653
+	//        few       8101   1544    418
+	// insertion sort does nothing on sorted input and n²/2 swaps on reversed; merge
+	// sort does the same n log n writes whatever it gets
+
+	runCase("TestSorting/against slices.Sort", func(errorf errorfFunc) {
+		r := rand.New(rand.NewPCG(3, 4))
+		for _, a := range sortAlgorithms {
+			for _, shape := range sortShapes {
+				for _, n := range []int{0, 1, 2, 3, 10, 101} {
+					got := sortInput(shape, n, r)
+					want := slices.Clone(got)
+					slices.Sort(want)
+					a.sort(got, nil)
+					if !slices.Equal(got, want) {
+						errorf("%s sort of %d %s items: %v", a.name, n, shape, got)
+					}
+				}
+			}
+		}
+	})
+	// hellogo sort --bench for timings. At 5000 items insertion sort ties slices.Sort on
+	// sorted input and is 30 times slower than the rest on random; slices.Sort is fastest
+	// nearly everywhere. quick is slow on "few": Lomuto partitioning puts every copy of
+	// the pivot on one side, so lots of equal items make for lopsided splits
+}
@@ -0,0 +1,28 @@
+//go:build !js
+
+package main
+
+import (
+	"context"
+	"database/sql"
+
+	_ "modernc.org/sqlite"
+)
+
+// The driver, and with it the sqlite backend, for every target but js/wasm: modernc.org/libc
+// has no js build, so sqlite_js.go stands in there
+
+// openSQLite opens (creating if needed) a sqlite database file
+// Note, *sql.DB is a connection POOL, not one connection; open it once and share it
+func openSQLite(ctx context.Context, path string) (*sql.DB, error) {
+	db, err := sql.Open(sqliteDriver, path)
+	if err != nil {
+		return nil, err
+	}
+	// Open doesn't connect, Ping does; it's where a bad path actually shows up
+	if err := db.PingContext(ctx); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
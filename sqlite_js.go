@@ -0,0 +1,17 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// Only built for GOOS=js: the sqlite driver doesn't compile there, so the sqlite backend
+// (and hellogo migrate) fail with this instead. The json backend works as usual
+
+var errSQLiteUnavailable = errors.New("sqlite backend unavailable")
+
+func openSQLite(ctx context.Context, path string) (*sql.DB, error) {
+	return nil, fmt.Errorf("%s: %w in js/wasm builds, use backend = \"json\"", path, errSQLiteUnavailable)
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//////// Server-sent events
+// One long HTTP response the server keeps writing to; the browser's EventSource reads it.
+// Server -> client only, but plain HTTP (works through proxies, no upgrade dance like
+// websocket.go) and reconnecting is built into the protocol. The format is just text:
+//
+//	id: 7
+//	event: progress
+//	data: {"lesson":"sse"}
+//	<blank line ends the event>
+
+type sseEvent struct {
+	ID    int
+	Event string
+	Data  string
+}
+
+// sseHandler sends a numbered tick every interval. When a client reconnects, the browser
+// sends the last id it saw in Last-Event-ID, so we carry on from there instead of restarting
+func sseHandler(interval time.Duration, count int) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+
+		start := 1
+		if last, err := strconv.Atoi(r.Header.Get("Last-Event-ID")); err == nil {
+			start = last + 1
+		}
+
+		rc := http.NewResponseController(w)
+		// retry: tells the client how long to wait before reconnecting (ms)
+		fmt.Fprint(w, "retry: 1000\n\n")
+
+		for id := start; id <= count; id++ {
+			select {
+			case <-r.Context().Done(): // client went away
+				return
+			case <-time.After(interval):
+			}
+			fmt.Fprintf(w, "id: %d\nevent: tick\ndata: tick %d\n\n", id, id)
+			// Without Flush the data sits in a buffer and the client sees nothing for ages
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+//// The leaderboard
+// hellogo serve's /leaderboard page follows /events/leaderboard with an EventSource
+
+type leaderboardEntry struct {
+	Lesson string `json:"lesson"`
+	Runs   int64  `json:"runs"`
+}
+
+// leaderboard is every lesson run so far (metrics.go counts them), the most run first
+func leaderboard() []leaderboardEntry {
+	board := []leaderboardEntry{}
+	lessonRuns.Do(func(kv expvar.KeyValue) {
+		board = append(board, leaderboardEntry{kv.Key, kv.Value.(*expvar.Int).Value()})
+	})
+	// Do goes in name order, and a stable sort keeps ties that way
+	sort.SliceStable(board, func(i, j int) bool { return board[i].Runs > board[j].Runs })
+	return board
+}
+
+// leaderboardEvents sends the leaderboard when it changes, looking every interval. The
+// event id is lessons_run_total, so a client that reconnects with the Last-Event-ID it
+// saw gets nothing until there's been another run
+func leaderboardEvents(interval time.Duration) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		last, err := strconv.ParseInt(r.Header.Get("Last-Event-ID"), 10, 64)
+		if err != nil {
+			last = -1
+		}
+
+		rc := http.NewResponseController(w)
+		fmt.Fprint(w, "retry: 1000\n\n")
+		if err := rc.Flush(); err != nil { // the headers too, so the client knows it's connected
+			return
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if id := lessonsRun.Value(); id != last {
+				data, _ := json.Marshal(leaderboard()) // one line, as data: needs
+				fmt.Fprintf(w, "id: %d\nevent: leaderboard\ndata: %s\n\n", id, data)
+				if err := rc.Flush(); err != nil {
+					return
+				}
+				last = id
+			}
+			select {
+			case <-r.Context().Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+}
+
+// readSSE parses events off a stream and hands each one to fn
+func readSSE(r io.Reader, fn func(sseEvent)) error {
+	scanner := bufio.NewScanner(r)
+	var ev sseEvent
+	var data []string
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" { // end of an event
+			if len(data) > 0 {
+				ev.Data = strings.Join(data, "\n")
+				fn(ev)
+			}
+			ev, data = sseEvent{ID: ev.ID}, nil // the id sticks until a new one is sent
+			continue
+		}
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+		switch field {
+		case "id":
+			ev.ID, _ = strconv.Atoi(value)
+		case "event":
+			ev.Event = value
+		case "data":
+			data = append(data, value)
+		}
+		// lines starting with ":" are comments, often sent as keep-alives
+	}
+	return scanner.Err()
+}
+
+// sseSubscribe follows a stream, reconnecting with Last-Event-ID when it drops,
+// which is what a browser's EventSource does for you
+func sseSubscribe(ctx context.Context, url string, fn func(sseEvent)) error {
+	lastID := 0
+	for {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		if lastID > 0 {
+			req.Header.Set("Last-Event-ID", strconv.Itoa(lastID))
+		}
+		resp, err := http.DefaultClient.Do(req) // no Timeout on purpose: the stream is meant to stay open
+		if err != nil {
+			return err
+		}
+		got := 0
+		err = readSSE(resp.Body, func(ev sseEvent) {
+			lastID = ev.ID
+			got++
+			fn(ev)
+		})
+		resp.Body.Close()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if got == 0 {
+			return err // server had nothing more for us, stop instead of looping forever
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+func testSSE() {
+	// The server drops the connection every 3 events to show reconnecting
+	events := sseHandler(50*time.Millisecond, 7)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 170*time.Millisecond)
+		defer cancel()
+		events(w, r.WithContext(ctx))
+	}))
+	defer srv.Close()
+
+	err := sseSubscribe(context.Background(), srv.URL, func(ev sseEvent) {
+		fmt.Printf("id=%d event=%s data=%q\n", ev.ID, ev.Event, ev.Data)
+	})
+	fmt.Println("stream ended:", err) // ids 1 to 7 with no gaps, over a few connections
+}
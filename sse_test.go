@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// Through the whole hellogo serve stack: events have to get past gzip as they're sent
+func TestLeaderboardEvents(t *testing.T) {
+	srv := httptest.NewServer(standardMiddleware(serveRoutes()))
+	defer srv.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	events := make(chan sseEvent)
+	go sseSubscribe(ctx, srv.URL+"/events/leaderboard", func(ev sseEvent) {
+		select {
+		case events <- ev:
+		case <-ctx.Done():
+		}
+	})
+	runs := func() int64 {
+		t.Helper()
+		var ev sseEvent
+		select {
+		case ev = <-events:
+		case <-ctx.Done():
+			t.Fatal("no leaderboard event")
+		}
+		var board []leaderboardEntry
+		if err := json.Unmarshal([]byte(ev.Data), &board); err != nil || ev.Event != "leaderboard" {
+			t.Fatalf("event %q %q: %v", ev.Event, ev.Data, err)
+		}
+		for _, entry := range board {
+			if entry.Lesson == "sse-test" {
+				return entry.Runs
+			}
+		}
+		return 0
+	}
+
+	// The board as it is right away, then again once it changes
+	before := runs()
+	countLessonRun("sse-test")
+	if after := runs(); after != before+1 {
+		t.Errorf("after a run: %d runs, want %d", after, before+1)
+	}
+}
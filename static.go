@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+)
+
+//////// Static files
+// http.FileServer serves any fs.FS: a real directory (os.DirFS / http.Dir) or
+// an embed.FS baked into the binary (see embed.go)
+
+// noListing hides directory listings: FileServer happily lists a directory's
+// contents when there's no index.html in it, which usually isn't what you want
+func noListing(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/") {
+			http.NotFound(w, r)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// cacheFor sets Cache-Control; embedded files only change with a new binary, so
+// browsers can keep them for a while (FileServer already handles If-Modified-Since)
+func cacheFor(seconds int) middleware {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", seconds))
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// staticFiles serves fsys under prefix, e.g. prefix "/src/" maps /src/hello.go to hello.go
+// StripPrefix is needed because FileServer looks up the full URL path in the FS
+func staticFiles(prefix string, fsys fs.FS) http.Handler {
+	files := http.StripPrefix(prefix, http.FileServerFS(fsys))
+	return chain(files, noListing, cacheFor(3600))
+}
+
+func testStaticFiles() {
+	mux := http.NewServeMux()
+	mux.Handle("GET /src/", staticFiles("/src/", lessonSources))
+
+	// Sub gives a view of one directory inside an FS, so only templates/ is reachable here
+	templates, _ := fs.Sub(templateFS, "templates")
+	mux.Handle("GET /templates/", staticFiles("/templates/", templates))
+
+	for _, path := range []string{"/src/hello.go", "/src/", "/src/../go.mod", "/templates/lessons.tmpl", "/templates/nope"} {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		fmt.Println(path, rec.Code, rec.Header().Get("Content-Type"), rec.Header().Get("Cache-Control"))
+	}
+	// /src/hello.go 200 text/x-go; charset=utf-8 public, max-age=3600 (type depends on your OS)
+	// /src/ 404 ...
+	// /src/../go.mod 307 (cleaned path redirect, and nothing outside the FS is reachable anyway)
+}
@@ -0,0 +1,423 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+//////// Storage behind an interface (the repository pattern)
+// Code that needs to save things asks for a store, not for "a JSON file" or "a sqlite
+// database". Which one it gets is decided once, from config, in openStore. Swapping
+// backends (or faking one) then touches no calling code at all.
+//
+// The catch: every implementation has to BEHAVE the same, not just have the same
+// methods. checkStore below spells that behaviour out and is run against each of them.
+
+type achievement struct {
+	Name       string    `json:"name"`
+	UnlockedAt time.Time `json:"unlocked_at"`
+}
+
+type todo struct {
+	ID      int       `json:"id"`
+	Title   string    `json:"title"`
+	Done    bool      `json:"done"`
+	Created time.Time `json:"created"`
+}
+
+// errTodoNotFound is returned for ids that don't exist (any more)
+var errTodoNotFound = errors.New("no such todo")
+
+// link is a shortened URL, see shortener.go
+type link struct {
+	ID      int       `json:"id"`
+	URL     string    `json:"url"`
+	Created time.Time `json:"created"`
+	Visits  int       `json:"visits"`
+}
+
+var errLinkNotFound = errors.New("no such link")
+
+//go:generate go run . fakegen storage.go store fake_store.go
+
+type store interface {
+	// SaveProgress adds or replaces records by lesson name; lessons not mentioned are kept
+	SaveProgress(ctx context.Context, ps []progress) error
+	// LoadProgress returns all records sorted by lesson name
+	LoadProgress(ctx context.Context) ([]progress, error)
+
+	// SaveQuizResult keeps r.ID, the submission id, as it is; newQuizResult makes one
+	SaveQuizResult(ctx context.Context, r quizResult) error
+	// QuizResults returns every result in the order it was saved
+	QuizResults(ctx context.Context) ([]quizResult, error)
+
+	// UnlockAchievement records the first unlock only, unlocking again is not an error
+	UnlockAchievement(ctx context.Context, name string, at time.Time) error
+	// Achievements returns everything unlocked, in unlock order
+	Achievements(ctx context.Context) ([]achievement, error)
+
+	// AddTodo stores a new todo and returns it with its id: one more than the highest
+	// id in use, so deleting the newest todo frees its id again
+	AddTodo(ctx context.Context, title string, created time.Time) (todo, error)
+	// Todos returns every todo in id order
+	Todos(ctx context.Context) ([]todo, error)
+	// SetTodoDone and DeleteTodo return errTodoNotFound for unknown ids
+	SetTodoDone(ctx context.Context, id int, done bool) error
+	DeleteTodo(ctx context.Context, id int) error
+
+	// CreateLink stores url under the next free id; ids are never reused
+	CreateLink(ctx context.Context, url string, created time.Time) (link, error)
+	// Link and RecordVisit return errLinkNotFound for unknown ids
+	Link(ctx context.Context, id int) (link, error)
+	RecordVisit(ctx context.Context, id int) error
+
+	Close() error
+}
+
+// Compile time checks that both really implement store
+var (
+	_ store = (*jsonStore)(nil)
+	_ store = (*sqlStore)(nil)
+)
+
+// storeOpeners maps storage.backend values to constructors; config.validate uses it too
+var storeOpeners = map[string]struct {
+	defaultPath string
+	open        func(ctx context.Context, path string) (store, error)
+}{
+	"json": {"hellogo.json", func(_ context.Context, path string) (store, error) {
+		return openJSONStore(path), nil
+	}},
+	"sqlite": {"hellogo.db", func(ctx context.Context, path string) (store, error) {
+		return openSQLStore(ctx, path)
+	}},
+}
+
+func openStore(ctx context.Context, cfg storageConfig) (store, error) {
+	opener, ok := storeOpeners[cfg.Backend]
+	if !ok {
+		return nil, fmt.Errorf("unknown storage backend %q", cfg.Backend)
+	}
+	path := cfg.Path
+	if path == "" {
+		path = opener.defaultPath
+	}
+	s, err := opener.open(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	return meteredStore{s}, nil // see metrics.go
+}
+
+//// JSON file backend
+// The whole file is read, changed and written back (atomically) on every call.
+// Fine for one user's progress, hopeless for anything big or shared
+
+type jsonStore struct {
+	path string
+	mu   sync.Mutex // one read-modify-write at a time
+}
+
+type jsonStoreData struct {
+	Progress     []progress    `json:"progress"`
+	QuizResults  []quizResult  `json:"quiz_results"`
+	Achievements []achievement `json:"achievements"`
+	Todos        []todo        `json:"todos"`
+	Links        []link        `json:"links"`
+}
+
+func openJSONStore(path string) *jsonStore {
+	return &jsonStore{path: path}
+}
+
+func (s *jsonStore) Close() error { return nil }
+
+// read returns empty data if the file doesn't exist yet
+func (s *jsonStore) read() (jsonStoreData, error) {
+	var data jsonStoreData
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return data, nil
+	}
+	if err != nil {
+		return data, err
+	}
+	if err := json.Unmarshal(b, &data); err != nil {
+		return data, fmt.Errorf("%s: %w", s.path, err)
+	}
+	return data, nil
+}
+
+// update runs change on the current data and writes the result back
+func (s *jsonStore) update(change func(*jsonStoreData)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	data, err := s.read()
+	if err != nil {
+		return err
+	}
+	change(&data)
+	b, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(s.path, b, 0600)
+}
+
+func (s *jsonStore) view() (jsonStoreData, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.read()
+}
+
+func (s *jsonStore) SaveProgress(_ context.Context, ps []progress) error {
+	return s.update(func(data *jsonStoreData) {
+		for _, p := range ps {
+			i := slices.IndexFunc(data.Progress, func(old progress) bool { return old.Lesson == p.Lesson })
+			if i >= 0 {
+				data.Progress[i] = p
+			} else {
+				data.Progress = append(data.Progress, p)
+			}
+		}
+		slices.SortFunc(data.Progress, func(a, b progress) int { return strings.Compare(a.Lesson, b.Lesson) })
+	})
+}
+
+func (s *jsonStore) LoadProgress(context.Context) ([]progress, error) {
+	data, err := s.view()
+	return data.Progress, err
+}
+
+func (s *jsonStore) SaveQuizResult(_ context.Context, r quizResult) error {
+	r.TakenAt = r.TakenAt.Truncate(time.Second).UTC() // same precision the sql store keeps
+	return s.update(func(data *jsonStoreData) {
+		data.QuizResults = append(data.QuizResults, r)
+	})
+}
+
+func (s *jsonStore) QuizResults(context.Context) ([]quizResult, error) {
+	data, err := s.view()
+	return data.QuizResults, err
+}
+
+func (s *jsonStore) UnlockAchievement(_ context.Context, name string, at time.Time) error {
+	return s.update(func(data *jsonStoreData) {
+		if !slices.ContainsFunc(data.Achievements, func(a achievement) bool { return a.Name == name }) {
+			data.Achievements = append(data.Achievements, achievement{name, at.Truncate(time.Second).UTC()})
+		}
+	})
+}
+
+func (s *jsonStore) Achievements(context.Context) ([]achievement, error) {
+	data, err := s.view()
+	return data.Achievements, err
+}
+
+func (s *jsonStore) AddTodo(_ context.Context, title string, created time.Time) (todo, error) {
+	var t todo
+	err := s.update(func(data *jsonStoreData) {
+		t = todo{ID: 1, Title: title, Created: created.Truncate(time.Second).UTC()}
+		if n := len(data.Todos); n > 0 {
+			t.ID = data.Todos[n-1].ID + 1 // kept in id order
+		}
+		data.Todos = append(data.Todos, t)
+	})
+	return t, err
+}
+
+func (s *jsonStore) Todos(context.Context) ([]todo, error) {
+	data, err := s.view()
+	return data.Todos, err
+}
+
+func (s *jsonStore) SetTodoDone(_ context.Context, id int, done bool) error {
+	found := false
+	err := s.update(func(data *jsonStoreData) {
+		for i := range data.Todos {
+			if data.Todos[i].ID == id {
+				data.Todos[i].Done = done
+				found = true
+			}
+		}
+	})
+	if err == nil && !found {
+		err = errTodoNotFound
+	}
+	return err
+}
+
+func (s *jsonStore) DeleteTodo(_ context.Context, id int) error {
+	found := false
+	err := s.update(func(data *jsonStoreData) {
+		data.Todos = slices.DeleteFunc(data.Todos, func(t todo) bool {
+			found = found || t.ID == id
+			return t.ID == id
+		})
+	})
+	if err == nil && !found {
+		err = errTodoNotFound
+	}
+	return err
+}
+
+func (s *jsonStore) CreateLink(_ context.Context, url string, created time.Time) (link, error) {
+	var l link
+	err := s.update(func(data *jsonStoreData) {
+		l = link{ID: len(data.Links) + 1, URL: url, Created: created.Truncate(time.Second).UTC()}
+		data.Links = append(data.Links, l) // never deleted, so ids are 1..n
+	})
+	return l, err
+}
+
+func (s *jsonStore) Link(_ context.Context, id int) (link, error) {
+	data, err := s.view()
+	if err != nil {
+		return link{}, err
+	}
+	if id < 1 || id > len(data.Links) {
+		return link{}, errLinkNotFound
+	}
+	return data.Links[id-1], nil
+}
+
+func (s *jsonStore) RecordVisit(_ context.Context, id int) error {
+	found := false
+	err := s.update(func(data *jsonStoreData) {
+		if id >= 1 && id <= len(data.Links) {
+			data.Links[id-1].Visits++
+			found = true
+		}
+	})
+	if err == nil && !found {
+		err = errLinkNotFound
+	}
+	return err
+}
+
+//// The contract
+// checkStore is the test suite for store: one set of expectations, run against every
+// implementation by TestStoreContract (storage_test.go). It wants a fresh, empty store
+
+func checkStore(ctx context.Context, s store) error {
+	ps, err := s.LoadProgress(ctx)
+	if err != nil || len(ps) != 0 {
+		return fmt.Errorf("empty store: LoadProgress = %v, %v", ps, err)
+	}
+
+	first := sampleProgress(2)
+	if err := s.SaveProgress(ctx, first); err != nil {
+		return fmt.Errorf("SaveProgress: %w", err)
+	}
+	changed := progress{Lesson: first[0].Lesson, Completed: true, Attempts: 9, LastSeen: 1}
+	extra := progress{Lesson: "a-first", Attempts: 1}
+	if err := s.SaveProgress(ctx, []progress{changed, extra}); err != nil {
+		return fmt.Errorf("SaveProgress again: %w", err)
+	}
+	want := []progress{extra, changed, first[1]}
+	if got, err := s.LoadProgress(ctx); err != nil || !slices.Equal(got, want) {
+		return fmt.Errorf("after upsert: LoadProgress = %+v, %v; want %+v", got, err, want)
+	}
+
+	taken := time.Date(2019, time.January, 1, 12, 0, 0, 0, time.UTC)
+	results := []quizResult{
+		newQuizResult("b", 3, taken),
+		{Lesson: "a", Score: 8, TakenAt: taken.Add(time.Hour), Comment: "second try"}, // from before ids
+	}
+	for _, r := range results {
+		if err := s.SaveQuizResult(ctx, r); err != nil {
+			return fmt.Errorf("SaveQuizResult: %w", err)
+		}
+	}
+	if got, err := s.QuizResults(ctx); err != nil || !slices.Equal(got, results) {
+		return fmt.Errorf("QuizResults = %+v, %v; want %+v", got, err, results)
+	}
+
+	for _, at := range []time.Time{taken, taken.Add(time.Hour)} {
+		if err := s.UnlockAchievement(ctx, "first-lesson", at); err != nil {
+			return fmt.Errorf("UnlockAchievement: %w", err)
+		}
+	}
+	if err := s.UnlockAchievement(ctx, "quiz-master", taken.Add(2*time.Hour)); err != nil {
+		return fmt.Errorf("UnlockAchievement: %w", err)
+	}
+	wantAch := []achievement{{"first-lesson", taken}, {"quiz-master", taken.Add(2 * time.Hour)}}
+	if got, err := s.Achievements(ctx); err != nil || !slices.Equal(got, wantAch) {
+		return fmt.Errorf("Achievements = %+v, %v; want %+v", got, err, wantAch)
+	}
+
+	var todos []todo
+	for _, title := range []string{"read", "write", "rest"} {
+		t, err := s.AddTodo(ctx, title, taken)
+		if err != nil {
+			return fmt.Errorf("AddTodo: %w", err)
+		}
+		todos = append(todos, t)
+	}
+	if err := s.SetTodoDone(ctx, 2, true); err != nil {
+		return fmt.Errorf("SetTodoDone: %w", err)
+	}
+	if err := s.DeleteTodo(ctx, 3); err != nil {
+		return fmt.Errorf("DeleteTodo: %w", err)
+	}
+	again, err := s.AddTodo(ctx, "again", taken)
+	if err != nil {
+		return fmt.Errorf("AddTodo: %w", err)
+	}
+	todos[1].Done = true
+	wantTodos := []todo{todos[0], todos[1], {ID: 3, Title: "again", Created: taken}}
+	if got, err := s.Todos(ctx); err != nil || again.ID != 3 || !slices.Equal(got, wantTodos) {
+		return fmt.Errorf("Todos = %+v, %v; want %+v", got, err, wantTodos)
+	}
+	for _, err := range []error{s.SetTodoDone(ctx, 9, true), s.DeleteTodo(ctx, 9)} {
+		if !errors.Is(err, errTodoNotFound) {
+			return fmt.Errorf("unknown todo: got %v, want errTodoNotFound", err)
+		}
+	}
+
+	for _, url := range []string{"https://go.dev", "https://pkg.go.dev"} {
+		if _, err := s.CreateLink(ctx, url, taken); err != nil {
+			return fmt.Errorf("CreateLink: %w", err)
+		}
+	}
+	for range 2 {
+		if err := s.RecordVisit(ctx, 2); err != nil {
+			return fmt.Errorf("RecordVisit: %w", err)
+		}
+	}
+	wantLink := link{ID: 2, URL: "https://pkg.go.dev", Created: taken, Visits: 2}
+	if got, err := s.Link(ctx, 2); err != nil || got != wantLink {
+		return fmt.Errorf("Link(2) = %+v, %v; want %+v", got, err, wantLink)
+	}
+	if _, err := s.Link(ctx, 3); !errors.Is(err, errLinkNotFound) {
+		return fmt.Errorf("Link(3): got %v, want errLinkNotFound", err)
+	}
+	if err := s.RecordVisit(ctx, 3); !errors.Is(err, errLinkNotFound) {
+		return fmt.Errorf("RecordVisit(3): got %v, want errLinkNotFound", err)
+	}
+	return nil
+}
+
+func testStorage() {
+	dir := makeTempDir("storage")
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+
+	for backend, opener := range storeOpeners {
+		s, err := openStore(ctx, storageConfig{Backend: backend, Path: filepath.Join(dir, opener.defaultPath)})
+		if err != nil {
+			fmt.Println(backend, "open failed:", err)
+			continue
+		}
+		fmt.Println(backend, "contract:", checkStore(ctx, s)) // json contract: <nil>, sqlite contract: <nil> (in either order)
+		s.Close()
+	}
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestStoreContract(t *testing.T) {
+	for _, backend := range sortedKeys(storeOpeners) {
+		t.Run(backend, func(t *testing.T) {
+			t.Parallel()
+			ctx := context.Background()
+			s, err := openStore(ctx, storageConfig{Backend: backend, Path: filepath.Join(t.TempDir(), storeOpeners[backend].defaultPath)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { s.Close() })
+			if err := checkStore(ctx, s); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+//////// strconv; strings <-> numbers
+
+func testStrconv() {
+	// Atoi is the quick one for plain base 10 ints
+	n, err := strconv.Atoi("123")
+	fmt.Println(n, err) // 123 <nil>
+
+	// ParseInt(s, base, bitSize); base 0 means "work it out from the prefix"
+	for _, s := range []string{"42", "0x2a", "0b101010", "0o52", "1_000"} {
+		v, err := strconv.ParseInt(s, 0, 64)
+		fmt.Println(s, "->", v, err)
+	}
+	v, _ := strconv.ParseInt("ff", 16, 64)
+	fmt.Println(v) // 255
+
+	f, _ := strconv.ParseFloat("3.14159", 64)
+	fmt.Println(f)
+	b, _ := strconv.ParseBool("true")
+	fmt.Println(b)
+
+	// Going the other way
+	fmt.Println(strconv.Itoa(99))                     // "99"
+	fmt.Println(strconv.FormatInt(255, 2))            // "11111111"
+	fmt.Println(strconv.FormatInt(255, 16))           // "ff"
+	fmt.Println(strconv.FormatFloat(1.5, 'f', 3, 64)) // "1.500"
+	fmt.Println(strconv.Quote("tab\there"))           // "tab\there" (quotes included, tab escaped)
+}
+
+func testStrconvErrors() {
+	// Errors are *strconv.NumError, which says what failed and why
+	_, err := strconv.Atoi("12a")
+	fmt.Println(err) // strconv.Atoi: parsing "12a": invalid syntax
+
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		fmt.Println(numErr.Func, numErr.Num, numErr.Err == strconv.ErrSyntax) // Atoi 12a true
+	}
+
+	// Overflow: the value doesn't fit in bitSize bits
+	// You get an error AND the closest value that does fit
+	i8, err := strconv.ParseInt("300", 10, 8)
+	fmt.Println(i8, err)                          // 127 strconv.ParseInt: parsing "300": value out of range
+	fmt.Println(errors.Is(err, strconv.ErrRange)) // true
+
+	big, err := strconv.ParseFloat("1e400", 64)
+	fmt.Println(big, err) // +Inf strconv.ParseFloat: parsing "1e400": value out of range
+
+	// Careful, plain int conversions DON'T check, they silently wrap
+	x := 300
+	fmt.Println(int8(x)) // 44
+
+	_, err = strconv.ParseUint("-1", 10, 64)
+	fmt.Println(err) // invalid syntax, unsigned parsing doesn't accept a sign
+}
+
+// parseCalcNumber is the calculator's number parser: ints in any Go notation
+// (0x.., 0b.., 1_000) and floats, with errors a user can act on
+func parseCalcNumber(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, errors.New("expected a number")
+	}
+
+	i, intErr := strconv.ParseInt(s, 0, 64)
+	if intErr == nil {
+		return float64(i), nil
+	}
+	// Not an int (or too big for one), maybe it's a float
+	f, floatErr := strconv.ParseFloat(s, 64)
+	if floatErr == nil {
+		return f, nil
+	}
+	if errors.Is(floatErr, strconv.ErrRange) {
+		return 0, fmt.Errorf("number %q is too big", s)
+	}
+	return 0, fmt.Errorf("%q is not a number", s)
+}
+
+func testParseCalcNumber() {
+	for _, s := range []string{"42", " 0xff ", "2.5e3", "1_000", "9223372036854775808", "1e999", "abc", ""} {
+		f, err := parseCalcNumber(s)
+		if err != nil {
+			fmt.Println("error:", err)
+			continue
+		}
+		fmt.Println(s, "=", f)
+	}
+}
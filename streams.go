@@ -0,0 +1,174 @@
+package main
+
+import (
+	"bufio"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+)
+
+//////// Streaming with io.Pipe, MultiWriter and Copy
+// io.Reader and io.Writer are one method each, which makes them easy to stack: a
+// gzip.Writer around a file, a hash next to it, a counter in front. Data goes through
+// in small pieces and the whole of it is never in memory at once.
+//
+// The pieces that join them up:
+//   - io.Pipe: a Writer and a Reader that are the same stream. A Write blocks until a
+//     Read takes the data, so one side goes in a goroutine. It's how to hand something
+//     that writes (json.Encoder, gzip.Writer) to something that wants to read (an HTTP
+//     request body) without a buffer in between. CloseWithError on the writing end
+//     makes the reading end's next Read return that error
+//   - io.MultiWriter: one Write to it is a Write to each writer in turn. The first one
+//     to fail stops it there, so the writers after it miss that write
+//   - io.TeeReader: the same for a reader, copying what's read into a writer
+//   - io.Copy: reads into a 32KB buffer and writes it out until EOF. If the reader
+//     has WriteTo or the writer ReadFrom it lets them do it instead, which can skip
+//     the buffer entirely (a file to a socket, say)
+//
+// hellogo run -record tees the lessons' output to the terminal and the recording with
+// a MultiWriter (teeStdout, ring.go). That one needs os.Pipe, not io.Pipe: os.Stdout is
+// an *os.File, and only a real file descriptor can stand in for it.
+
+// progressReader reports how much has been read after every Read, for io.Copy
+// progress: wrap the source, not the destination, and any copy reports for free
+type progressReader struct {
+	r      io.Reader
+	n      int64
+	report func(n int64)
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	p.n += int64(n)
+	if n > 0 {
+		p.report(p.n)
+	}
+	return n, err
+}
+
+// copyWithProgress is io.Copy moving a progress bar (termui.go) along, and finishing it
+func copyWithProgress(dst io.Writer, src io.Reader, bar *progressBar) (int64, error) {
+	n, err := io.Copy(dst, &progressReader{r: src, report: bar.Set})
+	bar.Finish()
+	return n, err
+}
+
+// gzipJSONLines streams vs as gzipped JSON lines through a pipe: nothing is built up in
+// memory, and an encoding error reaches whoever is reading
+func gzipJSONLines[T any](vs []T) io.ReadCloser {
+	pr, pw := io.Pipe()
+	go func() {
+		zw := gzip.NewWriter(pw)
+		enc := json.NewEncoder(zw)
+		for _, v := range vs {
+			if err := enc.Encode(v); err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+		}
+		pw.CloseWithError(zw.Close()) // nil closes it normally: the reader sees EOF
+	}()
+	return pr
+}
+
+func testPipes() {
+	//// io.Pipe: uploading without building the body first
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		lines := 0
+		for sc := bufio.NewScanner(zr); sc.Scan(); {
+			lines++
+		}
+		fmt.Fprintf(w, "%d lines, %d bytes on the wire", lines, r.ContentLength)
+	}))
+	defer srv.Close()
+
+	notes := make([]note, 1000)
+	for i := range notes {
+		notes[i] = note{ID: i + 1, Text: strings.Repeat("word ", i%20)}
+	}
+	resp, err := http.Post(srv.URL, "application/gzip", gzipJSONLines(notes))
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	fmt.Println(string(body)) // 1000 lines, -1 bytes on the wire: a pipe has no length, so it's sent chunked
+
+	// An error on the writing side comes out of the reading side
+	bad := gzipJSONLines([]any{1, func() {}})
+	_, err = io.ReadAll(bad)
+	fmt.Println(err) // json: unsupported type: func()
+
+	//// io.MultiWriter: one pass, three results
+	var saved strings.Builder
+	h := sha256.New()
+	counter := &countingWriter{}
+	n, _ := io.Copy(io.MultiWriter(&saved, h, counter), strings.NewReader("hello, world\n"))
+	fmt.Printf("%d %q %x %d\n", n, saved.String(), h.Sum(nil)[:4], counter.n) // 13 "hello, world\n" 853ff937 13
+
+	// The first writer to fail stops the rest
+	saved.Reset()
+	_, err = io.MultiWriter(failingWriter{}, &saved).Write([]byte("lost"))
+	fmt.Printf("%v %q\n", err, saved.String()) // disk full "": saved never got it
+
+	//// io.Copy with progress
+	// struct{ io.Writer } hides bytes.Buffer-style ReadFrom methods, so io.Copy uses its
+	// own 32KB buffer and the reports come a buffer at a time
+	var reports []int64
+	src := &progressReader{r: strings.NewReader(strings.Repeat("x", 100_000)), report: func(n int64) { reports = append(reports, n) }}
+	io.Copy(struct{ io.Writer }{io.Discard}, src)
+	fmt.Println(reports) // [32768 65536 98304 100000]
+
+	// And to a progress bar; not a terminal, so one plain line at the end
+	var out strings.Builder
+	bar := newProgressBarClock(&out, "copying", 100_000, realClock{}, false, 80)
+	copyWithProgress(io.Discard, strings.NewReader(strings.Repeat("x", 100_000)), bar)
+	fmt.Println(strings.Fields(out.String())[:3]) // [copying 100% 100000/100000]
+
+	runCase("TestPipes/round trip", func(errorf errorfFunc) {
+		zr, err := gzip.NewReader(gzipJSONLines(notes))
+		if err != nil {
+			errorf("gzip.NewReader: %v", err)
+			return
+		}
+		dec := json.NewDecoder(zr)
+		for i := 0; ; i++ {
+			var n note
+			if err := dec.Decode(&n); errors.Is(err, io.EOF) {
+				if i != len(notes) {
+					errorf("read %d notes back, want %d", i, len(notes))
+				}
+				return
+			} else if err != nil {
+				errorf("note %d: %v", i, err)
+				return
+			}
+			if n != notes[i] {
+				errorf("note %d = %+v, want %+v", i, n, notes[i])
+			}
+		}
+	})
+}
+
+type countingWriter struct{ n int64 }
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	c.n += int64(len(p))
+	return len(p), nil
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) { return 0, errors.New("disk full") }
@@ -0,0 +1,315 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+	"unicode/utf8"
+)
+
+//////// Columns with text/tabwriter, and a table helper
+// tabwriter lines text up in columns. Write cells ending in \t, lines ending in \n,
+// then Flush: it holds everything back until then, since the last row might be the
+// widest. NewWriter(w, minwidth, tabwidth, padding, padchar, flags) is nearly always
+// NewWriter(w, 0, 0, 2, ' ', 0) here: columns as wide as their widest cell, plus 2.
+//
+// The catches:
+//   - a cell is text followed by a \t. The last bit of a line has no \t after it, so
+//     it isn't a cell and isn't lined up with anything: handy for a long last column
+//   - AlignRight is for every column or none; a table of names and numbers wants both
+//   - width is counted in runes, so colour codes (ansi.go) count as text and wide
+//     characters (中文, most emoji) as one column each, and the columns go crooked
+//
+// table works round those: per-column alignment, a header with a rule under it, a
+// maximum cell width with the rest cut off with …, and widths that skip colour codes.
+// hellogo list, progress and the sort and loadtest reports use it.
+
+type table struct {
+	headers  []string
+	right    []bool // per column
+	maxWidth int    // cells longer than this are cut short, 0 for no limit
+	rows     [][]string
+}
+
+func newTable(headers ...string) *table {
+	return &table{headers: headers, right: make([]bool, len(headers))}
+}
+
+// AlignRight right-aligns columns (numbers, mostly) by index
+func (t *table) AlignRight(cols ...int) *table {
+	for _, c := range cols {
+		t.right[c] = true
+	}
+	return t
+}
+
+func (t *table) MaxWidth(n int) *table {
+	t.maxWidth = n
+	return t
+}
+
+// Row adds a row, formatting each cell with fmt.Sprint; missing cells are blank
+func (t *table) Row(cells ...any) {
+	row := make([]string, len(t.headers))
+	for i, c := range cells[:min(len(cells), len(row))] {
+		row[i] = fmt.Sprint(c)
+		if t.maxWidth > 0 {
+			row[i] = truncateVisible(row[i], t.maxWidth)
+		}
+	}
+	t.rows = append(t.rows, row)
+}
+
+func (t *table) Render(w io.Writer) error {
+	widths := make([]int, len(t.headers))
+	for _, row := range append([][]string{t.headers}, t.rows...) {
+		for i, cell := range row {
+			widths[i] = max(widths[i], visibleWidth(cell))
+		}
+	}
+	rule := make([]string, len(widths))
+	for i, n := range widths {
+		rule[i] = strings.Repeat("-", n)
+	}
+
+	var sb strings.Builder
+	for _, row := range append([][]string{t.headers, rule}, t.rows...) {
+		var line strings.Builder
+		for i, cell := range row {
+			pad := strings.Repeat(" ", widths[i]-visibleWidth(cell))
+			if i > 0 {
+				line.WriteString("  ")
+			}
+			if t.right[i] {
+				line.WriteString(pad + cell)
+			} else {
+				line.WriteString(cell + pad)
+			}
+		}
+		sb.WriteString(strings.TrimRight(line.String(), " ") + "\n")
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// ansiEscapeLen is the length of the ANSI escape sequence at the start of s, or 0: ESC [,
+// then parameters, then a letter
+func ansiEscapeLen(s string) int {
+	if !strings.HasPrefix(s, "\x1b[") {
+		return 0
+	}
+	for i := 2; i < len(s); i++ {
+		if c := s[i]; c >= '@' && c <= '~' {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// visibleWidth counts the runes in s that end up on screen
+func visibleWidth(s string) int {
+	n := 0
+	for i := 0; i < len(s); {
+		if esc := ansiEscapeLen(s[i:]); esc > 0 {
+			i += esc
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		i += size
+		n++
+	}
+	return n
+}
+
+// truncateVisible cuts s to limit visible runes, the last one an ellipsis. Escape codes
+// after the cut are kept, so a style that was switched on still gets switched off.
+func truncateVisible(s string, limit int) string {
+	if visibleWidth(s) <= limit {
+		return s
+	}
+	var sb strings.Builder
+	n := 0
+	for i := 0; i < len(s); {
+		if esc := ansiEscapeLen(s[i:]); esc > 0 {
+			sb.WriteString(s[i : i+esc])
+			i += esc
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case n < limit-1:
+			sb.WriteString(s[i : i+size])
+		case n == limit-1:
+			sb.WriteString("…")
+		}
+		i += size
+		n++
+	}
+	return sb.String()
+}
+
+// hellogo list [-width n]: every lesson, whether it has a golden file, and what it builds on
+func listCommand(args []string) error {
+	flags := flag.NewFlagSet("list", flag.ContinueOnError)
+	width := flags.Int("width", 50, "cut longer cells short, 0 for no limit")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 || *width < 0 {
+		return errUsage
+	}
+	t := newTable("lesson", "golden", "after").MaxWidth(*width)
+	for _, name := range lessonNames() {
+		golden := ""
+		if _, ok := goldenLessons[name]; ok {
+			golden = "yes"
+		}
+		t.Row(name, golden, strings.Join(lessonPrereqs[name], ", "))
+	}
+	return t.Render(os.Stdout)
+}
+
+// hellogo progress: what the store (storage.go) has recorded about each lesson
+func progressCommand(args []string) error {
+	if len(args) > 0 {
+		return errUsage
+	}
+	cfg, err := loadConfig(configFile)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	s, err := openStore(ctx, cfg.Storage)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	ps, err := s.LoadProgress(ctx)
+	if err != nil {
+		return err
+	}
+	if len(ps) == 0 {
+		fmt.Println("no progress recorded yet")
+		return nil
+	}
+	writeProgress(os.Stdout, ps, len(lessonRegistry))
+	return nil
+}
+
+func writeProgress(w io.Writer, ps []progress, lessons int) {
+	t := newTable("lesson", "done", "attempts", "last seen").AlignRight(2)
+	completed := 0
+	for _, p := range ps {
+		done := ""
+		if p.Completed {
+			done = "yes"
+			completed++
+		}
+		t.Row(p.Lesson, done, p.Attempts, time.Unix(p.LastSeen, 0).UTC().Format(time.DateTime))
+	}
+	t.Render(w)
+	fmt.Fprintf(w, "%d of %d lessons completed\n", completed, lessons)
+}
+
+func testTabwriter() {
+	// Cells end in \t; the padding (2) goes after each one
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "name\tsize\tmodified")
+	fmt.Fprintln(tw, "hello.go\t17320\t2019-01-01")
+	fmt.Fprintln(tw, "go.mod\t38\t2019-01-01")
+	tw.Flush()
+	// name      size   modified
+	// hello.go  17320  2019-01-01
+	// go.mod    38     2019-01-01
+
+	// The trailing text isn't a cell: the long description doesn't widen anything
+	tw = tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "trie\tprefix trees, and how hellogo guesses what you meant to type")
+	fmt.Fprintln(tw, "heap\tpriority queues")
+	tw.Flush()
+	// trie  prefix trees, and how hellogo guesses what you meant to type
+	// heap  priority queues
+
+	// AlignRight, for every column, and only cells with a \t after them. Debug draws the
+	// column boundaries
+	tw = tabwriter.NewWriter(os.Stdout, 0, 0, 1, ' ', tabwriter.AlignRight|tabwriter.Debug)
+	fmt.Fprintln(tw, "insertion\t9048\t")
+	fmt.Fprintln(tw, "merge\t1544\t")
+	tw.Flush()
+	//  insertion| 9048|
+	//      merge| 1544|
+
+	// Colour codes count as width, so a coloured cell pushes its column out of line
+	defer func(saved colorLevel) { termColors = saved }(termColors)
+	termColors = color16
+	var sb strings.Builder
+	tw = tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "%s\tok\n", green("PASS"))
+	fmt.Fprintf(tw, "%s\tbroken\n", "FAIL")
+	tw.Flush()
+	for _, line := range strings.Split(strings.TrimSpace(sb.String()), "\n") {
+		fmt.Printf("%q\n", line)
+	}
+	// "\x1b[32mPASS\x1b[39m  ok"
+	// "FAIL            broken": padded as if the codes were 10 more letters
+}
+
+func testTables() {
+	t := newTable("algorithm", "random", "sorted", "note").AlignRight(1, 2).MaxWidth(24)
+	t.Row("insertion", 9048, 0, "O(n) on sorted input, O(n²) writes otherwise")
+	t.Row("merge", 1544, 1544, "stable")
+	t.Row("quick", 925, 789) // no note
+	t.Render(os.Stdout)
+	// algorithm  random  sorted  note
+	// ---------  ------  ------  ------------------------
+	// insertion    9048       0  O(n) on sorted input, O…
+	// merge        1544    1544  stable
+	// quick         925     789
+
+	// Colour doesn't throw the columns out here
+	defer func(saved colorLevel) { termColors = saved }(termColors)
+	termColors = color16
+	var sb strings.Builder
+	t = newTable("result", "lesson")
+	t.Row(green("PASS"), "trie")
+	t.Row("FAIL", "heap")
+	t.Render(&sb)
+	for _, line := range strings.Split(strings.TrimSpace(sb.String()), "\n")[2:] {
+		fmt.Printf("%q\n", line)
+	}
+	// "\x1b[32mPASS\x1b[39m    trie"
+	// "FAIL    heap": on screen both lessons start in column 9
+
+	writeProgress(os.Stdout, []progress{
+		{"heap", true, 1, 1546300800},
+		{"trie", false, 12, 1546387200},
+	}, 2)
+	// lesson  done  attempts  last seen
+	// ------  ----  --------  -------------------
+	// heap    yes          1  2019-01-01 00:00:00
+	// trie               12  2019-01-02 00:00:00
+	// 1 of 2 lessons completed
+
+	runCase("TestTables/truncate", func(errorf errorfFunc) {
+		for _, tc := range []struct {
+			in   string
+			max  int
+			want string
+		}{
+			{"hello", 5, "hello"},
+			{"hello!", 5, "hell…"},
+			{"héllo wörld", 6, "héllo…"},
+			{"\x1b[31mfailure\x1b[39m", 4, "\x1b[31mfai…\x1b[39m"}, // still switches red off
+		} {
+			got := truncateVisible(tc.in, tc.max)
+			if got != tc.want {
+				errorf("truncateVisible(%q, %d) = %q, want %q", tc.in, tc.max, got, tc.want)
+			}
+			if w := visibleWidth(got); w > tc.max {
+				errorf("truncateVisible(%q, %d) is %d wide", tc.in, tc.max, w)
+			}
+		}
+	})
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+//////// TCP servers
+// net.Listen + Accept in a loop + a goroutine per connection; that's a whole server.
+// Try it: hellogo tcpchat --serve, then in other terminals hellogo tcpchat --join
+// (or `nc localhost 9000`, it's just lines of text)
+
+func serveTCP(ln net.Listener, handle func(net.Conn)) error {
+	for {
+		conn, err := ln.Accept()
+		if errors.Is(err, net.ErrClosed) {
+			return nil // listener closed, we're done
+		}
+		if err != nil {
+			return err
+		}
+		// One goroutine per connection, so a slow client doesn't block the others
+		go handle(conn)
+	}
+}
+
+// handleEcho sends every line straight back, upper cased
+func handleEcho(conn net.Conn) {
+	defer conn.Close()
+	scanner := bufio.NewScanner(conn)
+	for scanner.Scan() {
+		// A deadline per write, so a client that stops reading can't hang us forever
+		conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+		if _, err := fmt.Fprintln(conn, strings.ToUpper(scanner.Text())); err != nil {
+			return
+		}
+	}
+}
+
+func testTCPEcho() {
+	// Port 0 means "any free port", ln.Addr() says which one we got
+	ln, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		fmt.Println("listen failed:", err)
+		return
+	}
+	defer ln.Close()
+	go serveTCP(ln, handleEcho)
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		fmt.Println("dial failed:", err)
+		return
+	}
+	defer conn.Close()
+
+	replies := bufio.NewScanner(conn)
+	for _, line := range []string{"hello", "tcp"} {
+		fmt.Fprintln(conn, line)
+		replies.Scan()
+		fmt.Println(replies.Text()) // HELLO, TCP
+	}
+}
+
+//// Chat
+// Every line anyone sends is published on a broker (pubsub.go), and every connection
+// has a goroutine forwarding what it's subscribed to back down its socket
+
+type chatServer struct {
+	messages *broker[string]
+}
+
+func (s *chatServer) handle(conn net.Conn) {
+	defer conn.Close()
+	in := bufio.NewScanner(conn)
+
+	fmt.Fprint(conn, "name? ")
+	if !in.Scan() {
+		return
+	}
+	name := strings.TrimSpace(in.Text())
+	if name == "" {
+		name = conn.RemoteAddr().String()
+	}
+
+	sub := s.messages.Subscribe(16)
+	defer s.messages.Unsubscribe(sub)
+	go func() {
+		for msg := range sub { // ends when Unsubscribe closes the channel
+			conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if _, err := fmt.Fprintln(conn, msg); err != nil {
+				return
+			}
+		}
+	}()
+
+	s.messages.Publish("* " + name + " joined")
+	for in.Scan() {
+		if line := strings.TrimSpace(in.Text()); line != "" {
+			s.messages.Publish(name + ": " + line)
+		}
+	}
+	s.messages.Publish("* " + name + " left")
+}
+
+// chatJoin connects stdin/stdout to a chat server, like a tiny netcat
+func chatJoin(addr string, in io.Reader, out io.Writer) error {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(out, conn) // server -> screen
+		done <- err
+	}()
+	go func() {
+		io.Copy(conn, in) // keyboard -> server
+		// Ctrl+D: tell the server we're done sending, but keep reading its replies
+		if tcp, ok := conn.(*net.TCPConn); ok {
+			tcp.CloseWrite()
+		}
+	}()
+	return <-done
+}
+
+// hellogo tcpchat --serve [addr] | --join [addr]
+func tcpchatCommand(args []string) error {
+	flags := flag.NewFlagSet("tcpchat", flag.ContinueOnError)
+	serve := flags.Bool("serve", false, "run the chat server")
+	join := flags.Bool("join", false, "connect to a chat server")
+	if err := flags.Parse(args); err != nil || *serve == *join || flags.NArg() > 1 {
+		return errUsage
+	}
+	addr := "localhost:9000"
+	if flags.NArg() == 1 {
+		addr = flags.Arg(0)
+	}
+
+	if *join {
+		return chatJoin(addr, os.Stdin, os.Stdout)
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+	defer ln.Close()
+	fmt.Fprintln(os.Stderr, "chat server on", ln.Addr())
+	srv := &chatServer{messages: newBroker[string]()}
+	return serveTCP(ln, srv.handle)
+}
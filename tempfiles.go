@@ -0,0 +1,48 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+//////// Temp files and directories
+// Don't hard code /tmp/something: it doesn't exist on Windows, and two runs at once
+// would trample each other. os.MkdirTemp/CreateTemp pick a unique name in os.TempDir()
+// Note, in tests use t.TempDir() instead (see tempfiles_test.go); it is deleted
+// automatically when the test ends
+
+// makeTempDir panics on failure like createFile does, the lessons can't go on without it
+func makeTempDir(name string) string {
+	// The * in the pattern is replaced by a random string
+	dir, err := os.MkdirTemp("", "hellogo-"+name+"-*")
+	if err != nil {
+		panic(err)
+	}
+	return dir
+}
+
+func testTempFiles() {
+	fmt.Println(os.TempDir()) // $TMPDIR, or /tmp
+
+	dir := makeTempDir("lesson")
+	// RemoveAll deletes the dir and everything in it; deferring it right away means
+	// we can't forget, even if we return early or panic
+	defer os.RemoveAll(dir)
+	fmt.Println(dir) // /tmp/hellogo-lesson-123456789
+
+	// CreateTemp opens the file for you; "" as the dir means os.TempDir()
+	f, err := os.CreateTemp(dir, "notes-*.txt")
+	if err != nil {
+		fmt.Println("create failed:", err)
+		return
+	}
+	defer os.Remove(f.Name()) // redundant with RemoveAll, but what you'd do for a lone temp file
+	defer f.Close()
+
+	fmt.Fprintln(f, "scratch data")
+	fmt.Println(filepath.Base(f.Name())) // notes-987654321.txt
+
+	entries, _ := os.ReadDir(dir)
+	fmt.Println(len(entries), "file in", dir) // 1 file in ...
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// t.TempDir is a new directory per call, removed with everything in it when the test
+// (or subtest) ends, so there's nothing to defer and nothing left behind when it fails
+func TestFileHelpers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "defer.txt")
+	f := createFile(path)
+	writeFile(f)
+	closeFile(f)
+
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "data\n" {
+		t.Errorf("read back %q, %v; want \"data\\n\"", data, err)
+	}
+}
+
+func TestMakeTempDir(t *testing.T) {
+	a, b := makeTempDir("test"), makeTempDir("test")
+	t.Cleanup(func() {
+		os.RemoveAll(a)
+		os.RemoveAll(b)
+	})
+	if a == b {
+		t.Errorf("two calls gave the same dir %s", a)
+	}
+	for _, dir := range []string{a, b} {
+		if !strings.HasPrefix(filepath.Base(dir), "hellogo-test-") {
+			t.Errorf("%s doesn't follow the hellogo-<name>-* pattern", dir)
+		}
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			t.Errorf("%s isn't a directory: %v", dir, err)
+		}
+	}
+}
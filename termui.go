@@ -0,0 +1,317 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+//////// Progress bars and spinners
+// A terminal redraws a line when you print \r (back to the start of the line) and write
+// over it. That's all a progress bar is: the same line printed again and again, a bit
+// fuller each time. The catch is where the output goes. Into a file or a CI log, every
+// \r redraw is another copy of the line, thousands of them, so when the output isn't a
+// terminal these print a plain line now and then instead.
+//
+// Is it a terminal? A terminal is a character device, which os.File.Stat reports; pipes
+// and files aren't. How wide is it? The shell's $COLUMNS if it's exported, else 80.
+// (Asking the terminal itself needs an ioctl, which golang.org/x/term wraps.)
+//
+// Both are safe to update from any goroutine, which is how they get used: workers call
+// Add, and the bar redraws at most every 100ms however often that is.
+//
+// The ETA assumes the rest goes as fast as everything so far: elapsed × left / done.
+
+const (
+	termRedrawEvery = 100 * time.Millisecond
+	termPlainEvery  = 5 * time.Second // between lines when it's not a terminal
+)
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func terminalWidth() int {
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 20 {
+		return n
+	}
+	return 80
+}
+
+// etaFor is how long the rest should take, going by how long done took; 0 if there's no telling yet
+func etaFor(done, total int64, elapsed time.Duration) time.Duration {
+	if done <= 0 || done >= total {
+		return 0
+	}
+	return time.Duration(float64(elapsed) * float64(total-done) / float64(done))
+}
+
+// formatETA rounds to what's worth showing: seconds, or minutes once there are a lot of them
+func formatETA(d time.Duration) string {
+	if d >= 10*time.Minute {
+		return d.Round(time.Minute).String()
+	}
+	return d.Round(time.Second).String()
+}
+
+type progressBar struct {
+	mu       sync.Mutex
+	w        io.Writer
+	label    string
+	total    int64
+	done     int64
+	clk      clock
+	start    time.Time
+	drawn    time.Time // last redraw or plain line
+	shown    int64     // done as of then
+	tty      bool
+	width    int
+	finished bool
+}
+
+func newProgressBar(w io.Writer, label string, total int64) *progressBar {
+	return newProgressBarClock(w, label, total, realClock{}, isTerminal(w), terminalWidth())
+}
+
+func newProgressBarClock(w io.Writer, label string, total int64, clk clock, tty bool, width int) *progressBar {
+	now := clk.Now()
+	return &progressBar{w: w, label: label, total: max(total, 1), clk: clk, start: now, drawn: now, shown: -1, tty: tty, width: width}
+}
+
+func (b *progressBar) Add(n int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.setLocked(b.done + n)
+}
+
+func (b *progressBar) Set(done int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.setLocked(done)
+}
+
+func (b *progressBar) setLocked(done int64) {
+	if b.finished {
+		return
+	}
+	b.done = min(max(done, 0), b.total)
+	now := b.clk.Now()
+	every := termPlainEvery
+	if b.tty {
+		every = termRedrawEvery
+	}
+	if now.Sub(b.drawn) >= every {
+		b.draw(now)
+	}
+}
+
+// Finish draws the bar at its final state, unless that's already showing, and ends the line
+func (b *progressBar) Finish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.finished {
+		return
+	}
+	if b.shown != b.done {
+		b.draw(b.clk.Now())
+	}
+	if b.tty {
+		fmt.Fprintln(b.w)
+	}
+	b.finished = true
+}
+
+// draw prints one frame: the status goes on the right and the bar gets whatever width is left
+func (b *progressBar) draw(now time.Time) {
+	b.drawn, b.shown = now, b.done
+	elapsed := now.Sub(b.start)
+	pct := b.done * 100 / b.total
+	status := fmt.Sprintf("%3d%% %d/%d", pct, b.done, b.total)
+	if b.done < b.total {
+		if eta := etaFor(b.done, b.total, elapsed); eta > 0 {
+			status += " ETA " + formatETA(eta)
+		}
+	} else {
+		status += " in " + formatETA(elapsed)
+	}
+	if !b.tty {
+		fmt.Fprintf(b.w, "%s %s\n", b.label, status)
+		return
+	}
+	// Room for the longest status there'll be, so the bar doesn't change length as the ETA does
+	status = fmt.Sprintf("%-*s", len(fmt.Sprintf("100%% %d/%d ETA 59m59s", b.total, b.total)), status)
+	barWidth := b.width - len(b.label) - len(status) - 5 // spaces and brackets, and a column spare so the terminal never wraps
+	if barWidth < 10 {
+		fmt.Fprint(b.w, "\r", b.label, " ", status, ansiClearLine)
+		return
+	}
+	filled := int(int64(barWidth) * b.done / b.total)
+	bar := strings.Repeat("=", filled)
+	if filled < barWidth {
+		bar += ">" + strings.Repeat(" ", barWidth-filled-1)
+	}
+	fmt.Fprint(b.w, "\r", b.label, " [", green(bar), "] ", status, ansiClearLine)
+}
+
+//// Spinners
+// For work with no known total: something that moves, so it's clearly not stuck, and
+// a status line from the caller (requests so far, say).
+
+var spinnerFrames = []string{"|", "/", "-", `\`}
+
+type spinner struct {
+	mu     sync.Mutex
+	w      io.Writer
+	label  string
+	status func() string // called on every redraw; may be nil
+	tty    bool
+	frame  int
+	stop   chan struct{}
+	done   chan struct{}
+}
+
+func newSpinner(w io.Writer, label string, status func() string) *spinner {
+	return &spinner{w: w, label: label, status: status, tty: isTerminal(w)}
+}
+
+// line is one frame; it advances the animation
+func (s *spinner) line() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	text := s.label
+	if s.status != nil {
+		text += " " + s.status()
+	}
+	if !s.tty {
+		return text + "\n"
+	}
+	s.frame = (s.frame + 1) % len(spinnerFrames)
+	return "\r" + cyan(spinnerFrames[s.frame]) + " " + text + ansiClearLine
+}
+
+// Start redraws every 100ms on a terminal, every 5s elsewhere, until Stop
+func (s *spinner) Start() {
+	s.stop, s.done = make(chan struct{}), make(chan struct{})
+	every := termPlainEvery
+	if s.tty {
+		every = termRedrawEvery
+	}
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+				io.WriteString(s.w, s.line())
+			}
+		}
+	}()
+}
+
+// Stop ends the animation and replaces it with final
+func (s *spinner) Stop(final string) {
+	close(s.stop)
+	<-s.done
+	if s.tty {
+		fmt.Fprint(s.w, "\r", final, ansiClearLine, "\n")
+	} else {
+		fmt.Fprintln(s.w, final)
+	}
+}
+
+func testTermUI() {
+	// A fake clock and a strings.Builder standing in for the terminal, to see every frame,
+	// and no colour (ansi.go) so the frames read the same whatever stdout is
+	defer func(saved colorLevel) { termColors = saved }(termColors)
+	termColors = colorNone
+	clk := &fakeClock{now: time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	var screen strings.Builder
+	bar := newProgressBarClock(&screen, "copying", 200, clk, true, 50)
+	for range 4 {
+		clk.Advance(time.Second)
+		bar.Add(40)
+	}
+	bar.Add(5) // too soon after the last redraw: no frame
+	clk.Advance(time.Second)
+	bar.Set(200)
+	bar.Finish()
+	for _, frame := range strings.Split(strings.TrimSuffix(screen.String(), "\n"), "\r")[1:] {
+		fmt.Printf("%q\n", strings.TrimRight(strings.TrimSuffix(frame, ansiClearLine), " "))
+	}
+	// "copying [===>           ]  20% 40/200 ETA 4s"
+	// "copying [======>        ]  40% 80/200 ETA 3s"
+	// "copying [=========>     ]  60% 120/200 ETA 2s"
+	// "copying [============>  ]  80% 160/200 ETA 1s"
+	// "copying [===============] 100% 200/200 in 5s"
+	// Finish had nothing new to draw, so it just ended the line
+
+	// The same into a log file: a plain line every 5 seconds and one at the end
+	screen.Reset()
+	clk = &fakeClock{now: clk.now}
+	bar = newProgressBarClock(&screen, "copying", 1000, clk, false, 50)
+	for range 10 {
+		clk.Advance(time.Second)
+		bar.Add(100)
+	}
+	bar.Finish()
+	fmt.Print(screen.String())
+	// copying  50% 500/1000 ETA 5s
+	// copying 100% 1000/1000 in 10s
+
+	runCase("TestTermUI/eta", func(errorf errorfFunc) {
+		for _, tc := range []struct {
+			done, total int64
+			elapsed     time.Duration
+			want        time.Duration
+		}{
+			{0, 100, time.Second, 0}, // nothing done yet: no idea
+			{25, 100, time.Second, 3 * time.Second},
+			{50, 100, time.Minute, time.Minute},
+			{99, 100, 99 * time.Second, time.Second},
+			{100, 100, time.Second, 0},
+		} {
+			if got := etaFor(tc.done, tc.total, tc.elapsed); got != tc.want {
+				errorf("etaFor(%d, %d, %v) = %v, want %v", tc.done, tc.total, tc.elapsed, got, tc.want)
+			}
+		}
+	})
+
+	runCase("TestTermUI/concurrent adds", func(errorf errorfFunc) {
+		bar := newProgressBarClock(io.Discard, "", 8000, realClock{}, true, 80)
+		var wg sync.WaitGroup
+		for range 8 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range 1000 {
+					bar.Add(1)
+				}
+			}()
+		}
+		wg.Wait()
+		if bar.done != 8000 {
+			errorf("done = %d after 8000 adds", bar.done)
+		}
+	})
+
+	// A spinner into a non-terminal prints its status only every 5 seconds, so a short
+	// job shows just the final line
+	var sent atomic.Int64 // the spinner reads it from its own goroutine
+	s := newSpinner(os.Stdout, "loadtest", func() string { return fmt.Sprint(sent.Load(), " requests") })
+	s.Start()
+	sent.Store(42)
+	s.Stop("loadtest: 42 requests") // loadtest: 42 requests
+}
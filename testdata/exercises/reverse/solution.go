@@ -0,0 +1,7 @@
+package exercise
+
+// Reverse returns s with its characters in the opposite order: "héllo" gives "olléh".
+// Work on runes, not bytes, or characters longer than a byte come out scrambled
+func Reverse(s string) string {
+	return "" // your code here
+}
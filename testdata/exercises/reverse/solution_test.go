@@ -0,0 +1,25 @@
+package exercise
+
+import "testing"
+
+func TestReverse(t *testing.T) {
+	for _, tc := range []struct{ in, want string }{
+		{"", ""},
+		{"a", "a"},
+		{"gopher", "rehpog"},
+		{"héllo", "olléh"},
+		{"日本語", "語本日"},
+	} {
+		if got := Reverse(tc.in); got != tc.want {
+			t.Errorf("Reverse(%q) = %q, want %q", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestReverseTwice(t *testing.T) {
+	for _, s := range []string{"", "round trip", "ünïcödé"} {
+		if got := Reverse(Reverse(s)); got != s {
+			t.Errorf("Reverse(Reverse(%q)) = %q", s, got)
+		}
+	}
+}
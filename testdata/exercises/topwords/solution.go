@@ -0,0 +1,8 @@
+package exercise
+
+// TopWords returns the n most common words in text, most common first. Words are
+// split on anything that isn't a letter and compared in lower case; words that are
+// equally common come in alphabetical order. Fewer than n words gives them all
+func TopWords(text string, n int) []string {
+	return nil // your code here
+}
@@ -0,0 +1,25 @@
+package exercise
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestTopWords(t *testing.T) {
+	for _, tc := range []struct {
+		text string
+		n    int
+		want []string
+	}{
+		{"", 3, nil},
+		{"go go gopher", 1, []string{"go"}},
+		{"Go go GO gopher", 2, []string{"go", "gopher"}},
+		{"b a c a b a", 3, []string{"a", "b", "c"}},
+		{"the cat, the hat; the end.", 2, []string{"the", "cat"}}, // cat, end and hat tie: alphabetical
+		{"one", 5, []string{"one"}},
+	} {
+		if got := TopWords(tc.text, tc.n); !slices.Equal(got, tc.want) {
+			t.Errorf("TopWords(%q, %d) = %q, want %q", tc.text, tc.n, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,9 @@
+package main
+
+import "fmt"
+
+func main() {
+	fmt.Println("hello, gopher")
+}
+
+func add(a, b int) int { return a + b }
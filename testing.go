@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+//////// Testing
+// Tests live next to the code in files ending _test.go, which `go build` ignores and
+// `go test` compiles in. A test is any func TestXxx(t *testing.T) in them:
+//
+//	func TestAddStuff(t *testing.T) {
+//		if got := addStuff(2, 3); got != 5 {
+//			t.Errorf("addStuff(2, 3) = %d, want 5", got) // Errorf marks it failed and keeps going,
+//		}                                                  // Fatalf stops this test right here
+//	}
+//
+//	go test ./...                   # everything
+//	go test -run 'Factorial/zero' -v # just matching tests/subtests, with output
+//	go test -count=1 -race ./...    # skip the result cache, look for data races
+//
+//// Table driven tests
+// Instead of one function per case, list the cases in a table and loop. Adding a case
+// is one line, and the failure message says which case broke. t.Run makes each row a
+// named subtest you can run on its own (-run above), and t.Parallel lets rows run at once:
+//
+//	func TestFactorial(t *testing.T) {
+//		for _, tc := range factorialCases {
+//			t.Run(tc.name, func(t *testing.T) {
+//				t.Parallel() // tc is per iteration since Go 1.22, so this is safe
+//				wantInt(t, "recursiveFunction", tc.n, recursiveFunction(tc.n), tc.want)
+//			})
+//		}
+//	}
+//
+// Helpers that call Errorf should start with t.Helper(), so failures point at the line
+// in the TEST that called them, not at the line inside the helper:
+//
+//	func wantInt(t *testing.T, fn string, in, got, want int) {
+//		t.Helper()
+//		if got != want {
+//			t.Errorf("%s(%d) = %d, want %d", fn, in, got, want)
+//		}
+//	}
+//
+// Those two tests are in testing_test.go, run by go test; the tables are below so
+// testTableDriven can walk them too and show what go test -v prints.
+
+type addStuffCase struct {
+	name string
+	a, b int
+	want int
+}
+
+var addStuffCases = []addStuffCase{
+	{"zeros", 0, 0, 0},
+	{"positive", 2, 3, 5},
+	{"negative", -2, -3, -5},
+	{"mixed", -2, 3, 1},
+	{"overflow wraps", 1<<63 - 1, 1, -1 << 63}, // ints wrap around, no error
+}
+
+type factorialCase struct {
+	name string
+	n    int
+	want int
+}
+
+var factorialCases = []factorialCase{
+	{"zero", 0, 1},
+	{"one", 1, 1},
+	{"five", 5, 120},
+	{"twenty", 20, 2432902008176640000}, // the biggest that fits in an int64
+}
+
+// errorfFunc stands in for t.Errorf
+type errorfFunc = func(format string, args ...any)
+
+// checkInt is wantInt from the comment above, reporting through a func instead of t.Errorf
+func checkInt(errorf errorfFunc, fn string, in, got, want int) {
+	if got != want {
+		errorf("%s(%d) = %d, want %d", fn, in, got, want)
+	}
+}
+
+// runCase prints a subtest's result the way go test -v does
+func runCase(name string, body func(errorf errorfFunc)) bool {
+	var failures []string
+	body(func(format string, args ...any) {
+		failures = append(failures, strings.ReplaceAll(fmt.Sprintf(format, args...), "\n", "\n    "))
+	})
+	if len(failures) > 0 {
+		fmt.Printf("--- FAIL: %s\n    %s\n", name, strings.Join(failures, "\n    "))
+		return false
+	}
+	fmt.Printf("--- PASS: %s\n", name)
+	return true
+}
+
+func testTableDriven() {
+	ok := true
+	for _, tc := range addStuffCases {
+		ok = runCase("TestAddStuff/"+tc.name, func(errorf errorfFunc) {
+			if got := addStuff(tc.a, tc.b); got != tc.want {
+				errorf("addStuff(%d, %d) = %d, want %d", tc.a, tc.b, got, tc.want)
+			}
+		}) && ok
+	}
+	for _, tc := range factorialCases {
+		ok = runCase("TestFactorial/"+tc.name, func(errorf errorfFunc) {
+			checkInt(errorf, "recursiveFunction", tc.n, recursiveFunction(tc.n), tc.want)
+		}) && ok
+	}
+
+	// What a failure looks like: a wrong row in the table
+	runCase("TestFactorial/wrong on purpose", func(errorf errorfFunc) {
+		checkInt(errorf, "recursiveFunction", 4, recursiveFunction(4), 42)
+	})
+	// --- FAIL: TestFactorial/wrong on purpose
+	//     recursiveFunction(4) = 24, want 42
+
+	fmt.Println("all passed:", ok) // all passed: true
+}
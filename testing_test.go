@@ -0,0 +1,30 @@
+package main
+
+import "testing"
+
+func TestAddStuff(t *testing.T) {
+	for _, tc := range addStuffCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			if got := addStuff(tc.a, tc.b); got != tc.want {
+				t.Errorf("addStuff(%d, %d) = %d, want %d", tc.a, tc.b, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFactorial(t *testing.T) {
+	for _, tc := range factorialCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			wantInt(t, "recursiveFunction", tc.n, recursiveFunction(tc.n), tc.want)
+		})
+	}
+}
+
+func wantInt(t *testing.T, fn string, in, got, want int) {
+	t.Helper()
+	if got != want {
+		t.Errorf("%s(%d) = %d, want %d", fn, in, got, want)
+	}
+}
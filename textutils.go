@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+//////// Mini project: wc and tail
+//
+//	hellogo tool wc [-l] [-w] [-c] [-m] [file...]   lines, words, bytes (-m: characters)
+//	hellogo tool tail [-n 10] [-f] file            last lines, -f keeps printing what's appended
+//
+// wc reads runes with bufio.Reader, so "héllo" is 5 characters but 6 bytes. tail
+// doesn't read the whole file to find the end: it seeks to the end and reads backwards
+// a chunk at a time until it has seen enough newlines. tail -f then polls the file's
+// size and copies whatever was added; fsnotify-style OS notifications would save the
+// polling, but they're a different API on every OS and this is stdlib only.
+
+type wcCounts struct {
+	lines, words, chars, bytes int
+}
+
+func (c *wcCounts) add(o wcCounts) {
+	c.lines += o.lines
+	c.words += o.words
+	c.chars += o.chars
+	c.bytes += o.bytes
+}
+
+// countText counts like wc: a line is a '\n', a word is a run of non-space characters,
+// and bytes that aren't valid UTF-8 still count as one character each
+func countText(r io.Reader) (wcCounts, error) {
+	var c wcCounts
+	br := bufio.NewReader(r)
+	inWord := false
+	for {
+		ch, size, err := br.ReadRune() // utf8.RuneError with size 1 for a bad byte
+		if err == io.EOF {
+			return c, nil
+		}
+		if err != nil {
+			return c, err
+		}
+		c.chars++
+		c.bytes += size
+		if ch == '\n' {
+			c.lines++
+		}
+		if unicode.IsSpace(ch) {
+			inWord = false
+		} else if !inWord {
+			inWord = true
+			c.words++
+		}
+	}
+}
+
+type wcOptions struct {
+	lines, words, chars, bytes bool
+}
+
+func (o wcOptions) format(c wcCounts, name string) string {
+	var cols []string
+	for _, col := range []struct {
+		show bool
+		n    int
+	}{{o.lines, c.lines}, {o.words, c.words}, {o.chars, c.chars}, {o.bytes, c.bytes}} {
+		if col.show {
+			cols = append(cols, fmt.Sprintf("%7d", col.n))
+		}
+	}
+	return strings.TrimRight(strings.Join(cols, " ")+" "+name, " ")
+}
+
+func wcCommand(args []string, stdin io.Reader, out io.Writer) error {
+	flags := flag.NewFlagSet("tool wc", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	var o wcOptions
+	flags.BoolVar(&o.lines, "l", false, "count lines")
+	flags.BoolVar(&o.words, "w", false, "count words")
+	flags.BoolVar(&o.chars, "m", false, "count characters")
+	flags.BoolVar(&o.bytes, "c", false, "count bytes")
+	if err := flags.Parse(args); err != nil {
+		return errUsage
+	}
+	if o == (wcOptions{}) {
+		o = wcOptions{lines: true, words: true, bytes: true} // wc's default columns
+	}
+
+	if flags.NArg() == 0 {
+		c, err := countText(stdin)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(out, o.format(c, ""))
+		return nil
+	}
+	var total wcCounts
+	for _, name := range flags.Args() {
+		f, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		c, err := countText(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		fmt.Fprintln(out, o.format(c, name))
+		total.add(c)
+	}
+	if flags.NArg() > 1 {
+		fmt.Fprintln(out, o.format(total, "total"))
+	}
+	return nil
+}
+
+// tailOffset finds where the last n lines of rs start by reading backwards from the end,
+// chunk bytes at a time. A newline at the very end doesn't start another (empty) line.
+func tailOffset(rs io.ReadSeeker, n int, chunk int) (int64, error) {
+	size, err := rs.Seek(0, io.SeekEnd)
+	if err != nil || n <= 0 {
+		return size, err
+	}
+	buf := make([]byte, chunk)
+	found := 0
+	for end := size; end > 0; {
+		start := max(0, end-int64(chunk))
+		if _, err := rs.Seek(start, io.SeekStart); err != nil {
+			return 0, err
+		}
+		b := buf[:end-start]
+		if _, err := io.ReadFull(rs, b); err != nil {
+			return 0, err
+		}
+		for i := len(b) - 1; i >= 0; i-- {
+			pos := start + int64(i)
+			if b[i] != '\n' || pos == size-1 {
+				continue
+			}
+			if found++; found == n {
+				return pos + 1, nil
+			}
+		}
+		end = start
+	}
+	return 0, nil // fewer than n lines: the whole file
+}
+
+// follow copies whatever is appended to f after offset until ctx is done, checking
+// every interval. A file that shrank was truncated (a log being reset), start over.
+// Polling the open file doesn't notice the file being renamed away and replaced,
+// which is what log rotation does; tail -F reopens by name for that.
+func follow(ctx context.Context, f *os.File, offset int64, out io.Writer, every time.Duration) error {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		info, err := f.Stat()
+		if err != nil {
+			return err
+		}
+		if info.Size() < offset {
+			offset = 0
+		}
+		if info.Size() > offset {
+			if _, err := f.Seek(offset, io.SeekStart); err != nil {
+				return err
+			}
+			n, err := io.CopyN(out, f, info.Size()-offset)
+			offset += n
+			if err != nil && err != io.EOF {
+				return err
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func tailCommand(args []string, out io.Writer) error {
+	flags := flag.NewFlagSet("tool tail", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	n := flags.Int("n", 10, "number of lines")
+	followFlag := flags.Bool("f", false, "keep printing lines as they're appended")
+	if err := flags.Parse(args); err != nil || flags.NArg() != 1 || *n < 0 {
+		return errUsage
+	}
+	f, err := os.Open(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	offset, err := tailOffset(f, *n, 4096)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	copied, err := io.Copy(out, f)
+	if err != nil || !*followFlag {
+		return err
+	}
+	ctx, stop := shutdownContext() // Ctrl+C ends -f
+	defer stop()
+	return follow(ctx, f, offset+copied, out, 250*time.Millisecond)
+}
+
+// hellogo tool wc|tail|diff [args...]
+func toolCommand(args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch args[0] {
+	case "wc":
+		return wcCommand(args[1:], os.Stdin, os.Stdout)
+	case "tail":
+		return tailCommand(args[1:], os.Stdout)
+	case "diff":
+		return diffCommand(args[1:])
+	default:
+		return errUsage
+	}
+}
+
+func testTextUtils() {
+	c, _ := countText(strings.NewReader("héllo wörld\n  Go  is\tfun\n"))
+	fmt.Printf("%+v\n", c) // {lines:2 words:5 chars:25 bytes:27}
+	c, _ = countText(strings.NewReader("no newline at the end"))
+	fmt.Println(c.lines, c.words) // 0 5, like wc: lines are newlines
+	c, _ = countText(bytes.NewReader([]byte{'a', 0xff, 'b'}))
+	fmt.Println(c.chars, c.bytes, utf8.Valid([]byte{0xff})) // 3 3 false
+
+	var out strings.Builder
+	wcCommand([]string{"-l", "-m"}, strings.NewReader("one\ntwö\n"), &out)
+	fmt.Print(out.String()) // "      2       8"
+
+	dir := makeTempDir("textutils")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.log")
+	var lines []string
+	for i := 1; i <= 100; i++ {
+		lines = append(lines, fmt.Sprintf("line %d", i))
+	}
+	os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+
+	f, err := os.Open(path)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer f.Close()
+	offset, _ := tailOffset(f, 3, 16) // a tiny chunk, so the newlines are spread over several reads
+	f.Seek(offset, io.SeekStart)
+	rest, _ := io.ReadAll(f)
+	fmt.Printf("%d %q\n", offset, rest) // 767 "line 98\nline 99\nline 100\n"
+
+	// tail -f: append from another goroutine while following
+	ctx, cancel := context.WithCancel(context.Background())
+	var followed bytes.Buffer
+	done := make(chan error)
+	go func() { done <- follow(ctx, f, offset+int64(len(rest)), &followed, 5*time.Millisecond) }()
+
+	w, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0)
+	for i := 101; i <= 103; i++ {
+		fmt.Fprintf(w, "line %d\n", i)
+		time.Sleep(15 * time.Millisecond)
+	}
+	w.Close()
+	time.Sleep(15 * time.Millisecond)
+	cancel()
+	fmt.Println(<-done)                   // <nil>, cancelling is how -f normally ends
+	fmt.Printf("%q\n", followed.String()) // "line 101\nline 102\nline 103\n"
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+//////// Time formatting, parsing and zones
+// Go doesn't use %Y-%m-%d style layouts, it uses one reference time written the way
+// you want yours to look: Mon Jan 2 15:04:05 MST 2006 (1 2 3 4 5 6 7, in US order)
+
+//// Clocks
+// Anything that calls time.Now() directly gives different output every run.
+// Take a clock instead and hand it a fakeClock when you need to pin time down
+
+type clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func testTimeLayouts() {
+	clk := &fakeClock{now: time.Date(2019, time.March, 14, 15, 9, 26, 535897932, time.UTC)}
+	t := clk.Now()
+
+	fmt.Println(t.Format(time.RFC3339))                    // 2019-03-14T15:09:26Z
+	fmt.Println(t.Format(time.RFC3339Nano))                // 2019-03-14T15:09:26.535897932Z
+	fmt.Println(t.Format(time.DateOnly))                   // 2019-03-14
+	fmt.Println(t.Format("Mon Jan 2 3:04PM"))              // Thu Mar 14 3:09PM
+	fmt.Println(t.Format("2006/01/02 15:04:05.000"))       // 2019/03/14 15:09:26.535
+	fmt.Println(t.Format("Monday, 02-Jan-06 at 15h04"))    // Thursday, 14-Mar-19 at 15h09
+	fmt.Println(t.Format("2006-01-02 15:04:05 -0700 MST")) // 2019-03-14 15:09:26 +0000 UTC
+}
+
+func testTimeParsing() {
+	t, err := time.Parse("2006-01-02 15:04", "2019-03-14 15:09")
+	fmt.Println(t, err) // 2019-03-14 15:09:00 +0000 UTC <nil>
+
+	// Errors say which part didn't match
+	_, err = time.Parse("2006-01-02", "2019-13-01")
+	fmt.Println(err) // parsing time "2019-13-01": month out of range
+	_, err = time.Parse("2006-01-02", "14/03/2019")
+	fmt.Println(err) // parsing time "14/03/2019" as "2006-01-02": cannot parse "14/03/2019" as "2006"
+
+	// Classic mistake: using the wrong reference numbers silently gives nonsense layouts
+	fmt.Println(t.Format("2019-03-14")) // 14039-03-39 (2019 isn't the reference year, those digits mean other things!)
+}
+
+func testDurations() {
+	d := 90 * time.Minute
+	fmt.Println(d, d.Hours(), d.Round(time.Hour)) // 1h30m0s 1.5 2h0m0s
+
+	d, err := time.ParseDuration("1h15m30.5s")
+	fmt.Println(d, err) // 1h15m30.5s <nil>
+
+	clk := &fakeClock{now: time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)}
+	start := clk.Now()
+	clk.Advance(3*time.Second + 250*time.Millisecond)
+	fmt.Println(clk.Now().Sub(start)) // 3.25s
+
+	// Note, Add works on durations, AddDate on calendar units (months have different lengths)
+	fmt.Println(start.AddDate(0, 1, 0).Format(time.DateOnly))         // 2019-02-01
+	fmt.Println(start.Add(31 * 24 * time.Hour).Format(time.DateOnly)) // 2019-02-01 too
+}
+
+func testTimeZones() {
+	t := time.Date(2019, time.March, 14, 15, 0, 0, 0, time.UTC)
+
+	// Zone names come from the system's tz database (or embed time/tzdata)
+	for _, name := range []string{"America/New_York", "Asia/Tokyo", "Not/AZone"} {
+		loc, err := time.LoadLocation(name)
+		if err != nil {
+			fmt.Println(err) // unknown time zone Not/AZone
+			continue
+		}
+		fmt.Println(name, t.In(loc).Format("2006-01-02 15:04 MST"))
+	}
+
+	// Same instant, different wall clock; Equal compares instants, == compares everything
+	tokyo := t.In(time.FixedZone("JST", 9*60*60))
+	fmt.Println(t.Equal(tokyo), t == tokyo) // true false
+}
+
+func testUnixTime() {
+	t := time.Date(2019, time.January, 1, 0, 0, 0, 0, time.UTC)
+	fmt.Println(t.Unix())      // 1546300800 seconds since 1970-01-01 UTC
+	fmt.Println(t.UnixMilli()) // 1546300800000
+
+	back := time.Unix(1546300800, 0).UTC()
+	fmt.Println(back)                    // 2019-01-01 00:00:00 +0000 UTC
+	fmt.Println(time.UnixMilli(0).UTC()) // 1970-01-01 00:00:00 +0000 UTC
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+//////// Mini project: a todo list
+// Pulls earlier lessons together: a struct and slices of it (todo), errors with a
+// sentinel (errTodoNotFound), flags per subcommand, and the store interface so the
+// list ends up wherever storage.backend says (hellogo.json by default):
+//
+//	hellogo todo add buy milk
+//	hellogo todo list [--all]
+//	hellogo todo done 1 3
+//	hellogo todo delete 2
+//
+// The logic takes the store, a writer and a clock instead of opening files or reading
+// the time itself, so TestTodo (todo_test.go) can run it against a temp store and check
+// every byte.
+
+type todoApp struct {
+	store store
+	out   io.Writer
+	clock clock // see timeformat.go
+}
+
+func (a todoApp) run(ctx context.Context, args []string) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	switch sub, rest := args[0], args[1:]; sub {
+	case "add":
+		return a.add(ctx, rest)
+	case "list":
+		return a.list(ctx, rest)
+	case "done":
+		return a.forEachID(ctx, rest, func(id int) error { return a.store.SetTodoDone(ctx, id, true) })
+	case "delete":
+		return a.forEachID(ctx, rest, func(id int) error { return a.store.DeleteTodo(ctx, id) })
+	default:
+		return errUsage
+	}
+}
+
+func (a todoApp) add(ctx context.Context, args []string) error {
+	title := strings.TrimSpace(strings.Join(args, " ")) // no quotes needed around the title
+	if title == "" {
+		return errUsage
+	}
+	t, err := a.store.AddTodo(ctx, title, a.clock.Now())
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(a.out, "added %d: %s\n", t.ID, t.Title)
+	return nil
+}
+
+func (a todoApp) list(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("todo list", flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	all := flags.Bool("all", false, "include the ones that are done")
+	if err := flags.Parse(args); err != nil || flags.NArg() > 0 {
+		return errUsage
+	}
+
+	todos, err := a.store.Todos(ctx)
+	if err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(a.out, 0, 0, 2, ' ', 0)
+	shown := 0
+	for _, t := range todos {
+		if t.Done && !*all {
+			continue
+		}
+		check := "[ ]"
+		if t.Done {
+			check = "[x]"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", t.ID, check, t.Title, t.Created.Local().Format(time.DateOnly))
+		shown++
+	}
+	if shown == 0 {
+		fmt.Fprintln(a.out, "nothing to do")
+	}
+	return tw.Flush()
+}
+
+// forEachID checks every argument is an id before changing anything, so a typo in
+// the third id doesn't leave the first two done and the rest not
+func (a todoApp) forEachID(ctx context.Context, args []string, fn func(id int) error) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	ids := make([]int, len(args))
+	for i, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil || id < 1 {
+			return fmt.Errorf("%q isn't a todo id", arg)
+		}
+		ids[i] = id
+	}
+	for _, id := range ids {
+		if err := fn(id); err != nil {
+			return fmt.Errorf("todo %d: %w", id, err)
+		}
+	}
+	return nil
+}
+
+// hellogo todo add|list|done|delete ...
+func todoCommand(args []string) error {
+	cfg, err := loadConfig(configFile)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	s, err := openStore(ctx, cfg.Storage)
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+	return todoApp{s, os.Stdout, realClock{}}.run(ctx, args)
+}
+
+func testTodo() {
+	dir := makeTempDir("todo")
+	defer os.RemoveAll(dir)
+	ctx := context.Background()
+	clk := &fakeClock{now: time.Date(2019, time.January, 1, 12, 0, 0, 0, time.Local)}
+	app := todoApp{openJSONStore(filepath.Join(dir, "todo.json")), os.Stdout, clk}
+
+	for _, args := range [][]string{{"add", "buy", "milk"}, {"add", "write", "tests"}, {"done", "1"}, {"list", "--all"}} {
+		if err := app.run(ctx, args); err != nil {
+			fmt.Println(err)
+		}
+	}
+	// added 1: buy milk
+	// added 2: write tests
+	// 1  [x]  buy milk     2019-01-01
+	// 2  [ ]  write tests  2019-01-01
+	fmt.Println(app.run(ctx, []string{"done", "7"})) // todo 7: no such todo
+}
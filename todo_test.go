@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTodo(t *testing.T) {
+	ctx := context.Background()
+	clk := &fakeClock{now: time.Date(2019, time.January, 1, 12, 0, 0, 0, time.Local)}
+
+	cases := []struct {
+		name    string
+		runs    [][]string // earlier commands, output ignored
+		args    []string
+		want    string
+		wantErr error
+	}{
+		{"add", nil, []string{"add", "buy", "milk"}, "added 1: buy milk\n", nil},
+		{"add without a title", nil, []string{"add", " "}, "", errUsage},
+		{"list empty", nil, []string{"list"}, "nothing to do\n", nil},
+		{"list hides done", [][]string{{"add", "a"}, {"add", "b"}, {"done", "1"}},
+			[]string{"list"}, "2  [ ]  b  2019-01-01\n", nil},
+		{"list --all", [][]string{{"add", "a"}, {"add", "b"}, {"done", "1"}},
+			[]string{"list", "--all"}, "1  [x]  a  2019-01-01\n2  [ ]  b  2019-01-01\n", nil},
+		{"delete", [][]string{{"add", "a"}, {"add", "b"}, {"delete", "1"}},
+			[]string{"list"}, "2  [ ]  b  2019-01-01\n", nil},
+		{"done unknown id", [][]string{{"add", "a"}}, []string{"done", "7"}, "", errTodoNotFound},
+		{"bad id changes nothing", [][]string{{"add", "a"}, {"done", "1", "x"}},
+			[]string{"list"}, "1  [ ]  a  2019-01-01\n", nil},
+		{"unknown subcommand", nil, []string{"undo"}, "", errUsage},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := openJSONStore(filepath.Join(t.TempDir(), "hellogo.json")) // a fresh store per case
+			for _, args := range tc.runs {
+				todoApp{s, io.Discard, clk}.run(ctx, args)
+			}
+
+			var out strings.Builder
+			err := todoApp{s, &out, clk}.run(ctx, tc.args)
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("error = %v, want %v", err, tc.wantErr)
+			}
+			if out.String() != tc.want {
+				t.Errorf("output:\n%s\nwant:\n%s", out.String(), tc.want)
+			}
+		})
+	}
+}
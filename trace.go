@@ -0,0 +1,143 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/trace"
+	"sync"
+	"time"
+)
+
+//////// Execution tracing
+// pprof says WHERE time goes; a trace says WHEN. runtime/trace records every goroutine
+// start, block, unblock, GC pause and syscall with timestamps, so you can see why work
+// that should run in parallel doesn't:
+//
+//	go tool trace out.trace   # opens a browser: a timeline per processor (P)
+//
+// Your own annotations show up in it too:
+//
+//	trace.NewTask     a logical operation ("process job 7"), can span goroutines
+//	trace.WithRegion  a named stretch of one goroutine's time inside a task
+//	trace.Log         a message pinned to a moment
+//
+// Any command can be traced: hellogo --trace out.trace <command> [args...]
+// Traces grow fast (MBs per second), keep them short.
+
+// startTracing writes an execution trace to path until stop is called
+func startTracing(path string) (stop func() error, err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := trace.Start(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return func() error {
+		trace.Stop()
+		return f.Close()
+	}, nil
+}
+
+//// A worker pool worth looking at
+// Jobs go in one channel, n workers take them, results come out another.
+// Each job has a "fetch" (waiting, like I/O) and a "compute" (CPU) part, so the
+// timeline shows goroutines blocking and the scheduler filling the gaps
+
+type poolJob struct {
+	ID    int
+	Input int
+}
+
+type poolResult struct {
+	ID     int
+	Output int
+	Worker int
+}
+
+func runWorkerPool(ctx context.Context, workers int, jobs []poolJob) []poolResult {
+	return runPool(ctx, workers, jobs, processJob)
+}
+
+// runPool is the pool itself, for any kind of job: do runs each job on one of the
+// workers, and the results come back in the order of jobs
+func runPool[J, R any](ctx context.Context, workers int, jobs []J, do func(ctx context.Context, worker int, job J) R) []R {
+	type indexed struct {
+		i   int
+		job J
+	}
+	jobCh := make(chan indexed)
+	results := make([]R, len(jobs)) // each worker writes only its own jobs' slots, so no lock
+
+	var wg sync.WaitGroup
+	for w := 1; w <= workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range jobCh {
+				results[job.i] = do(ctx, w, job.job)
+			}
+		}()
+	}
+
+	for i, job := range jobs {
+		jobCh <- indexed{i, job}
+	}
+	close(jobCh) // workers' range loops end once the channel is drained
+	wg.Wait()
+	return results
+}
+
+func processJob(ctx context.Context, worker int, job poolJob) poolResult {
+	ctx, task := trace.NewTask(ctx, "job")
+	defer task.End()
+	trace.Logf(ctx, "job", "id=%d worker=%d", job.ID, worker)
+
+	trace.WithRegion(ctx, "fetch", func() {
+		time.Sleep(time.Duration(1+job.Input%3) * time.Millisecond)
+	})
+	var out int
+	trace.WithRegion(ctx, "compute", func() {
+		for i := range 200_000 * (1 + job.Input%4) {
+			out += i % (job.Input + 1)
+		}
+	})
+	return poolResult{ID: job.ID, Output: out, Worker: worker}
+}
+
+func testTracing() {
+	dir := makeTempDir("trace")
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "pool.trace")
+
+	stop, err := startTracing(path)
+	if err != nil {
+		fmt.Println("tracing failed:", err)
+		return
+	}
+	jobs := make([]poolJob, 24)
+	for i := range jobs {
+		jobs[i] = poolJob{ID: i, Input: i * 7}
+	}
+	start := time.Now()
+	results := runWorkerPool(context.Background(), 4, jobs)
+	elapsed := time.Since(start)
+	if err := stop(); err != nil {
+		fmt.Println("tracing failed:", err)
+		return
+	}
+
+	perWorker := map[int]int{}
+	for _, r := range results {
+		perWorker[r.Worker]++
+	}
+	fmt.Println(len(results), "jobs,", len(perWorker), "workers busy") // 24 jobs, 4 workers busy
+	info, _ := os.Stat(path)
+	fmt.Printf("%v, trace is %dKB\n", elapsed.Round(time.Millisecond), info.Size()>>10)
+	// In go tool trace: "User-defined tasks" lists the 24 jobs with their fetch and
+	// compute regions; the goroutine view shows each worker sleeping in fetch while
+	// another one computes on the same P
+}
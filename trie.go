@@ -0,0 +1,268 @@
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+//////// Tries
+// A tree of prefixes: each edge is a letter, each path from the root spells a prefix,
+// and nodes are marked where a whole word ends. Looking up a word or a prefix takes as
+// many steps as it has letters, however many words are stored, and everything with a
+// given prefix sits under one node. That's what completion wants:
+//   - hellogo run ch<TAB> lists the lessons under the node for "ch"
+//   - when only one path leads on from a node, TAB can fill it in
+//   - a typo still shares most of its path with the real word, so a search that allows
+//     a couple of edits on the way down finds "did you mean" suggestions without
+//     comparing against every word
+//
+// hellogo complete prints completions for a shell. For bash:
+//
+//	_hellogo() { COMPREPLY=($(hellogo complete "${COMP_WORDS[@]:1:COMP_CWORD}")); }
+//	complete -F _hellogo hellogo
+
+type trieNode struct {
+	children map[byte]*trieNode
+	word     bool // a word ends here
+}
+
+type trie struct {
+	root trieNode
+	size int
+}
+
+func newTrie(words ...string) *trie {
+	t := &trie{}
+	for _, w := range words {
+		t.Insert(w)
+	}
+	return t
+}
+
+// Insert adds word, false if it was already there
+func (t *trie) Insert(word string) bool {
+	n := &t.root
+	for i := 0; i < len(word); i++ {
+		if n.children == nil {
+			n.children = map[byte]*trieNode{}
+		}
+		next, ok := n.children[word[i]]
+		if !ok {
+			next = &trieNode{}
+			n.children[word[i]] = next
+		}
+		n = next
+	}
+	if n.word {
+		return false
+	}
+	n.word = true
+	t.size++
+	return true
+}
+
+// find walks down prefix, nil if no word starts with it
+func (t *trie) find(prefix string) *trieNode {
+	n := &t.root
+	for i := 0; i < len(prefix) && n != nil; i++ {
+		n = n.children[prefix[i]]
+	}
+	return n
+}
+
+func (t *trie) Contains(word string) bool {
+	n := t.find(word)
+	return n != nil && n.word
+}
+
+// sortedEdges lists n's children in letter order, so walks come out sorted
+func (n *trieNode) sortedEdges() []byte {
+	edges := make([]byte, 0, len(n.children))
+	for c := range n.children {
+		edges = append(edges, c)
+	}
+	slices.Sort(edges)
+	return edges
+}
+
+// WithPrefix lists every word starting with prefix, sorted
+func (t *trie) WithPrefix(prefix string) []string {
+	var words []string
+	var walk func(n *trieNode, path []byte)
+	walk = func(n *trieNode, path []byte) {
+		if n.word {
+			words = append(words, string(path))
+		}
+		for _, c := range n.sortedEdges() {
+			walk(n.children[c], append(path, c))
+		}
+	}
+	if n := t.find(prefix); n != nil {
+		walk(n, []byte(prefix))
+	}
+	return words
+}
+
+// Extend is what TAB fills in: prefix plus however far every word starting with it agrees
+func (t *trie) Extend(prefix string) string {
+	n := t.find(prefix)
+	if n == nil {
+		return prefix
+	}
+	path := []byte(prefix)
+	for !n.word && len(n.children) == 1 {
+		for c, next := range n.children {
+			path, n = append(path, c), next
+		}
+	}
+	return string(path)
+}
+
+// Suggest finds words within maxEdits edits (a letter added, removed or changed) of
+// word, closest first. It's the Levenshtein distance table, one row per trie level:
+// every word below a node shares the rows so far, and once a row has nothing within
+// maxEdits, no word below can come back within it, so that branch is skipped
+func (t *trie) Suggest(word string, maxEdits int) []string {
+	type match struct {
+		word  string
+		edits int
+	}
+	var matches []match
+	var walk func(n *trieNode, path []byte, prev []int)
+	walk = func(n *trieNode, path []byte, prev []int) {
+		last := len(prev) - 1
+		if n.word && prev[last] <= maxEdits {
+			matches = append(matches, match{string(path), prev[last]})
+		}
+		if slices.Min(prev) > maxEdits {
+			return
+		}
+		for _, c := range n.sortedEdges() {
+			row := make([]int, len(prev))
+			row[0] = prev[0] + 1
+			for i := 1; i < len(row); i++ {
+				change := prev[i-1]
+				if word[i-1] != c {
+					change++
+				}
+				row[i] = min(row[i-1]+1, prev[i]+1, change)
+			}
+			walk(n.children[c], append(path, c), row)
+		}
+	}
+	first := make([]int, len(word)+1) // from "" to each prefix of word: that many insertions
+	for i := range first {
+		first[i] = i
+	}
+	walk(&t.root, nil, first)
+
+	slices.SortStableFunc(matches, func(a, b match) int { return cmp.Compare(a.edits, b.edits) })
+	words := make([]string, len(matches))
+	for i, m := range matches {
+		words[i] = m.word
+	}
+	return words
+}
+
+//// Lesson and command names
+
+func lessonTrie() *trie { return newTrie(lessonNames()...) }
+
+// resolveLessonName accepts a lesson's name or any prefix only one lesson starts with
+func resolveLessonName(name string) (string, error) {
+	t := lessonTrie()
+	if t.Contains(name) {
+		return name, nil
+	}
+	switch matches := t.WithPrefix(name); {
+	case len(matches) == 1:
+		return matches[0], nil
+	case len(matches) > 1:
+		return "", fmt.Errorf("%q could be any of: %s", name, strings.Join(matches, ", "))
+	}
+	msg := fmt.Sprintf("no lesson %q", name)
+	if close := t.Suggest(name, 2); len(close) > 0 {
+		msg += fmt.Sprintf(", did you mean %s?", strings.Join(close[:min(len(close), 3)], " or "))
+	}
+	return "", fmt.Errorf("%s (hellogo run lists them)", msg)
+}
+
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	return names
+}
+
+// completions is what the shell may put in place of the last of args, the word being typed
+func completions(args []string) []string {
+	if len(args) == 0 {
+		args = []string{""}
+	}
+	word := args[len(args)-1]
+	switch {
+	case len(args) == 1:
+		return newTrie(commandNames()...).WithPrefix(word)
+	case args[0] == "run" && !strings.HasPrefix(word, "-"):
+		return lessonTrie().WithPrefix(word)
+	case args[0] == "verify" && !strings.HasPrefix(word, "-"):
+		names := make([]string, 0, len(goldenLessons))
+		for name := range goldenLessons {
+			names = append(names, name)
+		}
+		return newTrie(names...).WithPrefix(word)
+	}
+	return nil // let the shell complete file names
+}
+
+// hellogo complete [word...]; the last word is the one being completed
+func completeCommand(args []string) error {
+	for _, c := range completions(args) {
+		fmt.Fprintln(os.Stdout, c)
+	}
+	return nil
+}
+
+// Registered here rather than in the commands literal: completeCommand reads commands,
+// which would make the map's initialization depend on itself
+func init() {
+	commands["complete"] = command{"complete [word...] (for shell completion, see trie.go)", completeCommand}
+}
+
+func testTrie() {
+	t := newTrie("channels", "chain", "cache", "calc", "cgo", "config", "context", "crawler", "gc", "generics")
+	fmt.Println(t.size, t.Insert("chain"), t.Contains("chai"), t.Contains("chain")) // 10 false false true
+	fmt.Println(t.WithPrefix("c"))                                                  // [cache calc cgo chain channels config context crawler]
+	fmt.Println(t.WithPrefix("cha"), t.WithPrefix("x"))                             // [chain channels] []
+
+	// TAB: "cr" can only be crawler; after "cha" chain and channels part, so it stays put
+	fmt.Println(t.Extend("cr"), t.Extend("cha"), t.Extend("co"), t.Extend("chan")) // crawler cha con channels
+
+	fmt.Println(t.Suggest("chanels", 2))  // [channels]
+	fmt.Println(t.Suggest("genrics", 2))  // [generics]
+	fmt.Println(t.Suggest("cahce", 2))    // [cache calc], a swap is two edits
+	fmt.Println(t.Suggest("zzzzzzzz", 2)) // []
+
+	runCase("TestTrie/every lesson by name and prefix", func(errorf errorfFunc) {
+		names := lessonNames()
+		lt := newTrie(names...)
+		if lt.size != len(names) {
+			errorf("size %d, want %d", lt.size, len(names))
+		}
+		for _, name := range names {
+			if got := lt.WithPrefix(name); len(got) == 0 || got[0] != name {
+				errorf("WithPrefix(%q) = %v, want %s first", name, got, name)
+			}
+			if got, err := resolveLessonName(name); got != name || err != nil {
+				errorf("resolveLessonName(%q) = %q, %v", name, got, err)
+			}
+		}
+	})
+	fmt.Println(resolveLessonName("dnss"))    // dnsserver <nil>
+	fmt.Println(resolveLessonName("ht"))      //  "ht" could be any of: httpclient, httpserver, httptest
+	fmt.Println(resolveLessonName("hashign")) //  no lesson "hashign", did you mean hashing? (hellogo run lists them)
+}
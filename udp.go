@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+//////// UDP
+// No connection, no ordering, no retries: each datagram arrives once, or not at all,
+// in whatever order the network likes. In exchange it's fast and cheap (DNS, games, video)
+
+func testUDPBurst() {
+	server, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}) // port 0: any free port
+	if err != nil {
+		fmt.Println("listen failed:", err)
+		return
+	}
+	defer server.Close()
+
+	// A tiny receive buffer makes loss easy to see, the kernel drops what doesn't fit
+	server.SetReadBuffer(4096)
+
+	// "Dial" for UDP just remembers the address, nothing is sent
+	client, err := net.DialUDP("udp", nil, server.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		fmt.Println("dial failed:", err)
+		return
+	}
+	defer client.Close()
+
+	const sent = 1000
+	for i := 0; i < sent; i++ {
+		packet := binary.BigEndian.AppendUint32(nil, uint32(i))
+		client.Write(append(packet, make([]byte, 500)...)) // no error even if nobody gets it
+	}
+
+	received, outOfOrder, last := 0, 0, -1
+	buf := make([]byte, 2048)
+	for {
+		server.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+		n, _, err := server.ReadFromUDP(buf)
+		if err != nil {
+			break // timeout: nothing more is coming
+		}
+		if n < 4 {
+			continue
+		}
+		seq := int(binary.BigEndian.Uint32(buf[:4]))
+		if seq < last {
+			outOfOrder++
+		}
+		last = seq
+		received++
+	}
+	// On loopback it's usually in order, but most get dropped: received 6 of 1000, 0 out of order
+	fmt.Printf("received %d of %d, %d out of order\n", received, sent, outOfOrder)
+}
+
+//// A tiny time protocol
+// Client sends any datagram, server replies with 8 bytes: unix nanoseconds, big endian.
+// If the reply is lost the client just... times out and asks again. That's UDP life
+
+func serveUDPTime(conn *net.UDPConn) {
+	buf := make([]byte, 64)
+	for {
+		_, addr, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			return // closed
+		}
+		reply := binary.BigEndian.AppendUint64(nil, uint64(time.Now().UnixNano()))
+		conn.WriteToUDP(reply, addr) // reply to whoever asked
+	}
+}
+
+func askUDPTime(addr *net.UDPAddr, attempts int) (time.Time, error) {
+	conn, err := net.DialUDP("udp", nil, addr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer conn.Close()
+
+	buf := make([]byte, 8)
+	for i := 0; i < attempts; i++ {
+		conn.Write([]byte("time?"))
+		conn.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+		n, err := conn.Read(buf)
+		if err == nil && n == 8 {
+			return time.Unix(0, int64(binary.BigEndian.Uint64(buf))), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("no answer from %s after %d tries", addr, attempts)
+}
+
+func testUDPTime() {
+	server, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		fmt.Println("listen failed:", err)
+		return
+	}
+	go serveUDPTime(server)
+
+	t, err := askUDPTime(server.LocalAddr().(*net.UDPAddr), 3)
+	fmt.Println(t.Format(time.RFC3339Nano), err)
+
+	server.Close()
+	_, err = askUDPTime(server.LocalAddr().(*net.UDPAddr), 2)
+	fmt.Println(err) // no answer from 127.0.0.1:xxxxx after 2 tries
+}
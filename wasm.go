@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"go/build"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+//////// WebAssembly
+// GOOS=js GOARCH=wasm compiles Go for the browser. The page loads wasm_exec.js (the
+// glue that ships with Go, version matched to the compiler) and then the .wasm file:
+//
+//	hellogo buildwasm          # writes web/hellogo.wasm and copies wasm_exec.js next to it
+//	hellogo serve              # then open http://localhost:8080/wasm/play.html
+//
+// The whole package compiles for js/wasm, but only wasm_js.go (the _js suffix is a build
+// constraint) talks to the page, through syscall/js: js.Global() is the window object,
+// js.FuncOf wraps a Go func so JavaScript can call it, js.Value wraps anything from JS.
+// It exposes the geometry and generics lessons; the rest want files, sockets or processes.
+//
+// Things to know: the .wasm is big (~20MB for this, most of it the runtime, tinygo makes
+// much smaller ones), fmt output goes to the browser console unless the page catches it
+// (play.html does), and a Go func called from JS must not block, or the page freezes.
+// GOOS=wasip1 is the other target, for running outside the browser (wasmtime, wazero).
+
+const wasmDir = "web"
+
+// hellogo buildwasm [dir]; run from the repo root
+func buildWasmCommand(args []string) error {
+	if len(args) > 1 {
+		return errUsage
+	}
+	dir := wasmDir
+	if len(args) == 1 {
+		dir = args[0]
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	ctx := build.Default
+	ctx.GOOS, ctx.GOARCH, ctx.CgoEnabled = "js", "wasm", false
+	sources, err := goSources(ctx, ".") // see escape.go
+	if err != nil {
+		return err
+	}
+	out := filepath.Join(dir, "hellogo.wasm")
+	cmd := exec.Command("go", append([]string{"build", "-o", out}, sources...)...)
+	cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+	cmd.Stdout, cmd.Stderr = os.Stdout, os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("go build: %w", err)
+	}
+
+	glue, err := wasmExecJS()
+	if err != nil {
+		return err
+	}
+	return copyFile(glue, filepath.Join(dir, "wasm_exec.js"))
+}
+
+// wasmExecJS finds the wasm_exec.js that came with the go command on the PATH;
+// it moved from misc/wasm to lib/wasm in Go 1.24
+func wasmExecJS() (string, error) {
+	out, err := exec.Command("go", "env", "GOROOT").Output()
+	if err != nil {
+		return "", fmt.Errorf("go env GOROOT: %w", err)
+	}
+	goroot := strings.TrimSpace(string(out))
+	for _, dir := range []string{"lib/wasm", "misc/wasm"} {
+		path := filepath.Join(goroot, dir, "wasm_exec.js")
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no wasm_exec.js under %s", goroot)
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func testWasm() {
+	dir := makeTempDir("wasm")
+	defer os.RemoveAll(dir)
+
+	if err := buildWasmCommand([]string{dir}); err != nil { // takes a while, it's the whole runtime
+		fmt.Println("build failed:", err)
+		return
+	}
+	for _, name := range []string{"hellogo.wasm", "wasm_exec.js"} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		fmt.Printf("%s %dKB\n", name, info.Size()>>10)
+	}
+	// hellogo.wasm 23810KB
+	// wasm_exec.js 16KB
+}
@@ -0,0 +1,71 @@
+package main
+
+import "syscall/js"
+
+// Only built for GOOS=js (the file name says so). The page starts the program as
+// `hellogo js`, which publishes window.hellogo and then waits for calls
+
+func init() {
+	commands["js"] = command{"js", jsCommand}
+}
+
+// wasmLessons are the lessons that make sense in a browser
+var wasmLessons = map[string]func(){
+	"geometry": testInteraface,
+	"generics": testGenerics,
+}
+
+func jsCommand(args []string) error {
+	js.Global().Set("hellogo", js.ValueOf(map[string]any{
+		"run":  js.FuncOf(jsRun),
+		"area": js.FuncOf(jsArea),
+		"sum":  js.FuncOf(jsSum),
+	}))
+	js.Global().Call("dispatchEvent", js.Global().Get("Event").New("hellogo-ready"))
+	select {} // returning would end the program, and the functions above with it
+}
+
+// hellogo.run("geometry") runs a lesson; its fmt output goes wherever the page sends stdout
+func jsRun(this js.Value, args []js.Value) any {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return "usage: hellogo.run(name)"
+	}
+	lesson, ok := wasmLessons[args[0].String()]
+	if !ok {
+		return "no lesson " + args[0].String()
+	}
+	lesson()
+	return nil
+}
+
+// hellogo.area("rect", 3, 4) or hellogo.area("circle", 1) returns {area, perim}
+func jsArea(this js.Value, args []js.Value) any {
+	if len(args) < 2 {
+		return js.Null()
+	}
+	var g geometry
+	switch args[0].String() {
+	case "rect":
+		if len(args) < 3 {
+			return js.Null()
+		}
+		g = rect{width: args[1].Float(), height: args[2].Float()}
+	case "circle":
+		g = circle{radius: args[1].Float()}
+	default:
+		return js.Null()
+	}
+	return map[string]any{"area": g.area(), "perim": g.perim()} // converted with js.ValueOf
+}
+
+// hellogo.sum([1, 2.5, 3]) copies a JS array into a []float64 and calls the generic sumOf
+func jsSum(this js.Value, args []js.Value) any {
+	if len(args) != 1 || !args[0].InstanceOf(js.Global().Get("Array")) {
+		return js.Null()
+	}
+	values := make([]float64, args[0].Length())
+	for i := range values {
+		values[i] = args[0].Index(i).Float()
+	}
+	return sumOf(values)
+}
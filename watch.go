@@ -0,0 +1,253 @@
+package main
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+//////// Watching files for changes
+// The portable way: look at every file now and then and compare with last time. A
+// snapshot maps each path to its size and modification time; anything new, gone or
+// different between two snapshots is an event. It costs a stat per file per tick, fine
+// for a project directory, too slow for a whole disk. The OS can push changes instead
+// (inotify on Linux, FSEvents on macOS, ReadDirectoryChangesW on Windows), which is what
+// the fsnotify package wraps; the events would come out of the same channel.
+//
+// Directories are watched recursively. Editors often save by writing a temp file and
+// renaming it over the original, which a poll sees as one write (or nothing at all, if
+// it happened between two ticks and changed neither size nor time; rare, but polling
+// can miss things).
+//
+// hellogo watch path... -- command [args...] runs the command whenever something under
+// the paths changes, e.g. hellogo watch todo.go -- go run . verify. hellogo exercise
+// check --watch (exercise.go) does the same with an exercise's tests.
+
+type watchOp int
+
+const (
+	watchCreate watchOp = iota + 1
+	watchWrite
+	watchRemove
+)
+
+func (op watchOp) String() string {
+	switch op {
+	case watchCreate:
+		return "create"
+	case watchWrite:
+		return "write"
+	case watchRemove:
+		return "remove"
+	}
+	return fmt.Sprintf("watchOp(%d)", int(op))
+}
+
+type watchEvent struct {
+	Path string
+	Op   watchOp
+}
+
+type watchedFile struct {
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+// snapshotPaths stats everything under paths. Files that vanish mid-walk are skipped,
+// they're simply gone by the next snapshot too
+func snapshotPaths(paths []string) (map[string]watchedFile, error) {
+	snap := map[string]watchedFile{}
+	for _, root := range paths {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if errors.Is(err, fs.ErrNotExist) && path != root {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			info, err := d.Info()
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			snap[path] = watchedFile{info.Size(), info.ModTime(), d.IsDir()}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return snap, nil
+}
+
+// diffSnapshots lists what changed from old to new, sorted by path. A directory's
+// modification time changes when files are added or removed; those files get their
+// own events, so directories only report being created or removed
+func diffSnapshots(old, new map[string]watchedFile) []watchEvent {
+	var events []watchEvent
+	for path, now := range new {
+		before, ok := old[path]
+		switch {
+		case !ok:
+			events = append(events, watchEvent{path, watchCreate})
+		case !now.dir && (now.size != before.size || !now.modTime.Equal(before.modTime)):
+			events = append(events, watchEvent{path, watchWrite})
+		}
+	}
+	for path := range old {
+		if _, ok := new[path]; !ok {
+			events = append(events, watchEvent{path, watchRemove})
+		}
+	}
+	slices.SortFunc(events, func(a, b watchEvent) int { return cmp.Compare(a.Path, b.Path) })
+	return events
+}
+
+// watchPaths sends an event for every change under paths, checking every interval,
+// until ctx is done; then it closes the channel. Paths that don't exist at the start
+// are an error, ones that disappear later are just remove events
+func watchPaths(ctx context.Context, paths []string, every time.Duration) (<-chan watchEvent, error) {
+	snap, err := snapshotPaths(paths)
+	if err != nil {
+		return nil, err
+	}
+	events := make(chan watchEvent)
+	go func() {
+		defer close(events)
+		ticker := time.NewTicker(every)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			next, err := snapshotPaths(paths)
+			if errors.Is(err, fs.ErrNotExist) {
+				next, err = map[string]watchedFile{}, nil // a watched root was removed
+			}
+			if err != nil {
+				continue // a permissions blip; try again next tick
+			}
+			for _, ev := range diffSnapshots(snap, next) {
+				select {
+				case events <- ev:
+				case <-ctx.Done():
+					return
+				}
+			}
+			snap = next
+		}
+	}()
+	return events, nil
+}
+
+// settle waits until events has been quiet for quiet, so a save that touches several
+// files triggers one run rather than one per file. False if the channel closed
+func settle(events <-chan watchEvent, quiet time.Duration) bool {
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				return false
+			}
+		case <-time.After(quiet):
+			return true
+		}
+	}
+}
+
+// hellogo watch [-every d] path... -- command [args...]
+func watchCommand(args []string) error {
+	flags := flag.NewFlagSet("watch", flag.ContinueOnError)
+	every := flags.Duration("every", 500*time.Millisecond, "how often to look for changes")
+	if err := flags.Parse(args); err != nil || *every <= 0 {
+		return errUsage
+	}
+	rest := flags.Args()
+	sep := slices.Index(rest, "--")
+	if sep < 1 || sep == len(rest)-1 {
+		return errUsage
+	}
+	paths, command := rest[:sep], rest[sep+1:]
+
+	ctx, stop := shutdownContext()
+	defer stop()
+	return rerunOnChange(ctx, paths, *every, func() error {
+		cmd := exec.CommandContext(ctx, command[0], command[1:]...)
+		cmd.Stdin, cmd.Stdout, cmd.Stderr = os.Stdin, os.Stdout, os.Stderr
+		return cmd.Run()
+	})
+}
+
+// rerunOnChange calls run now, and again after every burst of changes under paths,
+// until ctx is done. run's errors are reported, not returned: the next save may fix them
+func rerunOnChange(ctx context.Context, paths []string, every time.Duration, run func() error) error {
+	events, err := watchPaths(ctx, paths, every)
+	if err != nil {
+		return err
+	}
+	report := func() {
+		status := "ok"
+		if err := run(); err != nil {
+			status = err.Error()
+		}
+		fmt.Fprintf(os.Stderr, "--- %s: %s, watching for changes\n", time.Now().Format(time.TimeOnly), status)
+	}
+
+	report()
+	for ev := range events {
+		fmt.Fprintf(os.Stderr, "--- %s %s\n", ev.Op, ev.Path)
+		if !settle(events, every) {
+			break
+		}
+		report()
+	}
+	return nil
+}
+
+func testWatch() {
+	dir := makeTempDir("watch")
+	defer os.RemoveAll(dir)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	events, err := watchPaths(ctx, []string{dir}, 10*time.Millisecond)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	// Each change waits for its event, so they don't land in the same tick
+	next := func() {
+		ev := <-events
+		rel, _ := filepath.Rel(dir, ev.Path)
+		fmt.Println(ev.Op, rel)
+	}
+	solution := filepath.Join(dir, "solution.go")
+	os.WriteFile(solution, []byte("package main\n"), 0644)
+	next() // create solution.go
+	os.WriteFile(solution, []byte("package main\n\nfunc main() {}\n"), 0644)
+	next() // write solution.go
+	os.Mkdir(filepath.Join(dir, "sub"), 0755)
+	next() // create sub
+	os.Remove(solution)
+	next() // remove solution.go
+
+	cancel()
+	_, open := <-events
+	fmt.Println("closed after cancel:", !open) // closed after cancel: true
+
+	_, err = watchPaths(context.Background(), []string{filepath.Join(dir, "nope")}, time.Second)
+	fmt.Println(errors.Is(err, fs.ErrNotExist)) // true
+}
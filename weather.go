@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+//////// Mini project: a weather CLI
+// The shape most API clients end up with: a client struct holding an *http.Client and
+// base URLs (so tests can point it somewhere else), one method per endpoint that
+// decodes JSON into structs, and a cache in front so repeat questions don't cost a
+// request. It uses Open-Meteo, which needs no API key: one call turns a city name into
+// coordinates, a second gets the forecast for them.
+//
+// The network is the bit tests can't rely on, so the *http.Client takes a Transport,
+// the thing that actually sends requests. recordingTransport saves every response to a
+// file as it goes; replayTransport answers from those files and never touches the
+// network. testdata/weather holds a recorded session for Berlin:
+//
+//	hellogo weather Berlin                  # live
+//	hellogo weather --offline Berlin        # from testdata/weather
+//	hellogo weather --record Paris          # live, and saves the responses for --offline
+
+const (
+	weatherGeocodeURL  = "https://geocoding-api.open-meteo.com/v1/search"
+	weatherForecastURL = "https://api.open-meteo.com/v1/forecast"
+	weatherRecordings  = "testdata/weather"
+)
+
+type weatherPlace struct {
+	Name      string  `json:"name"`
+	Country   string  `json:"country"`
+	Latitude  float64 `json:"latitude"`
+	Longitude float64 `json:"longitude"`
+}
+
+type weatherForecast struct {
+	Current struct {
+		Time        string  `json:"time"`
+		Temperature float64 `json:"temperature_2m"`
+		WindSpeed   float64 `json:"wind_speed_10m"`
+		Code        int     `json:"weather_code"`
+	} `json:"current"`
+	Daily struct {
+		Date []string  `json:"time"`
+		Min  []float64 `json:"temperature_2m_min"`
+		Max  []float64 `json:"temperature_2m_max"`
+		Code []int     `json:"weather_code"`
+	} `json:"daily"`
+}
+
+// WMO weather codes, the ones Open-Meteo uses
+var weatherCodes = map[int]string{
+	0: "clear", 1: "mainly clear", 2: "partly cloudy", 3: "overcast", 45: "fog", 48: "freezing fog",
+	51: "light drizzle", 53: "drizzle", 55: "heavy drizzle", 61: "light rain", 63: "rain", 65: "heavy rain",
+	71: "light snow", 73: "snow", 75: "heavy snow", 80: "light showers", 81: "showers", 82: "violent showers",
+	95: "thunderstorm", 96: "thunderstorm with hail", 99: "thunderstorm with heavy hail",
+}
+
+func describeWeather(code int) string {
+	if s, ok := weatherCodes[code]; ok {
+		return s
+	}
+	return fmt.Sprintf("weather code %d", code)
+}
+
+var errNoSuchPlace = errors.New("no such place")
+
+type weatherClient struct {
+	http        *http.Client
+	geocodeURL  string
+	forecastURL string
+	cache       *ttlCache[string, []byte] // response bodies by URL
+	ttl         time.Duration
+}
+
+func newWeatherClient(transport http.RoundTripper, clk clock) *weatherClient {
+	return &weatherClient{
+		http:        &http.Client{Transport: transport, Timeout: 10 * time.Second},
+		geocodeURL:  weatherGeocodeURL,
+		forecastURL: weatherForecastURL,
+		cache:       newTTLCache[string, []byte](clk),
+		ttl:         10 * time.Minute, // forecasts don't change faster than that
+	}
+}
+
+// get fetches u and decodes the JSON into v, from the cache when it can. The cache keeps
+// the raw bytes rather than v, so one cache works for every response type
+func (c *weatherClient) get(ctx context.Context, u string, v any) error {
+	body, ok := c.cache.Get(u)
+	if !ok {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := c.http.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		body, err = io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode != http.StatusOK {
+			return fmt.Errorf("GET %s: %s: %s", u, resp.Status, bytes.TrimSpace(body))
+		}
+		c.cache.Set(u, body, c.ttl)
+	}
+	return json.Unmarshal(body, v)
+}
+
+// Locate finds the best match for a place name
+func (c *weatherClient) Locate(ctx context.Context, name string) (weatherPlace, error) {
+	q := url.Values{"name": {name}, "count": {"1"}, "format": {"json"}}
+	var found struct {
+		Results []weatherPlace `json:"results"`
+	}
+	if err := c.get(ctx, c.geocodeURL+"?"+q.Encode(), &found); err != nil {
+		return weatherPlace{}, err
+	}
+	if len(found.Results) == 0 {
+		return weatherPlace{}, fmt.Errorf("%w: %s", errNoSuchPlace, name)
+	}
+	return found.Results[0], nil
+}
+
+func (c *weatherClient) Forecast(ctx context.Context, p weatherPlace, days int) (weatherForecast, error) {
+	q := url.Values{
+		"latitude":      {fmt.Sprint(p.Latitude)},
+		"longitude":     {fmt.Sprint(p.Longitude)},
+		"current":       {"temperature_2m,wind_speed_10m,weather_code"},
+		"daily":         {"temperature_2m_min,temperature_2m_max,weather_code"},
+		"forecast_days": {fmt.Sprint(days)},
+		"timezone":      {"auto"}, // days in the place's time zone, not UTC
+	}
+	var f weatherForecast
+	err := c.get(ctx, c.forecastURL+"?"+q.Encode(), &f)
+	return f, err
+}
+
+// writeWeather renders the forecast as a table
+func writeWeather(w io.Writer, p weatherPlace, f weatherForecast) error {
+	fmt.Fprintf(w, "%s, %s (%.2f, %.2f)\n", p.Name, p.Country, p.Latitude, p.Longitude)
+	fmt.Fprintf(w, "now: %.1f°C, %s, wind %.1f km/h\n\n", f.Current.Temperature, describeWeather(f.Current.Code), f.Current.WindSpeed)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "DATE\tMIN\tMAX\tSKY")
+	d := f.Daily
+	for i := range d.Date {
+		if i >= len(d.Min) || i >= len(d.Max) || i >= len(d.Code) {
+			return fmt.Errorf("daily forecast arrays have different lengths")
+		}
+		fmt.Fprintf(tw, "%s\t%.1f°C\t%.1f°C\t%s\n", d.Date[i], d.Min[i], d.Max[i], describeWeather(d.Code[i]))
+	}
+	return tw.Flush()
+}
+
+//// Recording and replaying
+// An http.RoundTripper takes a request and returns a response, that's all. Wrapping
+// http.DefaultTransport in one is how you add logging, recording or faking to any client.
+
+// recordingName is the file a request's response is saved under: host and path, plus a
+// hash of the query so different cities get different files
+func recordingName(u *url.URL) string {
+	h := fnv.New32a()
+	h.Write([]byte(u.Query().Encode())) // Encode sorts by key, so parameter order doesn't matter
+	name := strings.ReplaceAll(u.Host+u.Path, "/", "_")
+	return fmt.Sprintf("%s-%08x.json", name, h.Sum32())
+}
+
+type recordingTransport struct {
+	dir  string
+	next http.RoundTripper
+}
+
+func (t recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(t.dir, 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(t.dir, recordingName(req.URL)), body, 0644); err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body)) // the body was used up saving it, hand over a fresh one
+	return resp, nil
+}
+
+type replayTransport struct {
+	dir string
+}
+
+func (t replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	name := recordingName(req.URL)
+	body, err := os.ReadFile(filepath.Join(t.dir, name))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("no recording %s for %s (record it with --record)", name, req.URL)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Status:     "200 OK",
+		Header:     http.Header{"Content-Type": {"application/json"}},
+		Body:       io.NopCloser(bytes.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+// hellogo weather [--offline|--record] [-days n] <place>
+func weatherCommand(args []string) error {
+	flags := flag.NewFlagSet("weather", flag.ContinueOnError)
+	offline := flags.Bool("offline", false, "answer from the recordings in "+weatherRecordings)
+	record := flags.Bool("record", false, "save the responses to "+weatherRecordings)
+	days := flags.Int("days", 3, "days of forecast, 1 to 16")
+	if err := flags.Parse(args); err != nil || flags.NArg() == 0 || *offline && *record || *days < 1 || *days > 16 {
+		return errUsage
+	}
+
+	var transport http.RoundTripper = http.DefaultTransport
+	switch {
+	case *offline:
+		transport = replayTransport{weatherRecordings}
+	case *record:
+		transport = recordingTransport{weatherRecordings, http.DefaultTransport}
+	}
+	c := newWeatherClient(transport, realClock{})
+	ctx := context.Background()
+	place, err := c.Locate(ctx, strings.Join(flags.Args(), " "))
+	if err != nil {
+		return err
+	}
+	f, err := c.Forecast(ctx, place, *days)
+	if err != nil {
+		return err
+	}
+	return writeWeather(os.Stdout, place, f)
+}
+
+// countingTransport counts the requests that get past the cache
+type countingTransport struct {
+	n    int
+	next http.RoundTripper
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.n++
+	return t.next.RoundTrip(req)
+}
+
+func testWeather() {
+	clk := &fakeClock{now: time.Date(2019, time.January, 1, 12, 0, 0, 0, time.UTC)}
+	requests := &countingTransport{next: replayTransport{weatherRecordings}}
+	c := newWeatherClient(requests, clk)
+	ctx := context.Background()
+
+	place, err := c.Locate(ctx, "Berlin")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	f, err := c.Forecast(ctx, place, 3)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	writeWeather(os.Stdout, place, f)
+	// Berlin, Germany (52.52, 13.41)
+	// now: 6.3°C, overcast, wind 14.8 km/h
+	//
+	// DATE        MIN     MAX    SKY
+	// 2019-01-01  2.1°C   7.4°C  overcast
+	// 2019-01-02  -0.4°C  3.9°C  light rain
+	// 2019-01-03  -2.7°C  1.2°C  light snow
+
+	c.Locate(ctx, "Berlin")
+	fmt.Println("requests:", requests.n) // requests: 2, the second Berlin came from the cache
+	clk.Advance(c.ttl)
+	c.Locate(ctx, "Berlin")
+	fmt.Println("requests:", requests.n) // requests: 3, expired
+
+	_, err = c.Locate(ctx, "Atlantis")
+	fmt.Println(err, errors.Is(err, errNoSuchPlace)) // no such place: Atlantis true
+	_, err = c.Locate(ctx, "Paris")
+	fmt.Println(err)                                     // Get "...": no recording geocoding-api.open-meteo.com_v1_search-1f107ad7.json for ... (record it with --record)
+	fmt.Println(describeWeather(61), describeWeather(7)) // light rain weather code 7
+}
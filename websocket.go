@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bufio"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+//////// WebSockets (RFC 6455), from scratch
+// Normally you'd use a library (github.com/coder/websocket, gorilla/websocket), but the
+// protocol is small enough to read in one sitting:
+// 1. client sends a normal HTTP GET with "Upgrade: websocket" and a random key
+// 2. server answers 101 Switching Protocols with a hash of that key
+// 3. from then on both sides swap frames over the raw TCP connection
+
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+	wsBinary       = 0x2
+	wsClose        = 0x8
+	wsPing         = 0x9
+	wsPong         = 0xA
+)
+
+// Fixed by the RFC; proves the server really speaks websocket and isn't just echoing headers
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+var errWSHandshake = errors.New("websocket: bad handshake")
+
+// wsMaxMessage caps a message, and so each frame in it: the lengths come from the other
+// side, and a message split into many frames must not add up to more than one could
+const wsMaxMessage = 16 << 20
+
+var errWSTooLarge = errors.New("websocket: message too large")
+
+type wsConn struct {
+	conn   net.Conn
+	br     *bufio.Reader
+	client bool // clients MUST mask every frame they send, servers must not
+}
+
+func wsAccept(key string) string {
+	sum := sha1.Sum([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// wsUpgrade turns an HTTP request into a websocket connection (server side)
+func wsUpgrade(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !strings.EqualFold(r.Header.Get("Upgrade"), "websocket") || key == "" {
+		http.Error(w, "expected a websocket upgrade", http.StatusBadRequest)
+		return nil, errWSHandshake
+	}
+
+	// Hijack takes the TCP connection away from net/http; from here on it's all ours
+	conn, rw, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\n"+
+		"Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n", wsAccept(key))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{conn: conn, br: rw.Reader}, nil
+}
+
+// wsDial connects to a ws:// URL (client side)
+func wsDial(rawURL string) (*wsConn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := net.Dial("tcp", u.Host)
+	if err != nil {
+		return nil, err
+	}
+
+	keyBytes := make([]byte, 16)
+	rand.Read(keyBytes)
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+	fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: %s\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n"+
+		"Sec-WebSocket-Key: %s\r\nSec-WebSocket-Version: 13\r\n\r\n", u.RequestURI(), u.Host, key)
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols || resp.Header.Get("Sec-WebSocket-Accept") != wsAccept(key) {
+		conn.Close()
+		return nil, errWSHandshake
+	}
+	return &wsConn{conn: conn, br: br, client: true}, nil
+}
+
+//// Frames
+//  byte 0: FIN bit + opcode
+//  byte 1: MASK bit + payload length (7 bits; 126 = next 2 bytes are the length, 127 = next 8)
+//  then the 4 byte mask key if MASK is set, then the payload
+
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	header := []byte{0x80 | opcode} // FIN: this is the whole message
+	var maskBit byte
+	if c.client {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, maskBit|byte(n))
+	case n <= 0xFFFF:
+		header = append(header, maskBit|126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, maskBit|127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	if c.client {
+		mask := make([]byte, 4)
+		rand.Read(mask)
+		header = append(header, mask...)
+		masked := make([]byte, len(payload))
+		for i, b := range payload {
+			masked[i] = b ^ mask[i%4]
+		}
+		payload = masked
+	}
+	_, err := c.conn.Write(append(header, payload...))
+	return err
+}
+
+func (c *wsConn) readFrame() (fin bool, opcode byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.br, head[:]); err != nil {
+		return
+	}
+	fin, opcode = head[0]&0x80 != 0, head[0]&0x0F
+	masked := head[1]&0x80 != 0
+
+	size := uint64(head[1] & 0x7F)
+	switch size {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		size = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.br, ext[:]); err != nil {
+			return
+		}
+		size = binary.BigEndian.Uint64(ext[:])
+	}
+	if size > wsMaxMessage {
+		err = errWSTooLarge
+		return
+	}
+	// A server must close on an unmasked frame from a client (RFC 6455 section 5.1), and
+	// a client on a masked one from a server
+	if masked == c.client {
+		err = errors.New("websocket: frame masking is the wrong way round")
+		return
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(c.br, mask[:]); err != nil {
+			return
+		}
+	}
+	payload = make([]byte, size)
+	if _, err = io.ReadFull(c.br, payload); err != nil {
+		return
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return
+}
+
+// ReadMessage returns the next text/binary message, joining fragments and answering
+// pings along the way. A close from the other side comes back as io.EOF
+func (c *wsConn) ReadMessage() (opcode byte, data []byte, err error) {
+	for {
+		fin, op, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+		switch op {
+		case wsPing:
+			if err := c.writeFrame(wsPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case wsPong:
+			continue
+		case wsClose:
+			c.writeFrame(wsClose, payload) // echo the close back, as the RFC asks
+			return 0, nil, io.EOF
+		case wsText, wsBinary:
+			opcode = op
+		}
+		if len(data)+len(payload) > wsMaxMessage {
+			return 0, nil, errWSTooLarge
+		}
+		data = append(data, payload...)
+		if fin {
+			return opcode, data, nil
+		}
+	}
+}
+
+func (c *wsConn) WriteMessage(opcode byte, data []byte) error {
+	return c.writeFrame(opcode, data)
+}
+
+// Close sends a close frame (1000 = normal closure) and hangs up
+func (c *wsConn) Close() error {
+	c.writeFrame(wsClose, binary.BigEndian.AppendUint16(nil, 1000))
+	return c.conn.Close()
+}
+
+func wsEchoHandler(w http.ResponseWriter, r *http.Request) {
+	ws, err := wsUpgrade(w, r)
+	if err != nil {
+		return
+	}
+	defer ws.conn.Close()
+	for {
+		op, msg, err := ws.ReadMessage()
+		if err != nil {
+			return // io.EOF when the client closed
+		}
+		if err := ws.WriteMessage(op, append([]byte("echo: "), msg...)); err != nil {
+			return
+		}
+	}
+}
+
+//// Streaming lesson output
+// hellogo serve's Run button (templates/index.html) opens /run/{name} and shows each
+// message as it arrives, instead of waiting for the whole lesson to finish
+
+// lessonRunMu makes runs take turns: lessons print to os.Stdout, and there's one per process
+var lessonRunMu sync.Mutex
+
+// wsTextWriter sends each Write as a text message. Once the client has gone it drops the
+// rest, so the lesson still runs to the end rather than blocking on a full pipe
+type wsTextWriter struct {
+	ws  *wsConn
+	err error
+}
+
+func (w *wsTextWriter) Write(p []byte) (int, error) {
+	if w.err == nil {
+		w.err = w.ws.WriteMessage(wsText, p)
+	}
+	return len(p), nil
+}
+
+func wsRunHandler(w http.ResponseWriter, r *http.Request) {
+	name, err := resolveLessonName(r.PathValue("name"))
+	if err != nil {
+		writeAPIError(w, errCodeNotFound, err.Error())
+		return
+	}
+	if lessonRegistry[name].interactive {
+		writeAPIError(w, errCodeBadRequest, name+" is interactive, run it with hellogo run "+name)
+		return
+	}
+	ws, err := wsUpgrade(w, r)
+	if err != nil {
+		return
+	}
+	defer ws.Close() // the client's cue that the lesson is over
+
+	lessonRunMu.Lock()
+	defer lessonRunMu.Unlock()
+	if err := pipeStdout(lessonRegistry[name].run, &wsTextWriter{ws: ws}); err != nil {
+		ws.WriteMessage(wsText, []byte(err.Error()))
+		return
+	}
+	countLessonRun(name)
+}
+
+func testWebSocketEcho() {
+	srv := httptest.NewServer(http.HandlerFunc(wsEchoHandler))
+	defer srv.Close()
+
+	ws, err := wsDial("ws" + strings.TrimPrefix(srv.URL, "http") + "/echo")
+	if err != nil {
+		fmt.Println("dial failed:", err)
+		return
+	}
+	defer ws.Close()
+
+	for _, msg := range []string{"hello", "websocket", strings.Repeat("big ", 100)} {
+		ws.WriteMessage(wsText, []byte(msg))
+		_, reply, err := ws.ReadMessage()
+		if err != nil {
+			fmt.Println("read failed:", err)
+			return
+		}
+		fmt.Println(len(reply), string(reply[:min(len(reply), 20)]))
+	}
+	// 11 echo: hello
+	// 15 echo: websocket
+	// 406 echo: big big big bi
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// wsTestFrame builds one frame by hand, so a test can send what a real peer wouldn't
+func wsTestFrame(fin bool, opcode byte, payload []byte, masked bool) []byte {
+	head := opcode
+	if fin {
+		head |= 0x80
+	}
+	frame := []byte{head}
+	var maskBit byte
+	if masked {
+		maskBit = 0x80
+	}
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, maskBit|byte(n))
+	case n <= 0xFFFF:
+		frame = binary.BigEndian.AppendUint16(append(frame, maskBit|126), uint16(n))
+	default:
+		frame = binary.BigEndian.AppendUint64(append(frame, maskBit|127), uint64(n))
+	}
+	if masked {
+		frame = append(frame, 0, 0, 0, 0) // a zero mask leaves the payload as it is
+	}
+	return append(frame, payload...)
+}
+
+// wsTestServer is the server end of a pipe, and the client end to write raw frames to
+func wsTestServer(t *testing.T) (*wsConn, net.Conn) {
+	server, client := net.Pipe()
+	t.Cleanup(func() {
+		server.Close()
+		client.Close()
+	})
+	return &wsConn{conn: server, br: bufio.NewReader(server)}, client
+}
+
+// wsSend writes frames in the background: a pipe write waits for the read
+func wsSend(client net.Conn, frames ...[]byte) {
+	go func() {
+		for _, f := range frames {
+			if _, err := client.Write(f); err != nil {
+				return
+			}
+		}
+	}()
+}
+
+func TestWSReadMessage(t *testing.T) {
+	t.Run("fragments", func(t *testing.T) {
+		ws, client := wsTestServer(t)
+		wsSend(client, wsTestFrame(false, wsText, []byte("hel"), true), wsTestFrame(true, wsContinuation, []byte("lo"), true))
+		if op, msg, err := ws.ReadMessage(); err != nil || op != wsText || string(msg) != "hello" {
+			t.Errorf("ReadMessage = %d %q %v, want a text hello", op, msg, err)
+		}
+	})
+	t.Run("unmasked from a client", func(t *testing.T) {
+		ws, client := wsTestServer(t)
+		wsSend(client, wsTestFrame(true, wsText, []byte("hello"), false))
+		if _, msg, err := ws.ReadMessage(); err == nil {
+			t.Errorf("ReadMessage = %q, want an error for an unmasked frame", msg)
+		}
+	})
+	t.Run("frame too large", func(t *testing.T) {
+		ws, client := wsTestServer(t)
+		// Only the header: the size alone has to be enough to refuse it
+		wsSend(client, binary.BigEndian.AppendUint64([]byte{0x80 | wsBinary, 0x80 | 127}, 1<<40))
+		if _, _, err := ws.ReadMessage(); !errors.Is(err, errWSTooLarge) {
+			t.Errorf("ReadMessage = %v, want errWSTooLarge", err)
+		}
+	})
+	t.Run("message too large", func(t *testing.T) {
+		ws, client := wsTestServer(t)
+		// Each fragment is under the limit, together they're over it
+		chunk := make([]byte, wsMaxMessage/4)
+		frames := [][]byte{wsTestFrame(false, wsBinary, chunk, true)}
+		for range 4 {
+			frames = append(frames, wsTestFrame(false, wsContinuation, chunk, true))
+		}
+		wsSend(client, frames...)
+		if _, msg, err := ws.ReadMessage(); !errors.Is(err, errWSTooLarge) {
+			t.Errorf("ReadMessage = %d bytes, %v; want errWSTooLarge", len(msg), err)
+		}
+	})
+}
+
+// The whole hellogo serve stack, so the upgrade has to get through the middleware and gzip
+func TestWSRunLesson(t *testing.T) {
+	saved := lessonRegistry
+	lessonRegistry = map[string]lessonEntry{
+		"count":  {run: func() { fmt.Println("one"); fmt.Println("two") }},
+		"server": {run: func() { select {} }, interactive: true},
+	}
+	t.Cleanup(func() { lessonRegistry = saved })
+	srv := httptest.NewServer(standardMiddleware(serveRoutes()))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http") + "/run/"
+
+	ws, err := wsDial(url + "co") // a prefix will do, as with hellogo run
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+	var out strings.Builder
+	for {
+		_, msg, err := ws.ReadMessage()
+		if err != nil {
+			if err != io.EOF {
+				t.Fatalf("after %q: %v, want io.EOF once the lesson is done", out.String(), err)
+			}
+			break
+		}
+		out.Write(msg)
+	}
+	if out.String() != "one\ntwo\n" {
+		t.Errorf("streamed %q, want the lesson's output", out.String())
+	}
+
+	for _, name := range []string{"server", "nope"} {
+		if _, err := wsDial(url + name); !errors.Is(err, errWSHandshake) {
+			t.Errorf("run %s: %v, want the upgrade refused", name, err)
+		}
+	}
+}
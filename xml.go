@@ -0,0 +1,129 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+//////// XML
+// encoding/xml works a lot like encoding/json; struct tags say how fields map to the document
+// Note, only exported fields get encoded, so our person/rect/circle types need little wrapper types
+
+type xmlPerson struct {
+	XMLName xml.Name `xml:"person"`
+	Name    string   `xml:"name,attr"` // ,attr makes it an attribute instead of a child element
+	Age     int      `xml:"age"`
+	Note    string   `xml:",chardata"` // ,chardata is the raw text inside the element
+}
+
+func toXMLPerson(p person) xmlPerson {
+	return xmlPerson{Name: p.name, Age: p.age}
+}
+
+type xmlShape struct {
+	Kind  string  `xml:"kind,attr"`
+	Area  float64 `xml:"area"`
+	Perim float64 `xml:"perim"`
+}
+
+func toXMLShape(kind string, g geometry) xmlShape {
+	return xmlShape{Kind: kind, Area: g.area(), Perim: g.perim()}
+}
+
+// Nested elements; a>b>c tags let you nest without declaring a struct per level
+type xmlDrawing struct {
+	XMLName xml.Name   `xml:"drawing"`
+	Owner   xmlPerson  `xml:"owner>person"`
+	Shapes  []xmlShape `xml:"shapes>shape"`
+	Comment string     `xml:",comment"`
+}
+
+func testXMLMarshal() {
+	bob := toXMLPerson(person{"Bob", 20})
+	bob.Note = "likes squares"
+
+	out, err := xml.MarshalIndent(bob, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(xml.Header + string(out))
+	// <person name="Bob">
+	//   <age>20</age>likes squares
+	// </person>
+
+	drawing := xmlDrawing{
+		Owner: bob,
+		Shapes: []xmlShape{
+			toXMLShape("rect", rect{width: 3, height: 4}),
+			toXMLShape("circle", circle{radius: 5}),
+		},
+		Comment: " made with encoding/xml ",
+	}
+	out, err = xml.MarshalIndent(drawing, "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(string(out))
+
+	// and back again
+	var back xmlDrawing
+	if err := xml.Unmarshal(out, &back); err != nil {
+		panic(err)
+	}
+	fmt.Println(back.Owner.Name, len(back.Shapes), back.Shapes[0].Kind) // Bob 2 rect
+}
+
+//// Streaming parse
+// For big documents (like an RSS feed) you don't want everything in memory at once,
+// so pull tokens off an xml.Decoder and only decode the elements you care about
+
+const rssFeed = `<?xml version="1.0"?>
+<rss version="2.0">
+  <channel>
+    <title>gobyexample</title>
+    <item><title>Values</title><link>https://gobyexample.com/values</link></item>
+    <item><title>Channels</title><link>https://gobyexample.com/channels</link></item>
+    <item><title>Select</title><link>https://gobyexample.com/select</link></item>
+  </channel>
+</rss>`
+
+type rssItem struct {
+	Title string `xml:"title"`
+	Link  string `xml:"link"`
+}
+
+func streamRSSItems(r io.Reader) ([]rssItem, error) {
+	var items []rssItem
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return items, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		// Only stop at <item> start tags, DecodeElement reads the rest of that element
+		if start, ok := tok.(xml.StartElement); ok && start.Name.Local == "item" {
+			var item rssItem
+			if err := dec.DecodeElement(&item, &start); err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+	}
+}
+
+func testXMLStreaming() {
+	items, err := streamRSSItems(strings.NewReader(rssFeed))
+	if err != nil {
+		fmt.Println("rss parse failed:", err)
+		return
+	}
+	for _, item := range items {
+		fmt.Printf("%s -> %s\n", item.Title, item.Link)
+	}
+}